@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+
 	"github.com/rmrfslashbin/goawsloc/subcmds/loc"
 
 	"github.com/sirupsen/logrus"
 )
 
 func main() {
+	// Cancel in-flight requests on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	// Catch errors
 	var err error
 	defer func() {
@@ -16,10 +24,10 @@ func main() {
 			}).Fatal("main crashed")
 		}
 	}()
-	Execute()
+	Execute(ctx)
 }
 
 // Execute the root command
-func Execute() error {
-	return loc.RootCmd.Execute()
+func Execute(ctx context.Context) error {
+	return loc.NewRootCmd().ExecuteContext(ctx)
 }
@@ -0,0 +1,159 @@
+// Package clientmgr caches AWS Location clients so long-running processes,
+// such as servers and warm Lambda invocations, reuse connections and
+// credentials instead of rebuilding the AWS configuration for every request.
+package clientmgr
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Key identifies the settings a client is built from. Clients are shared by
+// every caller asking for the same key.
+type Key struct {
+	Region  string
+	Profile string
+
+	// RoleARN, if set, is assumed with the credentials of the profile.
+	// ExternalID is passed when assuming it.
+	RoleARN    string
+	ExternalID string
+
+	// Anonymous builds a client sending unsigned requests, for calls
+	// authorized with an API key. It cannot be combined with RoleARN, as
+	// unsigned requests use no credentials to assume a role with.
+	Anonymous bool
+}
+
+// ErrAnonymousRole is returned for a key with both Anonymous and RoleARN.
+var ErrAnonymousRole = errors.New("anonymous clients cannot assume a role")
+
+// Manager lazily builds and caches one client per key. It is safe for
+// concurrent use.
+type Manager struct {
+	mu         sync.Mutex
+	entries    map[Key]*entry
+	apiOptions []func(*middleware.Stack) error
+}
+
+type entry struct {
+	mu     sync.Mutex
+	config *aws.Config
+	client *location.Client
+}
+
+// Default is the process wide manager.
+var Default = New()
+
+func New() *Manager {
+	return &Manager{
+		entries: make(map[Key]*entry),
+	}
+}
+
+// AddAPIOptions adds middleware, such as request logging, to every client
+// built afterwards.
+func (m *Manager) AddAPIOptions(fns ...func(*middleware.Stack) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiOptions = append(m.apiOptions, fns...)
+}
+
+// Config returns the cached AWS configuration for the key, loading it on
+// first use. It is the configuration location clients are built from, for
+// building clients of other services with the same credentials.
+func (m *Manager) Config(key Key) (aws.Config, error) {
+	e, apiOptions := m.entry(key)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c, err := e.load(key, apiOptions)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	return *c, nil
+}
+
+// Client returns the cached client for the key, building it on first use.
+// Building a client for one key does not block callers of other keys, and a
+// failed build is retried on the next call.
+func (m *Manager) Client(key Key) (*location.Client, error) {
+	e, apiOptions := m.entry(key)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client != nil {
+		return e.client, nil
+	}
+
+	c, err := e.load(key, apiOptions)
+	if err != nil {
+		return nil, err
+	}
+	e.client = location.NewFromConfig(*c)
+
+	return e.client, nil
+}
+
+// entry returns the entry of the key, adding it if needed, and the API
+// options of new clients.
+func (m *Manager) entry(key Key) (*entry, []func(*middleware.Stack) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{}
+		m.entries[key] = e
+	}
+	return e, m.apiOptions
+}
+
+// load returns the configuration of the entry, loading it on first use. The
+// caller must hold e.mu.
+func (e *entry) load(key Key, apiOptions []func(*middleware.Stack) error) (*aws.Config, error) {
+	if e.config != nil {
+		return e.config, nil
+	}
+	if key.Anonymous && key.RoleARN != "" {
+		return nil, ErrAnonymousRole
+	}
+
+	c, err := awsconfig.LoadDefaultConfig(context.TODO(), func(o *awsconfig.LoadOptions) error {
+		o.Region = key.Region
+		if key.Profile != "" {
+			o.SharedConfigProfile = key.Profile
+		}
+		if key.Anonymous {
+			o.Credentials = aws.AnonymousCredentials{}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if key.RoleARN != "" {
+		c.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(c), key.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+			if key.ExternalID != "" {
+				o.ExternalID = aws.String(key.ExternalID)
+			}
+		}))
+	}
+	c.APIOptions = append(c.APIOptions, apiOptions...)
+	e.config = &c
+
+	return e.config, nil
+}
+
+// Reset drops every cached client, for example after credentials were rotated.
+func (m *Manager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[Key]*entry)
+}
@@ -0,0 +1,25 @@
+package clientmgr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     Key
+		wantErr error
+	}{
+		{"anonymous", Key{Region: "us-east-1", Anonymous: true}, nil},
+		{"anonymous with role", Key{Region: "us-east-1", Anonymous: true, RoleARN: "arn:aws:iam::123456789012:role/loc"}, ErrAnonymousRole},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().Config(tt.key)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Config() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
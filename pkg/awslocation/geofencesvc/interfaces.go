@@ -0,0 +1,25 @@
+package geofencesvc
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/location"
+)
+
+// LocationClient is the subset of the Amazon Location client used by this
+// package. *location.Client implements it; tests can substitute a fake with
+// SetLocationClient.
+type LocationClient interface {
+	BatchDeleteGeofence(ctx context.Context, params *location.BatchDeleteGeofenceInput, optFns ...func(*location.Options)) (*location.BatchDeleteGeofenceOutput, error)
+	BatchPutGeofence(ctx context.Context, params *location.BatchPutGeofenceInput, optFns ...func(*location.Options)) (*location.BatchPutGeofenceOutput, error)
+	CreateGeofenceCollection(ctx context.Context, params *location.CreateGeofenceCollectionInput, optFns ...func(*location.Options)) (*location.CreateGeofenceCollectionOutput, error)
+	DeleteGeofenceCollection(ctx context.Context, params *location.DeleteGeofenceCollectionInput, optFns ...func(*location.Options)) (*location.DeleteGeofenceCollectionOutput, error)
+	DescribeGeofenceCollection(ctx context.Context, params *location.DescribeGeofenceCollectionInput, optFns ...func(*location.Options)) (*location.DescribeGeofenceCollectionOutput, error)
+	GetGeofence(ctx context.Context, params *location.GetGeofenceInput, optFns ...func(*location.Options)) (*location.GetGeofenceOutput, error)
+	ListGeofenceCollections(ctx context.Context, params *location.ListGeofenceCollectionsInput, optFns ...func(*location.Options)) (*location.ListGeofenceCollectionsOutput, error)
+	ListGeofences(ctx context.Context, params *location.ListGeofencesInput, optFns ...func(*location.Options)) (*location.ListGeofencesOutput, error)
+	PutGeofence(ctx context.Context, params *location.PutGeofenceInput, optFns ...func(*location.Options)) (*location.PutGeofenceOutput, error)
+	UpdateGeofenceCollection(ctx context.Context, params *location.UpdateGeofenceCollectionInput, optFns ...func(*location.Options)) (*location.UpdateGeofenceCollectionOutput, error)
+}
+
+var _ LocationClient = (*location.Client)(nil)
@@ -0,0 +1,531 @@
+package geofencesvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxBatchDeleteGeofences is the maximum number of geofence IDs BatchDeleteGeofence accepts per call.
+	maxBatchDeleteGeofences = 10
+
+	// maxBatchPutGeofences is the maximum number of geofences BatchPutGeofence accepts per call.
+	maxBatchPutGeofences = 10
+
+	// maxGeofenceProperties is the maximum number of properties of a geofence.
+	maxGeofenceProperties = 3
+
+	// maxPolygonVertices is the maximum number of vertices of a polygon geofence, over all rings.
+	maxPolygonVertices = 1000
+)
+
+// Geofence statuses reported by the service.
+const (
+	StatusActive   = "ACTIVE"
+	StatusDeleted  = "DELETED"
+	StatusDeleting = "DELETING"
+	StatusFailed   = "FAILED"
+	StatusPending  = "PENDING"
+)
+
+type Option func(config *Config)
+
+// Circle is a circular geofence area. Radius is in meters.
+type Circle struct {
+	Latitude  float64
+	Longitude float64
+	Radius    float64
+}
+
+// Geometry is the area of a geofence, either a polygon or a circle.
+type Geometry struct {
+	// Polygon holds the linear rings of a polygon, exterior ring first, as
+	// lon,lat positions. Each ring must be closed, repeating its first
+	// position at the end.
+	Polygon [][][]float64
+
+	Circle *Circle
+}
+
+// validate checks the geometry for shapes the API rejects.
+func (g *Geometry) validate() error {
+	if g == nil || (g.Polygon == nil) == (g.Circle == nil) {
+		return errors.New("exactly one of polygon or circle must be set")
+	}
+	if g.Circle != nil {
+		if g.Circle.Latitude < -90 || g.Circle.Latitude > 90 || g.Circle.Longitude < -180 || g.Circle.Longitude > 180 {
+			return fmt.Errorf("circle center %f,%f out of range", g.Circle.Latitude, g.Circle.Longitude)
+		}
+		if g.Circle.Radius <= 0 {
+			return fmt.Errorf("circle radius %f must be positive", g.Circle.Radius)
+		}
+		return nil
+	}
+	if len(g.Polygon) == 0 {
+		return errors.New("polygon has no rings")
+	}
+	vertices := 0
+	for i, ring := range g.Polygon {
+		if len(ring) < 4 {
+			return fmt.Errorf("ring %d has %d positions, need at least 4", i+1, len(ring))
+		}
+		for _, position := range ring {
+			if len(position) < 2 {
+				return fmt.Errorf("ring %d has a position with %d coordinates", i+1, len(position))
+			}
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return fmt.Errorf("ring %d is not closed", i+1)
+		}
+		// The exterior ring must run counter-clockwise, holes clockwise.
+		area := signedArea(ring)
+		if i == 0 && area <= 0 {
+			return errors.New("exterior ring must be counter-clockwise")
+		}
+		if i > 0 && area >= 0 {
+			return fmt.Errorf("interior ring %d must be clockwise", i)
+		}
+		vertices += len(ring) - 1
+	}
+	if vertices > maxPolygonVertices {
+		return fmt.Errorf("polygon has %d vertices, the maximum is %d", vertices, maxPolygonVertices)
+	}
+	return nil
+}
+
+// signedArea returns twice the signed area of a closed ring with the shoelace
+// formula. It is positive for counter-clockwise rings.
+func signedArea(ring [][]float64) float64 {
+	area := 0.0
+	for i := 0; i+1 < len(ring); i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return area
+}
+
+// geofenceGeometry converts the geometry into the geofence geometry of the API.
+func (g *Geometry) geofenceGeometry() *types.GeofenceGeometry {
+	if g.Circle != nil {
+		return &types.GeofenceGeometry{
+			Circle: &types.Circle{
+				Center: []float64{g.Circle.Longitude, g.Circle.Latitude},
+				Radius: aws.Float64(g.Circle.Radius),
+			},
+		}
+	}
+	return &types.GeofenceGeometry{Polygon: g.Polygon}
+}
+
+// Configuration structure.
+type Config struct {
+	region         string
+	profile        string
+	collectionName string
+	kmsKeyID       string
+	log            *logrus.Logger
+	svc            LocationClient
+}
+
+func New(opts ...func(*Config)) (*Config, error) {
+	config := &Config{}
+
+	// apply the list of options to Config
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.region == "" {
+		config.region = os.Getenv("AWS_REGION")
+	}
+
+	if config.svc == nil {
+		c, err := awsconfig.LoadDefaultConfig(context.TODO(), func(o *awsconfig.LoadOptions) error {
+			o.Region = config.region
+			if config.profile != "" {
+				o.SharedConfigProfile = config.profile
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		config.svc = location.NewFromConfig(c)
+	}
+
+	return config, nil
+}
+
+func SetAWSRegion(region string) Option {
+	return func(config *Config) {
+		config.region = region
+	}
+}
+
+func SetAWSProfile(profile string) Option {
+	return func(config *Config) {
+		config.profile = profile
+	}
+}
+
+func SetCollectionName(collectionName string) Option {
+	return func(config *Config) {
+		config.collectionName = collectionName
+	}
+}
+
+// SetKMSKeyID encrypts the data of new collections with a customer managed
+// KMS key instead of an AWS owned key.
+func SetKMSKeyID(kmsKeyID string) Option {
+	return func(config *Config) {
+		config.kmsKeyID = kmsKeyID
+	}
+}
+
+// SetLocationClient uses an existing client, such as one cached by clientmgr
+// or a fake in tests, instead of building a new one from the region and profile.
+func SetLocationClient(client LocationClient) Option {
+	return func(config *Config) {
+		config.svc = client
+	}
+}
+
+func SetLogger(log *logrus.Logger) Option {
+	return func(config *Config) {
+		config.log = log
+	}
+}
+
+func (c *Config) sanity() error {
+	if c.collectionName == "" {
+		return errors.New("collectionName not set")
+	}
+	return nil
+}
+
+func (config *Config) CreateGeofenceCollection(ctx context.Context, description string, tags *map[string]string) (*location.CreateGeofenceCollectionOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	input := &location.CreateGeofenceCollectionInput{
+		CollectionName: aws.String(config.collectionName),
+		Description:    aws.String(description),
+		Tags:           *tags,
+	}
+	if config.kmsKeyID != "" {
+		input.KmsKeyId = aws.String(config.kmsKeyID)
+	}
+	return config.svc.CreateGeofenceCollection(ctx, input)
+}
+
+func (config *Config) DeleteGeofenceCollection(ctx context.Context) (*location.DeleteGeofenceCollectionOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.DeleteGeofenceCollection(
+		ctx,
+		&location.DeleteGeofenceCollectionInput{
+			CollectionName: aws.String(config.collectionName),
+		},
+	)
+}
+
+func (config *Config) DescribeGeofenceCollection(ctx context.Context) (*location.DescribeGeofenceCollectionOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.DescribeGeofenceCollection(
+		ctx,
+		&location.DescribeGeofenceCollectionInput{
+			CollectionName: aws.String(config.collectionName),
+		},
+	)
+}
+
+// ListGeofenceCollections returns up to maxItems geofence collections,
+// following result pages as needed. A maxItems of zero returns every
+// collection.
+func (config *Config) ListGeofenceCollections(ctx context.Context, maxItems int) ([]types.ListGeofenceCollectionsResponseEntry, error) {
+	if maxItems < 0 {
+		return nil, fmt.Errorf("max items %d must not be negative", maxItems)
+	}
+
+	paginator := location.NewListGeofenceCollectionsPaginator(
+		config.svc,
+		&location.ListGeofenceCollectionsInput{},
+	)
+
+	var entries []types.ListGeofenceCollectionsResponseEntry
+	for paginator.HasMorePages() {
+		ret, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ret.Entries...)
+		if maxItems > 0 && len(entries) >= maxItems {
+			return entries[:maxItems], nil
+		}
+	}
+
+	return entries, nil
+}
+
+func (config *Config) UpdateGeofenceCollection(ctx context.Context, description string) (*location.UpdateGeofenceCollectionOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.UpdateGeofenceCollection(
+		ctx,
+		&location.UpdateGeofenceCollectionInput{
+			CollectionName: aws.String(config.collectionName),
+			Description:    aws.String(description),
+		},
+	)
+}
+
+// GeofenceEntry is a geofence to store with BatchPutGeofences.
+type GeofenceEntry struct {
+	ID         string
+	Geometry   *Geometry
+	Properties map[string]string
+}
+
+// validate checks the entry for values the API rejects.
+func (e *GeofenceEntry) validate() error {
+	if e.ID == "" {
+		return errors.New("geofence ID not set")
+	}
+	if err := e.Geometry.validate(); err != nil {
+		return fmt.Errorf("geofence %s: %w", e.ID, err)
+	}
+	if len(e.Properties) > maxGeofenceProperties {
+		return fmt.Errorf("geofence %s: %d properties given, the maximum is %d", e.ID, len(e.Properties), maxGeofenceProperties)
+	}
+	return nil
+}
+
+// BatchPutGeofences creates or replaces the given geofences. Every entry is
+// validated before anything is stored. The entries are split into chunks to
+// stay within the API limit and the successes and errors of all chunks are
+// merged into a single output.
+func (config *Config) BatchPutGeofences(ctx context.Context, entries []GeofenceEntry) (*location.BatchPutGeofenceOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("no geofences given")
+	}
+	var errs []error
+	for i := range entries {
+		if err := entries[i].validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	out := &location.BatchPutGeofenceOutput{}
+	for start := 0; start < len(entries); start += maxBatchPutGeofences {
+		end := start + maxBatchPutGeofences
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		chunk := make([]types.BatchPutGeofenceRequestEntry, 0, end-start)
+		for _, entry := range entries[start:end] {
+			chunk = append(chunk, types.BatchPutGeofenceRequestEntry{
+				GeofenceId:         aws.String(entry.ID),
+				Geometry:           entry.Geometry.geofenceGeometry(),
+				GeofenceProperties: entry.Properties,
+			})
+		}
+		ret, err := config.svc.BatchPutGeofence(
+			ctx,
+			&location.BatchPutGeofenceInput{
+				CollectionName: aws.String(config.collectionName),
+				Entries:        chunk,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		out.Successes = append(out.Successes, ret.Successes...)
+		out.Errors = append(out.Errors, ret.Errors...)
+		out.ResultMetadata = ret.ResultMetadata
+	}
+
+	return out, nil
+}
+
+// PutGeofence creates the geofence, or replaces its geometry and properties
+// if it already exists.
+func (config *Config) PutGeofence(ctx context.Context, geofenceID string, geometry *Geometry, properties map[string]string) (*location.PutGeofenceOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if geofenceID == "" {
+		return nil, errors.New("geofenceID not set")
+	}
+	if err := geometry.validate(); err != nil {
+		return nil, err
+	}
+	if len(properties) > maxGeofenceProperties {
+		return nil, fmt.Errorf("%d properties given, the maximum is %d", len(properties), maxGeofenceProperties)
+	}
+
+	return config.svc.PutGeofence(
+		ctx,
+		&location.PutGeofenceInput{
+			CollectionName:     aws.String(config.collectionName),
+			GeofenceId:         aws.String(geofenceID),
+			Geometry:           geometry.geofenceGeometry(),
+			GeofenceProperties: properties,
+		},
+	)
+}
+
+func (config *Config) GetGeofence(ctx context.Context, geofenceID string) (*location.GetGeofenceOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if geofenceID == "" {
+		return nil, errors.New("geofenceID not set")
+	}
+
+	return config.svc.GetGeofence(
+		ctx,
+		&location.GetGeofenceInput{
+			CollectionName: aws.String(config.collectionName),
+			GeofenceId:     aws.String(geofenceID),
+		},
+	)
+}
+
+// BatchDeleteGeofences deletes the given geofences from the collection.
+// The geofence IDs are split into chunks to stay within the API limit and the
+// errors of all chunks are merged into a single output.
+func (config *Config) BatchDeleteGeofences(ctx context.Context, geofenceIDs []string) (*location.BatchDeleteGeofenceOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if len(geofenceIDs) == 0 {
+		return nil, errors.New("no geofence IDs given")
+	}
+
+	out := &location.BatchDeleteGeofenceOutput{}
+	for start := 0; start < len(geofenceIDs); start += maxBatchDeleteGeofences {
+		end := start + maxBatchDeleteGeofences
+		if end > len(geofenceIDs) {
+			end = len(geofenceIDs)
+		}
+
+		ret, err := config.svc.BatchDeleteGeofence(
+			ctx,
+			&location.BatchDeleteGeofenceInput{
+				CollectionName: aws.String(config.collectionName),
+				GeofenceIds:    geofenceIDs[start:end],
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		out.Errors = append(out.Errors, ret.Errors...)
+		out.ResultMetadata = ret.ResultMetadata
+	}
+
+	return out, nil
+}
+
+// ListGeofences returns every geofence in the collection, following all result pages.
+func (config *Config) ListGeofences(ctx context.Context) ([]types.ListGeofenceResponseEntry, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	paginator := location.NewListGeofencesPaginator(
+		config.svc,
+		&location.ListGeofencesInput{
+			CollectionName: aws.String(config.collectionName),
+		},
+	)
+
+	var entries []types.ListGeofenceResponseEntry
+	for paginator.HasMorePages() {
+		ret, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ret.Entries...)
+	}
+
+	return entries, nil
+}
+
+// WaitForGeofences polls the collection until every given geofence has left
+// the PENDING state or the timeout expires. It returns the last seen status
+// of each geofence; geofences not found in the collection are reported as
+// DELETED.
+func (config *Config) WaitForGeofences(ctx context.Context, geofenceIDs []string, interval time.Duration, timeout time.Duration) (map[string]string, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		entries, err := config.ListGeofences(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		found := make(map[string]string, len(entries))
+		for _, entry := range entries {
+			found[*entry.GeofenceId] = *entry.Status
+		}
+
+		statuses := make(map[string]string, len(geofenceIDs))
+		pending := 0
+		for _, id := range geofenceIDs {
+			status, ok := found[id]
+			if !ok {
+				status = StatusDeleted
+			}
+			if status == StatusPending || status == StatusDeleting {
+				pending++
+			}
+			statuses[id] = status
+		}
+
+		if pending == 0 {
+			return statuses, nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return statuses, fmt.Errorf("timed out waiting for %d geofence(s) to become active", pending)
+		}
+
+		if config.log != nil {
+			config.log.WithFields(logrus.Fields{
+				"pending": pending,
+			}).Debug("waiting for geofences")
+		}
+		select {
+		case <-ctx.Done():
+			return statuses, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package geofencesvc
+
+import (
+	"testing"
+)
+
+// square is a closed counter-clockwise ring around 0,0.
+var square = [][]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}, {-1, -1}}
+
+// hole is a closed clockwise ring inside square.
+var hole = [][]float64{{-0.5, -0.5}, {-0.5, 0.5}, {0.5, 0.5}, {0.5, -0.5}, {-0.5, -0.5}}
+
+// reversed returns the ring in the opposite direction.
+func reversed(ring [][]float64) [][]float64 {
+	out := make([][]float64, len(ring))
+	for i, position := range ring {
+		out[len(ring)-1-i] = position
+	}
+	return out
+}
+
+func TestGeometryValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		geometry *Geometry
+		wantErr  bool
+	}{
+		{"nil", nil, true},
+		{"empty", &Geometry{}, true},
+		{"polygon and circle", &Geometry{Polygon: [][][]float64{square}, Circle: &Circle{Radius: 1}}, true},
+		{"circle", &Geometry{Circle: &Circle{Latitude: 47.6, Longitude: -122.3, Radius: 100}}, false},
+		{"circle latitude out of range", &Geometry{Circle: &Circle{Latitude: 91, Radius: 100}}, true},
+		{"circle longitude out of range", &Geometry{Circle: &Circle{Longitude: -181, Radius: 100}}, true},
+		{"circle without radius", &Geometry{Circle: &Circle{}}, true},
+		{"polygon", &Geometry{Polygon: [][][]float64{square}}, false},
+		{"polygon with hole", &Geometry{Polygon: [][][]float64{square, hole}}, false},
+		{"polygon without rings", &Geometry{Polygon: [][][]float64{}}, true},
+		{"open ring", &Geometry{Polygon: [][][]float64{square[:4]}}, true},
+		{"unclosed ring", &Geometry{Polygon: [][][]float64{{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}, {-1, 0}}}}, true},
+		{"too few positions", &Geometry{Polygon: [][][]float64{{{0, 0}, {1, 0}, {0, 0}}}}, true},
+		{"short position", &Geometry{Polygon: [][][]float64{{{-1, -1}, {1}, {1, 1}, {-1, -1}}}}, true},
+		{"clockwise exterior", &Geometry{Polygon: [][][]float64{reversed(square)}}, true},
+		{"counter-clockwise hole", &Geometry{Polygon: [][][]float64{square, reversed(hole)}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.geometry.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignedArea(t *testing.T) {
+	tests := []struct {
+		name string
+		ring [][]float64
+		want float64
+	}{
+		{"counter-clockwise", square, 8},
+		{"clockwise", reversed(square), -8},
+		{"degenerate", [][]float64{{0, 0}, {1, 1}, {2, 2}, {0, 0}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signedArea(tt.ring); got != tt.want {
+				t.Errorf("signedArea() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
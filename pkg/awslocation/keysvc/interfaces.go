@@ -0,0 +1,20 @@
+package keysvc
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/location"
+)
+
+// LocationClient is the subset of the Amazon Location client used by this
+// package. *location.Client implements it; tests can substitute a fake with
+// SetLocationClient.
+type LocationClient interface {
+	CreateKey(ctx context.Context, params *location.CreateKeyInput, optFns ...func(*location.Options)) (*location.CreateKeyOutput, error)
+	DeleteKey(ctx context.Context, params *location.DeleteKeyInput, optFns ...func(*location.Options)) (*location.DeleteKeyOutput, error)
+	DescribeKey(ctx context.Context, params *location.DescribeKeyInput, optFns ...func(*location.Options)) (*location.DescribeKeyOutput, error)
+	ListKeys(ctx context.Context, params *location.ListKeysInput, optFns ...func(*location.Options)) (*location.ListKeysOutput, error)
+	UpdateKey(ctx context.Context, params *location.UpdateKeyInput, optFns ...func(*location.Options)) (*location.UpdateKeyOutput, error)
+}
+
+var _ LocationClient = (*location.Client)(nil)
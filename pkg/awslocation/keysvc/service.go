@@ -0,0 +1,264 @@
+package keysvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+)
+
+type Option func(config *Config)
+
+// Configuration structure.
+type Config struct {
+	region  string
+	profile string
+	keyName string
+	log     *logrus.Logger
+	svc     LocationClient
+}
+
+// Restrictions limit what an API key may be used for.
+type Restrictions struct {
+	// The actions the key allows, for example geo:GetMap* or
+	// geo:SearchPlaceIndexForText.
+	//
+	// This member is required.
+	AllowActions []string
+
+	// The ARNs of the resources the key may be used with. Wildcards may be
+	// used at the end of the resource name.
+	//
+	// This member is required.
+	AllowResources []string
+
+	// The HTTP referers the key may be used from, for example
+	// https://example.com/*. Empty allows any referer.
+	AllowReferers []string
+}
+
+// validate checks the restrictions for values the API requires.
+func (r *Restrictions) validate() error {
+	if r == nil || len(r.AllowActions) == 0 || len(r.AllowResources) == 0 {
+		return errors.New("allowed actions and resources must be set")
+	}
+	return nil
+}
+
+// apiKeyRestrictions converts the restrictions into the API key restrictions of the API.
+func (r *Restrictions) apiKeyRestrictions() *types.ApiKeyRestrictions {
+	return &types.ApiKeyRestrictions{
+		AllowActions:   r.AllowActions,
+		AllowResources: r.AllowResources,
+		AllowReferers:  r.AllowReferers,
+	}
+}
+
+// KeyUpdate holds the changes to make to an API key.
+type KeyUpdate struct {
+	// The new description. Nil leaves the description unchanged.
+	Description *string
+
+	// The new restrictions, replacing the current ones. Nil leaves the
+	// restrictions unchanged.
+	Restrictions *Restrictions
+
+	// The new expiry time. Nil leaves the expiry unchanged unless NoExpiry is
+	// set.
+	ExpireTime *time.Time
+
+	// Set to make the key never expire. Cannot be combined with ExpireTime.
+	NoExpiry bool
+
+	// Set to update a key which was used in the last 7 days.
+	Force bool
+}
+
+func New(opts ...func(*Config)) (*Config, error) {
+	config := &Config{}
+
+	// apply the list of options to Config
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.region == "" {
+		config.region = os.Getenv("AWS_REGION")
+	}
+
+	if config.svc == nil {
+		c, err := awsconfig.LoadDefaultConfig(context.TODO(), func(o *awsconfig.LoadOptions) error {
+			o.Region = config.region
+			if config.profile != "" {
+				o.SharedConfigProfile = config.profile
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		config.svc = location.NewFromConfig(c)
+	}
+
+	return config, nil
+}
+
+func SetAWSRegion(region string) Option {
+	return func(config *Config) {
+		config.region = region
+	}
+}
+
+func SetAWSProfile(profile string) Option {
+	return func(config *Config) {
+		config.profile = profile
+	}
+}
+
+func SetKeyName(keyName string) Option {
+	return func(config *Config) {
+		config.keyName = keyName
+	}
+}
+
+// SetLocationClient uses an existing client, such as one cached by clientmgr
+// or a fake in tests, instead of building a new one from the region and profile.
+func SetLocationClient(client LocationClient) Option {
+	return func(config *Config) {
+		config.svc = client
+	}
+}
+
+func SetLogger(log *logrus.Logger) Option {
+	return func(config *Config) {
+		config.log = log
+	}
+}
+
+func (c *Config) sanity() error {
+	if c.keyName == "" {
+		return errors.New("keyName not set")
+	}
+	return nil
+}
+
+// CreateKey creates an API key with the given restrictions. A nil expireTime
+// creates a key which never expires.
+func (config *Config) CreateKey(ctx context.Context, description string, restrictions *Restrictions, expireTime *time.Time, tags *map[string]string) (*location.CreateKeyOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if err := restrictions.validate(); err != nil {
+		return nil, err
+	}
+	if expireTime != nil && !expireTime.After(time.Now()) {
+		return nil, fmt.Errorf("expire time %s is in the past", expireTime.Format(time.RFC3339))
+	}
+
+	input := &location.CreateKeyInput{
+		Description:  aws.String(description),
+		KeyName:      aws.String(config.keyName),
+		Restrictions: restrictions.apiKeyRestrictions(),
+		Tags:         *tags,
+	}
+	if expireTime != nil {
+		input.ExpireTime = expireTime
+	} else {
+		input.NoExpiry = aws.Bool(true)
+	}
+	return config.svc.CreateKey(ctx, input)
+}
+
+// DeleteKey deletes the API key. Keys used in the last 7 days, or which have
+// not expired, are only deleted if force is set.
+func (config *Config) DeleteKey(ctx context.Context, force bool) (*location.DeleteKeyOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.DeleteKey(
+		ctx,
+		&location.DeleteKeyInput{
+			ForceDelete: aws.Bool(force),
+			KeyName:     aws.String(config.keyName),
+		},
+	)
+}
+
+// DescribeKey returns the details of the API key, including its value.
+func (config *Config) DescribeKey(ctx context.Context) (*location.DescribeKeyOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.DescribeKey(
+		ctx,
+		&location.DescribeKeyInput{
+			KeyName: aws.String(config.keyName),
+		},
+	)
+}
+
+// ListKeys returns up to maxItems API keys, following result pages as needed.
+// A maxItems of zero returns every key.
+func (config *Config) ListKeys(ctx context.Context, maxItems int) ([]types.ListKeysResponseEntry, error) {
+	if maxItems < 0 {
+		return nil, fmt.Errorf("max items %d must not be negative", maxItems)
+	}
+
+	paginator := location.NewListKeysPaginator(
+		config.svc,
+		&location.ListKeysInput{},
+	)
+
+	var entries []types.ListKeysResponseEntry
+	for paginator.HasMorePages() {
+		ret, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ret.Entries...)
+		if maxItems > 0 && len(entries) >= maxItems {
+			return entries[:maxItems], nil
+		}
+	}
+
+	return entries, nil
+}
+
+func (config *Config) UpdateKey(ctx context.Context, update *KeyUpdate) (*location.UpdateKeyOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if update == nil {
+		return nil, errors.New("no update given")
+	}
+	if update.NoExpiry && update.ExpireTime != nil {
+		return nil, errors.New("expire time and no expiry are mutually exclusive")
+	}
+
+	input := &location.UpdateKeyInput{
+		Description: update.Description,
+		ExpireTime:  update.ExpireTime,
+		ForceUpdate: aws.Bool(update.Force),
+		KeyName:     aws.String(config.keyName),
+	}
+	if update.NoExpiry {
+		input.NoExpiry = aws.Bool(true)
+	}
+	if update.Restrictions != nil {
+		if err := update.Restrictions.validate(); err != nil {
+			return nil, err
+		}
+		input.Restrictions = update.Restrictions.apiKeyRestrictions()
+	}
+	return config.svc.UpdateKey(ctx, input)
+}
@@ -0,0 +1,24 @@
+package mapsvc
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/location"
+)
+
+// LocationClient is the subset of the Amazon Location client used by this
+// package. *location.Client implements it; tests can substitute a fake with
+// SetLocationClient.
+type LocationClient interface {
+	CreateMap(ctx context.Context, params *location.CreateMapInput, optFns ...func(*location.Options)) (*location.CreateMapOutput, error)
+	DeleteMap(ctx context.Context, params *location.DeleteMapInput, optFns ...func(*location.Options)) (*location.DeleteMapOutput, error)
+	DescribeMap(ctx context.Context, params *location.DescribeMapInput, optFns ...func(*location.Options)) (*location.DescribeMapOutput, error)
+	GetMapGlyphs(ctx context.Context, params *location.GetMapGlyphsInput, optFns ...func(*location.Options)) (*location.GetMapGlyphsOutput, error)
+	GetMapSprites(ctx context.Context, params *location.GetMapSpritesInput, optFns ...func(*location.Options)) (*location.GetMapSpritesOutput, error)
+	GetMapStyleDescriptor(ctx context.Context, params *location.GetMapStyleDescriptorInput, optFns ...func(*location.Options)) (*location.GetMapStyleDescriptorOutput, error)
+	GetMapTile(ctx context.Context, params *location.GetMapTileInput, optFns ...func(*location.Options)) (*location.GetMapTileOutput, error)
+	ListMaps(ctx context.Context, params *location.ListMapsInput, optFns ...func(*location.Options)) (*location.ListMapsOutput, error)
+	UpdateMap(ctx context.Context, params *location.UpdateMapInput, optFns ...func(*location.Options)) (*location.UpdateMapOutput, error)
+}
+
+var _ LocationClient = (*location.Client)(nil)
@@ -0,0 +1,333 @@
+package mapsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+)
+
+type Option func(config *Config)
+
+// Configuration structure.
+type Config struct {
+	region  string
+	profile string
+	mapName string
+	apiKey  string
+	log     *logrus.Logger
+	svc     LocationClient
+}
+
+type MapConfiguration struct {
+	// The map style, for example VectorEsriNavigation.
+	//
+	// This member is required.
+	Style string
+
+	// The political view for the style, for example IND for the Indian view.
+	// Leave empty to not use a political view. Not all styles support
+	// political views.
+	PoliticalView string
+
+	// The custom layers to enable, for example POI for the VectorEsriNavigation
+	// style. Not all styles support custom layers.
+	CustomLayers []string
+}
+
+type MapConfigurationUpdate struct {
+	// The new political view. Nil leaves the political view unchanged, an
+	// empty string removes it.
+	PoliticalView *string
+
+	// The new custom layers. Nil leaves the custom layers unchanged, an empty
+	// slice removes them.
+	CustomLayers []string
+}
+
+func New(opts ...func(*Config)) (*Config, error) {
+	config := &Config{}
+
+	// apply the list of options to Config
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.region == "" {
+		config.region = os.Getenv("AWS_REGION")
+	}
+
+	if config.svc == nil {
+		c, err := awsconfig.LoadDefaultConfig(context.TODO(), func(o *awsconfig.LoadOptions) error {
+			o.Region = config.region
+			if config.profile != "" {
+				o.SharedConfigProfile = config.profile
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		config.svc = location.NewFromConfig(c)
+	}
+
+	return config, nil
+}
+
+func SetAWSRegion(region string) Option {
+	return func(config *Config) {
+		config.region = region
+	}
+}
+
+func SetAWSProfile(profile string) Option {
+	return func(config *Config) {
+		config.profile = profile
+	}
+}
+
+// SetAPIKey authorizes the tile, sprite, glyph and style requests with an
+// Amazon Location API key instead of IAM credentials.
+func SetAPIKey(apiKey string) Option {
+	return func(config *Config) {
+		config.apiKey = apiKey
+	}
+}
+
+// SetLocationClient uses an existing client, such as one cached by clientmgr
+// or a fake in tests, instead of building a new one from the region and profile.
+func SetLocationClient(client LocationClient) Option {
+	return func(config *Config) {
+		config.svc = client
+	}
+}
+
+func SetLogger(log *logrus.Logger) Option {
+	return func(config *Config) {
+		config.log = log
+	}
+}
+
+func SetMapName(mapName string) Option {
+	return func(config *Config) {
+		config.mapName = mapName
+	}
+}
+
+func (c *Config) sanity() error {
+	if c.mapName == "" {
+		return errors.New("mapName not set")
+	}
+	return nil
+}
+
+// key returns the API key as a request parameter, or nil if not set.
+func (config *Config) key() *string {
+	if config.apiKey == "" {
+		return nil
+	}
+	return aws.String(config.apiKey)
+}
+
+// maxZoom is the highest zoom level map tiles are served for.
+const maxZoom = 22
+
+// glyphRangeSize is the number of code points in a glyph range.
+const glyphRangeSize = 256
+
+// GetMapTile fetches the vector or raster tile at the given zoom level and
+// tile coordinates.
+func (config *Config) GetMapTile(ctx context.Context, z int, x int, y int) (*location.GetMapTileOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if z < 0 || z > maxZoom {
+		return nil, fmt.Errorf("zoom %d out of range 0-%d", z, maxZoom)
+	}
+	if n := 1 << z; x < 0 || x >= n || y < 0 || y >= n {
+		return nil, fmt.Errorf("tile %d/%d out of range 0-%d at zoom %d", x, y, n-1, z)
+	}
+
+	return config.svc.GetMapTile(
+		ctx,
+		&location.GetMapTileInput{
+			Key:     config.key(),
+			MapName: aws.String(config.mapName),
+			X:       aws.String(strconv.Itoa(x)),
+			Y:       aws.String(strconv.Itoa(y)),
+			Z:       aws.String(strconv.Itoa(z)),
+		},
+	)
+}
+
+// GetMapSprites fetches a sprite sheet or its index, for example sprites.png,
+// sprites.json, sprites@2x.png or sprites@2x.json.
+func (config *Config) GetMapSprites(ctx context.Context, fileName string) (*location.GetMapSpritesOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if fileName == "" {
+		return nil, errors.New("fileName not set")
+	}
+
+	return config.svc.GetMapSprites(
+		ctx,
+		&location.GetMapSpritesInput{
+			FileName: aws.String(fileName),
+			Key:      config.key(),
+			MapName:  aws.String(config.mapName),
+		},
+	)
+}
+
+// GetMapGlyphs fetches the glyphs of a font stack, for example "Noto Sans
+// Regular", for a range of 256 code points given as start-end, such as 0-255.
+func (config *Config) GetMapGlyphs(ctx context.Context, fontStack string, fontRange string) (*location.GetMapGlyphsOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if fontStack == "" {
+		return nil, errors.New("fontStack not set")
+	}
+	var start, end int
+	if n, err := fmt.Sscanf(fontRange, "%d-%d", &start, &end); err != nil || n != 2 || start%glyphRangeSize != 0 || end != start+glyphRangeSize-1 {
+		return nil, fmt.Errorf("invalid font range %q, must be a range of %d code points like 0-255", fontRange, glyphRangeSize)
+	}
+
+	return config.svc.GetMapGlyphs(
+		ctx,
+		&location.GetMapGlyphsInput{
+			FontStack:        aws.String(fontStack),
+			FontUnicodeRange: aws.String(fontRange),
+			Key:              config.key(),
+			MapName:          aws.String(config.mapName),
+		},
+	)
+}
+
+// GetMapStyleDescriptor fetches the style descriptor of the map, a JSON
+// document in the Mapbox GL style format.
+func (config *Config) GetMapStyleDescriptor(ctx context.Context) (*location.GetMapStyleDescriptorOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.GetMapStyleDescriptor(
+		ctx,
+		&location.GetMapStyleDescriptorInput{
+			Key:     config.key(),
+			MapName: aws.String(config.mapName),
+		},
+	)
+}
+
+func (config *Config) CreateMap(ctx context.Context, description string, mapConfig *MapConfiguration, tags *map[string]string) (*location.CreateMapOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if mapConfig == nil || mapConfig.Style == "" {
+		return nil, errors.New("map style not set")
+	}
+
+	configuration := &types.MapConfiguration{
+		Style:        aws.String(mapConfig.Style),
+		CustomLayers: mapConfig.CustomLayers,
+	}
+	if mapConfig.PoliticalView != "" {
+		configuration.PoliticalView = aws.String(mapConfig.PoliticalView)
+	}
+
+	return config.svc.CreateMap(
+		ctx,
+		&location.CreateMapInput{
+			Configuration: configuration,
+			Description:   aws.String(description),
+			MapName:       aws.String(config.mapName),
+			Tags:          *tags,
+		},
+	)
+}
+
+func (config *Config) DeleteMap(ctx context.Context) (*location.DeleteMapOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.DeleteMap(
+		ctx,
+		&location.DeleteMapInput{
+			MapName: aws.String(config.mapName),
+		},
+	)
+}
+
+func (config *Config) DescribeMap(ctx context.Context) (*location.DescribeMapOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.DescribeMap(
+		ctx,
+		&location.DescribeMapInput{
+			MapName: aws.String(config.mapName),
+		},
+	)
+}
+
+// ListMaps returns up to maxItems map resources, following result pages as
+// needed. A maxItems of zero returns every map.
+func (config *Config) ListMaps(ctx context.Context, maxItems int) ([]types.ListMapsResponseEntry, error) {
+	if maxItems < 0 {
+		return nil, fmt.Errorf("max items %d must not be negative", maxItems)
+	}
+
+	paginator := location.NewListMapsPaginator(
+		config.svc,
+		&location.ListMapsInput{},
+	)
+
+	var entries []types.ListMapsResponseEntry
+	for paginator.HasMorePages() {
+		ret, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ret.Entries...)
+		if maxItems > 0 && len(entries) >= maxItems {
+			return entries[:maxItems], nil
+		}
+	}
+
+	return entries, nil
+}
+
+func (config *Config) UpdateMap(ctx context.Context, description *string, update *MapConfigurationUpdate) (*location.UpdateMapOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	var configurationUpdate *types.MapConfigurationUpdate
+	if update != nil && (update.PoliticalView != nil || update.CustomLayers != nil) {
+		configurationUpdate = &types.MapConfigurationUpdate{
+			CustomLayers:  update.CustomLayers,
+			PoliticalView: update.PoliticalView,
+		}
+	}
+
+	return config.svc.UpdateMap(
+		ctx,
+		&location.UpdateMapInput{
+			ConfigurationUpdate: configurationUpdate,
+			Description:         description,
+			MapName:             aws.String(config.mapName),
+		},
+	)
+}
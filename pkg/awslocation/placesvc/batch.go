@@ -0,0 +1,246 @@
+package placesvc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"golang.org/x/time/rate"
+)
+
+// defaultBatchConcurrency is the number of workers used when BatchOptions
+// does not set one.
+const defaultBatchConcurrency = 4
+
+// orderWindow is the number of items per worker which may be started ahead
+// of the oldest unfinished one when results are ordered.
+const orderWindow = 8
+
+// defaultRetryDelay is the first backoff of a throttled request when
+// BatchOptions does not set one.
+const defaultRetryDelay = 200 * time.Millisecond
+
+// TextSearch is a single free-form text search.
+type TextSearch = SuggestionSearch
+
+// BatchOptions controls how batch searches are fanned out.
+type BatchOptions struct {
+	// Concurrency is the number of requests in flight at once. Defaults to 4.
+	Concurrency int
+
+	// RequestsPerSecond limits the rate requests and their retries are
+	// started at with a token bucket of size one, so bursts never exceed a
+	// TPS quota of the same value. Zero means no limit.
+	RequestsPerSecond float64
+
+	// Retries is how often a throttled request is retried, with exponential
+	// backoff starting at RetryDelay (default 200ms), on top of the retries of
+	// the AWS SDK.
+	Retries    int
+	RetryDelay time.Duration
+
+	// Ordered delivers results in the order of the input instead of as soon
+	// as they complete. A slow request then holds back the ones after it.
+	Ordered bool
+}
+
+// BatchPositionResult is the outcome of one lookup of BatchReverseGeocode.
+// Index is the position of the point in the points slice, or in the order
+// received by StreamReverseGeocode.
+type BatchPositionResult struct {
+	Index    int
+	Position *LatLon
+	Output   *location.SearchPlaceIndexForPositionOutput
+	Err      error
+}
+
+// BatchTextResult is the outcome of one search of BatchSearchText. Index is
+// the position of the search in the requests slice, or in the order received
+// by StreamSearchText.
+type BatchTextResult struct {
+	Index  int
+	Search *TextSearch
+	Output *location.SearchPlaceIndexForTextOutput
+	Err    error
+}
+
+// BatchSearchText runs the text searches over a pool of workers and streams
+// each result as soon as it completes, or in input order if opts.Ordered is
+// set. The channel is
+// closed once every search has finished or ctx is cancelled; searches not
+// started before cancellation are reported with the context error. Callers
+// must receive until the channel is closed.
+func (config *Config) BatchSearchText(ctx context.Context, requests []TextSearch, opts BatchOptions) <-chan BatchTextResult {
+	return config.StreamSearchText(ctx, feed(requests), opts)
+}
+
+// StreamSearchText is BatchSearchText for searches received from a channel,
+// so input of any length is searched in constant memory. Index is the
+// position of the search in the order received. Searches are received until
+// the channel is closed, also after ctx is cancelled.
+func (config *Config) StreamSearchText(ctx context.Context, requests <-chan TextSearch, opts BatchOptions) <-chan BatchTextResult {
+	return runBatch(ctx, requests, opts, func(ctx context.Context, limiter *rate.Limiter, i int, search *TextSearch) BatchTextResult {
+		var out *location.SearchPlaceIndexForTextOutput
+		err := retry(ctx, opts, limiter, func() (err error) {
+			out, err = config.SearchPlaceIndexForText(ctx, search)
+			return err
+		})
+		return BatchTextResult{Index: i, Search: search, Output: out, Err: err}
+	}, func(i int, search *TextSearch, err error) BatchTextResult {
+		return BatchTextResult{Index: i, Search: search, Err: err}
+	}, func(r BatchTextResult) int { return r.Index })
+}
+
+// BatchReverseGeocode looks up the places at the points over a pool of
+// workers. Results are streamed like those of BatchSearchText.
+func (config *Config) BatchReverseGeocode(ctx context.Context, points []LatLon, opts BatchOptions) <-chan BatchPositionResult {
+	return config.StreamReverseGeocode(ctx, feed(points), opts)
+}
+
+// StreamReverseGeocode is BatchReverseGeocode for points received from a
+// channel, like StreamSearchText.
+func (config *Config) StreamReverseGeocode(ctx context.Context, points <-chan LatLon, opts BatchOptions) <-chan BatchPositionResult {
+	return runBatch(ctx, points, opts, func(ctx context.Context, limiter *rate.Limiter, i int, point *LatLon) BatchPositionResult {
+		var out *location.SearchPlaceIndexForPositionOutput
+		err := retry(ctx, opts, limiter, func() (err error) {
+			out, err = config.SearchPlaceIndexForPosition(ctx, &PositionSearch{Position: point})
+			return err
+		})
+		return BatchPositionResult{Index: i, Position: point, Output: out, Err: err}
+	}, func(i int, point *LatLon, err error) BatchPositionResult {
+		return BatchPositionResult{Index: i, Position: point, Err: err}
+	}, func(r BatchPositionResult) int { return r.Index })
+}
+
+// feed returns a channel sending the items and then closed.
+func feed[I any](items []I) <-chan I {
+	in := make(chan I)
+	go func() {
+		defer close(in)
+		for _, item := range items {
+			in <- item
+		}
+	}()
+	return in
+}
+
+// retry calls do until it succeeds, fails with an error other than
+// ErrThrottled, or opts.Retries is exhausted. Every attempt, retries included,
+// waits for a token of limiter first so retries count against the rate too.
+func retry(ctx context.Context, opts BatchOptions, limiter *rate.Limiter, do func() error) error {
+	delay := opts.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	for attempt := 0; ; attempt++ {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		err := do()
+		if err == nil || attempt >= opts.Retries || !errors.Is(err, ErrThrottled) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay << attempt):
+		}
+	}
+}
+
+// inOrder re-sequences results by their index, holding back results which
+// complete before an earlier one. A slot of window is freed for every result
+// sent.
+func inOrder[T any](in <-chan T, index func(T) int, window <-chan struct{}) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		pending := make(map[int]T)
+		next := 0
+		for r := range in {
+			pending[index(r)] = r
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				out <- r
+				<-window
+				next++
+			}
+		}
+	}()
+	return out
+}
+
+// runBatch calls do for every item received from in on a pool of workers and
+// sends the results on the returned channel, in the order of in if
+// opts.Ordered is set. do is passed the rate limiter shared by all workers,
+// nil without a rate, to wait on before each request. failed builds the result
+// of an item which could not be started, and index returns the position of
+// the item of a result.
+func runBatch[I, T any](ctx context.Context, in <-chan I, opts BatchOptions, do func(ctx context.Context, limiter *rate.Limiter, i int, item *I) T, failed func(i int, item *I, err error) T, index func(T) int) <-chan T {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = defaultBatchConcurrency
+	}
+	var limiter *rate.Limiter
+	if opts.RequestsPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(opts.RequestsPerSecond), 1)
+	}
+	// Ordered results wait behind a slow request in a reorder buffer. Items
+	// are only started while fewer than window of them wait or run, so the
+	// buffer cannot grow with the input.
+	var window chan struct{}
+	if opts.Ordered {
+		window = make(chan struct{}, orderWindow*concurrency)
+	}
+
+	type job struct {
+		i    int
+		item *I
+	}
+	results := make(chan T, concurrency)
+	jobs := make(chan job)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results <- do(ctx, limiter, j.i, j.item)
+			}
+		}()
+	}
+
+	go func() {
+		defer func() {
+			close(jobs)
+			wg.Wait()
+			close(results)
+		}()
+		i := 0
+		for item := range in {
+			if window != nil {
+				window <- struct{}{}
+			}
+			select {
+			case <-ctx.Done():
+				results <- failed(i, &item, ctx.Err())
+			case jobs <- job{i: i, item: &item}:
+			}
+			i++
+		}
+	}()
+
+	if opts.Ordered {
+		return inOrder(results, index, window)
+	}
+	return results
+}
@@ -0,0 +1,159 @@
+package placesvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/smithy-go"
+	"golang.org/x/time/rate"
+)
+
+var errThrottling = &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+
+// failing returns a do function of retry which fails with err the first
+// failures times, and counts its calls.
+func failing(failures int, err error, calls *int) func() error {
+	return func() error {
+		*calls++
+		if *calls <= failures {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		retries   int
+		failures  int
+		err       error
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", 2, 0, nil, 1, nil},
+		{"throttled then success", 2, 2, fmt.Errorf("%w: slow down", ErrThrottled), 3, nil},
+		{"throttled until exhausted", 2, 5, fmt.Errorf("%w: slow down", ErrThrottled), 3, ErrThrottled},
+		{"no retries", 0, 1, fmt.Errorf("%w: slow down", ErrThrottled), 1, ErrThrottled},
+		{"other error", 2, 1, ErrAccessDenied, 1, ErrAccessDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			opts := BatchOptions{Retries: tt.retries, RetryDelay: time.Millisecond}
+			err := retry(context.Background(), opts, nil, failing(tt.failures, tt.err, &calls))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("retry() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("retry() made %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	opts := BatchOptions{Retries: 3, RetryDelay: time.Hour}
+	err := retry(ctx, opts, nil, failing(1, ErrThrottled, &calls))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("retry() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRetryWaitsForLimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+	}{
+		{"first attempt", 0},
+		{"every retry", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval := 20 * time.Millisecond
+			limiter := rate.NewLimiter(rate.Every(interval), 1)
+			calls := 0
+			opts := BatchOptions{Retries: tt.failures, RetryDelay: time.Nanosecond}
+			start := time.Now()
+			if err := retry(context.Background(), opts, limiter, failing(tt.failures, ErrThrottled, &calls)); err != nil {
+				t.Fatal(err)
+			}
+			// The first token is free, every further attempt waits for one.
+			if want := time.Duration(tt.failures) * interval; time.Since(start) < want {
+				t.Errorf("%d attempts took %v, want at least %v", calls, time.Since(start), want)
+			}
+		})
+	}
+}
+
+func TestBatchSearchText(t *testing.T) {
+	tests := []struct {
+		name    string
+		ordered bool
+		rps     float64
+	}{
+		{"unordered", false, 0},
+		{"ordered", true, 0},
+		{"rate limited", true, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttled := map[string]bool{}
+			var mu sync.Mutex
+			fake := &fakeClient{search: func(input *location.SearchPlaceIndexForTextInput) (*location.SearchPlaceIndexForTextOutput, error) {
+				i, _ := strconv.Atoi(*input.Text)
+				// Earlier searches finish later, and every third one is
+				// throttled once.
+				time.Sleep(time.Duration(20-i) * time.Millisecond / 4)
+				mu.Lock()
+				defer mu.Unlock()
+				if i%3 == 0 && !throttled[*input.Text] {
+					throttled[*input.Text] = true
+					return nil, errThrottling
+				}
+				return echo(input)
+			}}
+			svc, err := New(SetLocationClient(fake), SetIndexName("index"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var requests []TextSearch
+			for i := range 20 {
+				requests = append(requests, TextSearch{Text: aws.String(strconv.Itoa(i))})
+			}
+			opts := BatchOptions{Concurrency: 4, Retries: 1, RetryDelay: time.Millisecond, Ordered: tt.ordered, RequestsPerSecond: tt.rps}
+			seen := map[int]bool{}
+			next := 0
+			for r := range svc.BatchSearchText(context.Background(), requests, opts) {
+				if r.Err != nil {
+					t.Fatalf("search %d: %v", r.Index, r.Err)
+				}
+				if got := *r.Output.Summary.Text; got != strconv.Itoa(r.Index) {
+					t.Errorf("result %d has the output of search %s", r.Index, got)
+				}
+				if tt.ordered && r.Index != next {
+					t.Errorf("got result %d, want %d", r.Index, next)
+				}
+				seen[r.Index] = true
+				next++
+			}
+			if len(seen) != len(requests) {
+				t.Errorf("got %d results, want %d", len(seen), len(requests))
+			}
+			if want := len(requests) + 7; fake.calls != want {
+				t.Errorf("made %d calls, want %d", fake.calls, want)
+			}
+		})
+	}
+}
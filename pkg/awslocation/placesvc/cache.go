@@ -0,0 +1,133 @@
+package placesvc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Cache stores search results, so identical searches are only paid for
+// once. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under the key, or false if there is
+	// none or it has expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Put stores the value under the key for ttl, or without expiry if ttl
+	// is zero.
+	Put(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// SetCache caches the results of text, position and suggestion searches,
+// including those of batch searches, for ttl. Results of SingleUse indexes
+// must not be stored under the AWS service terms, so only cache searches of
+// Storage indexes; see CheckCacheable.
+func SetCache(cache Cache, ttl time.Duration) Option {
+	return func(config *Config) {
+		config.cache = cache
+		config.cacheTTL = ttl
+	}
+}
+
+// CheckCacheable returns an error unless the results of the index may be
+// stored, that is unless its intended use is Storage.
+func (config *Config) CheckCacheable(ctx context.Context, indexName string) error {
+	ret, err := config.DescribePlaceIndex(ctx, indexName)
+	if err != nil {
+		return err
+	}
+	if ret.DataSourceConfiguration == nil || ret.DataSourceConfiguration.IntendedUse != types.IntendedUse(IntendedUseStorage) {
+		return fmt.Errorf("%w: results of place index %s must not be stored, only indexes with intended use %s can be cached", ErrInvalidOption, aws.ToString(ret.IndexName), IntendedUseStorage)
+	}
+	return nil
+}
+
+// cacheRegion returns the region searches are sent to: that of the location
+// client if it is an AWS SDK client, else the configured one.
+func (config *Config) cacheRegion() string {
+	if c, ok := config.svc.(*location.Client); ok && c.Options().Region != "" {
+		return c.Options().Region
+	}
+	return config.region
+}
+
+// cacheKey returns the key of a search: the operation and region followed by
+// a hash of its input, with the text normalized and the API key left out, as
+// neither changes the results. Indexes of the same name in other regions may
+// use other data sources, so their results are kept apart.
+func cacheKey(region string, operation string, input any) (string, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+	delete(fields, "Key")
+	if text, ok := fields["Text"].(string); ok {
+		fields["Text"] = strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	}
+	// Maps are encoded with sorted keys, so equal inputs hash equally.
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return operation + ":" + region + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// cached returns the result of a search from the cache, or else runs the
+// search and stores its result. Cache errors are logged and the search is
+// run, so a broken cache never fails a search.
+func cached[T any](ctx context.Context, config *Config, operation string, input any, search func() (*T, error)) (*T, error) {
+	if config.cache == nil {
+		return search()
+	}
+	key, err := cacheKey(config.cacheRegion(), operation, input)
+	if err != nil {
+		config.warn(err, "error building cache key")
+		return search()
+	}
+
+	value, ok, err := config.cache.Get(ctx, key)
+	if err != nil {
+		config.warn(err, "error reading cache")
+	} else if ok {
+		var out T
+		if err := json.Unmarshal(value, &out); err == nil {
+			return &out, nil
+		}
+		config.warn(err, "error decoding cached result")
+	}
+
+	out, err := search()
+	if err != nil {
+		return nil, err
+	}
+	if value, err := json.Marshal(out); err != nil {
+		config.warn(err, "error encoding result for cache")
+	} else if err := config.cache.Put(ctx, key, value, config.cacheTTL); err != nil {
+		config.warn(err, "error writing cache")
+	}
+	return out, nil
+}
+
+// warn logs a warning if a logger is set.
+func (config *Config) warn(err error, msg string) {
+	if config.log == nil {
+		return
+	}
+	config.log.WithFields(logrus.Fields{
+		"error": err,
+	}).Warn(msg)
+}
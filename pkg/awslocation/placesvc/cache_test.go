@@ -0,0 +1,143 @@
+package placesvc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+)
+
+// memoryCache is a Cache in a map, ignoring the ttl.
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	return value, ok, nil
+}
+
+func (c *memoryCache) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	return nil
+}
+
+func TestCacheKey(t *testing.T) {
+	base := &location.SearchPlaceIndexForTextInput{IndexName: aws.String("index"), Text: aws.String("1600 Pennsylvania Ave")}
+	baseKey, err := cacheKey("us-east-1", "SearchPlaceIndexForText", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		region    string
+		operation string
+		input     *location.SearchPlaceIndexForTextInput
+		same      bool
+	}{
+		{"identical", "us-east-1", "SearchPlaceIndexForText", &location.SearchPlaceIndexForTextInput{IndexName: aws.String("index"), Text: aws.String("1600 Pennsylvania Ave")}, true},
+		{"case and spaces", "us-east-1", "SearchPlaceIndexForText", &location.SearchPlaceIndexForTextInput{IndexName: aws.String("index"), Text: aws.String("  1600 pennsylvania   AVE ")}, true},
+		{"api key", "us-east-1", "SearchPlaceIndexForText", &location.SearchPlaceIndexForTextInput{IndexName: aws.String("index"), Text: aws.String("1600 Pennsylvania Ave"), Key: aws.String("v1.public.key")}, true},
+		{"region", "eu-west-1", "SearchPlaceIndexForText", base, false},
+		{"operation", "us-east-1", "SearchPlaceIndexForSuggestions", base, false},
+		{"index", "us-east-1", "SearchPlaceIndexForText", &location.SearchPlaceIndexForTextInput{IndexName: aws.String("other"), Text: aws.String("1600 Pennsylvania Ave")}, false},
+		{"text", "us-east-1", "SearchPlaceIndexForText", &location.SearchPlaceIndexForTextInput{IndexName: aws.String("index"), Text: aws.String("1601 Pennsylvania Ave")}, false},
+		{"language", "us-east-1", "SearchPlaceIndexForText", &location.SearchPlaceIndexForTextInput{IndexName: aws.String("index"), Text: aws.String("1600 Pennsylvania Ave"), Language: aws.String("fr")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := cacheKey(tt.region, tt.operation, tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (key == baseKey) != tt.same {
+				t.Errorf("cacheKey() = %s, base %s, want equal %v", key, baseKey, tt.same)
+			}
+		})
+	}
+}
+
+func TestCachedSearch(t *testing.T) {
+	fake := &fakeClient{search: echo}
+	svc, err := New(
+		SetLocationClient(fake),
+		SetAWSRegion("us-east-1"),
+		SetIndexName("index"),
+		SetCache(&memoryCache{values: map[string][]byte{}}, 0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		text      string
+		wantCalls int
+	}{
+		{"miss", "Berlin", 1},
+		{"hit", "Berlin", 1},
+		{"normalized hit", " berlin ", 1},
+		{"other text", "Paris", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := svc.SearchPlaceIndexForText(context.Background(), &SuggestionSearch{Text: aws.String(tt.text)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Summary == nil || out.Summary.Text == nil {
+				t.Fatalf("got output %+v without summary text", out)
+			}
+			if fake.calls != tt.wantCalls {
+				t.Errorf("made %d calls, want %d", fake.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// describeClient is a LocationClient describing every index with the
+// intended use.
+type describeClient struct {
+	LocationClient
+	intendedUse types.IntendedUse
+}
+
+func (c *describeClient) DescribePlaceIndex(ctx context.Context, params *location.DescribePlaceIndexInput, optFns ...func(*location.Options)) (*location.DescribePlaceIndexOutput, error) {
+	return &location.DescribePlaceIndexOutput{
+		IndexName:               params.IndexName,
+		DataSourceConfiguration: &types.DataSourceConfiguration{IntendedUse: c.intendedUse},
+	}, nil
+}
+
+func TestCheckCacheable(t *testing.T) {
+	tests := []struct {
+		name        string
+		intendedUse types.IntendedUse
+		wantErr     bool
+	}{
+		{"storage", types.IntendedUse(IntendedUseStorage), false},
+		{"single use", types.IntendedUse(IntendedUseSingleUse), true},
+		{"unknown", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, err := New(SetLocationClient(&describeClient{intendedUse: tt.intendedUse}), SetIndexName("index"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := svc.CheckCacheable(context.Background(), "index"); (err != nil) != tt.wantErr {
+				t.Errorf("CheckCacheable() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
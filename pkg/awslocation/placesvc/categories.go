@@ -0,0 +1,73 @@
+package placesvc
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Categories lists the Amazon Location place categories which can be used to
+// filter text and suggestion searches. See
+// https://docs.aws.amazon.com/location/previous/developerguide/category-filtering.html
+var Categories = []string{
+	// Result types
+	"AddressType",
+	"CountryType",
+	"IntersectionType",
+	"MunicipalityType",
+	"NeighborhoodType",
+	"PointAddressType",
+	"PointOfInterestType",
+	"PostalCodeType",
+	"RegionType",
+	"StreetType",
+	"SubRegionType",
+
+	// Points of interest
+	"Airport",
+	"AmusementPark",
+	"Aquarium",
+	"ATM",
+	"Bakery",
+	"Bank",
+	"Bar",
+	"BusStation",
+	"Cafe",
+	"CarRental",
+	"Casino",
+	"ConvenienceStore",
+	"Dentist",
+	"GasStation",
+	"Hospital",
+	"HotelMotel",
+	"Library",
+	"Museum",
+	"Park",
+	"Parking",
+	"Pharmacy",
+	"PoliceStation",
+	"PostOffice",
+	"Restaurant",
+	"School",
+	"ShoppingCenter",
+	"Stadium",
+	"Supermarket",
+	"TrainStation",
+	"University",
+	"Zoo",
+}
+
+// validateCategories checks that every category is one of Categories.
+func validateCategories(categories []string) error {
+	known := make(map[string]bool, len(Categories))
+	for _, category := range Categories {
+		known[category] = true
+	}
+	for _, category := range categories {
+		if !known[category] {
+			sorted := append([]string(nil), Categories...)
+			sort.Strings(sorted)
+			return fmt.Errorf("%w: unknown category %q, must be one of %v", ErrInvalidOption, category, sorted)
+		}
+	}
+	return nil
+}
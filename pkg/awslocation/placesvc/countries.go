@@ -0,0 +1,42 @@
+package placesvc
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// countryIndex maps alpha-2 codes, alpha-3 codes and lower case names to the
+// alpha-3 code.
+var countryIndex = sync.OnceValue(func() map[string]string {
+	index := make(map[string]string, len(countries)*4)
+	for _, c := range countries {
+		index[c.alpha2] = c.alpha3
+		index[c.alpha3] = c.alpha3
+		index[strings.ToLower(c.name)] = c.alpha3
+		if c.official != "" {
+			index[strings.ToLower(c.official)] = c.alpha3
+		}
+	}
+	return index
+})
+
+// CountryCode converts an ISO 3166-1 alpha-2 or alpha-3 code, or an English
+// country name, into the alpha-3 code the search APIs expect, e.g. US and
+// Germany into USA and DEU.
+func CountryCode(country string) (string, error) {
+	country = strings.TrimSpace(country)
+	index := countryIndex()
+	if code, ok := index[strings.ToUpper(country)]; ok && len(country) <= 3 {
+		return code, nil
+	}
+	if code, ok := index[strings.ToLower(country)]; ok {
+		return code, nil
+	}
+	return "", fmt.Errorf("%w: unknown country %q", ErrInvalidOption, country)
+}
+
+// isAlpha3 reports whether code is an ISO 3166-1 alpha-3 country code.
+func isAlpha3(code string) bool {
+	return len(code) == 3 && countryIndex()[code] == code
+}
@@ -0,0 +1,81 @@
+package placesvc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/smithy-go"
+)
+
+var (
+	// ErrRegionNotSet is returned by New when no region was given and AWS_REGION is empty.
+	ErrRegionNotSet = errors.New("AWS region not set")
+
+	// ErrInvalidOption is returned by New when an option has an unsupported value.
+	ErrInvalidOption = errors.New("invalid option")
+
+	// ErrInvalidSearch is returned before calling AWS when a search or place
+	// lookup is incomplete or out of the limits of the API.
+	ErrInvalidSearch = errors.New("invalid search")
+
+	// ErrInvalidCoordinates is returned before calling AWS when a position or
+	// bounding box is out of range.
+	ErrInvalidCoordinates = errors.New("invalid coordinates")
+
+	// ErrIndexNotFound is returned when the place index, or another resource
+	// of the call, does not exist.
+	ErrIndexNotFound = errors.New("place index not found")
+
+	// ErrIndexAlreadyExists is returned when creating a place index whose name is taken.
+	ErrIndexAlreadyExists = errors.New("place index already exists")
+
+	// ErrThrottled is returned when AWS rejected the call for exceeding the request rate.
+	ErrThrottled = errors.New("request throttled")
+
+	// ErrAccessDenied is returned when the credentials lack permission for the call.
+	ErrAccessDenied = errors.New("access denied")
+)
+
+// wrapError adds the matching sentinel error to an AWS error. The original
+// error stays in the chain, so errors.As still finds the SDK error types.
+func wrapError(err error) error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.ErrorCode() {
+	case "ResourceNotFoundException":
+		return fmt.Errorf("%w: %w", ErrIndexNotFound, err)
+	case "ConflictException":
+		return fmt.Errorf("%w: %w", ErrIndexAlreadyExists, err)
+	case "ThrottlingException":
+		return fmt.Errorf("%w: %w", ErrThrottled, err)
+	case "AccessDeniedException":
+		return fmt.Errorf("%w: %w", ErrAccessDenied, err)
+	}
+	return err
+}
+
+// wrap applies wrapError to the error of an SDK call.
+func wrap[T any](out T, err error) (T, error) {
+	return out, wrapError(err)
+}
+
+// CredentialsError is returned by New when the AWS configuration or
+// credentials could not be loaded, for example because the profile does not
+// exist or its session has expired.
+type CredentialsError struct {
+	Profile string
+	Err     error
+}
+
+func (e *CredentialsError) Error() string {
+	if e.Profile == "" {
+		return fmt.Sprintf("unable to load AWS credentials: %v", e.Err)
+	}
+	return fmt.Sprintf("unable to load AWS credentials for profile %s: %v", e.Profile, e.Err)
+}
+
+func (e *CredentialsError) Unwrap() error {
+	return e.Err
+}
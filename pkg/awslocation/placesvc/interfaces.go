@@ -0,0 +1,52 @@
+package placesvc
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+)
+
+// LocationClient is the subset of the Amazon Location client used by this
+// package. *location.Client implements it; tests can substitute a fake with
+// SetLocationClient.
+type LocationClient interface {
+	CreatePlaceIndex(ctx context.Context, params *location.CreatePlaceIndexInput, optFns ...func(*location.Options)) (*location.CreatePlaceIndexOutput, error)
+	DeletePlaceIndex(ctx context.Context, params *location.DeletePlaceIndexInput, optFns ...func(*location.Options)) (*location.DeletePlaceIndexOutput, error)
+	DescribePlaceIndex(ctx context.Context, params *location.DescribePlaceIndexInput, optFns ...func(*location.Options)) (*location.DescribePlaceIndexOutput, error)
+	GetPlace(ctx context.Context, params *location.GetPlaceInput, optFns ...func(*location.Options)) (*location.GetPlaceOutput, error)
+	ListPlaceIndexes(ctx context.Context, params *location.ListPlaceIndexesInput, optFns ...func(*location.Options)) (*location.ListPlaceIndexesOutput, error)
+	ListTagsForResource(ctx context.Context, params *location.ListTagsForResourceInput, optFns ...func(*location.Options)) (*location.ListTagsForResourceOutput, error)
+	SearchPlaceIndexForPosition(ctx context.Context, params *location.SearchPlaceIndexForPositionInput, optFns ...func(*location.Options)) (*location.SearchPlaceIndexForPositionOutput, error)
+	SearchPlaceIndexForSuggestions(ctx context.Context, params *location.SearchPlaceIndexForSuggestionsInput, optFns ...func(*location.Options)) (*location.SearchPlaceIndexForSuggestionsOutput, error)
+	SearchPlaceIndexForText(ctx context.Context, params *location.SearchPlaceIndexForTextInput, optFns ...func(*location.Options)) (*location.SearchPlaceIndexForTextOutput, error)
+	TagResource(ctx context.Context, params *location.TagResourceInput, optFns ...func(*location.Options)) (*location.TagResourceOutput, error)
+	UntagResource(ctx context.Context, params *location.UntagResourceInput, optFns ...func(*location.Options)) (*location.UntagResourceOutput, error)
+	UpdatePlaceIndex(ctx context.Context, params *location.UpdatePlaceIndexInput, optFns ...func(*location.Options)) (*location.UpdatePlaceIndexOutput, error)
+}
+
+// PlaceIndexer covers every place index operation of Config, so callers can
+// depend on it and substitute a fake in tests.
+type PlaceIndexer interface {
+	BatchReverseGeocode(ctx context.Context, points []LatLon, opts BatchOptions) <-chan BatchPositionResult
+	BatchSearchText(ctx context.Context, requests []TextSearch, opts BatchOptions) <-chan BatchTextResult
+	CreatePlaceIndex(ctx context.Context, description string, tags *map[string]string) (*location.CreatePlaceIndexOutput, error)
+	DeletePlaceIndex(ctx context.Context) (*location.DeletePlaceIndexOutput, error)
+	DescribePlaceIndex(ctx context.Context, indexName string) (*location.DescribePlaceIndexOutput, error)
+	GetPlace(ctx context.Context, placeID string, language *string) (*location.GetPlaceOutput, error)
+	ListPlaceIndexes(ctx context.Context, maxItems int) ([]types.ListPlaceIndexesResponseEntry, error)
+	ListTagsForResource(ctx context.Context, arn string) (*location.ListTagsForResourceOutput, error)
+	SearchPlaceIndexForPosition(ctx context.Context, search *PositionSearch) (*location.SearchPlaceIndexForPositionOutput, error)
+	SearchPlaceIndexForSuggestions(ctx context.Context, search *SuggestionSearch) (*location.SearchPlaceIndexForSuggestionsOutput, error)
+	SearchPlaceIndexForText(ctx context.Context, search *SuggestionSearch) (*location.SearchPlaceIndexForTextOutput, error)
+	StreamReverseGeocode(ctx context.Context, points <-chan LatLon, opts BatchOptions) <-chan BatchPositionResult
+	StreamSearchText(ctx context.Context, requests <-chan TextSearch, opts BatchOptions) <-chan BatchTextResult
+	TagResource(ctx context.Context, arn string, tags map[string]string) (*location.TagResourceOutput, error)
+	UntagResource(ctx context.Context, arn string, keys []string) (*location.UntagResourceOutput, error)
+	UpdatePlaceIndex(ctx context.Context, description string) (*location.UpdatePlaceIndexOutput, error)
+}
+
+var (
+	_ LocationClient = (*location.Client)(nil)
+	_ PlaceIndexer   = (*Config)(nil)
+)
@@ -0,0 +1,77 @@
+package placesvc
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+)
+
+// fakeClient is a LocationClient answering text searches with search, and
+// panicking on any other call.
+type fakeClient struct {
+	LocationClient
+
+	mu     sync.Mutex
+	calls  int
+	search func(input *location.SearchPlaceIndexForTextInput) (*location.SearchPlaceIndexForTextOutput, error)
+}
+
+func (f *fakeClient) SearchPlaceIndexForText(ctx context.Context, params *location.SearchPlaceIndexForTextInput, optFns ...func(*location.Options)) (*location.SearchPlaceIndexForTextOutput, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	return f.search(params)
+}
+
+// echo answers a search with its text as summary.
+func echo(input *location.SearchPlaceIndexForTextInput) (*location.SearchPlaceIndexForTextOutput, error) {
+	return &location.SearchPlaceIndexForTextOutput{Summary: &types.SearchPlaceIndexForTextSummary{Text: input.Text}}, nil
+}
+
+func TestSetLocationClient(t *testing.T) {
+	tests := []struct {
+		name         string
+		indexName    string
+		language     *string
+		wantLanguage string
+	}{
+		{"index", "index", nil, "en"},
+		{"other index", "other", nil, "en"},
+		{"language", "index", aws.String("fr"), "fr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *location.SearchPlaceIndexForTextInput
+			fake := &fakeClient{search: func(input *location.SearchPlaceIndexForTextInput) (*location.SearchPlaceIndexForTextOutput, error) {
+				got = input
+				return echo(input)
+			}}
+			var svc PlaceIndexer
+			svc, err := New(SetLocationClient(fake), SetIndexName(tt.indexName))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := svc.SearchPlaceIndexForText(context.Background(), &SuggestionSearch{Text: aws.String("Berlin"), Language: tt.language})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fake.calls != 1 {
+				t.Fatalf("made %d calls to the fake, want 1", fake.calls)
+			}
+			if aws.ToString(got.IndexName) != tt.indexName {
+				t.Errorf("searched index %q, want %q", aws.ToString(got.IndexName), tt.indexName)
+			}
+			if aws.ToString(got.Language) != tt.wantLanguage {
+				t.Errorf("searched in language %q, want %q", aws.ToString(got.Language), tt.wantLanguage)
+			}
+			if aws.ToString(out.Summary.Text) != "Berlin" {
+				t.Errorf("got output %+v, want the output of the fake", out)
+			}
+		})
+	}
+}
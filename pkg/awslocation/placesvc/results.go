@@ -0,0 +1,241 @@
+package placesvc
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+)
+
+// Place is a place returned by a search or GetPlace, independent of the AWS
+// SDK types.
+type Place struct {
+	Label                  string    `json:"label,omitempty"`
+	Point                  []float64 `json:"point,omitempty"`
+	AddressNumber          string    `json:"addressNumber,omitempty"`
+	UnitType               string    `json:"unitType,omitempty"`
+	UnitNumber             string    `json:"unitNumber,omitempty"`
+	Street                 string    `json:"street,omitempty"`
+	Neighborhood           string    `json:"neighborhood,omitempty"`
+	SubMunicipality        string    `json:"subMunicipality,omitempty"`
+	Municipality           string    `json:"municipality,omitempty"`
+	SubRegion              string    `json:"subRegion,omitempty"`
+	Region                 string    `json:"region,omitempty"`
+	PostalCode             string    `json:"postalCode,omitempty"`
+	Country                string    `json:"country,omitempty"`
+	TimeZone               *TimeZone `json:"timeZone,omitempty"`
+	Categories             []string  `json:"categories,omitempty"`
+	SupplementalCategories []string  `json:"supplementalCategories,omitempty"`
+	Interpolated           bool      `json:"interpolated,omitempty"`
+}
+
+// TimeZone is the time zone of a place. Offset is in seconds from UTC.
+type TimeZone struct {
+	Name   string `json:"name"`
+	Offset int32  `json:"offset"`
+}
+
+// SearchResult is a place found by a text or position search. Distance is in
+// meters and Relevance is only set for text searches.
+type SearchResult struct {
+	PlaceID   string   `json:"placeId,omitempty"`
+	Place     *Place   `json:"place"`
+	Distance  *float64 `json:"distance,omitempty"`
+	Relevance *float64 `json:"relevance,omitempty"`
+}
+
+// Suggestion is a result of a suggestion search.
+type Suggestion struct {
+	Text                   string   `json:"text"`
+	PlaceID                string   `json:"placeId,omitempty"`
+	Categories             []string `json:"categories,omitempty"`
+	SupplementalCategories []string `json:"supplementalCategories,omitempty"`
+}
+
+// SearchSummary describes the parameters a search was run with. Text is set
+// for text and suggestion searches, Position for position searches.
+type SearchSummary struct {
+	DataSource       string    `json:"dataSource"`
+	Language         string    `json:"language,omitempty"`
+	MaxResults       int32     `json:"maxResults,omitempty"`
+	Text             string    `json:"text,omitempty"`
+	Position         *LatLon   `json:"position,omitempty"`
+	BiasPosition     *LatLon   `json:"biasPosition,omitempty"`
+	FilterBBox       *Box      `json:"filterBBox,omitempty"`
+	FilterCategories []string  `json:"filterCategories,omitempty"`
+	FilterCountries  []string  `json:"filterCountries,omitempty"`
+	ResultBBox       []float64 `json:"resultBBox,omitempty"`
+}
+
+// Coordinates returns the position of the place, or nil if it has none.
+func (place *Place) Coordinates() *LatLon {
+	if place == nil || len(place.Point) != 2 {
+		return nil
+	}
+	return &LatLon{Latitude: place.Point[1], Longitude: place.Point[0]}
+}
+
+// FormattedAddress joins the address components of the place into a single
+// line, falling back to the label if the place has no street address.
+func (place *Place) FormattedAddress() string {
+	if place == nil {
+		return ""
+	}
+	street := joinNonEmpty(" ", place.AddressNumber, place.Street, place.UnitType, place.UnitNumber)
+	if street == "" {
+		return place.Label
+	}
+	return joinNonEmpty(", ",
+		street,
+		place.Municipality,
+		joinNonEmpty(" ", place.Region, place.PostalCode),
+		place.Country,
+	)
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
+
+// NewPlace converts an SDK place. It returns nil if place is nil.
+func NewPlace(place *types.Place) *Place {
+	if place == nil {
+		return nil
+	}
+	ret := &Place{
+		Label:                  aws.ToString(place.Label),
+		AddressNumber:          aws.ToString(place.AddressNumber),
+		UnitType:               aws.ToString(place.UnitType),
+		UnitNumber:             aws.ToString(place.UnitNumber),
+		Street:                 aws.ToString(place.Street),
+		Neighborhood:           aws.ToString(place.Neighborhood),
+		SubMunicipality:        aws.ToString(place.SubMunicipality),
+		Municipality:           aws.ToString(place.Municipality),
+		SubRegion:              aws.ToString(place.SubRegion),
+		Region:                 aws.ToString(place.Region),
+		PostalCode:             aws.ToString(place.PostalCode),
+		Country:                aws.ToString(place.Country),
+		Categories:             place.Categories,
+		SupplementalCategories: place.SupplementalCategories,
+		Interpolated:           aws.ToBool(place.Interpolated),
+	}
+	if place.Geometry != nil {
+		ret.Point = place.Geometry.Point
+	}
+	if place.TimeZone != nil {
+		ret.TimeZone = &TimeZone{
+			Name:   aws.ToString(place.TimeZone.Name),
+			Offset: aws.ToInt32(place.TimeZone.Offset),
+		}
+	}
+	return ret
+}
+
+// NewTextResults converts the output of SearchPlaceIndexForText.
+func NewTextResults(out *location.SearchPlaceIndexForTextOutput) (*SearchSummary, []SearchResult) {
+	if out == nil {
+		return nil, nil
+	}
+	var summary *SearchSummary
+	if s := out.Summary; s != nil {
+		summary = &SearchSummary{
+			DataSource:       aws.ToString(s.DataSource),
+			Language:         aws.ToString(s.Language),
+			MaxResults:       aws.ToInt32(s.MaxResults),
+			Text:             aws.ToString(s.Text),
+			BiasPosition:     newLatLon(s.BiasPosition),
+			FilterBBox:       newBox(s.FilterBBox),
+			FilterCategories: s.FilterCategories,
+			FilterCountries:  s.FilterCountries,
+			ResultBBox:       s.ResultBBox,
+		}
+	}
+	results := make([]SearchResult, 0, len(out.Results))
+	for _, r := range out.Results {
+		results = append(results, SearchResult{
+			PlaceID:   aws.ToString(r.PlaceId),
+			Place:     NewPlace(r.Place),
+			Distance:  r.Distance,
+			Relevance: r.Relevance,
+		})
+	}
+	return summary, results
+}
+
+// NewPositionResults converts the output of SearchPlaceIndexForPosition.
+func NewPositionResults(out *location.SearchPlaceIndexForPositionOutput) (*SearchSummary, []SearchResult) {
+	if out == nil {
+		return nil, nil
+	}
+	var summary *SearchSummary
+	if s := out.Summary; s != nil {
+		summary = &SearchSummary{
+			DataSource: aws.ToString(s.DataSource),
+			Language:   aws.ToString(s.Language),
+			MaxResults: aws.ToInt32(s.MaxResults),
+			Position:   newLatLon(s.Position),
+		}
+	}
+	results := make([]SearchResult, 0, len(out.Results))
+	for _, r := range out.Results {
+		results = append(results, SearchResult{
+			PlaceID:  aws.ToString(r.PlaceId),
+			Place:    NewPlace(r.Place),
+			Distance: r.Distance,
+		})
+	}
+	return summary, results
+}
+
+// NewSuggestionResults converts the output of SearchPlaceIndexForSuggestions.
+func NewSuggestionResults(out *location.SearchPlaceIndexForSuggestionsOutput) (*SearchSummary, []Suggestion) {
+	if out == nil {
+		return nil, nil
+	}
+	var summary *SearchSummary
+	if s := out.Summary; s != nil {
+		summary = &SearchSummary{
+			DataSource:       aws.ToString(s.DataSource),
+			Language:         aws.ToString(s.Language),
+			MaxResults:       aws.ToInt32(s.MaxResults),
+			Text:             aws.ToString(s.Text),
+			BiasPosition:     newLatLon(s.BiasPosition),
+			FilterBBox:       newBox(s.FilterBBox),
+			FilterCategories: s.FilterCategories,
+			FilterCountries:  s.FilterCountries,
+		}
+	}
+	results := make([]Suggestion, 0, len(out.Results))
+	for _, r := range out.Results {
+		results = append(results, Suggestion{
+			Text:                   aws.ToString(r.Text),
+			PlaceID:                aws.ToString(r.PlaceId),
+			Categories:             r.Categories,
+			SupplementalCategories: r.SupplementalCategories,
+		})
+	}
+	return summary, results
+}
+
+// newLatLon converts a longitude, latitude pair as used by the API.
+func newLatLon(position []float64) *LatLon {
+	if len(position) != 2 {
+		return nil
+	}
+	return &LatLon{Latitude: position[1], Longitude: position[0]}
+}
+
+// newBox converts a southwest, northeast bounding box as used by the API.
+func newBox(bbox []float64) *Box {
+	if len(bbox) != 4 {
+		return nil
+	}
+	return &Box{X1: bbox[0], Y1: bbox[1], X2: bbox[2], Y2: bbox[3]}
+}
@@ -3,15 +3,60 @@ package placesvc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/location"
 	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	// dataSources lists the geospatial data providers of place indexes.
+	dataSources = []string{DataSourceEsri, DataSourceGrab, DataSourceHere}
+
+	// grabRegions lists the AWS regions Grab data is available in.
+	grabRegions = []string{"ap-southeast-1"}
+
+	// regions lists the AWS regions Amazon Location Service is available in.
+	regions = []string{
+		"ap-northeast-1", "ap-south-1", "ap-southeast-1", "ap-southeast-2",
+		"ca-central-1", "eu-central-1", "eu-north-1", "eu-south-2", "eu-west-1",
+		"eu-west-2", "sa-east-1", "us-east-1", "us-east-2", "us-gov-west-1", "us-west-2",
+	}
+
+	// intendedUses lists how search results may be used. Indexes are created
+	// for SingleUse unless set otherwise.
+	intendedUses = []string{IntendedUseSingleUse, IntendedUseStorage}
+
+	// pricingPlans lists the pricing plans of place indexes. The other plans
+	// of the API are deprecated.
+	pricingPlans = []string{"RequestBasedUsage"}
+)
+
+// Data providers of place indexes.
+const (
+	DataSourceEsri = "Esri"
+	DataSourceGrab = "Grab"
+	DataSourceHere = "Here"
+)
+
+// Intended uses of place index results.
+const (
+	// IntendedUseSingleUse results may not be stored.
+	IntendedUseSingleUse = "SingleUse"
+
+	// IntendedUseStorage results may be stored, which is billed at a higher rate.
+	IntendedUseStorage = "Storage"
+)
+
 type Option func(config *Config)
 
 // Configuration structure.
@@ -23,8 +68,14 @@ type Config struct {
 	intendedUse  string
 	language     string
 	pricingPlan  string
+	roleARN      string
+	externalID   string
+	staticCreds  *aws.Credentials
+	apiKey       string
 	log          *logrus.Logger
-	svc          *location.Client
+	svc          LocationClient
+	cache        Cache
+	cacheTTL     time.Duration
 }
 
 type LatLon struct {
@@ -39,6 +90,24 @@ type Box struct {
 	Y2 float64
 }
 
+// position returns the coordinate in the longitude, latitude order used by
+// the API, or nil if not set.
+func (latLon *LatLon) position() []float64 {
+	if latLon == nil {
+		return nil
+	}
+	return []float64{latLon.Longitude, latLon.Latitude}
+}
+
+// bbox returns the box as southwest longitude, latitude followed by
+// northeast longitude, latitude, or nil if not set.
+func (box *Box) bbox() []float64 {
+	if box == nil {
+		return nil
+	}
+	return []float64{box.X1, box.Y1, box.X2, box.Y2}
+}
+
 type SuggestionSearch struct {
 	// The free-form partial text to use to generate place suggestions. For example,
 	// eiffel tow.
@@ -68,6 +137,11 @@ type SuggestionSearch struct {
 	// are mutually exclusive. Specifying both options results in an error.
 	FilterBBox *Box
 
+	// An optional parameter that limits the search results by returning only places
+	// in any of the given categories, for example HotelMotel or GasStation. See
+	// Categories for the accepted values.
+	FilterCategories []string
+
 	// An optional parameter that limits the search results by returning only
 	// suggestions within the provided list of countries.
 	//
@@ -83,6 +157,50 @@ type SuggestionSearch struct {
 	// particular result, the partner automatically chooses a language for the result.
 	// Used only when the partner selected is Here.
 	Language *string
+
+	// An optional parameter. The maximum number of results returned per request.
+	// Zero uses the API default.
+	MaxResults int32
+}
+
+type PositionSearch struct {
+	// The position to search for places near.
+	//
+	// This member is required.
+	Position *LatLon
+
+	// The preferred language used to return results, as a BCP 47 language tag.
+	// Overrides the language the service was created with.
+	Language *string
+
+	// An optional parameter. The maximum number of results returned per request.
+	// Zero uses the API default.
+	MaxResults int32
+}
+
+// languageFor returns the language of a single search, falling back to the
+// language the service was created with.
+func (config *Config) languageFor(language *string) *string {
+	if language != nil && *language != "" {
+		return language
+	}
+	return aws.String(config.language)
+}
+
+// key returns the API key as an API parameter, or nil if not set.
+func (config *Config) key() *string {
+	if config.apiKey == "" {
+		return nil
+	}
+	return aws.String(config.apiKey)
+}
+
+// maxResults returns n as an API parameter, or nil to use the API default.
+func maxResults(n int32) *int32 {
+	if n == 0 {
+		return nil
+	}
+	return aws.Int32(n)
 }
 
 func New(opts ...func(*Config)) (*Config, error) {
@@ -98,11 +216,7 @@ func New(opts ...func(*Config)) (*Config, error) {
 	}
 
 	if config.indexService == "" {
-		config.indexService = "Here"
-	}
-
-	if config.intendedUse == "" {
-		config.intendedUse = "SingleUse"
+		config.indexService = DataSourceHere
 	}
 
 	if config.language == "" {
@@ -113,20 +227,117 @@ func New(opts ...func(*Config)) (*Config, error) {
 		config.pricingPlan = "RequestBasedUsage"
 	}
 
-	c, err := awsconfig.LoadDefaultConfig(context.TODO(), func(o *awsconfig.LoadOptions) error {
-		o.Region = config.region
-		if config.profile != "" {
-			o.SharedConfigProfile = config.profile
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	if config.svc == nil {
+		c, err := awsconfig.LoadDefaultConfig(context.TODO(), func(o *awsconfig.LoadOptions) error {
+			o.Region = config.region
+			if config.profile != "" {
+				o.SharedConfigProfile = config.profile
+			}
+			if config.staticCreds != nil {
+				o.Credentials = credentials.StaticCredentialsProvider{Value: *config.staticCreds}
+			}
+			if config.apiKey != "" {
+				o.Credentials = aws.AnonymousCredentials{}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, &CredentialsError{Profile: config.profile, Err: err}
 		}
+		if config.roleARN != "" {
+			c.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(c), config.roleARN, func(o *stscreds.AssumeRoleOptions) {
+				if config.externalID != "" {
+					o.ExternalID = aws.String(config.externalID)
+				}
+			}))
+		}
+		if _, err := c.Credentials.Retrieve(context.TODO()); err != nil && config.apiKey == "" {
+			return nil, &CredentialsError{Profile: config.profile, Err: err}
+		}
+		config.svc = location.NewFromConfig(c)
+	}
 
+	return config, nil
+}
+
+// validate checks the options before any AWS call is made.
+func (config *Config) validate() error {
+	if config.region == "" && config.svc == nil {
+		return ErrRegionNotSet
+	}
+	if config.externalID != "" && config.roleARN == "" {
+		return fmt.Errorf("%w: external ID given without a role ARN", ErrInvalidOption)
+	}
+	if config.apiKey != "" && (config.staticCreds != nil || config.roleARN != "") {
+		return fmt.Errorf("%w: an API key cannot be combined with credentials or a role", ErrInvalidOption)
+	}
+	if config.staticCreds != nil && (config.staticCreds.AccessKeyID == "" || config.staticCreds.SecretAccessKey == "") {
+		return fmt.Errorf("%w: static credentials need an access key ID and secret access key", ErrInvalidOption)
+	}
+	dataSource, ok := canonical(dataSources, config.indexService)
+	if !ok {
+		return fmt.Errorf("%w: data source %q, must be one of %s", ErrInvalidOption, config.indexService, strings.Join(dataSources, ", "))
+	}
+	config.indexService = dataSource
+	if err := checkDataSourceRegion(dataSource, config.region); err != nil {
+		return err
+	}
+	if config.intendedUse != "" && !contains(intendedUses, config.intendedUse) {
+		return fmt.Errorf("%w: intended use %q, must be one of %s", ErrInvalidOption, config.intendedUse, strings.Join(intendedUses, ", "))
+	}
+	if !contains(pricingPlans, config.pricingPlan) {
+		return fmt.Errorf("%w: pricing plan %q, must be one of %s", ErrInvalidOption, config.pricingPlan, strings.Join(pricingPlans, ", "))
+	}
+	return nil
+}
+
+// CheckRegion returns an error if Amazon Location Service, or the data
+// source if one is given, is not available in the region.
+func CheckRegion(dataSource string, region string) error {
+	if !contains(regions, region) {
+		return fmt.Errorf("%w: Amazon Location Service is not available in %s", ErrInvalidOption, region)
+	}
+	if dataSource == "" {
 		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
-	config.svc = location.NewFromConfig(c)
+	canonicalSource, ok := canonical(dataSources, dataSource)
+	if !ok {
+		return fmt.Errorf("%w: data source %q, must be one of %s", ErrInvalidOption, dataSource, strings.Join(dataSources, ", "))
+	}
+	return checkDataSourceRegion(canonicalSource, region)
+}
 
-	return config, nil
+// checkDataSourceRegion returns an error if the data source is limited to
+// other regions than the region, if one is given.
+func checkDataSourceRegion(dataSource string, region string) error {
+	if dataSource == DataSourceGrab && region != "" && !contains(grabRegions, region) {
+		return fmt.Errorf("%w: data source Grab is only available in %s, not %s", ErrInvalidOption, strings.Join(grabRegions, ", "), region)
+	}
+	return nil
+}
+
+// canonical returns the entry of values matching value case-insensitively.
+func canonical(values []string, value string) (string, bool) {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 func SetAWSRegion(region string) Option {
@@ -141,24 +352,87 @@ func SetAWSProfile(profile string) Option {
 	}
 }
 
+// SetAssumeRoleARN assumes the given IAM role, for example in another
+// account, using the credentials of the profile or SetStaticCredentials.
+func SetAssumeRoleARN(roleARN string) Option {
+	return func(config *Config) {
+		config.roleARN = roleARN
+	}
+}
+
+// SetExternalID sets the external ID passed when assuming the role set with
+// SetAssumeRoleARN.
+func SetExternalID(externalID string) Option {
+	return func(config *Config) {
+		config.externalID = externalID
+	}
+}
+
+// SetStaticCredentials uses the given access key instead of the default
+// credential chain. sessionToken may be empty for long-term keys.
+func SetStaticCredentials(accessKeyID, secretAccessKey, sessionToken string) Option {
+	return func(config *Config) {
+		config.staticCreds = &aws.Credentials{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+			SessionToken:    sessionToken,
+			Source:          credentials.StaticCredentialsName,
+		}
+	}
+}
+
+// SetAPIKey authorizes searches and GetPlace with an Amazon Location API key
+// instead of IAM credentials. Clients built by New then send unsigned
+// requests; other operations still need IAM credentials.
+func SetAPIKey(apiKey string) Option {
+	return func(config *Config) {
+		config.apiKey = apiKey
+	}
+}
+
 func SetIndexName(indexName string) Option {
 	return func(config *Config) {
 		config.indexName = indexName
 	}
 }
 
+// SetIndexService sets the data provider of created indexes, one of
+// DataSourceEsri, DataSourceGrab or DataSourceHere. Defaults to Here.
 func SetIndexService(indexService string) Option {
 	return func(config *Config) {
 		config.indexService = indexService
 	}
 }
 
+// SetIntendedUse sets whether results of the index may be stored, see
+// IntendedUseSingleUse and IntendedUseStorage. It applies to CreatePlaceIndex
+// and UpdatePlaceIndex; updates leave the intended use unchanged if not set.
+func SetIntendedUse(intendedUse string) Option {
+	return func(config *Config) {
+		config.intendedUse = intendedUse
+	}
+}
+
+func SetPricingPlan(pricingPlan string) Option {
+	return func(config *Config) {
+		config.pricingPlan = pricingPlan
+	}
+}
+
 func SetLanguage(language string) Option {
 	return func(config *Config) {
 		config.language = language
 	}
 }
 
+// SetLocationClient uses an existing client, such as one cached by clientmgr
+// or a fake in tests, instead of building a new one from the region and profile.
+func SetLocationClient(client LocationClient) Option {
+	return func(config *Config) {
+		config.svc = client
+	}
+}
+
 func SetLogger(log *logrus.Logger) Option {
 	return func(config *Config) {
 		config.log = log
@@ -172,38 +446,38 @@ func (c *Config) sanity() error {
 	return nil
 }
 
-func (config *Config) CreatePlaceIndex(description string, tags *map[string]string) (*location.CreatePlaceIndexOutput, error) {
+func (config *Config) CreatePlaceIndex(ctx context.Context, description string, tags *map[string]string) (*location.CreatePlaceIndexOutput, error) {
 	if err := config.sanity(); err != nil {
 		return nil, err
 	}
 
-	return config.svc.CreatePlaceIndex(
-		context.TODO(),
+	return wrap(config.svc.CreatePlaceIndex(
+		ctx,
 		&location.CreatePlaceIndexInput{
 			DataSource:              aws.String(config.indexService),
-			DataSourceConfiguration: &types.DataSourceConfiguration{IntendedUse: types.IntendedUse(config.intendedUse)},
+			DataSourceConfiguration: config.dataSourceConfiguration(IntendedUseSingleUse),
 			Description:             aws.String(description),
 			IndexName:               aws.String(config.indexName),
 			PricingPlan:             types.PricingPlan(config.pricingPlan),
 			Tags:                    *tags,
 		},
-	)
+	))
 }
 
-func (config *Config) DeletePlaceIndex() (*location.DeletePlaceIndexOutput, error) {
+func (config *Config) DeletePlaceIndex(ctx context.Context) (*location.DeletePlaceIndexOutput, error) {
 	if err := config.sanity(); err != nil {
 		return nil, err
 	}
 
-	return config.svc.DeletePlaceIndex(
-		context.TODO(),
+	return wrap(config.svc.DeletePlaceIndex(
+		ctx,
 		&location.DeletePlaceIndexInput{
 			IndexName: aws.String(config.indexName),
 		},
-	)
+	))
 }
 
-func (config *Config) DescribePlaceIndex(indexName string) (*location.DescribePlaceIndexOutput, error) {
+func (config *Config) DescribePlaceIndex(ctx context.Context, indexName string) (*location.DescribePlaceIndexOutput, error) {
 	if indexName == "" {
 		if err := config.sanity(); err != nil {
 			return nil, err
@@ -211,72 +485,195 @@ func (config *Config) DescribePlaceIndex(indexName string) (*location.DescribePl
 		indexName = config.indexName
 	}
 
-	return config.svc.DescribePlaceIndex(
-		context.TODO(),
+	return wrap(config.svc.DescribePlaceIndex(
+		ctx,
 		&location.DescribePlaceIndexInput{
 			IndexName: aws.String(indexName),
 		},
-	)
+	))
 }
 
-func (config *Config) ListPlaceIndexes() (*location.ListPlaceIndexesOutput, error) {
-	return config.svc.ListPlaceIndexes(
-		context.TODO(),
+// GetPlace gets a place by the place ID returned by a search, in language if
+// it is set, else in the language the service was created with.
+func (config *Config) GetPlace(ctx context.Context, placeID string, language *string) (*location.GetPlaceOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if placeID == "" {
+		return nil, fmt.Errorf("%w: placeID not set", ErrInvalidSearch)
+	}
+
+	return wrap(config.svc.GetPlace(
+		ctx,
+		&location.GetPlaceInput{
+			Key:       config.key(),
+			IndexName: aws.String(config.indexName),
+			PlaceId:   aws.String(placeID),
+			Language:  config.languageFor(language),
+		},
+	))
+}
+
+// ListPlaceIndexes returns up to maxItems place indexes, following result
+// pages as needed. A maxItems of zero returns every index.
+func (config *Config) ListPlaceIndexes(ctx context.Context, maxItems int) ([]types.ListPlaceIndexesResponseEntry, error) {
+	if maxItems < 0 {
+		return nil, fmt.Errorf("max items %d must not be negative", maxItems)
+	}
+
+	paginator := location.NewListPlaceIndexesPaginator(
+		config.svc,
 		&location.ListPlaceIndexesInput{},
 	)
+
+	var entries []types.ListPlaceIndexesResponseEntry
+	for paginator.HasMorePages() {
+		ret, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		entries = append(entries, ret.Entries...)
+		if maxItems > 0 && len(entries) >= maxItems {
+			return entries[:maxItems], nil
+		}
+	}
+
+	return entries, nil
 }
 
-func (config *Config) SearchPlaceIndexForPosition(latLon *LatLon) (*location.SearchPlaceIndexForPositionOutput, error) {
-	return config.svc.SearchPlaceIndexForPosition(
-		context.TODO(),
-		&location.SearchPlaceIndexForPositionInput{
-			IndexName: aws.String(config.indexName),
-			Language:  aws.String(config.language),
-			Position:  []float64{latLon.Longitude, latLon.Latitude},
+func (config *Config) SearchPlaceIndexForPosition(ctx context.Context, search *PositionSearch) (*location.SearchPlaceIndexForPositionOutput, error) {
+	if err := search.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSearch, err)
+	}
+
+	input := &location.SearchPlaceIndexForPositionInput{
+		Key:        config.key(),
+		IndexName:  aws.String(config.indexName),
+		Language:   config.languageFor(search.Language),
+		MaxResults: maxResults(search.MaxResults),
+		Position:   search.Position.position(),
+	}
+	return cached(ctx, config, "SearchPlaceIndexForPosition", input, func() (*location.SearchPlaceIndexForPositionOutput, error) {
+		return wrap(config.svc.SearchPlaceIndexForPosition(ctx, input))
+	})
+}
+
+func (config *Config) SearchPlaceIndexForSuggestions(ctx context.Context, search *SuggestionSearch) (*location.SearchPlaceIndexForSuggestionsOutput, error) {
+	search, err := search.normalize(maxSuggestionResults)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSearch, err)
+	}
+
+	input := &location.SearchPlaceIndexForSuggestionsInput{
+		Key:              config.key(),
+		IndexName:        aws.String(config.indexName),
+		Text:             search.Text,
+		BiasPosition:     search.BiasPosition.position(),
+		FilterBBox:       search.FilterBBox.bbox(),
+		FilterCategories: search.FilterCategories,
+		FilterCountries:  search.FilterCountries,
+		Language:         config.languageFor(search.Language),
+		MaxResults:       maxResults(search.MaxResults),
+	}
+	return cached(ctx, config, "SearchPlaceIndexForSuggestions", input, func() (*location.SearchPlaceIndexForSuggestionsOutput, error) {
+		return wrap(config.svc.SearchPlaceIndexForSuggestions(ctx, input))
+	})
+}
+
+func (config *Config) SearchPlaceIndexForText(ctx context.Context, search *SuggestionSearch) (*location.SearchPlaceIndexForTextOutput, error) {
+	search, err := search.normalize(maxTextResults)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSearch, err)
+	}
+
+	input := &location.SearchPlaceIndexForTextInput{
+		Key:              config.key(),
+		IndexName:        aws.String(config.indexName),
+		Text:             search.Text,
+		BiasPosition:     search.BiasPosition.position(),
+		FilterBBox:       search.FilterBBox.bbox(),
+		FilterCategories: search.FilterCategories,
+		FilterCountries:  search.FilterCountries,
+		Language:         config.languageFor(search.Language),
+		MaxResults:       maxResults(search.MaxResults),
+	}
+	return cached(ctx, config, "SearchPlaceIndexForText", input, func() (*location.SearchPlaceIndexForTextOutput, error) {
+		return wrap(config.svc.SearchPlaceIndexForText(ctx, input))
+	})
+}
+
+func (config *Config) ListTagsForResource(ctx context.Context, arn string) (*location.ListTagsForResourceOutput, error) {
+	if arn == "" {
+		return nil, errors.New("arn not set")
+	}
+
+	return wrap(config.svc.ListTagsForResource(
+		ctx,
+		&location.ListTagsForResourceInput{
+			ResourceArn: aws.String(arn),
 		},
-	)
+	))
 }
 
-func (config *Config) SearchPlaceIndexForSuggestions(search *SuggestionSearch) (*location.SearchPlaceIndexForSuggestionsOutput, error) {
-	return config.svc.SearchPlaceIndexForSuggestions(
-		context.TODO(),
-		&location.SearchPlaceIndexForSuggestionsInput{
-			IndexName: aws.String(config.indexName),
-			Text:      search.Text,
-			//BiasPosition:    []float64{search.BiasPosition.Longitude, search.BiasPosition.Latitude},
-			//FilterBBox:      []float64{search.FilterBBox.X1, search.FilterBBox.Y1, search.FilterBBox.X2, search.FilterBBox.Y2},
-			FilterCountries: search.FilterCountries,
-			Language:        aws.String(config.language),
+func (config *Config) TagResource(ctx context.Context, arn string, tags map[string]string) (*location.TagResourceOutput, error) {
+	if arn == "" {
+		return nil, errors.New("arn not set")
+	}
+	if len(tags) == 0 {
+		return nil, errors.New("no tags given")
+	}
+
+	return wrap(config.svc.TagResource(
+		ctx,
+		&location.TagResourceInput{
+			ResourceArn: aws.String(arn),
+			Tags:        tags,
 		},
-	)
+	))
 }
 
-func (config *Config) SearchPlaceIndexForText(search *SuggestionSearch) (*location.SearchPlaceIndexForTextOutput, error) {
-	return config.svc.SearchPlaceIndexForText(
-		context.TODO(),
-		&location.SearchPlaceIndexForTextInput{
-			IndexName: aws.String(config.indexName),
-			Text:      search.Text,
-			//BiasPosition:    []float64{search.BiasPosition.Longitude, search.BiasPosition.Latitude},
-			//FilterBBox:      []float64{search.FilterBBox.X1, search.FilterBBox.Y1, search.FilterBBox.X2, search.FilterBBox.Y2},
-			FilterCountries: search.FilterCountries,
-			Language:        aws.String(config.language),
+func (config *Config) UntagResource(ctx context.Context, arn string, keys []string) (*location.UntagResourceOutput, error) {
+	if arn == "" {
+		return nil, errors.New("arn not set")
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no tag keys given")
+	}
+
+	return wrap(config.svc.UntagResource(
+		ctx,
+		&location.UntagResourceInput{
+			ResourceArn: aws.String(arn),
+			TagKeys:     keys,
 		},
-	)
+	))
 }
 
-func (config *Config) UpdatePlaceIndex(description string) (*location.UpdatePlaceIndexOutput, error) {
+func (config *Config) UpdatePlaceIndex(ctx context.Context, description string) (*location.UpdatePlaceIndexOutput, error) {
 	if err := config.sanity(); err != nil {
 		return nil, err
 	}
 
-	return config.svc.UpdatePlaceIndex(
-		context.TODO(),
+	return wrap(config.svc.UpdatePlaceIndex(
+		ctx,
 		&location.UpdatePlaceIndexInput{
 			IndexName:               aws.String(config.indexName),
-			DataSourceConfiguration: &types.DataSourceConfiguration{IntendedUse: types.IntendedUse(config.intendedUse)},
+			DataSourceConfiguration: config.dataSourceConfiguration(""),
 			Description:             aws.String(description),
 			PricingPlan:             types.PricingPlan(config.pricingPlan),
 		},
-	)
+	))
+}
+
+// dataSourceConfiguration returns the configured intended use, or def if not
+// set. It returns nil if neither is set.
+func (config *Config) dataSourceConfiguration(def string) *types.DataSourceConfiguration {
+	intendedUse := config.intendedUse
+	if intendedUse == "" {
+		intendedUse = def
+	}
+	if intendedUse == "" {
+		return nil
+	}
+	return &types.DataSourceConfiguration{IntendedUse: types.IntendedUse(intendedUse)}
 }
@@ -0,0 +1,162 @@
+package placesvc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxTextLength is the longest free-form text the search APIs accept.
+	maxTextLength = 200
+
+	// maxFilterCountries is the largest number of countries a search can be limited to.
+	maxFilterCountries = 100
+
+	// maxFilterCategories is the largest number of categories a search can be limited to.
+	maxFilterCategories = 5
+
+	// maxPositionResults, maxSuggestionResults and maxTextResults are the
+	// largest MaxResults each search API accepts.
+	maxPositionResults   = 50
+	maxSuggestionResults = 15
+	maxTextResults       = 50
+)
+
+// normalizeText replaces control characters with spaces, collapses runs of
+// whitespace into a single space and trims the result.
+func normalizeText(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// validateMaxResults checks maxResults against the limit of a search API.
+// Zero leaves the API default in place.
+func validateMaxResults(maxResults, limit int32) error {
+	if maxResults < 0 || maxResults > limit {
+		return fmt.Errorf("max results %d out of range [1, %d]", maxResults, limit)
+	}
+	return nil
+}
+
+// normalize checks the search against the limits of the search APIs and
+// returns a copy with normalized text and country codes. maxResults is the
+// largest MaxResults the calling API accepts.
+func (search *SuggestionSearch) normalize(maxResults int32) (*SuggestionSearch, error) {
+	if search == nil || search.Text == nil {
+		return nil, errors.New("search text not set")
+	}
+
+	ret := *search
+	text := normalizeText(*search.Text)
+	if text == "" {
+		return nil, errors.New("search text is empty")
+	}
+	if n := utf8.RuneCountInString(text); n > maxTextLength {
+		return nil, fmt.Errorf("search text is %d characters long, the maximum is %d", n, maxTextLength)
+	}
+	ret.Text = &text
+
+	if len(search.FilterCountries) > maxFilterCountries {
+		return nil, fmt.Errorf("%d countries given, the maximum is %d", len(search.FilterCountries), maxFilterCountries)
+	}
+	if len(search.FilterCountries) > 0 {
+		ret.FilterCountries = make([]string, 0, len(search.FilterCountries))
+		for _, country := range search.FilterCountries {
+			country = strings.ToUpper(strings.TrimSpace(country))
+			if country == "" {
+				return nil, errors.New("empty country code given")
+			}
+			if !isAlpha3(country) {
+				return nil, fmt.Errorf("%w: %q is not an ISO 3166-1 alpha-3 country code", ErrInvalidOption, country)
+			}
+			ret.FilterCountries = append(ret.FilterCountries, country)
+		}
+	}
+
+	if err := validateMaxResults(search.MaxResults, maxResults); err != nil {
+		return nil, err
+	}
+
+	if search.Language != nil {
+		language := strings.TrimSpace(*search.Language)
+		ret.Language = &language
+	}
+
+	if len(search.FilterCategories) > maxFilterCategories {
+		return nil, fmt.Errorf("%d categories given, the maximum is %d", len(search.FilterCategories), maxFilterCategories)
+	}
+	if err := validateCategories(search.FilterCategories); err != nil {
+		return nil, err
+	}
+
+	if search.BiasPosition != nil && search.FilterBBox != nil {
+		return nil, errors.New("bias position and filter bounding box are mutually exclusive")
+	}
+	if search.BiasPosition != nil {
+		if err := search.BiasPosition.Validate(); err != nil {
+			return nil, fmt.Errorf("bias position: %w", err)
+		}
+	}
+	if search.FilterBBox != nil {
+		if err := search.FilterBBox.Validate(); err != nil {
+			return nil, fmt.Errorf("filter bounding box: %w", err)
+		}
+	}
+
+	return &ret, nil
+}
+
+// Validate checks that the position is a valid WGS 84 coordinate. Errors
+// wrap ErrInvalidCoordinates.
+func (latLon *LatLon) Validate() error {
+	if math.IsNaN(latLon.Latitude) || latLon.Latitude < -90 || latLon.Latitude > 90 {
+		return fmt.Errorf("%w: latitude %g out of range [-90, 90]", ErrInvalidCoordinates, latLon.Latitude)
+	}
+	if math.IsNaN(latLon.Longitude) || latLon.Longitude < -180 || latLon.Longitude > 180 {
+		return fmt.Errorf("%w: longitude %g out of range [-180, 180]", ErrInvalidCoordinates, latLon.Longitude)
+	}
+	return nil
+}
+
+// Validate checks that the box corners are valid coordinates and that the
+// southwest corner lies south of the northeast corner. A box crossing the
+// antimeridian has its southwest longitude X1 east of its northeast
+// longitude X2, e.g. X1=170, X2=-170 spans 20 degrees. Errors wrap
+// ErrInvalidCoordinates.
+func (box *Box) Validate() error {
+	southWest := &LatLon{Latitude: box.Y1, Longitude: box.X1}
+	northEast := &LatLon{Latitude: box.Y2, Longitude: box.X2}
+	if err := southWest.Validate(); err != nil {
+		return fmt.Errorf("southwest corner: %w", err)
+	}
+	if err := northEast.Validate(); err != nil {
+		return fmt.Errorf("northeast corner: %w", err)
+	}
+	if box.Y1 >= box.Y2 {
+		return fmt.Errorf("%w: southwest latitude %g must be less than northeast latitude %g", ErrInvalidCoordinates, box.Y1, box.Y2)
+	}
+	if box.X1 == box.X2 {
+		return fmt.Errorf("%w: southwest and northeast longitude are both %g, the box has no width", ErrInvalidCoordinates, box.X1)
+	}
+	return nil
+}
+
+// validate checks the search against the limits of the position search API.
+func (search *PositionSearch) validate() error {
+	if search == nil || search.Position == nil {
+		return errors.New("search position not set")
+	}
+	if err := search.Position.Validate(); err != nil {
+		return fmt.Errorf("position: %w", err)
+	}
+	return validateMaxResults(search.MaxResults, maxPositionResults)
+}
@@ -0,0 +1,22 @@
+package routesvc
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/location"
+)
+
+// LocationClient is the subset of the Amazon Location client used by this
+// package. *location.Client implements it; tests can substitute a fake with
+// SetLocationClient.
+type LocationClient interface {
+	CalculateRoute(ctx context.Context, params *location.CalculateRouteInput, optFns ...func(*location.Options)) (*location.CalculateRouteOutput, error)
+	CalculateRouteMatrix(ctx context.Context, params *location.CalculateRouteMatrixInput, optFns ...func(*location.Options)) (*location.CalculateRouteMatrixOutput, error)
+	CreateRouteCalculator(ctx context.Context, params *location.CreateRouteCalculatorInput, optFns ...func(*location.Options)) (*location.CreateRouteCalculatorOutput, error)
+	DeleteRouteCalculator(ctx context.Context, params *location.DeleteRouteCalculatorInput, optFns ...func(*location.Options)) (*location.DeleteRouteCalculatorOutput, error)
+	DescribeRouteCalculator(ctx context.Context, params *location.DescribeRouteCalculatorInput, optFns ...func(*location.Options)) (*location.DescribeRouteCalculatorOutput, error)
+	ListRouteCalculators(ctx context.Context, params *location.ListRouteCalculatorsInput, optFns ...func(*location.Options)) (*location.ListRouteCalculatorsOutput, error)
+	UpdateRouteCalculator(ctx context.Context, params *location.UpdateRouteCalculatorInput, optFns ...func(*location.Options)) (*location.UpdateRouteCalculatorOutput, error)
+}
+
+var _ LocationClient = (*location.Client)(nil)
@@ -0,0 +1,439 @@
+package routesvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Modes of transport of a route.
+const (
+	TravelModeBicycle    = "Bicycle"
+	TravelModeCar        = "Car"
+	TravelModeMotorcycle = "Motorcycle"
+	TravelModeTruck      = "Truck"
+	TravelModeWalking    = "Walking"
+)
+
+// Units of the truck dimensions and weight.
+const (
+	DimensionUnitFeet   = "Feet"
+	DimensionUnitMeters = "Meters"
+	WeightUnitKilograms = "Kilograms"
+	WeightUnitPounds    = "Pounds"
+)
+
+// maxWaypoints is the largest number of waypoints a route can pass through.
+const maxWaypoints = 23
+
+type Option func(config *Config)
+
+// Configuration structure.
+type Config struct {
+	region         string
+	profile        string
+	calculatorName string
+	log            *logrus.Logger
+	svc            LocationClient
+}
+
+type LatLon struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// AvoidanceOptions lists the road features a route should avoid. The
+// CalculateRoute API only supports avoiding ferries and tolls, for car and
+// truck travel. It cannot avoid areas given as polygons or bounding boxes,
+// controlled-access highways, tunnels or U-turns, so there are no options
+// for them.
+type AvoidanceOptions struct {
+	Ferries bool
+	Tolls   bool
+}
+
+// TruckOptions describes the truck of a Truck route, so the route avoids
+// roads it may not use. Zero values are left out of the request.
+type TruckOptions struct {
+	// Height, Length and Width of the truck in DimensionUnit.
+	Height float64
+	Length float64
+	Width  float64
+
+	// DimensionUnit is Meters or Feet. Defaults to Meters.
+	DimensionUnit string
+
+	// Weight is the total weight of the truck in WeightUnit.
+	Weight float64
+
+	// WeightUnit is Kilograms or Pounds. Defaults to Kilograms.
+	WeightUnit string
+}
+
+// validate checks the truck options for values the API rejects.
+func (t *TruckOptions) validate() error {
+	if t == nil {
+		return nil
+	}
+	if t.Height < 0 || t.Length < 0 || t.Width < 0 || t.Weight < 0 {
+		return errors.New("truck dimensions and weight must not be negative")
+	}
+	switch t.DimensionUnit {
+	case "", DimensionUnitMeters, DimensionUnitFeet:
+	default:
+		return fmt.Errorf("invalid dimension unit %q, must be %s or %s", t.DimensionUnit, DimensionUnitMeters, DimensionUnitFeet)
+	}
+	switch t.WeightUnit {
+	case "", WeightUnitKilograms, WeightUnitPounds:
+	default:
+		return fmt.Errorf("invalid weight unit %q, must be %s or %s", t.WeightUnit, WeightUnitKilograms, WeightUnitPounds)
+	}
+	return nil
+}
+
+// optional returns a pointer to v, or nil if v is zero.
+func optional(v float64) *float64 {
+	if v == 0 {
+		return nil
+	}
+	return aws.Float64(v)
+}
+
+// truckModeOptions combines the avoidance and truck options into the truck
+// mode options of the API.
+func truckModeOptions(a *AvoidanceOptions, t *TruckOptions) *types.CalculateRouteTruckModeOptions {
+	if a == nil && t == nil {
+		return nil
+	}
+	options := &types.CalculateRouteTruckModeOptions{}
+	if a != nil {
+		options.AvoidFerries = aws.Bool(a.Ferries)
+		options.AvoidTolls = aws.Bool(a.Tolls)
+	}
+	if t != nil {
+		if t.Height != 0 || t.Length != 0 || t.Width != 0 {
+			options.Dimensions = &types.TruckDimensions{
+				Height: optional(t.Height),
+				Length: optional(t.Length),
+				Width:  optional(t.Width),
+				Unit:   types.DimensionUnit(t.DimensionUnit),
+			}
+		}
+		if t.Weight != 0 {
+			options.Weight = &types.TruckWeight{
+				Total: aws.Float64(t.Weight),
+				Unit:  types.VehicleWeightUnit(t.WeightUnit),
+			}
+		}
+	}
+	return options
+}
+
+// carModeOptions converts the avoidance options into the car mode options of the API.
+func (a *AvoidanceOptions) carModeOptions() *types.CalculateRouteCarModeOptions {
+	if a == nil {
+		return nil
+	}
+	return &types.CalculateRouteCarModeOptions{
+		AvoidFerries: aws.Bool(a.Ferries),
+		AvoidTolls:   aws.Bool(a.Tolls),
+	}
+}
+
+type RouteRequest struct {
+	// The start position of the route.
+	//
+	// This member is required.
+	Departure *LatLon
+
+	// The finish position of the route.
+	//
+	// This member is required.
+	Destination *LatLon
+
+	// The unit of the distances in the response, Kilometers or Miles. Defaults
+	// to Kilometers.
+	DistanceUnit string
+
+	// Road features the route should avoid where possible.
+	Avoid *AvoidanceOptions
+
+	// The size and weight of the truck, for Truck travel only.
+	Truck *TruckOptions
+
+	// Set to include the geometry of each leg, and with it the geometry of each
+	// step, in the response.
+	IncludeLegGeometry bool
+
+	// Positions to pass through between departure and destination, in order.
+	// Each waypoint starts a new leg. Up to 23 waypoints are supported.
+	Waypoints []LatLon
+
+	// The mode of transport, one of the TravelMode constants. Defaults to Car.
+	TravelMode string
+
+	// When to depart, to take traffic into account. DepartNow uses the current
+	// time and cannot be combined with DepartureTime.
+	DepartureTime *time.Time
+	DepartNow     bool
+}
+
+type RouteMatrixRequest struct {
+	// The start positions, one row of the matrix each.
+	//
+	// This member is required.
+	Departures []LatLon
+
+	// The finish positions, one column of the matrix each.
+	//
+	// This member is required.
+	Destinations []LatLon
+
+	// The unit of the distances in the response, Kilometers or Miles. Defaults
+	// to Kilometers.
+	DistanceUnit string
+
+	// Road features the routes should avoid where possible.
+	Avoid *AvoidanceOptions
+}
+
+// positions converts a list of LatLon into the lon,lat pairs used by the API.
+func positions(latLons []LatLon) [][]float64 {
+	ret := make([][]float64, 0, len(latLons))
+	for _, latLon := range latLons {
+		ret = append(ret, []float64{latLon.Longitude, latLon.Latitude})
+	}
+	return ret
+}
+
+func New(opts ...func(*Config)) (*Config, error) {
+	config := &Config{}
+
+	// apply the list of options to Config
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.region == "" {
+		config.region = os.Getenv("AWS_REGION")
+	}
+
+	if config.svc == nil {
+		c, err := awsconfig.LoadDefaultConfig(context.TODO(), func(o *awsconfig.LoadOptions) error {
+			o.Region = config.region
+			if config.profile != "" {
+				o.SharedConfigProfile = config.profile
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		config.svc = location.NewFromConfig(c)
+	}
+
+	return config, nil
+}
+
+func SetAWSRegion(region string) Option {
+	return func(config *Config) {
+		config.region = region
+	}
+}
+
+func SetAWSProfile(profile string) Option {
+	return func(config *Config) {
+		config.profile = profile
+	}
+}
+
+func SetCalculatorName(calculatorName string) Option {
+	return func(config *Config) {
+		config.calculatorName = calculatorName
+	}
+}
+
+// SetLocationClient uses an existing client, such as one cached by clientmgr
+// or a fake in tests, instead of building a new one from the region and profile.
+func SetLocationClient(client LocationClient) Option {
+	return func(config *Config) {
+		config.svc = client
+	}
+}
+
+func SetLogger(log *logrus.Logger) Option {
+	return func(config *Config) {
+		config.log = log
+	}
+}
+
+func (c *Config) sanity() error {
+	if c.calculatorName == "" {
+		return errors.New("calculatorName not set")
+	}
+	return nil
+}
+
+func (config *Config) CalculateRoute(ctx context.Context, request *RouteRequest) (*location.CalculateRouteOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if request.Departure == nil || request.Destination == nil {
+		return nil, errors.New("departure and destination must be set")
+	}
+	if len(request.Waypoints) > maxWaypoints {
+		return nil, fmt.Errorf("%d waypoints given, the maximum is %d", len(request.Waypoints), maxWaypoints)
+	}
+	if request.DepartNow && request.DepartureTime != nil {
+		return nil, errors.New("departure time and depart now are mutually exclusive")
+	}
+	if err := request.Truck.validate(); err != nil {
+		return nil, err
+	}
+	if request.Truck != nil && request.TravelMode != TravelModeTruck {
+		return nil, fmt.Errorf("truck options require %s travel", TravelModeTruck)
+	}
+
+	input := &location.CalculateRouteInput{
+		CalculatorName:      aws.String(config.calculatorName),
+		DeparturePosition:   []float64{request.Departure.Longitude, request.Departure.Latitude},
+		DestinationPosition: []float64{request.Destination.Longitude, request.Destination.Latitude},
+		DistanceUnit:        types.DistanceUnit(request.DistanceUnit),
+		IncludeLegGeometry:  aws.Bool(request.IncludeLegGeometry),
+		TravelMode:          types.TravelMode(request.TravelMode),
+		DepartureTime:       request.DepartureTime,
+	}
+	if len(request.Waypoints) > 0 {
+		input.WaypointPositions = positions(request.Waypoints)
+	}
+	if request.DepartNow {
+		input.DepartNow = aws.Bool(true)
+	}
+
+	switch request.TravelMode {
+	case "", TravelModeCar:
+		input.CarModeOptions = request.Avoid.carModeOptions()
+	case TravelModeTruck:
+		input.TruckModeOptions = truckModeOptions(request.Avoid, request.Truck)
+	case TravelModeBicycle, TravelModeMotorcycle, TravelModeWalking:
+		if request.Avoid != nil && (request.Avoid.Ferries || request.Avoid.Tolls) {
+			return nil, fmt.Errorf("avoiding ferries or tolls is only supported for %s and %s travel", TravelModeCar, TravelModeTruck)
+		}
+	default:
+		return nil, fmt.Errorf("invalid travel mode %q", request.TravelMode)
+	}
+
+	return config.svc.CalculateRoute(ctx, input)
+}
+
+func (config *Config) CalculateRouteMatrix(ctx context.Context, request *RouteMatrixRequest) (*location.CalculateRouteMatrixOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if len(request.Departures) == 0 || len(request.Destinations) == 0 {
+		return nil, errors.New("departures and destinations must be set")
+	}
+
+	return config.svc.CalculateRouteMatrix(
+		ctx,
+		&location.CalculateRouteMatrixInput{
+			CalculatorName:       aws.String(config.calculatorName),
+			CarModeOptions:       request.Avoid.carModeOptions(),
+			DeparturePositions:   positions(request.Departures),
+			DestinationPositions: positions(request.Destinations),
+			DistanceUnit:         types.DistanceUnit(request.DistanceUnit),
+		},
+	)
+}
+
+func (config *Config) CreateRouteCalculator(ctx context.Context, dataSource string, description string, tags *map[string]string) (*location.CreateRouteCalculatorOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.CreateRouteCalculator(
+		ctx,
+		&location.CreateRouteCalculatorInput{
+			CalculatorName: aws.String(config.calculatorName),
+			DataSource:     aws.String(dataSource),
+			Description:    aws.String(description),
+			Tags:           *tags,
+		},
+	)
+}
+
+func (config *Config) DeleteRouteCalculator(ctx context.Context) (*location.DeleteRouteCalculatorOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.DeleteRouteCalculator(
+		ctx,
+		&location.DeleteRouteCalculatorInput{
+			CalculatorName: aws.String(config.calculatorName),
+		},
+	)
+}
+
+func (config *Config) DescribeRouteCalculator(ctx context.Context) (*location.DescribeRouteCalculatorOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.DescribeRouteCalculator(
+		ctx,
+		&location.DescribeRouteCalculatorInput{
+			CalculatorName: aws.String(config.calculatorName),
+		},
+	)
+}
+
+// ListRouteCalculators returns up to maxItems route calculators, following
+// result pages as needed. A maxItems of zero returns every calculator.
+func (config *Config) ListRouteCalculators(ctx context.Context, maxItems int) ([]types.ListRouteCalculatorsResponseEntry, error) {
+	if maxItems < 0 {
+		return nil, fmt.Errorf("max items %d must not be negative", maxItems)
+	}
+
+	paginator := location.NewListRouteCalculatorsPaginator(
+		config.svc,
+		&location.ListRouteCalculatorsInput{},
+	)
+
+	var entries []types.ListRouteCalculatorsResponseEntry
+	for paginator.HasMorePages() {
+		ret, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ret.Entries...)
+		if maxItems > 0 && len(entries) >= maxItems {
+			return entries[:maxItems], nil
+		}
+	}
+
+	return entries, nil
+}
+
+func (config *Config) UpdateRouteCalculator(ctx context.Context, description string) (*location.UpdateRouteCalculatorOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.UpdateRouteCalculator(
+		ctx,
+		&location.UpdateRouteCalculatorInput{
+			CalculatorName: aws.String(config.calculatorName),
+			Description:    aws.String(description),
+		},
+	)
+}
@@ -0,0 +1,167 @@
+// Package ssologin signs in to AWS IAM Identity Center (SSO) with the device
+// authorization flow and caches the token where the AWS SDK and CLI look for
+// it, so SSO profiles work without running `aws sso login` first.
+package ssologin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	"github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+)
+
+const (
+	clientName      = "goawsloc"
+	deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+	refreshGrant    = "refresh_token"
+	defaultScope    = "sso:account:access"
+
+	// slowDownStep is added to the polling interval when asked to slow down.
+	slowDownStep = 5 * time.Second
+)
+
+// ErrNotSSOProfile is returned by Login for profiles without SSO settings.
+var ErrNotSSOProfile = errors.New("profile is not configured for SSO")
+
+// Prompt shows the user where to approve the sign-in request.
+type Prompt func(verificationURI, userCode string)
+
+// IsExpiredToken reports whether err was caused by a missing or expired SSO
+// token, which Login fixes.
+func IsExpiredToken(err error) bool {
+	var invalid *ssocreds.InvalidTokenError
+	return errors.As(err, &invalid)
+}
+
+// Login runs the device authorization flow for the SSO profile, calling
+// prompt with the URL to approve the request at, and waits until the user has
+// approved it. It returns when the cached token expires.
+func Login(ctx context.Context, profile string, prompt Prompt) (time.Time, error) {
+	shared, err := awsconfig.LoadSharedConfigProfile(ctx, profile)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	startURL, region, cacheKey := shared.SSOStartURL, shared.SSORegion, shared.SSOStartURL
+	var scopes []string
+	if shared.SSOSession != nil {
+		// sso-session profiles can refresh their token, legacy profiles cannot.
+		startURL, region, cacheKey = shared.SSOSession.SSOStartURL, shared.SSOSession.SSORegion, shared.SSOSession.Name
+		scopes = []string{defaultScope}
+	}
+	if startURL == "" || region == "" {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrNotSSOProfile, profile)
+	}
+
+	client := ssooidc.New(ssooidc.Options{Region: region})
+
+	registerInput := &ssooidc.RegisterClientInput{
+		ClientName: aws.String(clientName),
+		ClientType: aws.String("public"),
+	}
+	if scopes != nil {
+		registerInput.Scopes = scopes
+		registerInput.GrantTypes = []string{deviceCodeGrant, refreshGrant}
+	}
+	registration, err := client.RegisterClient(ctx, registerInput)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	auth, err := client.StartDeviceAuthorization(ctx, &ssooidc.StartDeviceAuthorizationInput{
+		ClientId:     registration.ClientId,
+		ClientSecret: registration.ClientSecret,
+		StartUrl:     aws.String(startURL),
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	prompt(aws.ToString(auth.VerificationUriComplete), aws.ToString(auth.UserCode))
+
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = slowDownStep
+	}
+	deadline := time.Now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+
+	for {
+		if time.Now().After(deadline) {
+			return time.Time{}, errors.New("timed out waiting for the sign-in request to be approved")
+		}
+		select {
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		token, err := client.CreateToken(ctx, &ssooidc.CreateTokenInput{
+			ClientId:     registration.ClientId,
+			ClientSecret: registration.ClientSecret,
+			DeviceCode:   auth.DeviceCode,
+			GrantType:    aws.String(deviceCodeGrant),
+		})
+		var pending *types.AuthorizationPendingException
+		var slowDown *types.SlowDownException
+		switch {
+		case errors.As(err, &pending):
+			continue
+		case errors.As(err, &slowDown):
+			interval += slowDownStep
+			continue
+		case err != nil:
+			return time.Time{}, err
+		}
+
+		expiresAt := time.Now().Add(time.Duration(token.ExpiresIn) * time.Second).UTC()
+		if err := writeToken(cacheKey, &cachedToken{
+			AccessToken:           aws.ToString(token.AccessToken),
+			ExpiresAt:             expiresAt.Format(time.RFC3339),
+			RefreshToken:          aws.ToString(token.RefreshToken),
+			ClientID:              aws.ToString(registration.ClientId),
+			ClientSecret:          aws.ToString(registration.ClientSecret),
+			RegistrationExpiresAt: time.Unix(registration.ClientSecretExpiresAt, 0).UTC().Format(time.RFC3339),
+			Region:                region,
+			StartURL:              startURL,
+		}); err != nil {
+			return time.Time{}, err
+		}
+		return expiresAt, nil
+	}
+}
+
+// cachedToken is the token cache file format shared with the AWS CLI.
+type cachedToken struct {
+	AccessToken           string `json:"accessToken"`
+	ExpiresAt             string `json:"expiresAt"`
+	RefreshToken          string `json:"refreshToken,omitempty"`
+	ClientID              string `json:"clientId,omitempty"`
+	ClientSecret          string `json:"clientSecret,omitempty"`
+	RegistrationExpiresAt string `json:"registrationExpiresAt,omitempty"`
+	Region                string `json:"region"`
+	StartURL              string `json:"startUrl"`
+}
+
+// writeToken stores the token in the standard SSO cache directory.
+func writeToken(cacheKey string, token *cachedToken) error {
+	filename, err := ssocreds.StandardCachedTokenFilepath(cacheKey)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0600)
+}
@@ -0,0 +1,30 @@
+package trackersvc
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/location"
+)
+
+// LocationClient is the subset of the Amazon Location client used by this
+// package. *location.Client implements it; tests can substitute a fake with
+// SetLocationClient.
+type LocationClient interface {
+	AssociateTrackerConsumer(ctx context.Context, params *location.AssociateTrackerConsumerInput, optFns ...func(*location.Options)) (*location.AssociateTrackerConsumerOutput, error)
+	BatchDeleteDevicePositionHistory(ctx context.Context, params *location.BatchDeleteDevicePositionHistoryInput, optFns ...func(*location.Options)) (*location.BatchDeleteDevicePositionHistoryOutput, error)
+	BatchGetDevicePosition(ctx context.Context, params *location.BatchGetDevicePositionInput, optFns ...func(*location.Options)) (*location.BatchGetDevicePositionOutput, error)
+	BatchUpdateDevicePosition(ctx context.Context, params *location.BatchUpdateDevicePositionInput, optFns ...func(*location.Options)) (*location.BatchUpdateDevicePositionOutput, error)
+	CreateTracker(ctx context.Context, params *location.CreateTrackerInput, optFns ...func(*location.Options)) (*location.CreateTrackerOutput, error)
+	DeleteTracker(ctx context.Context, params *location.DeleteTrackerInput, optFns ...func(*location.Options)) (*location.DeleteTrackerOutput, error)
+	DescribeTracker(ctx context.Context, params *location.DescribeTrackerInput, optFns ...func(*location.Options)) (*location.DescribeTrackerOutput, error)
+	DisassociateTrackerConsumer(ctx context.Context, params *location.DisassociateTrackerConsumerInput, optFns ...func(*location.Options)) (*location.DisassociateTrackerConsumerOutput, error)
+	GetDevicePosition(ctx context.Context, params *location.GetDevicePositionInput, optFns ...func(*location.Options)) (*location.GetDevicePositionOutput, error)
+	GetDevicePositionHistory(ctx context.Context, params *location.GetDevicePositionHistoryInput, optFns ...func(*location.Options)) (*location.GetDevicePositionHistoryOutput, error)
+	ListDevicePositions(ctx context.Context, params *location.ListDevicePositionsInput, optFns ...func(*location.Options)) (*location.ListDevicePositionsOutput, error)
+	ListTrackerConsumers(ctx context.Context, params *location.ListTrackerConsumersInput, optFns ...func(*location.Options)) (*location.ListTrackerConsumersOutput, error)
+	ListTrackers(ctx context.Context, params *location.ListTrackersInput, optFns ...func(*location.Options)) (*location.ListTrackersOutput, error)
+	UpdateTracker(ctx context.Context, params *location.UpdateTrackerInput, optFns ...func(*location.Options)) (*location.UpdateTrackerOutput, error)
+	VerifyDevicePosition(ctx context.Context, params *location.VerifyDevicePositionInput, optFns ...func(*location.Options)) (*location.VerifyDevicePositionOutput, error)
+}
+
+var _ LocationClient = (*location.Client)(nil)
@@ -0,0 +1,568 @@
+package trackersvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxBatchGetDevices is the maximum number of device IDs BatchGetDevicePosition accepts per call.
+	maxBatchGetDevices = 10
+
+	// maxBatchUpdatePositions is the maximum number of position updates BatchUpdateDevicePosition accepts per call.
+	maxBatchUpdatePositions = 10
+
+	// maxBatchDeleteDevices is the maximum number of device IDs BatchDeleteDevicePositionHistory accepts per call.
+	maxBatchDeleteDevices = 100
+)
+
+// Position filtering modes of a tracker.
+const (
+	PositionFilteringAccuracyBased = "AccuracyBased"
+	PositionFilteringDistanceBased = "DistanceBased"
+	PositionFilteringTimeBased     = "TimeBased"
+)
+
+type Option func(config *Config)
+
+// Configuration structure.
+type Config struct {
+	region      string
+	profile     string
+	trackerName string
+	filtering   string
+	kmsKeyID    string
+	log         *logrus.Logger
+	svc         LocationClient
+}
+
+func New(opts ...func(*Config)) (*Config, error) {
+	config := &Config{}
+
+	// apply the list of options to Config
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.region == "" {
+		config.region = os.Getenv("AWS_REGION")
+	}
+
+	switch config.filtering {
+	case "", PositionFilteringAccuracyBased, PositionFilteringDistanceBased, PositionFilteringTimeBased:
+	default:
+		return nil, fmt.Errorf("invalid position filtering %q, must be %s, %s or %s", config.filtering, PositionFilteringTimeBased, PositionFilteringDistanceBased, PositionFilteringAccuracyBased)
+	}
+
+	if config.svc == nil {
+		c, err := awsconfig.LoadDefaultConfig(context.TODO(), func(o *awsconfig.LoadOptions) error {
+			o.Region = config.region
+			if config.profile != "" {
+				o.SharedConfigProfile = config.profile
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		config.svc = location.NewFromConfig(c)
+	}
+
+	return config, nil
+}
+
+func SetAWSRegion(region string) Option {
+	return func(config *Config) {
+		config.region = region
+	}
+}
+
+func SetAWSProfile(profile string) Option {
+	return func(config *Config) {
+		config.profile = profile
+	}
+}
+
+// SetKMSKeyID encrypts the data of new trackers with a customer managed KMS
+// key instead of an AWS owned key.
+func SetKMSKeyID(kmsKeyID string) Option {
+	return func(config *Config) {
+		config.kmsKeyID = kmsKeyID
+	}
+}
+
+// SetLocationClient uses an existing client, such as one cached by clientmgr
+// or a fake in tests, instead of building a new one from the region and profile.
+func SetLocationClient(client LocationClient) Option {
+	return func(config *Config) {
+		config.svc = client
+	}
+}
+
+func SetLogger(log *logrus.Logger) Option {
+	return func(config *Config) {
+		config.log = log
+	}
+}
+
+// SetPositionFiltering sets how position updates are filtered, one of the
+// PositionFiltering constants. Trackers are created with TimeBased filtering
+// if not set, and updates keep the current mode.
+func SetPositionFiltering(filtering string) Option {
+	return func(config *Config) {
+		config.filtering = filtering
+	}
+}
+
+func SetTrackerName(trackerName string) Option {
+	return func(config *Config) {
+		config.trackerName = trackerName
+	}
+}
+
+func (c *Config) sanity() error {
+	if c.trackerName == "" {
+		return errors.New("trackerName not set")
+	}
+	return nil
+}
+
+func (config *Config) CreateTracker(ctx context.Context, description string, tags *map[string]string) (*location.CreateTrackerOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	input := &location.CreateTrackerInput{
+		Description:       aws.String(description),
+		PositionFiltering: types.PositionFiltering(config.filtering),
+		Tags:              *tags,
+		TrackerName:       aws.String(config.trackerName),
+	}
+	if config.kmsKeyID != "" {
+		input.KmsKeyId = aws.String(config.kmsKeyID)
+	}
+	return config.svc.CreateTracker(ctx, input)
+}
+
+func (config *Config) DeleteTracker(ctx context.Context) (*location.DeleteTrackerOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.DeleteTracker(
+		ctx,
+		&location.DeleteTrackerInput{
+			TrackerName: aws.String(config.trackerName),
+		},
+	)
+}
+
+func (config *Config) DescribeTracker(ctx context.Context) (*location.DescribeTrackerOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.DescribeTracker(
+		ctx,
+		&location.DescribeTrackerInput{
+			TrackerName: aws.String(config.trackerName),
+		},
+	)
+}
+
+// ListTrackers returns up to maxItems trackers, following result pages as
+// needed. A maxItems of zero returns every tracker.
+func (config *Config) ListTrackers(ctx context.Context, maxItems int) ([]types.ListTrackersResponseEntry, error) {
+	if maxItems < 0 {
+		return nil, fmt.Errorf("max items %d must not be negative", maxItems)
+	}
+
+	paginator := location.NewListTrackersPaginator(
+		config.svc,
+		&location.ListTrackersInput{},
+	)
+
+	var entries []types.ListTrackersResponseEntry
+	for paginator.HasMorePages() {
+		ret, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ret.Entries...)
+		if maxItems > 0 && len(entries) >= maxItems {
+			return entries[:maxItems], nil
+		}
+	}
+
+	return entries, nil
+}
+
+// UpdateTracker sets the description of the tracker unless it is nil and,
+// if configured, its position filtering mode.
+func (config *Config) UpdateTracker(ctx context.Context, description *string) (*location.UpdateTrackerOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	return config.svc.UpdateTracker(
+		ctx,
+		&location.UpdateTrackerInput{
+			Description:       description,
+			PositionFiltering: types.PositionFiltering(config.filtering),
+			TrackerName:       aws.String(config.trackerName),
+		},
+	)
+}
+
+// DevicePosition is a position update of a device.
+type DevicePosition struct {
+	// DeviceID identifies the device.
+	DeviceID string
+
+	// Latitude and Longitude of the device.
+	Latitude  float64
+	Longitude float64
+
+	// SampleTime is when the device was at the position.
+	SampleTime time.Time
+
+	// Accuracy is the optional horizontal accuracy of the position in meters.
+	Accuracy *float64
+
+	// Properties are optional metadata of the position, up to 3 pairs.
+	Properties map[string]string
+}
+
+// update converts the position into a position update of the API.
+func (p *DevicePosition) update() types.DevicePositionUpdate {
+	update := types.DevicePositionUpdate{
+		DeviceId:           aws.String(p.DeviceID),
+		Position:           []float64{p.Longitude, p.Latitude},
+		SampleTime:         aws.Time(p.SampleTime),
+		PositionProperties: p.Properties,
+	}
+	if p.Accuracy != nil {
+		update.Accuracy = &types.PositionalAccuracy{Horizontal: p.Accuracy}
+	}
+	return update
+}
+
+// UpdatePositions sends position updates of devices to a tracker, or to the
+// configured tracker if trackerName is empty. The updates are split into
+// chunks to stay within the API limit and the errors of all chunks are merged
+// into a single output.
+func (config *Config) UpdatePositions(ctx context.Context, trackerName string, updates []DevicePosition) (*location.BatchUpdateDevicePositionOutput, error) {
+	if trackerName == "" {
+		if err := config.sanity(); err != nil {
+			return nil, err
+		}
+		trackerName = config.trackerName
+	}
+	if len(updates) == 0 {
+		return nil, errors.New("no position updates given")
+	}
+	for i, update := range updates {
+		if update.DeviceID == "" {
+			return nil, fmt.Errorf("position update %d has no device ID", i+1)
+		}
+		if update.SampleTime.IsZero() {
+			return nil, fmt.Errorf("position update %d of device %s has no sample time", i+1, update.DeviceID)
+		}
+	}
+
+	out := &location.BatchUpdateDevicePositionOutput{}
+	for start := 0; start < len(updates); start += maxBatchUpdatePositions {
+		end := start + maxBatchUpdatePositions
+		if end > len(updates) {
+			end = len(updates)
+		}
+
+		chunk := make([]types.DevicePositionUpdate, 0, end-start)
+		for i := start; i < end; i++ {
+			chunk = append(chunk, updates[i].update())
+		}
+		ret, err := config.svc.BatchUpdateDevicePosition(
+			ctx,
+			&location.BatchUpdateDevicePositionInput{
+				TrackerName: aws.String(trackerName),
+				Updates:     chunk,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		out.Errors = append(out.Errors, ret.Errors...)
+		out.ResultMetadata = ret.ResultMetadata
+	}
+
+	return out, nil
+}
+
+// BatchGetDevicePositions fetches the latest position of each device.
+// The device IDs are split into chunks to stay within the API limit and the
+// positions and errors of all chunks are merged into a single output.
+func (config *Config) BatchGetDevicePositions(ctx context.Context, deviceIDs []string) (*location.BatchGetDevicePositionOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if len(deviceIDs) == 0 {
+		return nil, errors.New("no device IDs given")
+	}
+
+	out := &location.BatchGetDevicePositionOutput{}
+	for start := 0; start < len(deviceIDs); start += maxBatchGetDevices {
+		end := start + maxBatchGetDevices
+		if end > len(deviceIDs) {
+			end = len(deviceIDs)
+		}
+
+		ret, err := config.svc.BatchGetDevicePosition(
+			ctx,
+			&location.BatchGetDevicePositionInput{
+				DeviceIds:   deviceIDs[start:end],
+				TrackerName: aws.String(config.trackerName),
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		out.DevicePositions = append(out.DevicePositions, ret.DevicePositions...)
+		out.Errors = append(out.Errors, ret.Errors...)
+		out.ResultMetadata = ret.ResultMetadata
+	}
+
+	return out, nil
+}
+
+// GetDevicePosition returns the latest position of a device.
+func (config *Config) GetDevicePosition(ctx context.Context, deviceID string) (*location.GetDevicePositionOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if deviceID == "" {
+		return nil, errors.New("deviceID not set")
+	}
+
+	return config.svc.GetDevicePosition(
+		ctx,
+		&location.GetDevicePositionInput{
+			DeviceId:    aws.String(deviceID),
+			TrackerName: aws.String(config.trackerName),
+		},
+	)
+}
+
+// ListDevicePositions returns the latest position of every device of the
+// tracker, following all result pages.
+func (config *Config) ListDevicePositions(ctx context.Context) ([]types.ListDevicePositionsResponseEntry, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	paginator := location.NewListDevicePositionsPaginator(
+		config.svc,
+		&location.ListDevicePositionsInput{
+			TrackerName: aws.String(config.trackerName),
+		},
+	)
+
+	var entries []types.ListDevicePositionsResponseEntry
+	for paginator.HasMorePages() {
+		ret, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ret.Entries...)
+	}
+
+	return entries, nil
+}
+
+// BatchDeleteDevicePositionHistory deletes the entire position history of each device.
+// The device IDs are split into chunks to stay within the API limit and the
+// errors of all chunks are merged into a single output.
+func (config *Config) BatchDeleteDevicePositionHistory(ctx context.Context, deviceIDs []string) (*location.BatchDeleteDevicePositionHistoryOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if len(deviceIDs) == 0 {
+		return nil, errors.New("no device IDs given")
+	}
+
+	out := &location.BatchDeleteDevicePositionHistoryOutput{}
+	for start := 0; start < len(deviceIDs); start += maxBatchDeleteDevices {
+		end := start + maxBatchDeleteDevices
+		if end > len(deviceIDs) {
+			end = len(deviceIDs)
+		}
+
+		ret, err := config.svc.BatchDeleteDevicePositionHistory(
+			ctx,
+			&location.BatchDeleteDevicePositionHistoryInput{
+				DeviceIds:   deviceIDs[start:end],
+				TrackerName: aws.String(config.trackerName),
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		out.Errors = append(out.Errors, ret.Errors...)
+		out.ResultMetadata = ret.ResultMetadata
+	}
+
+	return out, nil
+}
+
+// GetDevicePositionHistory returns every position a device reported between
+// start (inclusive) and end (exclusive), following all result pages.
+// A nil start or end leaves that side of the range open.
+func (config *Config) GetDevicePositionHistory(ctx context.Context, deviceID string, start *time.Time, end *time.Time) ([]types.DevicePosition, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if deviceID == "" {
+		return nil, errors.New("deviceID not set")
+	}
+
+	paginator := location.NewGetDevicePositionHistoryPaginator(
+		config.svc,
+		&location.GetDevicePositionHistoryInput{
+			DeviceId:           aws.String(deviceID),
+			TrackerName:        aws.String(config.trackerName),
+			StartTimeInclusive: start,
+			EndTimeExclusive:   end,
+		},
+	)
+
+	var positions []types.DevicePosition
+	for paginator.HasMorePages() {
+		ret, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, ret.DevicePositions...)
+	}
+
+	return positions, nil
+}
+
+// Downsample thins out a position history so consecutive positions are at
+// least interval apart, keeping the first position of each interval.
+// The positions are returned in sample time order.
+func Downsample(positions []types.DevicePosition, interval time.Duration) []types.DevicePosition {
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].SampleTime.Before(*positions[j].SampleTime)
+	})
+
+	var sampled []types.DevicePosition
+	var last time.Time
+	for _, pos := range positions {
+		if len(sampled) > 0 && pos.SampleTime.Sub(last) < interval {
+			continue
+		}
+		sampled = append(sampled, pos)
+		last = *pos.SampleTime
+	}
+	return sampled
+}
+
+// VerifyDevicePosition checks a position reported by a device against its
+// cellular, Wi-Fi and IP signals, reporting where the device is inferred to
+// be and whether a proxy was detected. distanceUnit is Kilometers or Miles,
+// and defaults to Kilometers if empty.
+func (config *Config) VerifyDevicePosition(ctx context.Context, state *types.DeviceState, distanceUnit types.DistanceUnit) (*location.VerifyDevicePositionOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if state == nil || aws.ToString(state.DeviceId) == "" {
+		return nil, errors.New("deviceID not set")
+	}
+	if len(state.Position) != 2 {
+		return nil, fmt.Errorf("device %s: position must be [longitude, latitude]", *state.DeviceId)
+	}
+	if state.SampleTime == nil {
+		return nil, fmt.Errorf("device %s: sample time not set", *state.DeviceId)
+	}
+
+	return config.svc.VerifyDevicePosition(
+		ctx,
+		&location.VerifyDevicePositionInput{
+			DeviceState:  state,
+			DistanceUnit: distanceUnit,
+			TrackerName:  aws.String(config.trackerName),
+		},
+	)
+}
+
+// AssociateTrackerConsumer links the tracker to a geofence collection, so the
+// collection evaluates the position updates of the tracker.
+func (config *Config) AssociateTrackerConsumer(ctx context.Context, consumerArn string) (*location.AssociateTrackerConsumerOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if consumerArn == "" {
+		return nil, errors.New("consumerArn not set")
+	}
+
+	return config.svc.AssociateTrackerConsumer(
+		ctx,
+		&location.AssociateTrackerConsumerInput{
+			ConsumerArn: aws.String(consumerArn),
+			TrackerName: aws.String(config.trackerName),
+		},
+	)
+}
+
+// DisassociateTrackerConsumer removes the association between the tracker and a geofence collection.
+func (config *Config) DisassociateTrackerConsumer(ctx context.Context, consumerArn string) (*location.DisassociateTrackerConsumerOutput, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+	if consumerArn == "" {
+		return nil, errors.New("consumerArn not set")
+	}
+
+	return config.svc.DisassociateTrackerConsumer(
+		ctx,
+		&location.DisassociateTrackerConsumerInput{
+			ConsumerArn: aws.String(consumerArn),
+			TrackerName: aws.String(config.trackerName),
+		},
+	)
+}
+
+// ListTrackerConsumers returns the ARNs of all geofence collections associated with the tracker.
+func (config *Config) ListTrackerConsumers(ctx context.Context) ([]string, error) {
+	if err := config.sanity(); err != nil {
+		return nil, err
+	}
+
+	paginator := location.NewListTrackerConsumersPaginator(
+		config.svc,
+		&location.ListTrackerConsumersInput{
+			TrackerName: aws.String(config.trackerName),
+		},
+	)
+
+	var consumerArns []string
+	for paginator.HasMorePages() {
+		ret, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		consumerArns = append(consumerArns, ret.ConsumerArns...)
+	}
+
+	return consumerArns, nil
+}
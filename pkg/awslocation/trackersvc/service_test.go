@@ -0,0 +1,226 @@
+package trackersvc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+)
+
+// fakeClient is a LocationClient recording the size of each batch call. It
+// fails every device whose ID ends in 7, and panics on any other call.
+type fakeClient struct {
+	LocationClient
+	chunks   []int
+	trackers []string
+}
+
+// failed reports whether the fake fails the device.
+func failed(deviceID string) bool {
+	return deviceID[len(deviceID)-1] == '7'
+}
+
+// batchError returns the error of the fake for a failed device.
+func batchError(deviceID string) *types.BatchItemError {
+	return &types.BatchItemError{Message: aws.String(deviceID)}
+}
+
+func (f *fakeClient) BatchGetDevicePosition(ctx context.Context, params *location.BatchGetDevicePositionInput, optFns ...func(*location.Options)) (*location.BatchGetDevicePositionOutput, error) {
+	f.chunks = append(f.chunks, len(params.DeviceIds))
+	out := &location.BatchGetDevicePositionOutput{}
+	for _, id := range params.DeviceIds {
+		if failed(id) {
+			out.Errors = append(out.Errors, types.BatchGetDevicePositionError{DeviceId: aws.String(id), Error: batchError(id)})
+			continue
+		}
+		out.DevicePositions = append(out.DevicePositions, types.DevicePosition{DeviceId: aws.String(id)})
+	}
+	return out, nil
+}
+
+// deviceIDs returns n device IDs.
+func deviceIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("device-%d", i)
+	}
+	return ids
+}
+
+// failures returns the number of device IDs the fake fails.
+func failures(ids []string) int {
+	n := 0
+	for _, id := range ids {
+		if failed(id) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestBatchGetDevicePositions(t *testing.T) {
+	tests := []struct {
+		name       string
+		devices    int
+		wantChunks []int
+		wantErr    bool
+	}{
+		{"none", 0, nil, true},
+		{"one", 1, []int{1}, false},
+		{"one chunk", 10, []int{10}, false},
+		{"two chunks", 11, []int{10, 1}, false},
+		{"full chunks", 30, []int{10, 10, 10}, false},
+		{"partial last chunk", 25, []int{10, 10, 5}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeClient{}
+			svc, err := New(SetLocationClient(fake), SetTrackerName("tracker"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := deviceIDs(tt.devices)
+			out, err := svc.BatchGetDevicePositions(context.Background(), ids)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BatchGetDevicePositions() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(fake.chunks, tt.wantChunks) {
+				t.Errorf("sent chunks of %v devices, want %v", fake.chunks, tt.wantChunks)
+			}
+			if err != nil {
+				return
+			}
+			if want := len(ids) - failures(ids); len(out.DevicePositions) != want {
+				t.Errorf("got %d positions, want %d", len(out.DevicePositions), want)
+			}
+			if want := failures(ids); len(out.Errors) != want {
+				t.Errorf("got %d errors, want %d", len(out.Errors), want)
+			}
+		})
+	}
+}
+
+func (f *fakeClient) BatchDeleteDevicePositionHistory(ctx context.Context, params *location.BatchDeleteDevicePositionHistoryInput, optFns ...func(*location.Options)) (*location.BatchDeleteDevicePositionHistoryOutput, error) {
+	f.chunks = append(f.chunks, len(params.DeviceIds))
+	out := &location.BatchDeleteDevicePositionHistoryOutput{}
+	for _, id := range params.DeviceIds {
+		if failed(id) {
+			out.Errors = append(out.Errors, types.BatchDeleteDevicePositionHistoryError{DeviceId: aws.String(id), Error: batchError(id)})
+		}
+	}
+	return out, nil
+}
+
+func TestBatchDeleteDevicePositionHistory(t *testing.T) {
+	tests := []struct {
+		name       string
+		devices    int
+		wantChunks []int
+		wantErr    bool
+	}{
+		{"none", 0, nil, true},
+		{"one", 1, []int{1}, false},
+		{"one chunk", 100, []int{100}, false},
+		{"two chunks", 101, []int{100, 1}, false},
+		{"partial last chunk", 250, []int{100, 100, 50}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeClient{}
+			svc, err := New(SetLocationClient(fake), SetTrackerName("tracker"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := deviceIDs(tt.devices)
+			out, err := svc.BatchDeleteDevicePositionHistory(context.Background(), ids)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BatchDeleteDevicePositionHistory() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(fake.chunks, tt.wantChunks) {
+				t.Errorf("sent chunks of %v devices, want %v", fake.chunks, tt.wantChunks)
+			}
+			if err != nil {
+				return
+			}
+			if want := failures(ids); len(out.Errors) != want {
+				t.Errorf("got %d errors, want %d", len(out.Errors), want)
+			}
+		})
+	}
+}
+
+func (f *fakeClient) BatchUpdateDevicePosition(ctx context.Context, params *location.BatchUpdateDevicePositionInput, optFns ...func(*location.Options)) (*location.BatchUpdateDevicePositionOutput, error) {
+	f.chunks = append(f.chunks, len(params.Updates))
+	f.trackers = append(f.trackers, aws.ToString(params.TrackerName))
+	out := &location.BatchUpdateDevicePositionOutput{}
+	for _, update := range params.Updates {
+		if id := aws.ToString(update.DeviceId); failed(id) {
+			out.Errors = append(out.Errors, types.BatchUpdateDevicePositionError{DeviceId: update.DeviceId, SampleTime: update.SampleTime, Error: batchError(id)})
+		}
+	}
+	return out, nil
+}
+
+// positions returns a position update of each device.
+func positions(ids []string) []DevicePosition {
+	updates := make([]DevicePosition, len(ids))
+	for i, id := range ids {
+		updates[i] = DevicePosition{DeviceID: id, Latitude: 47.6, Longitude: -122.3, SampleTime: time.Unix(1700000000, 0)}
+	}
+	return updates
+}
+
+func TestUpdatePositions(t *testing.T) {
+	tests := []struct {
+		name        string
+		trackerName string
+		updates     []DevicePosition
+		wantChunks  []int
+		wantTracker string
+		wantErr     bool
+	}{
+		{"none", "", nil, nil, "", true},
+		{"one", "", positions(deviceIDs(1)), []int{1}, "tracker", false},
+		{"one chunk", "", positions(deviceIDs(10)), []int{10}, "tracker", false},
+		{"partial last chunk", "", positions(deviceIDs(23)), []int{10, 10, 3}, "tracker", false},
+		{"other tracker", "other", positions(deviceIDs(11)), []int{10, 1}, "other", false},
+		{"no device id", "", []DevicePosition{{SampleTime: time.Unix(1700000000, 0)}}, nil, "", true},
+		{"no sample time", "", []DevicePosition{{DeviceID: "device-1"}}, nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeClient{}
+			svc, err := New(SetLocationClient(fake), SetTrackerName("tracker"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := svc.UpdatePositions(context.Background(), tt.trackerName, tt.updates)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdatePositions() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(fake.chunks, tt.wantChunks) {
+				t.Errorf("sent chunks of %v updates, want %v", fake.chunks, tt.wantChunks)
+			}
+			if err != nil {
+				return
+			}
+			for _, tracker := range fake.trackers {
+				if tracker != tt.wantTracker {
+					t.Errorf("updated tracker %q, want %q", tracker, tt.wantTracker)
+				}
+			}
+			ids := make([]string, len(tt.updates))
+			for i, update := range tt.updates {
+				ids[i] = update.DeviceID
+			}
+			if want := failures(ids); len(out.Errors) != want {
+				t.Errorf("got %d errors, want %d", len(out.Errors), want)
+			}
+		})
+	}
+}
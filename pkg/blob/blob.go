@@ -0,0 +1,145 @@
+// Package blob opens the files commands read and write by name, which may be
+// a local path, - for stdin or stdout, or an s3://bucket/key URI, so batch
+// jobs can run against S3 objects without staging them on disk.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/transfermanager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3Scheme is the prefix of S3 URIs.
+const s3Scheme = "s3://"
+
+// Opener opens blobs by name.
+type Opener struct {
+	// S3 returns the client S3 objects are read and written with. It is
+	// only called once an s3:// URI is opened, so callers working with
+	// local files never need AWS credentials for S3.
+	S3 func() (*s3.Client, error)
+}
+
+// IsS3 reports whether the name is an S3 URI.
+func IsS3(name string) bool {
+	return strings.HasPrefix(name, s3Scheme)
+}
+
+// ParseS3URI splits an s3://bucket/key URI into its bucket and key.
+func ParseS3URI(uri string) (string, string, error) {
+	if !IsS3(uri) {
+		return "", "", fmt.Errorf("invalid S3 URI %q, must start with %s", uri, s3Scheme)
+	}
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(uri, s3Scheme), "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid S3 URI %q, must be %sbucket/key", uri, s3Scheme)
+	}
+	return bucket, key, nil
+}
+
+// Open opens a blob for reading: an S3 object, stdin if the name is - or
+// empty, or else a local file. S3 objects are streamed, not downloaded first.
+func (o *Opener) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	if name == "" || name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	if !IsS3(name) {
+		return os.Open(path.Clean(name))
+	}
+
+	bucket, key, err := ParseS3URI(name)
+	if err != nil {
+		return nil, err
+	}
+	client, err := o.s3()
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return out.Body, nil
+}
+
+// Create opens a blob for writing: an S3 object, stdout if the name is - or
+// empty, or else a local file. S3 objects are uploaded while they are
+// written, in parts once they outgrow a single upload, and Close returns once
+// the upload has completed.
+func (o *Opener) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	if name == "" || name == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	if !IsS3(name) {
+		return os.Create(path.Clean(name))
+	}
+
+	bucket, key, err := ParseS3URI(name)
+	if err != nil {
+		return nil, err
+	}
+	client, err := o.s3()
+	if err != nil {
+		return nil, err
+	}
+
+	r, w := io.Pipe()
+	u := &upload{w: w, done: make(chan error, 1)}
+	go func() {
+		_, err := transfermanager.New(client).UploadObject(ctx, &transfermanager.UploadObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+			Body:   r,
+		})
+		if err != nil {
+			err = fmt.Errorf("%s: %w", name, err)
+		}
+		// Unblock writers if the upload failed before reading everything.
+		r.CloseWithError(err)
+		u.done <- err
+	}()
+	return u, nil
+}
+
+func (o *Opener) s3() (*s3.Client, error) {
+	if o.S3 == nil {
+		return nil, errors.New("S3 is not configured")
+	}
+	return o.S3()
+}
+
+// upload is the writer of an S3 object being uploaded.
+type upload struct {
+	w    *io.PipeWriter
+	done chan error
+}
+
+func (u *upload) Write(p []byte) (int, error) {
+	return u.w.Write(p)
+}
+
+// Close ends the object and waits for the upload to complete.
+func (u *upload) Close() error {
+	if err := u.w.Close(); err != nil {
+		return err
+	}
+	return <-u.done
+}
+
+// nopCloser is stdout, which must not be closed.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
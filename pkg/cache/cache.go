@@ -0,0 +1,155 @@
+// Package cache stores search results for placesvc.SetCache, so repeated
+// searches are not paid for twice: in a file under the user cache directory,
+// or in a DynamoDB table shared by every machine running batch jobs.
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// bucket is the bbolt bucket holding the entries.
+var bucket = []byte("results")
+
+// DefaultPath returns the path of the cache file in the user cache directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goawsloc", "cache.db"), nil
+}
+
+// File is a cache stored in a bbolt database file. Each entry holds its
+// expiry time followed by the value.
+type File struct {
+	db  *bolt.DB
+	now func() time.Time
+}
+
+// Stats describes the entries of a cache. Location is the path of a cache
+// file or the name of a table. Expired entries are only counted in cache
+// files, as DynamoDB deletes them itself.
+type Stats struct {
+	Location string
+	Entries  int64
+	Expired  int64
+	Bytes    int64
+}
+
+// Open opens the cache file, creating it and its directory if needed. Only
+// one process can have the file open at a time; Open fails after waiting a
+// second for another one to close it.
+func Open(path string) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("cache %s is in use by another process", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &File{db: db, now: time.Now}, nil
+}
+
+// Close closes the cache file.
+func (f *File) Close() error {
+	return f.db.Close()
+}
+
+// Get returns the value stored under the key, or false if there is none or
+// it has expired.
+func (f *File) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	var value []byte
+	err := f.db.View(func(tx *bolt.Tx) error {
+		entry := tx.Bucket(bucket).Get([]byte(key))
+		if entry == nil || f.expired(entry) {
+			return nil
+		}
+		// The entry is only valid during the transaction.
+		value = append([]byte{}, entry[8:]...)
+		return nil
+	})
+	return value, value != nil, err
+}
+
+// Put stores the value under the key for ttl, or without expiry if ttl is
+// zero.
+func (f *File) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := make([]byte, 8, 8+len(value))
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(entry, uint64(f.now().Add(ttl).UnixNano()))
+	}
+	entry = append(entry, value...)
+	return f.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), entry)
+	})
+}
+
+// Purge deletes the expired entries, or every entry if all is set, and
+// returns how many were deleted.
+func (f *File) Purge(ctx context.Context, all bool) (int, error) {
+	deleted := 0
+	err := f.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		// Deleting while iterating skips entries, so collect the keys first.
+		var keys [][]byte
+		if err := b.ForEach(func(k, v []byte) error {
+			if all || f.expired(v) {
+				keys = append(keys, append([]byte{}, k...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		deleted = len(keys)
+		return nil
+	})
+	return deleted, err
+}
+
+// Stats counts the entries of the cache.
+func (f *File) Stats(ctx context.Context) (*Stats, error) {
+	stats := &Stats{Location: f.db.Path()}
+	err := f.db.View(func(tx *bolt.Tx) error {
+		stats.Bytes = tx.Size()
+		return tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+			stats.Entries++
+			if f.expired(v) {
+				stats.Expired++
+			}
+			return nil
+		})
+	})
+	return stats, err
+}
+
+// expired reports whether an entry has expired.
+func (f *File) expired(entry []byte) bool {
+	if len(entry) < 8 {
+		return true
+	}
+	expiry := binary.BigEndian.Uint64(entry)
+	return expiry != 0 && f.now().UnixNano() > int64(expiry)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// entry is a value put into a cache at the start of a test.
+type entry struct {
+	key   string
+	value string
+	ttl   time.Duration
+}
+
+var entries = []entry{
+	{"hour", "a", time.Hour},
+	{"day", "b", 24 * time.Hour},
+	{"forever", "c", 0},
+}
+
+func TestFileExpiry(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		after       time.Duration
+		wantFound   []string
+		wantExpired int64
+	}{
+		{"fresh", 0, []string{"hour", "day", "forever"}, 0},
+		{"at expiry", time.Hour, []string{"hour", "day", "forever"}, 0},
+		{"one expired", time.Hour + time.Nanosecond, []string{"day", "forever"}, 1},
+		{"all expired but forever", 48 * time.Hour, []string{"forever"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f, err := Open(filepath.Join(t.TempDir(), "cache.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			f.now = func() time.Time { return start }
+			for _, e := range entries {
+				if err := f.Put(ctx, e.key, []byte(e.value), e.ttl); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f.now = func() time.Time { return start.Add(tt.after) }
+			found := map[string]bool{}
+			for _, name := range tt.wantFound {
+				found[name] = true
+			}
+			for _, e := range entries {
+				value, ok, err := f.Get(ctx, e.key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok != found[e.key] {
+					t.Errorf("Get(%q) found %v, want %v", e.key, ok, found[e.key])
+				}
+				if ok && string(value) != e.value {
+					t.Errorf("Get(%q) = %q, want %q", e.key, value, e.value)
+				}
+			}
+
+			stats, err := f.Stats(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Entries != int64(len(entries)) || stats.Expired != tt.wantExpired {
+				t.Errorf("Stats() = %d entries, %d expired, want %d, %d", stats.Entries, stats.Expired, len(entries), tt.wantExpired)
+			}
+			deleted, err := f.Purge(ctx, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int64(deleted) != tt.wantExpired {
+				t.Errorf("Purge() deleted %d entries, want %d", deleted, tt.wantExpired)
+			}
+			if stats, err = f.Stats(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if stats.Entries != int64(len(tt.wantFound)) || stats.Expired != 0 {
+				t.Errorf("Stats() after Purge() = %d entries, %d expired, want %d, 0", stats.Entries, stats.Expired, len(tt.wantFound))
+			}
+		})
+	}
+}
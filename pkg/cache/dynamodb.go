@@ -0,0 +1,197 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Attributes of the items of a DynamoDB cache table. The table needs a
+// string partition key named key, and time to live enabled on expires to
+// have DynamoDB delete expired entries.
+const (
+	attrKey     = "key"
+	attrValue   = "value"
+	attrExpires = "expires"
+)
+
+// maxBatchWrite is the maximum number of deletes BatchWriteItem accepts per
+// call.
+const maxBatchWrite = 25
+
+// DynamoDBClient is the part of the DynamoDB API a table cache uses.
+type DynamoDBClient interface {
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// Table is a cache stored in a DynamoDB table, shared by every user with
+// access to it. Expiry times are stored in seconds, the unit of DynamoDB
+// time to live.
+type Table struct {
+	client DynamoDBClient
+	name   string
+	now    func() time.Time
+}
+
+// NewTable returns a cache stored in the named table.
+func NewTable(client DynamoDBClient, name string) *Table {
+	return &Table{client: client, name: name, now: time.Now}
+}
+
+// Close does nothing, tables need no closing.
+func (t *Table) Close() error {
+	return nil
+}
+
+// Get returns the value stored under the key, or false if there is none or
+// it has expired. DynamoDB deletes expired items up to days late, so the
+// expiry is checked here as well.
+func (t *Table) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	out, err := t.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(t.name),
+		Key: map[string]types.AttributeValue{
+			attrKey: &types.AttributeValueMemberS{Value: key},
+		},
+	})
+	if err != nil || out.Item == nil || t.expired(out.Item) {
+		return nil, false, err
+	}
+	value, ok := out.Item[attrValue].(*types.AttributeValueMemberB)
+	if !ok {
+		return nil, false, nil
+	}
+	return value.Value, true, nil
+}
+
+// Put stores the value under the key for ttl, or without expiry if ttl is
+// zero. An unexpired entry written by another user in the meantime is kept,
+// as it holds the same result.
+func (t *Table) Put(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	item := map[string]types.AttributeValue{
+		attrKey:   &types.AttributeValueMemberS{Value: key},
+		attrValue: &types.AttributeValueMemberB{Value: value},
+	}
+	if ttl > 0 {
+		item[attrExpires] = &types.AttributeValueMemberN{Value: strconv.FormatInt(t.now().Add(ttl).Unix(), 10)}
+	}
+	_, err := t.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(t.name),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#key) OR #expires < :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#key":     attrKey,
+			"#expires": attrExpires,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(t.now().Unix(), 10)},
+		},
+	})
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return nil
+	}
+	return err
+}
+
+// Purge deletes the expired entries, or every entry if all is set, and
+// returns how many were deleted. It scans the whole table, so it is billed
+// for reading every item.
+func (t *Table) Purge(ctx context.Context, all bool) (int, error) {
+	input := &dynamodb.ScanInput{
+		TableName:            aws.String(t.name),
+		ProjectionExpression: aws.String("#key"),
+		ExpressionAttributeNames: map[string]string{
+			"#key": attrKey,
+		},
+	}
+	if !all {
+		input.FilterExpression = aws.String("#expires < :now")
+		input.ExpressionAttributeNames["#expires"] = attrExpires
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(t.now().Unix(), 10)},
+		}
+	}
+
+	deleted := 0
+	paginator := dynamodb.NewScanPaginator(t.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return deleted, err
+		}
+		for start := 0; start < len(page.Items); start += maxBatchWrite {
+			end := min(start+maxBatchWrite, len(page.Items))
+			requests := make([]types.WriteRequest, 0, end-start)
+			for _, item := range page.Items[start:end] {
+				requests = append(requests, types.WriteRequest{
+					DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{attrKey: item[attrKey]}},
+				})
+			}
+			n, err := t.batchDelete(ctx, requests)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// batchDelete runs the delete requests, retrying the ones DynamoDB left
+// unprocessed, and returns how many were run.
+func (t *Table) batchDelete(ctx context.Context, requests []types.WriteRequest) (int, error) {
+	total := len(requests)
+	for delay := 100 * time.Millisecond; len(requests) > 0; delay *= 2 {
+		out, err := t.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{t.name: requests},
+		})
+		if err != nil {
+			return total - len(requests), err
+		}
+		requests = out.UnprocessedItems[t.name]
+		if len(requests) == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return total - len(requests), ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	return total, nil
+}
+
+// Stats returns the item count and size of the table, which DynamoDB
+// updates about every six hours.
+func (t *Table) Stats(ctx context.Context) (*Stats, error) {
+	out, err := t.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(t.name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Stats{
+		Location: t.name,
+		Entries:  aws.ToInt64(out.Table.ItemCount),
+		Bytes:    aws.ToInt64(out.Table.TableSizeBytes),
+	}, nil
+}
+
+// expired reports whether an item has expired.
+func (t *Table) expired(item map[string]types.AttributeValue) bool {
+	n, ok := item[attrExpires].(*types.AttributeValueMemberN)
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(n.Value, 10, 64)
+	return err == nil && t.now().Unix() > expires
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeDynamoDB is a DynamoDBClient holding the items of one table in a map,
+// checking the condition of Table.Put, and panicking on any other call.
+type fakeDynamoDB struct {
+	DynamoDBClient
+	items map[string]map[string]types.AttributeValue
+}
+
+func (f *fakeDynamoDB) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	key := params.Key[attrKey].(*types.AttributeValueMemberS).Value
+	return &dynamodb.GetItemOutput{Item: f.items[key]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	key := params.Item[attrKey].(*types.AttributeValueMemberS).Value
+	if existing, ok := f.items[key]; ok {
+		now, _ := strconv.ParseInt(params.ExpressionAttributeValues[":now"].(*types.AttributeValueMemberN).Value, 10, 64)
+		expires, ok := existing[attrExpires].(*types.AttributeValueMemberN)
+		if !ok {
+			return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+		}
+		if n, _ := strconv.ParseInt(expires.Value, 10, 64); n >= now {
+			return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+		}
+	}
+	f.items[key] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestTableExpiry(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		after     time.Duration
+		wantFound []string
+	}{
+		{"fresh", 0, []string{"hour", "day", "forever"}},
+		{"at expiry", time.Hour, []string{"hour", "day", "forever"}},
+		{"one expired", time.Hour + time.Second, []string{"day", "forever"}},
+		{"all expired but forever", 48 * time.Hour, []string{"forever"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			table := NewTable(&fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}, "cache")
+			table.now = func() time.Time { return start }
+			for _, e := range entries {
+				if err := table.Put(ctx, e.key, []byte(e.value), e.ttl); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			table.now = func() time.Time { return start.Add(tt.after) }
+			found := map[string]bool{}
+			for _, name := range tt.wantFound {
+				found[name] = true
+			}
+			for _, e := range entries {
+				value, ok, err := table.Get(ctx, e.key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if ok != found[e.key] {
+					t.Errorf("Get(%q) found %v, want %v", e.key, ok, found[e.key])
+				}
+				if ok && string(value) != e.value {
+					t.Errorf("Get(%q) = %q, want %q", e.key, value, e.value)
+				}
+			}
+		})
+	}
+}
+
+func TestTablePutKeepsUnexpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	table := NewTable(&fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}, "cache")
+	table.now = func() time.Time { return start }
+	if err := table.Put(ctx, "key", []byte("first"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		after time.Duration
+		value string
+		want  string
+	}{
+		{"unexpired", time.Minute, "second", "first"},
+		{"expired", 2 * time.Hour, "third", "third"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table.now = func() time.Time { return start.Add(tt.after) }
+			if err := table.Put(ctx, "key", []byte(tt.value), time.Hour); err != nil {
+				t.Fatal(err)
+			}
+			value, ok, err := table.Get(ctx, "key")
+			if err != nil || !ok {
+				t.Fatalf("Get() = %v, %v, want an entry", ok, err)
+			}
+			if string(value) != tt.want {
+				t.Errorf("Get() = %q, want %q", value, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// Package client is the gRPC client of `loc serve --grpc`, generated from
+// proto/loc/v1/geocoder.proto with `make proto`. Connect with grpc.NewClient
+// and wrap the connection with NewGeocoderClient:
+//
+//	conn, err := grpc.NewClient("localhost:9090", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//	resp, err := client.NewGeocoderClient(conn).Geocode(ctx, &client.SearchRequest{Text: "1600 Pennsylvania Ave"})
+//
+// If the server requires API keys, send one as bearer token in the
+// authorization metadata:
+//
+//	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+key)
+//
+// Errors carry the gRPC codes InvalidArgument, Unauthenticated,
+// PermissionDenied, NotFound, AlreadyExists and ResourceExhausted for the
+// failures the REST API answers with 400, 401, 403, 404, 409 and 429,
+// Canceled and DeadlineExceeded when the call is cancelled or times out,
+// Unavailable for errors of AWS and Internal for any other error.
+package client
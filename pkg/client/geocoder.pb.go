@@ -0,0 +1,1101 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: loc/v1/geocoder.proto
+
+// The searches of a place index, as served by `loc serve --grpc`. The
+// messages mirror the JSON bodies of the REST API.
+
+package client
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// LatLon is a WGS 84 position.
+type LatLon struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Latitude      float64                `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float64                `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LatLon) Reset() {
+	*x = LatLon{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LatLon) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatLon) ProtoMessage() {}
+
+func (x *LatLon) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatLon.ProtoReflect.Descriptor instead.
+func (*LatLon) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LatLon) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *LatLon) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+// SearchRequest is a geocode or suggest request. bias and bbox are mutually
+// exclusive; bbox is west, south, east, north. Countries are ISO 3166 codes
+// or English names.
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Bias          *LatLon                `protobuf:"bytes,2,opt,name=bias,proto3" json:"bias,omitempty"`
+	Bbox          []float64              `protobuf:"fixed64,3,rep,packed,name=bbox,proto3" json:"bbox,omitempty"`
+	Categories    []string               `protobuf:"bytes,4,rep,name=categories,proto3" json:"categories,omitempty"`
+	Countries     []string               `protobuf:"bytes,5,rep,name=countries,proto3" json:"countries,omitempty"`
+	Language      string                 `protobuf:"bytes,6,opt,name=language,proto3" json:"language,omitempty"`
+	MaxResults    int32                  `protobuf:"varint,7,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetBias() *LatLon {
+	if x != nil {
+		return x.Bias
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetBbox() []float64 {
+	if x != nil {
+		return x.Bbox
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetCategories() []string {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetCountries() []string {
+	if x != nil {
+		return x.Countries
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+// ReverseRequest is a reverse geocode request.
+type ReverseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Position      *LatLon                `protobuf:"bytes,1,opt,name=position,proto3" json:"position,omitempty"`
+	Language      string                 `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`
+	MaxResults    int32                  `protobuf:"varint,3,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReverseRequest) Reset() {
+	*x = ReverseRequest{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReverseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReverseRequest) ProtoMessage() {}
+
+func (x *ReverseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReverseRequest.ProtoReflect.Descriptor instead.
+func (*ReverseRequest) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ReverseRequest) GetPosition() *LatLon {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *ReverseRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *ReverseRequest) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+// GetPlaceRequest is a place request.
+type GetPlaceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlaceId       string                 `protobuf:"bytes,1,opt,name=place_id,json=placeId,proto3" json:"place_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPlaceRequest) Reset() {
+	*x = GetPlaceRequest{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPlaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPlaceRequest) ProtoMessage() {}
+
+func (x *GetPlaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPlaceRequest.ProtoReflect.Descriptor instead.
+func (*GetPlaceRequest) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetPlaceRequest) GetPlaceId() string {
+	if x != nil {
+		return x.PlaceId
+	}
+	return ""
+}
+
+// TimeZone is the time zone of a place. offset is in seconds from UTC.
+type TimeZone struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TimeZone) Reset() {
+	*x = TimeZone{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TimeZone) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TimeZone) ProtoMessage() {}
+
+func (x *TimeZone) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TimeZone.ProtoReflect.Descriptor instead.
+func (*TimeZone) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TimeZone) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TimeZone) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// Place is a place returned by a search or GetPlace. point is longitude,
+// latitude.
+type Place struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Label                  string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	Point                  []float64              `protobuf:"fixed64,2,rep,packed,name=point,proto3" json:"point,omitempty"`
+	AddressNumber          string                 `protobuf:"bytes,3,opt,name=address_number,json=addressNumber,proto3" json:"address_number,omitempty"`
+	UnitType               string                 `protobuf:"bytes,4,opt,name=unit_type,json=unitType,proto3" json:"unit_type,omitempty"`
+	UnitNumber             string                 `protobuf:"bytes,5,opt,name=unit_number,json=unitNumber,proto3" json:"unit_number,omitempty"`
+	Street                 string                 `protobuf:"bytes,6,opt,name=street,proto3" json:"street,omitempty"`
+	Neighborhood           string                 `protobuf:"bytes,7,opt,name=neighborhood,proto3" json:"neighborhood,omitempty"`
+	SubMunicipality        string                 `protobuf:"bytes,8,opt,name=sub_municipality,json=subMunicipality,proto3" json:"sub_municipality,omitempty"`
+	Municipality           string                 `protobuf:"bytes,9,opt,name=municipality,proto3" json:"municipality,omitempty"`
+	SubRegion              string                 `protobuf:"bytes,10,opt,name=sub_region,json=subRegion,proto3" json:"sub_region,omitempty"`
+	Region                 string                 `protobuf:"bytes,11,opt,name=region,proto3" json:"region,omitempty"`
+	PostalCode             string                 `protobuf:"bytes,12,opt,name=postal_code,json=postalCode,proto3" json:"postal_code,omitempty"`
+	Country                string                 `protobuf:"bytes,13,opt,name=country,proto3" json:"country,omitempty"`
+	TimeZone               *TimeZone              `protobuf:"bytes,14,opt,name=time_zone,json=timeZone,proto3" json:"time_zone,omitempty"`
+	Categories             []string               `protobuf:"bytes,15,rep,name=categories,proto3" json:"categories,omitempty"`
+	SupplementalCategories []string               `protobuf:"bytes,16,rep,name=supplemental_categories,json=supplementalCategories,proto3" json:"supplemental_categories,omitempty"`
+	Interpolated           bool                   `protobuf:"varint,17,opt,name=interpolated,proto3" json:"interpolated,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Place) Reset() {
+	*x = Place{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Place) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Place) ProtoMessage() {}
+
+func (x *Place) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Place.ProtoReflect.Descriptor instead.
+func (*Place) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Place) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Place) GetPoint() []float64 {
+	if x != nil {
+		return x.Point
+	}
+	return nil
+}
+
+func (x *Place) GetAddressNumber() string {
+	if x != nil {
+		return x.AddressNumber
+	}
+	return ""
+}
+
+func (x *Place) GetUnitType() string {
+	if x != nil {
+		return x.UnitType
+	}
+	return ""
+}
+
+func (x *Place) GetUnitNumber() string {
+	if x != nil {
+		return x.UnitNumber
+	}
+	return ""
+}
+
+func (x *Place) GetStreet() string {
+	if x != nil {
+		return x.Street
+	}
+	return ""
+}
+
+func (x *Place) GetNeighborhood() string {
+	if x != nil {
+		return x.Neighborhood
+	}
+	return ""
+}
+
+func (x *Place) GetSubMunicipality() string {
+	if x != nil {
+		return x.SubMunicipality
+	}
+	return ""
+}
+
+func (x *Place) GetMunicipality() string {
+	if x != nil {
+		return x.Municipality
+	}
+	return ""
+}
+
+func (x *Place) GetSubRegion() string {
+	if x != nil {
+		return x.SubRegion
+	}
+	return ""
+}
+
+func (x *Place) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *Place) GetPostalCode() string {
+	if x != nil {
+		return x.PostalCode
+	}
+	return ""
+}
+
+func (x *Place) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Place) GetTimeZone() *TimeZone {
+	if x != nil {
+		return x.TimeZone
+	}
+	return nil
+}
+
+func (x *Place) GetCategories() []string {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *Place) GetSupplementalCategories() []string {
+	if x != nil {
+		return x.SupplementalCategories
+	}
+	return nil
+}
+
+func (x *Place) GetInterpolated() bool {
+	if x != nil {
+		return x.Interpolated
+	}
+	return false
+}
+
+// SearchResult is a place found by a geocode or reverse request. distance is
+// in meters and relevance is only set for geocode requests.
+type SearchResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlaceId       string                 `protobuf:"bytes,1,opt,name=place_id,json=placeId,proto3" json:"place_id,omitempty"`
+	Place         *Place                 `protobuf:"bytes,2,opt,name=place,proto3" json:"place,omitempty"`
+	Distance      *float64               `protobuf:"fixed64,3,opt,name=distance,proto3,oneof" json:"distance,omitempty"`
+	Relevance     *float64               `protobuf:"fixed64,4,opt,name=relevance,proto3,oneof" json:"relevance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResult) Reset() {
+	*x = SearchResult{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResult) ProtoMessage() {}
+
+func (x *SearchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResult.ProtoReflect.Descriptor instead.
+func (*SearchResult) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SearchResult) GetPlaceId() string {
+	if x != nil {
+		return x.PlaceId
+	}
+	return ""
+}
+
+func (x *SearchResult) GetPlace() *Place {
+	if x != nil {
+		return x.Place
+	}
+	return nil
+}
+
+func (x *SearchResult) GetDistance() float64 {
+	if x != nil && x.Distance != nil {
+		return *x.Distance
+	}
+	return 0
+}
+
+func (x *SearchResult) GetRelevance() float64 {
+	if x != nil && x.Relevance != nil {
+		return *x.Relevance
+	}
+	return 0
+}
+
+// Suggestion is a result of a suggest request.
+type Suggestion struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Text                   string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	PlaceId                string                 `protobuf:"bytes,2,opt,name=place_id,json=placeId,proto3" json:"place_id,omitempty"`
+	Categories             []string               `protobuf:"bytes,3,rep,name=categories,proto3" json:"categories,omitempty"`
+	SupplementalCategories []string               `protobuf:"bytes,4,rep,name=supplemental_categories,json=supplementalCategories,proto3" json:"supplemental_categories,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Suggestion) Reset() {
+	*x = Suggestion{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Suggestion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Suggestion) ProtoMessage() {}
+
+func (x *Suggestion) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Suggestion.ProtoReflect.Descriptor instead.
+func (*Suggestion) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Suggestion) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Suggestion) GetPlaceId() string {
+	if x != nil {
+		return x.PlaceId
+	}
+	return ""
+}
+
+func (x *Suggestion) GetCategories() []string {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *Suggestion) GetSupplementalCategories() []string {
+	if x != nil {
+		return x.SupplementalCategories
+	}
+	return nil
+}
+
+// SearchSummary describes the parameters a search was run with. Bounding
+// boxes are west, south, east, north.
+type SearchSummary struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	DataSource       string                 `protobuf:"bytes,1,opt,name=data_source,json=dataSource,proto3" json:"data_source,omitempty"`
+	Language         string                 `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`
+	MaxResults       int32                  `protobuf:"varint,3,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`
+	Text             string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	Position         *LatLon                `protobuf:"bytes,5,opt,name=position,proto3" json:"position,omitempty"`
+	BiasPosition     *LatLon                `protobuf:"bytes,6,opt,name=bias_position,json=biasPosition,proto3" json:"bias_position,omitempty"`
+	FilterBbox       []float64              `protobuf:"fixed64,7,rep,packed,name=filter_bbox,json=filterBbox,proto3" json:"filter_bbox,omitempty"`
+	FilterCategories []string               `protobuf:"bytes,8,rep,name=filter_categories,json=filterCategories,proto3" json:"filter_categories,omitempty"`
+	FilterCountries  []string               `protobuf:"bytes,9,rep,name=filter_countries,json=filterCountries,proto3" json:"filter_countries,omitempty"`
+	ResultBbox       []float64              `protobuf:"fixed64,10,rep,packed,name=result_bbox,json=resultBbox,proto3" json:"result_bbox,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SearchSummary) Reset() {
+	*x = SearchSummary{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchSummary) ProtoMessage() {}
+
+func (x *SearchSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchSummary.ProtoReflect.Descriptor instead.
+func (*SearchSummary) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SearchSummary) GetDataSource() string {
+	if x != nil {
+		return x.DataSource
+	}
+	return ""
+}
+
+func (x *SearchSummary) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *SearchSummary) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+func (x *SearchSummary) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SearchSummary) GetPosition() *LatLon {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *SearchSummary) GetBiasPosition() *LatLon {
+	if x != nil {
+		return x.BiasPosition
+	}
+	return nil
+}
+
+func (x *SearchSummary) GetFilterBbox() []float64 {
+	if x != nil {
+		return x.FilterBbox
+	}
+	return nil
+}
+
+func (x *SearchSummary) GetFilterCategories() []string {
+	if x != nil {
+		return x.FilterCategories
+	}
+	return nil
+}
+
+func (x *SearchSummary) GetFilterCountries() []string {
+	if x != nil {
+		return x.FilterCountries
+	}
+	return nil
+}
+
+func (x *SearchSummary) GetResultBbox() []float64 {
+	if x != nil {
+		return x.ResultBbox
+	}
+	return nil
+}
+
+// SearchResponse answers geocode and reverse requests.
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Summary       *SearchSummary         `protobuf:"bytes,1,opt,name=summary,proto3" json:"summary,omitempty"`
+	Results       []*SearchResult        `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchResponse) GetSummary() *SearchSummary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetResults() []*SearchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// SuggestResponse answers suggest requests.
+type SuggestResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Summary       *SearchSummary         `protobuf:"bytes,1,opt,name=summary,proto3" json:"summary,omitempty"`
+	Results       []*Suggestion          `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SuggestResponse) Reset() {
+	*x = SuggestResponse{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SuggestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuggestResponse) ProtoMessage() {}
+
+func (x *SuggestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuggestResponse.ProtoReflect.Descriptor instead.
+func (*SuggestResponse) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SuggestResponse) GetSummary() *SearchSummary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *SuggestResponse) GetResults() []*Suggestion {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// PlaceResponse answers place requests.
+type PlaceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PlaceId       string                 `protobuf:"bytes,1,opt,name=place_id,json=placeId,proto3" json:"place_id,omitempty"`
+	Place         *Place                 `protobuf:"bytes,2,opt,name=place,proto3" json:"place,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceResponse) Reset() {
+	*x = PlaceResponse{}
+	mi := &file_loc_v1_geocoder_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceResponse) ProtoMessage() {}
+
+func (x *PlaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_loc_v1_geocoder_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceResponse.ProtoReflect.Descriptor instead.
+func (*PlaceResponse) Descriptor() ([]byte, []int) {
+	return file_loc_v1_geocoder_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PlaceResponse) GetPlaceId() string {
+	if x != nil {
+		return x.PlaceId
+	}
+	return ""
+}
+
+func (x *PlaceResponse) GetPlace() *Place {
+	if x != nil {
+		return x.Place
+	}
+	return nil
+}
+
+var File_loc_v1_geocoder_proto protoreflect.FileDescriptor
+
+const file_loc_v1_geocoder_proto_rawDesc = "" +
+	"\n" +
+	"\x15loc/v1/geocoder.proto\x12\x06loc.v1\"B\n" +
+	"\x06LatLon\x12\x1a\n" +
+	"\blatitude\x18\x01 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x02 \x01(\x01R\tlongitude\"\xd6\x01\n" +
+	"\rSearchRequest\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\"\n" +
+	"\x04bias\x18\x02 \x01(\v2\x0e.loc.v1.LatLonR\x04bias\x12\x12\n" +
+	"\x04bbox\x18\x03 \x03(\x01R\x04bbox\x12\x1e\n" +
+	"\n" +
+	"categories\x18\x04 \x03(\tR\n" +
+	"categories\x12\x1c\n" +
+	"\tcountries\x18\x05 \x03(\tR\tcountries\x12\x1a\n" +
+	"\blanguage\x18\x06 \x01(\tR\blanguage\x12\x1f\n" +
+	"\vmax_results\x18\a \x01(\x05R\n" +
+	"maxResults\"y\n" +
+	"\x0eReverseRequest\x12*\n" +
+	"\bposition\x18\x01 \x01(\v2\x0e.loc.v1.LatLonR\bposition\x12\x1a\n" +
+	"\blanguage\x18\x02 \x01(\tR\blanguage\x12\x1f\n" +
+	"\vmax_results\x18\x03 \x01(\x05R\n" +
+	"maxResults\",\n" +
+	"\x0fGetPlaceRequest\x12\x19\n" +
+	"\bplace_id\x18\x01 \x01(\tR\aplaceId\"6\n" +
+	"\bTimeZone\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\"\xc1\x04\n" +
+	"\x05Place\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\tR\x05label\x12\x14\n" +
+	"\x05point\x18\x02 \x03(\x01R\x05point\x12%\n" +
+	"\x0eaddress_number\x18\x03 \x01(\tR\raddressNumber\x12\x1b\n" +
+	"\tunit_type\x18\x04 \x01(\tR\bunitType\x12\x1f\n" +
+	"\vunit_number\x18\x05 \x01(\tR\n" +
+	"unitNumber\x12\x16\n" +
+	"\x06street\x18\x06 \x01(\tR\x06street\x12\"\n" +
+	"\fneighborhood\x18\a \x01(\tR\fneighborhood\x12)\n" +
+	"\x10sub_municipality\x18\b \x01(\tR\x0fsubMunicipality\x12\"\n" +
+	"\fmunicipality\x18\t \x01(\tR\fmunicipality\x12\x1d\n" +
+	"\n" +
+	"sub_region\x18\n" +
+	" \x01(\tR\tsubRegion\x12\x16\n" +
+	"\x06region\x18\v \x01(\tR\x06region\x12\x1f\n" +
+	"\vpostal_code\x18\f \x01(\tR\n" +
+	"postalCode\x12\x18\n" +
+	"\acountry\x18\r \x01(\tR\acountry\x12-\n" +
+	"\ttime_zone\x18\x0e \x01(\v2\x10.loc.v1.TimeZoneR\btimeZone\x12\x1e\n" +
+	"\n" +
+	"categories\x18\x0f \x03(\tR\n" +
+	"categories\x127\n" +
+	"\x17supplemental_categories\x18\x10 \x03(\tR\x16supplementalCategories\x12\"\n" +
+	"\finterpolated\x18\x11 \x01(\bR\finterpolated\"\xad\x01\n" +
+	"\fSearchResult\x12\x19\n" +
+	"\bplace_id\x18\x01 \x01(\tR\aplaceId\x12#\n" +
+	"\x05place\x18\x02 \x01(\v2\r.loc.v1.PlaceR\x05place\x12\x1f\n" +
+	"\bdistance\x18\x03 \x01(\x01H\x00R\bdistance\x88\x01\x01\x12!\n" +
+	"\trelevance\x18\x04 \x01(\x01H\x01R\trelevance\x88\x01\x01B\v\n" +
+	"\t_distanceB\f\n" +
+	"\n" +
+	"_relevance\"\x94\x01\n" +
+	"\n" +
+	"Suggestion\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x19\n" +
+	"\bplace_id\x18\x02 \x01(\tR\aplaceId\x12\x1e\n" +
+	"\n" +
+	"categories\x18\x03 \x03(\tR\n" +
+	"categories\x127\n" +
+	"\x17supplemental_categories\x18\x04 \x03(\tR\x16supplementalCategories\"\xfc\x02\n" +
+	"\rSearchSummary\x12\x1f\n" +
+	"\vdata_source\x18\x01 \x01(\tR\n" +
+	"dataSource\x12\x1a\n" +
+	"\blanguage\x18\x02 \x01(\tR\blanguage\x12\x1f\n" +
+	"\vmax_results\x18\x03 \x01(\x05R\n" +
+	"maxResults\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x12*\n" +
+	"\bposition\x18\x05 \x01(\v2\x0e.loc.v1.LatLonR\bposition\x123\n" +
+	"\rbias_position\x18\x06 \x01(\v2\x0e.loc.v1.LatLonR\fbiasPosition\x12\x1f\n" +
+	"\vfilter_bbox\x18\a \x03(\x01R\n" +
+	"filterBbox\x12+\n" +
+	"\x11filter_categories\x18\b \x03(\tR\x10filterCategories\x12)\n" +
+	"\x10filter_countries\x18\t \x03(\tR\x0ffilterCountries\x12\x1f\n" +
+	"\vresult_bbox\x18\n" +
+	" \x03(\x01R\n" +
+	"resultBbox\"q\n" +
+	"\x0eSearchResponse\x12/\n" +
+	"\asummary\x18\x01 \x01(\v2\x15.loc.v1.SearchSummaryR\asummary\x12.\n" +
+	"\aresults\x18\x02 \x03(\v2\x14.loc.v1.SearchResultR\aresults\"p\n" +
+	"\x0fSuggestResponse\x12/\n" +
+	"\asummary\x18\x01 \x01(\v2\x15.loc.v1.SearchSummaryR\asummary\x12,\n" +
+	"\aresults\x18\x02 \x03(\v2\x12.loc.v1.SuggestionR\aresults\"O\n" +
+	"\rPlaceResponse\x12\x19\n" +
+	"\bplace_id\x18\x01 \x01(\tR\aplaceId\x12#\n" +
+	"\x05place\x18\x02 \x01(\v2\r.loc.v1.PlaceR\x05place2\xf6\x01\n" +
+	"\bGeocoder\x128\n" +
+	"\aGeocode\x12\x15.loc.v1.SearchRequest\x1a\x16.loc.v1.SearchResponse\x129\n" +
+	"\aReverse\x12\x16.loc.v1.ReverseRequest\x1a\x16.loc.v1.SearchResponse\x129\n" +
+	"\aSuggest\x12\x15.loc.v1.SearchRequest\x1a\x17.loc.v1.SuggestResponse\x12:\n" +
+	"\bGetPlace\x12\x17.loc.v1.GetPlaceRequest\x1a\x15.loc.v1.PlaceResponseB-Z+github.com/rmrfslashbin/goawsloc/pkg/clientb\x06proto3"
+
+var (
+	file_loc_v1_geocoder_proto_rawDescOnce sync.Once
+	file_loc_v1_geocoder_proto_rawDescData []byte
+)
+
+func file_loc_v1_geocoder_proto_rawDescGZIP() []byte {
+	file_loc_v1_geocoder_proto_rawDescOnce.Do(func() {
+		file_loc_v1_geocoder_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_loc_v1_geocoder_proto_rawDesc), len(file_loc_v1_geocoder_proto_rawDesc)))
+	})
+	return file_loc_v1_geocoder_proto_rawDescData
+}
+
+var file_loc_v1_geocoder_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_loc_v1_geocoder_proto_goTypes = []any{
+	(*LatLon)(nil),          // 0: loc.v1.LatLon
+	(*SearchRequest)(nil),   // 1: loc.v1.SearchRequest
+	(*ReverseRequest)(nil),  // 2: loc.v1.ReverseRequest
+	(*GetPlaceRequest)(nil), // 3: loc.v1.GetPlaceRequest
+	(*TimeZone)(nil),        // 4: loc.v1.TimeZone
+	(*Place)(nil),           // 5: loc.v1.Place
+	(*SearchResult)(nil),    // 6: loc.v1.SearchResult
+	(*Suggestion)(nil),      // 7: loc.v1.Suggestion
+	(*SearchSummary)(nil),   // 8: loc.v1.SearchSummary
+	(*SearchResponse)(nil),  // 9: loc.v1.SearchResponse
+	(*SuggestResponse)(nil), // 10: loc.v1.SuggestResponse
+	(*PlaceResponse)(nil),   // 11: loc.v1.PlaceResponse
+}
+var file_loc_v1_geocoder_proto_depIdxs = []int32{
+	0,  // 0: loc.v1.SearchRequest.bias:type_name -> loc.v1.LatLon
+	0,  // 1: loc.v1.ReverseRequest.position:type_name -> loc.v1.LatLon
+	4,  // 2: loc.v1.Place.time_zone:type_name -> loc.v1.TimeZone
+	5,  // 3: loc.v1.SearchResult.place:type_name -> loc.v1.Place
+	0,  // 4: loc.v1.SearchSummary.position:type_name -> loc.v1.LatLon
+	0,  // 5: loc.v1.SearchSummary.bias_position:type_name -> loc.v1.LatLon
+	8,  // 6: loc.v1.SearchResponse.summary:type_name -> loc.v1.SearchSummary
+	6,  // 7: loc.v1.SearchResponse.results:type_name -> loc.v1.SearchResult
+	8,  // 8: loc.v1.SuggestResponse.summary:type_name -> loc.v1.SearchSummary
+	7,  // 9: loc.v1.SuggestResponse.results:type_name -> loc.v1.Suggestion
+	5,  // 10: loc.v1.PlaceResponse.place:type_name -> loc.v1.Place
+	1,  // 11: loc.v1.Geocoder.Geocode:input_type -> loc.v1.SearchRequest
+	2,  // 12: loc.v1.Geocoder.Reverse:input_type -> loc.v1.ReverseRequest
+	1,  // 13: loc.v1.Geocoder.Suggest:input_type -> loc.v1.SearchRequest
+	3,  // 14: loc.v1.Geocoder.GetPlace:input_type -> loc.v1.GetPlaceRequest
+	9,  // 15: loc.v1.Geocoder.Geocode:output_type -> loc.v1.SearchResponse
+	9,  // 16: loc.v1.Geocoder.Reverse:output_type -> loc.v1.SearchResponse
+	10, // 17: loc.v1.Geocoder.Suggest:output_type -> loc.v1.SuggestResponse
+	11, // 18: loc.v1.Geocoder.GetPlace:output_type -> loc.v1.PlaceResponse
+	15, // [15:19] is the sub-list for method output_type
+	11, // [11:15] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_loc_v1_geocoder_proto_init() }
+func file_loc_v1_geocoder_proto_init() {
+	if File_loc_v1_geocoder_proto != nil {
+		return
+	}
+	file_loc_v1_geocoder_proto_msgTypes[6].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_loc_v1_geocoder_proto_rawDesc), len(file_loc_v1_geocoder_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_loc_v1_geocoder_proto_goTypes,
+		DependencyIndexes: file_loc_v1_geocoder_proto_depIdxs,
+		MessageInfos:      file_loc_v1_geocoder_proto_msgTypes,
+	}.Build()
+	File_loc_v1_geocoder_proto = out.File
+	file_loc_v1_geocoder_proto_goTypes = nil
+	file_loc_v1_geocoder_proto_depIdxs = nil
+}
@@ -0,0 +1,250 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: loc/v1/geocoder.proto
+
+// The searches of a place index, as served by `loc serve --grpc`. The
+// messages mirror the JSON bodies of the REST API.
+
+package client
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Geocoder_Geocode_FullMethodName  = "/loc.v1.Geocoder/Geocode"
+	Geocoder_Reverse_FullMethodName  = "/loc.v1.Geocoder/Reverse"
+	Geocoder_Suggest_FullMethodName  = "/loc.v1.Geocoder/Suggest"
+	Geocoder_GetPlace_FullMethodName = "/loc.v1.Geocoder/GetPlace"
+)
+
+// GeocoderClient is the client API for Geocoder service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Geocoder searches one place index.
+type GeocoderClient interface {
+	// Geocode geocodes free-form text, such as an address or place name.
+	Geocode(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// Reverse finds the places nearest to a position.
+	Reverse(ctx context.Context, in *ReverseRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// Suggest suggests places for partial or misspelled text.
+	Suggest(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SuggestResponse, error)
+	// GetPlace gets a place by the ID returned by a search.
+	GetPlace(ctx context.Context, in *GetPlaceRequest, opts ...grpc.CallOption) (*PlaceResponse, error)
+}
+
+type geocoderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGeocoderClient(cc grpc.ClientConnInterface) GeocoderClient {
+	return &geocoderClient{cc}
+}
+
+func (c *geocoderClient) Geocode(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, Geocoder_Geocode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geocoderClient) Reverse(ctx context.Context, in *ReverseRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, Geocoder_Reverse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geocoderClient) Suggest(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SuggestResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SuggestResponse)
+	err := c.cc.Invoke(ctx, Geocoder_Suggest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *geocoderClient) GetPlace(ctx context.Context, in *GetPlaceRequest, opts ...grpc.CallOption) (*PlaceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PlaceResponse)
+	err := c.cc.Invoke(ctx, Geocoder_GetPlace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GeocoderServer is the server API for Geocoder service.
+// All implementations must embed UnimplementedGeocoderServer
+// for forward compatibility.
+//
+// Geocoder searches one place index.
+type GeocoderServer interface {
+	// Geocode geocodes free-form text, such as an address or place name.
+	Geocode(context.Context, *SearchRequest) (*SearchResponse, error)
+	// Reverse finds the places nearest to a position.
+	Reverse(context.Context, *ReverseRequest) (*SearchResponse, error)
+	// Suggest suggests places for partial or misspelled text.
+	Suggest(context.Context, *SearchRequest) (*SuggestResponse, error)
+	// GetPlace gets a place by the ID returned by a search.
+	GetPlace(context.Context, *GetPlaceRequest) (*PlaceResponse, error)
+	mustEmbedUnimplementedGeocoderServer()
+}
+
+// UnimplementedGeocoderServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGeocoderServer struct{}
+
+func (UnimplementedGeocoderServer) Geocode(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Geocode not implemented")
+}
+func (UnimplementedGeocoderServer) Reverse(context.Context, *ReverseRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reverse not implemented")
+}
+func (UnimplementedGeocoderServer) Suggest(context.Context, *SearchRequest) (*SuggestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Suggest not implemented")
+}
+func (UnimplementedGeocoderServer) GetPlace(context.Context, *GetPlaceRequest) (*PlaceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPlace not implemented")
+}
+func (UnimplementedGeocoderServer) mustEmbedUnimplementedGeocoderServer() {}
+func (UnimplementedGeocoderServer) testEmbeddedByValue()                  {}
+
+// UnsafeGeocoderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GeocoderServer will
+// result in compilation errors.
+type UnsafeGeocoderServer interface {
+	mustEmbedUnimplementedGeocoderServer()
+}
+
+func RegisterGeocoderServer(s grpc.ServiceRegistrar, srv GeocoderServer) {
+	// If the following call pancis, it indicates UnimplementedGeocoderServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Geocoder_ServiceDesc, srv)
+}
+
+func _Geocoder_Geocode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeocoderServer).Geocode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geocoder_Geocode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeocoderServer).Geocode(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geocoder_Reverse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReverseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeocoderServer).Reverse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geocoder_Reverse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeocoderServer).Reverse(ctx, req.(*ReverseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geocoder_Suggest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeocoderServer).Suggest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geocoder_Suggest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeocoderServer).Suggest(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Geocoder_GetPlace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPlaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeocoderServer).GetPlace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Geocoder_GetPlace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeocoderServer).GetPlace(ctx, req.(*GetPlaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Geocoder_ServiceDesc is the grpc.ServiceDesc for Geocoder service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Geocoder_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "loc.v1.Geocoder",
+	HandlerType: (*GeocoderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Geocode",
+			Handler:    _Geocoder_Geocode_Handler,
+		},
+		{
+			MethodName: "Reverse",
+			Handler:    _Geocoder_Reverse_Handler,
+		},
+		{
+			MethodName: "Suggest",
+			Handler:    _Geocoder_Suggest_Handler,
+		},
+		{
+			MethodName: "GetPlace",
+			Handler:    _Geocoder_GetPlace_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "loc/v1/geocoder.proto",
+}
@@ -0,0 +1,363 @@
+// Package geoevents streams the geofence ENTER and EXIT events and the
+// device position UPDATE events Amazon Location Service publishes to
+// EventBridge. EventBridge cannot deliver to a terminal, so events are routed
+// by a rule to an SQS queue and received from there.
+package geoevents
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Source, GeofenceDetailType and PositionDetailType identify geofence
+	// and device position events on EventBridge.
+	Source             = "aws.geo"
+	GeofenceDetailType = "Location Geofence Event"
+	PositionDetailType = "Location Device Position Event"
+
+	// EventTypes of geofence and device position events.
+	EventEnter  = "ENTER"
+	EventExit   = "EXIT"
+	EventUpdate = "UPDATE"
+
+	// targetID is the ID of the queue among the targets of a rule.
+	targetID = "goawsloc"
+
+	// waitTime is how long a receive waits for events.
+	waitTime = 20 * time.Second
+)
+
+// Event is a geofence or device position event: a device entered or exited
+// a geofence, or a tracker received a position of a device.
+type Event struct {
+	ID                 string            `json:"id"`
+	Time               time.Time         `json:"time"`
+	EventType          string            `json:"eventType"`
+	GeofenceID         string            `json:"geofenceId,omitempty"`
+	TrackerName        string            `json:"trackerName,omitempty"`
+	DeviceID           string            `json:"deviceId"`
+	SampleTime         time.Time         `json:"sampleTime"`
+	ReceivedTime       *time.Time        `json:"receivedTime,omitempty"`
+	Position           []float64         `json:"position"`
+	Accuracy           *Accuracy         `json:"accuracy,omitempty"`
+	GeofenceProperties map[string]string `json:"geofenceProperties,omitempty"`
+	PositionProperties map[string]string `json:"positionProperties,omitempty"`
+}
+
+// Accuracy is the accuracy of the position of an event, in meters.
+type Accuracy struct {
+	Horizontal float64 `json:"horizontal"`
+}
+
+// EventBridgeEvent is an event as delivered by EventBridge, to a queue or an
+// API destination.
+type EventBridgeEvent struct {
+	Version    string    `json:"version"`
+	ID         string    `json:"id"`
+	DetailType string    `json:"detail-type"`
+	Source     string    `json:"source"`
+	Account    string    `json:"account"`
+	Time       time.Time `json:"time"`
+	Region     string    `json:"region"`
+	Resources  []string  `json:"resources"`
+	Detail     Detail    `json:"detail"`
+}
+
+// Detail is the detail of a geofence or device position event.
+type Detail struct {
+	EventType          string            `json:"EventType"`
+	GeofenceID         string            `json:"GeofenceId,omitempty"`
+	TrackerName        string            `json:"TrackerName,omitempty"`
+	DeviceID           string            `json:"DeviceId"`
+	SampleTime         time.Time         `json:"SampleTime"`
+	ReceivedTime       *time.Time        `json:"ReceivedTime,omitempty"`
+	Position           []float64         `json:"Position"`
+	Accuracy           *Accuracy         `json:"Accuracy,omitempty"`
+	GeofenceProperties map[string]string `json:"GeofenceProperties,omitempty"`
+	PositionProperties map[string]string `json:"PositionProperties,omitempty"`
+}
+
+// Event returns the geofence or device position event, or nil if it is
+// another event.
+func (e *EventBridgeEvent) Event() *Event {
+	if e.Source != Source || e.DetailType != GeofenceDetailType && e.DetailType != PositionDetailType {
+		return nil
+	}
+	d := e.Detail
+	return &Event{
+		ID:                 e.ID,
+		Time:               e.Time,
+		EventType:          d.EventType,
+		GeofenceID:         d.GeofenceID,
+		TrackerName:        d.TrackerName,
+		DeviceID:           d.DeviceID,
+		SampleTime:         d.SampleTime,
+		ReceivedTime:       d.ReceivedTime,
+		Position:           d.Position,
+		Accuracy:           d.Accuracy,
+		GeofenceProperties: d.GeofenceProperties,
+		PositionProperties: d.PositionProperties,
+	}
+}
+
+// Parse parses an EventBridge event. It returns nil without an error for
+// events which are neither geofence nor device position events.
+func Parse(data []byte) (*Event, error) {
+	var e EventBridgeEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e.Event(), nil
+}
+
+// EventBridgeClient is the part of the EventBridge API used to route events
+// to a queue.
+type EventBridgeClient interface {
+	DeleteRule(ctx context.Context, params *eventbridge.DeleteRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.DeleteRuleOutput, error)
+	PutRule(ctx context.Context, params *eventbridge.PutRuleInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutRuleOutput, error)
+	PutTargets(ctx context.Context, params *eventbridge.PutTargetsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutTargetsOutput, error)
+	RemoveTargets(ctx context.Context, params *eventbridge.RemoveTargetsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.RemoveTargetsOutput, error)
+}
+
+// SQSClient is the part of the SQS API used to create and read the queue.
+type SQSClient interface {
+	CreateQueue(ctx context.Context, params *sqs.CreateQueueInput, optFns ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	DeleteQueue(ctx context.Context, params *sqs.DeleteQueueInput, optFns ...func(*sqs.Options)) (*sqs.DeleteQueueOutput, error)
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	SetQueueAttributes(ctx context.Context, params *sqs.SetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.SetQueueAttributesOutput, error)
+}
+
+type Option func(sub *Subscription)
+
+// Subscription is a queue receiving geofence or device position events, and
+// the rule routing them there if it was created by Create.
+type Subscription struct {
+	QueueURL string
+	RuleName string
+
+	eb  EventBridgeClient
+	sqs SQSClient
+	log *logrus.Logger
+}
+
+// SetLogger logs events which cannot be parsed or handled.
+func SetLogger(log *logrus.Logger) Option {
+	return func(sub *Subscription) {
+		sub.log = log
+	}
+}
+
+// Subscribe returns a subscription to a queue which already receives
+// geofence or device position events.
+func Subscribe(client SQSClient, queueURL string, opts ...func(*Subscription)) *Subscription {
+	sub := &Subscription{QueueURL: queueURL, sqs: client}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	return sub
+}
+
+// Create creates a queue and a rule routing the geofence events of the
+// collection to it, both named name, or goawsloc-events- with a random
+// suffix if name is empty. eventTypes limits the events to ENTER or EXIT
+// events; empty means both. Delete removes both again.
+func Create(ctx context.Context, eb EventBridgeClient, client SQSClient, name string, collectionARN string, eventTypes []string, opts ...func(*Subscription)) (*Subscription, error) {
+	if name == "" {
+		suffix := make([]byte, 4)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, err
+		}
+		name = "goawsloc-events-" + hex.EncodeToString(suffix)
+	}
+	pattern, err := eventPattern(collectionARN, eventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	queue, err := client.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName: aws.String(name),
+		Attributes: map[string]string{
+			string(types.QueueAttributeNameMessageRetentionPeriod): "3600",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	sub := Subscribe(client, aws.ToString(queue.QueueUrl), opts...)
+	sub.eb = eb
+
+	// Undo what was created if a later step fails.
+	fail := func(err error) (*Subscription, error) {
+		return nil, errors.Join(err, sub.Delete(context.WithoutCancel(ctx)))
+	}
+
+	attrs, err := client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       queue.QueueUrl,
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return fail(err)
+	}
+	queueARN := attrs.Attributes[string(types.QueueAttributeNameQueueArn)]
+
+	rule, err := eb.PutRule(ctx, &eventbridge.PutRuleInput{
+		Name:         aws.String(name),
+		Description:  aws.String("Geofence events of " + collectionARN + " for goawsloc"),
+		EventPattern: aws.String(pattern),
+	})
+	if err != nil {
+		return fail(err)
+	}
+	sub.RuleName = name
+
+	policy, err := queuePolicy(queueARN, aws.ToString(rule.RuleArn))
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := client.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   queue.QueueUrl,
+		Attributes: map[string]string{string(types.QueueAttributeNamePolicy): policy},
+	}); err != nil {
+		return fail(err)
+	}
+
+	out, err := eb.PutTargets(ctx, &eventbridge.PutTargetsInput{
+		Rule:    aws.String(name),
+		Targets: []ebtypes.Target{{Id: aws.String(targetID), Arn: aws.String(queueARN)}},
+	})
+	if err != nil {
+		return fail(err)
+	}
+	if out.FailedEntryCount > 0 {
+		return fail(fmt.Errorf("adding queue to rule: %s", aws.ToString(out.FailedEntries[0].ErrorMessage)))
+	}
+	return sub, nil
+}
+
+// eventPattern returns the pattern of a rule matching the geofence events of
+// a collection.
+func eventPattern(collectionARN string, eventTypes []string) (string, error) {
+	pattern := map[string]any{
+		"source":      []string{Source},
+		"detail-type": []string{GeofenceDetailType},
+		"resources":   []string{collectionARN},
+	}
+	if len(eventTypes) > 0 {
+		for _, t := range eventTypes {
+			if t != EventEnter && t != EventExit {
+				return "", fmt.Errorf("invalid event type %q, must be %s or %s", t, EventEnter, EventExit)
+			}
+		}
+		pattern["detail"] = map[string]any{"EventType": eventTypes}
+	}
+	data, err := json.Marshal(pattern)
+	return string(data), err
+}
+
+// queuePolicy returns the policy allowing the rule to send to the queue.
+func queuePolicy(queueARN string, ruleARN string) (string, error) {
+	data, err := json.Marshal(map[string]any{
+		"Version": "2012-10-17",
+		"Statement": []any{map[string]any{
+			"Effect":    "Allow",
+			"Principal": map[string]any{"Service": "events.amazonaws.com"},
+			"Action":    "sqs:SendMessage",
+			"Resource":  queueARN,
+			"Condition": map[string]any{
+				"ArnEquals": map[string]any{"aws:SourceArn": ruleARN},
+			},
+		}},
+	})
+	return string(data), err
+}
+
+// Delete removes the rule and queue created by Create. Subscriptions of
+// Subscribe are left alone.
+func (s *Subscription) Delete(ctx context.Context) error {
+	if s.eb == nil {
+		return nil
+	}
+	var errs []error
+	if s.RuleName != "" {
+		if _, err := s.eb.RemoveTargets(ctx, &eventbridge.RemoveTargetsInput{
+			Rule: aws.String(s.RuleName),
+			Ids:  []string{targetID},
+		}); err != nil {
+			errs = append(errs, err)
+		} else if _, err := s.eb.DeleteRule(ctx, &eventbridge.DeleteRuleInput{
+			Name: aws.String(s.RuleName),
+		}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if _, err := s.sqs.DeleteQueue(ctx, &sqs.DeleteQueueInput{
+		QueueUrl: aws.String(s.QueueURL),
+	}); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Receive calls handle for every geofence or device position event until ctx
+// is done. Events are deleted from the queue once handle returns; events it
+// fails to handle are received again after the visibility timeout of the
+// queue. Other messages are deleted.
+func (s *Subscription) Receive(ctx context.Context, handle func(*Event) error) error {
+	for {
+		out, err := s.sqs.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(s.QueueURL),
+			MaxNumberOfMessages: 10,
+			WaitTimeSeconds:     int32(waitTime.Seconds()),
+		})
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range out.Messages {
+			event, err := Parse([]byte(aws.ToString(msg.Body)))
+			if err != nil {
+				s.warn(err, aws.ToString(msg.MessageId), "error parsing event")
+			} else if event != nil {
+				if err := handle(event); err != nil {
+					s.warn(err, event.ID, "error handling event")
+					continue
+				}
+			}
+			if _, err := s.sqs.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(s.QueueURL),
+				ReceiptHandle: msg.ReceiptHandle,
+			}); err != nil && ctx.Err() == nil {
+				s.warn(err, aws.ToString(msg.MessageId), "error deleting event")
+			}
+		}
+	}
+}
+
+// warn logs a warning if a logger is set.
+func (s *Subscription) warn(err error, id string, msg string) {
+	if s.log == nil {
+		return
+	}
+	s.log.WithFields(logrus.Fields{
+		"error": err,
+		"id":    id,
+	}).Warn(msg)
+}
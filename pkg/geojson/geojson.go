@@ -0,0 +1,150 @@
+// Package geojson provides the subset of RFC 7946 GeoJSON used to exchange
+// location data with GIS tools.
+package geojson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Geometry types.
+const (
+	TypeLineString        = "LineString"
+	TypeMultiPolygon      = "MultiPolygon"
+	TypePoint             = "Point"
+	TypePolygon           = "Polygon"
+	typeFeature           = "Feature"
+	typeFeatureCollection = "FeatureCollection"
+)
+
+// FeatureCollection is a GeoJSON FeatureCollection object.
+type FeatureCollection struct {
+	Type     string          `json:"type"`
+	BBox     json.RawMessage `json:"bbox,omitempty"`
+	Features []*Feature      `json:"features"`
+}
+
+// Feature is a GeoJSON Feature object.
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	BBox       json.RawMessage        `json:"bbox,omitempty"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is a GeoJSON geometry object. Coordinates are kept in their raw
+// form and decoded on demand by the typed accessors, so geometries read from
+// a file are written back unchanged.
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates,omitempty"`
+	Geometries  []*Geometry     `json:"geometries,omitempty"`
+}
+
+// Read decodes a FeatureCollection.
+func Read(r io.Reader) (*FeatureCollection, error) {
+	fc := &FeatureCollection{}
+	if err := json.NewDecoder(r).Decode(fc); err != nil {
+		return nil, err
+	}
+	if fc.Type != typeFeatureCollection {
+		return nil, fmt.Errorf("GeoJSON object is a %s, not a %s", fc.Type, typeFeatureCollection)
+	}
+	return fc, nil
+}
+
+// NewFeatureCollection returns an empty FeatureCollection.
+func NewFeatureCollection() *FeatureCollection {
+	return &FeatureCollection{
+		Type:     typeFeatureCollection,
+		Features: []*Feature{},
+	}
+}
+
+// AddFeature appends a feature to the collection.
+func (fc *FeatureCollection) AddFeature(feature *Feature) {
+	fc.Features = append(fc.Features, feature)
+}
+
+// NewFeature returns a feature for the geometry with the given properties.
+func NewFeature(geometry *Geometry, properties map[string]interface{}) *Feature {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return &Feature{
+		Type:       typeFeature,
+		Geometry:   geometry,
+		Properties: properties,
+	}
+}
+
+func newGeometry(geometryType string, coordinates interface{}) *Geometry {
+	// Marshalling nested float64 slices cannot fail.
+	data, _ := json.Marshal(coordinates)
+	return &Geometry{
+		Type:        geometryType,
+		Coordinates: data,
+	}
+}
+
+// NewPoint returns a Point geometry. Positions are ordered longitude, latitude.
+func NewPoint(position []float64) *Geometry {
+	return newGeometry(TypePoint, position)
+}
+
+// NewLineString returns a LineString geometry.
+func NewLineString(positions [][]float64) *Geometry {
+	return newGeometry(TypeLineString, positions)
+}
+
+// NewPolygon returns a Polygon geometry from its linear rings.
+func NewPolygon(rings [][][]float64) *Geometry {
+	return newGeometry(TypePolygon, rings)
+}
+
+// Point decodes the coordinates of a Point geometry.
+func (g *Geometry) Point() ([]float64, error) {
+	if g.Type != TypePoint {
+		return nil, fmt.Errorf("geometry is a %s, not a %s", g.Type, TypePoint)
+	}
+	var position []float64
+	if err := json.Unmarshal(g.Coordinates, &position); err != nil {
+		return nil, err
+	}
+	if len(position) < 2 {
+		return nil, fmt.Errorf("point has %d coordinates, need at least 2", len(position))
+	}
+	return position, nil
+}
+
+// Polygon decodes the linear rings of a Polygon geometry.
+func (g *Geometry) Polygon() ([][][]float64, error) {
+	if g.Type != TypePolygon {
+		return nil, fmt.Errorf("geometry is a %s, not a %s", g.Type, TypePolygon)
+	}
+	var rings [][][]float64
+	if err := json.Unmarshal(g.Coordinates, &rings); err != nil {
+		return nil, err
+	}
+	if len(rings) == 0 {
+		return nil, fmt.Errorf("polygon has no rings")
+	}
+	return rings, nil
+}
+
+// MultiPolygon decodes the polygons of a MultiPolygon geometry.
+func (g *Geometry) MultiPolygon() ([][][][]float64, error) {
+	if g.Type != TypeMultiPolygon {
+		return nil, fmt.Errorf("geometry is a %s, not a %s", g.Type, TypeMultiPolygon)
+	}
+	var polygons [][][][]float64
+	if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+		return nil, err
+	}
+	if len(polygons) == 0 {
+		return nil, fmt.Errorf("multipolygon has no polygons")
+	}
+	return polygons, nil
+}
@@ -0,0 +1,65 @@
+// Package gpx writes GPS Exchange Format 1.1 documents.
+package gpx
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const namespace = "http://www.topografix.com/GPX/1/1"
+
+// GPX is the root element of a GPX document.
+type GPX struct {
+	XMLName xml.Name `xml:"gpx"`
+	Version string   `xml:"version,attr"`
+	Creator string   `xml:"creator,attr"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Tracks  []*Track `xml:"trk"`
+}
+
+// Track is an ordered list of track segments.
+type Track struct {
+	Name     string          `xml:"name,omitempty"`
+	Segments []*TrackSegment `xml:"trkseg"`
+}
+
+// TrackSegment is a list of track points which are logically connected in order.
+type TrackSegment struct {
+	Points []*TrackPoint `xml:"trkpt"`
+}
+
+// TrackPoint is a single recorded position.
+type TrackPoint struct {
+	Lat  float64    `xml:"lat,attr"`
+	Lon  float64    `xml:"lon,attr"`
+	Time *time.Time `xml:"time,omitempty"`
+}
+
+// New returns an empty GPX document.
+func New(creator string) *GPX {
+	return &GPX{
+		Version: "1.1",
+		Creator: creator,
+		Xmlns:   namespace,
+	}
+}
+
+// AddTrack appends a track to the document.
+func (g *GPX) AddTrack(track *Track) {
+	g.Tracks = append(g.Tracks, track)
+}
+
+// Write encodes the document, including the XML header, to w.
+func (g *GPX) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(g); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
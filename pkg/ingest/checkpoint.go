@@ -0,0 +1,92 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultCheckpointPath returns the path of the checkpoint file in the user
+// cache directory.
+func DefaultCheckpointPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goawsloc", "checkpoints.json"), nil
+}
+
+// Checkpoints are the positions up to which sources were ingested, by key,
+// saved to a JSON file after every change so an ingest resumes where it
+// stopped.
+type Checkpoints struct {
+	mu     sync.Mutex
+	path   string
+	values map[string]string
+}
+
+// LoadCheckpoints reads the checkpoint file at path. A missing file has no
+// checkpoints; an empty path keeps them in memory only.
+func LoadCheckpoints(path string) (*Checkpoints, error) {
+	c := &Checkpoints{path: path, values: map[string]string{}}
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.values); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Get returns the checkpoint of key, or "" if it has none.
+func (c *Checkpoints) Get(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+// Set sets the checkpoint of key and saves the checkpoints.
+func (c *Checkpoints) Set(key string, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	return c.save()
+}
+
+// save writes the checkpoints to their file, replacing it atomically. The
+// caller must hold the lock.
+func (c *Checkpoints) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c.values, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(c.path), ".checkpoints-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.path)
+}
@@ -0,0 +1,78 @@
+package ingest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpoints(t *testing.T) {
+	tests := []struct {
+		name string
+		path func(dir string) string
+		// reloaded reports whether checkpoints survive a reload.
+		reloaded bool
+	}{
+		{"file", func(dir string) string { return filepath.Join(dir, "checkpoints.json") }, true},
+		{"missing directory", func(dir string) string { return filepath.Join(dir, "goawsloc", "checkpoints.json") }, true},
+		{"in memory", func(dir string) string { return "" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path(t.TempDir())
+			c, err := LoadCheckpoints(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Get("stream/shard-1"); got != "" {
+				t.Errorf("Get() = %q before Set, want empty", got)
+			}
+			if err := c.Set("stream/shard-1", "100"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Set("stream/shard-2", "200"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Set("stream/shard-1", "150"); err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Get("stream/shard-1"); got != "150" {
+				t.Errorf("Get() = %q, want 150", got)
+			}
+
+			reloaded, err := LoadCheckpoints(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]string{"stream/shard-1": "", "stream/shard-2": ""}
+			if tt.reloaded {
+				want = map[string]string{"stream/shard-1": "150", "stream/shard-2": "200"}
+			}
+			for key, value := range want {
+				if got := reloaded.Get(key); got != value {
+					t.Errorf("Get(%q) after reload = %q, want %q", key, got, value)
+				}
+			}
+			if path != "" {
+				// No temporary files are left beside the checkpoints.
+				entries, err := os.ReadDir(filepath.Dir(path))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(entries) != 1 {
+					t.Errorf("directory holds %d files, want 1", len(entries))
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCheckpointsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCheckpoints(path); err == nil {
+		t.Error("LoadCheckpoints() of a malformed file succeeded")
+	}
+}
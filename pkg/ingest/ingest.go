@@ -0,0 +1,486 @@
+// Package ingest reads device positions from a Kinesis data stream, or from
+// the files Kinesis Data Firehose delivers to S3, and sends them to a
+// tracker in batches. How far each shard or prefix was ingested is kept in
+// Checkpoints once its positions were sent, so a restarted ingest neither
+// skips nor, apart from the last batch, repeats positions.
+package ingest
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/trackersvc"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	ktypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// shardEnd is the checkpoint of a shard which was read to its end.
+	shardEnd = "SHARD_END"
+
+	// shardRefresh is how often the shards of a stream are listed, to pick
+	// up the shards of a resharding.
+	shardRefresh = time.Minute
+
+	// recordsDelay is the pause between reads of a shard, keeping below the
+	// limit of five reads per second and shard.
+	recordsDelay = 250 * time.Millisecond
+
+	// idleDelay is the pause after a read of a shard returned no records.
+	idleDelay = time.Second
+
+	// finalFlushTimeout bounds sending the last batch after ctx is done.
+	finalFlushTimeout = 30 * time.Second
+)
+
+// Updater sends position updates to a tracker, like trackersvc.Config.
+type Updater interface {
+	UpdatePositions(ctx context.Context, trackerName string, updates []trackersvc.DevicePosition) (*location.BatchUpdateDevicePositionOutput, error)
+}
+
+// KinesisClient is the part of the Kinesis API used to read a stream.
+type KinesisClient interface {
+	GetRecords(ctx context.Context, params *kinesis.GetRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error)
+	GetShardIterator(ctx context.Context, params *kinesis.GetShardIteratorInput, optFns ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error)
+	ListShards(ctx context.Context, params *kinesis.ListShardsInput, optFns ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error)
+}
+
+// S3Client is the part of the S3 API used to read Firehose deliveries.
+type S3Client interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+}
+
+// Decoder decodes the device positions of a record or file. If part of the
+// data is malformed, it returns the positions it could decode with an error.
+type Decoder func(data []byte) ([]trackersvc.DevicePosition, error)
+
+type Option func(ingester *Ingester)
+
+// Ingester sends the device positions of its sources to a tracker.
+type Ingester struct {
+	updater       Updater
+	trackerName   string
+	decode        Decoder
+	checkpoints   *Checkpoints
+	batchSize     int
+	flushInterval time.Duration
+	latest        bool
+	pollInterval  time.Duration
+	log           *logrus.Logger
+}
+
+// New returns an ingester sending the positions decoded by decode to the
+// tracker.
+func New(updater Updater, trackerName string, decode Decoder, opts ...func(*Ingester)) (*Ingester, error) {
+	in := &Ingester{
+		updater:       updater,
+		trackerName:   trackerName,
+		decode:        decode,
+		batchSize:     10,
+		flushInterval: 5 * time.Second,
+		pollInterval:  30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(in)
+	}
+
+	switch {
+	case trackerName == "":
+		return nil, errors.New("tracker name not set")
+	case in.batchSize < 1:
+		return nil, errors.New("batch size must be at least 1")
+	case in.flushInterval <= 0:
+		return nil, errors.New("flush interval must be positive")
+	case in.pollInterval <= 0:
+		return nil, errors.New("poll interval must be positive")
+	}
+	if in.checkpoints == nil {
+		in.checkpoints, _ = LoadCheckpoints("")
+	}
+	return in, nil
+}
+
+// SetCheckpoints resumes from and records to the checkpoints. Without them,
+// checkpoints are kept in memory only.
+func SetCheckpoints(checkpoints *Checkpoints) Option {
+	return func(ingester *Ingester) {
+		ingester.checkpoints = checkpoints
+	}
+}
+
+// SetBatch sends positions once size of them are pending or the first of
+// them waited for interval. Defaults to 10 positions, the most one
+// BatchUpdateDevicePosition call takes, or 5 seconds.
+func SetBatch(size int, interval time.Duration) Option {
+	return func(ingester *Ingester) {
+		ingester.batchSize = size
+		ingester.flushInterval = interval
+	}
+}
+
+// SetLatest starts reading shards without a checkpoint at their latest
+// record instead of their oldest.
+func SetLatest(latest bool) Option {
+	return func(ingester *Ingester) {
+		ingester.latest = latest
+	}
+}
+
+// SetPollInterval sets how often S3 is listed for new files when following
+// a prefix. Defaults to 30 seconds.
+func SetPollInterval(interval time.Duration) Option {
+	return func(ingester *Ingester) {
+		ingester.pollInterval = interval
+	}
+}
+
+// SetLogger logs every batch sent and every record skipped.
+func SetLogger(log *logrus.Logger) Option {
+	return func(ingester *Ingester) {
+		ingester.log = log
+	}
+}
+
+// Kinesis ingests the shards of a stream until ctx is done, reading each
+// shard in its own goroutine. Child shards of a resharding are read once
+// their parents were read to their end.
+func (in *Ingester) Kinesis(ctx context.Context, client KinesisClient, stream string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		// Stop the other shards once one fails, flushing their batches.
+		cancel()
+		wg.Wait()
+	}()
+
+	// ended receives nil when a shard was read to its end, so its children
+	// are started right away, or the error stopping a shard.
+	ended := make(chan error)
+	running := map[string]bool{}
+
+	for {
+		shards, err := listShards(ctx, client, stream)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		listed := map[string]bool{}
+		for _, shard := range shards {
+			listed[aws.ToString(shard.ShardId)] = true
+		}
+		// A parent is done once read to its end, or once it expired.
+		done := func(parent *string) bool {
+			return parent == nil || !listed[*parent] || in.checkpoints.Get(in.shardKey(stream, *parent)) == shardEnd
+		}
+
+		for _, shard := range shards {
+			id := aws.ToString(shard.ShardId)
+			if running[id] || in.checkpoints.Get(in.shardKey(stream, id)) == shardEnd {
+				continue
+			}
+			if !done(shard.ParentShardId) || !done(shard.AdjacentParentShardId) {
+				continue
+			}
+			running[id] = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := in.readShard(ctx, client, stream, id)
+				select {
+				case ended <- err:
+				case <-ctx.Done():
+				}
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-ended:
+			if err != nil {
+				return err
+			}
+		case <-time.After(shardRefresh):
+		}
+	}
+}
+
+// listShards lists the shards of a stream.
+func listShards(ctx context.Context, client KinesisClient, stream string) ([]ktypes.Shard, error) {
+	var shards []ktypes.Shard
+	input := &kinesis.ListShardsInput{StreamName: aws.String(stream)}
+	for {
+		out, err := client.ListShards(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, out.Shards...)
+		if out.NextToken == nil {
+			return shards, nil
+		}
+		input = &kinesis.ListShardsInput{NextToken: out.NextToken}
+	}
+}
+
+// shardKey returns the checkpoint key of a shard.
+func (in *Ingester) shardKey(stream string, shardID string) string {
+	return in.trackerName + "/kinesis/" + stream + "/" + shardID
+}
+
+// readShard ingests a shard from its checkpoint until its end or until ctx
+// is done.
+func (in *Ingester) readShard(ctx context.Context, client KinesisClient, stream string, shardID string) error {
+	key := in.shardKey(stream, shardID)
+	input := &kinesis.GetShardIteratorInput{
+		StreamName:        aws.String(stream),
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: ktypes.ShardIteratorTypeTrimHorizon,
+	}
+	if seq := in.checkpoints.Get(key); seq != "" {
+		input.ShardIteratorType = ktypes.ShardIteratorTypeAfterSequenceNumber
+		input.StartingSequenceNumber = aws.String(seq)
+	} else if in.latest {
+		input.ShardIteratorType = ktypes.ShardIteratorTypeLatest
+	}
+	out, err := client.GetShardIterator(ctx, input)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("shard %s: %w", shardID, err)
+	}
+
+	b := &batch{in: in, key: key}
+	iterator := out.ShardIterator
+	for iterator != nil {
+		out, err := client.GetRecords(ctx, &kinesis.GetRecordsInput{ShardIterator: iterator})
+		if ctx.Err() != nil {
+			return b.flushDetached(ctx)
+		}
+		if err != nil {
+			return errors.Join(fmt.Errorf("shard %s: %w", shardID, err), b.flushDetached(ctx))
+		}
+		for _, record := range out.Records {
+			b.add(record.Data, aws.ToString(record.SequenceNumber))
+		}
+		if b.due() {
+			if err := b.flush(ctx); err != nil {
+				return err
+			}
+		}
+
+		iterator = out.NextShardIterator
+		delay := recordsDelay
+		if len(out.Records) == 0 {
+			delay = idleDelay
+		}
+		select {
+		case <-ctx.Done():
+			return b.flushDetached(ctx)
+		case <-time.After(delay):
+		}
+	}
+
+	if err := b.flush(ctx); err != nil {
+		return err
+	}
+	if in.log != nil {
+		in.log.WithFields(logrus.Fields{
+			"shard": shardID,
+		}).Info("Shard ended")
+	}
+	return in.checkpoints.Set(key, shardEnd)
+}
+
+// batch collects the positions of the records of a shard until they are
+// sent, with the checkpoint of the last record.
+type batch struct {
+	in         *Ingester
+	key        string
+	positions  []trackersvc.DevicePosition
+	checkpoint string
+	since      time.Time
+}
+
+// add decodes a record and adds its positions. Records which cannot be
+// decoded are skipped, as reading them again would not help.
+func (b *batch) add(data []byte, checkpoint string) {
+	positions, err := b.in.decode(data)
+	if err != nil {
+		b.in.warn(err, b.key, checkpoint, "skipping malformed record")
+	}
+	if b.checkpoint == "" {
+		b.since = time.Now()
+	}
+	b.positions = append(b.positions, positions...)
+	b.checkpoint = checkpoint
+}
+
+// due reports whether the batch is full or waited long enough.
+func (b *batch) due() bool {
+	return b.checkpoint != "" && (len(b.positions) >= b.in.batchSize || time.Since(b.since) >= b.in.flushInterval)
+}
+
+// flush sends the positions of the batch and records its checkpoint.
+func (b *batch) flush(ctx context.Context) error {
+	if b.checkpoint == "" {
+		return nil
+	}
+	if err := b.in.send(ctx, b.key, b.positions); err != nil {
+		return err
+	}
+	if err := b.in.checkpoints.Set(b.key, b.checkpoint); err != nil {
+		return err
+	}
+	b.positions = nil
+	b.checkpoint = ""
+	return nil
+}
+
+// flushDetached flushes the batch once ctx is done.
+func (b *batch) flushDetached(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalFlushTimeout)
+	defer cancel()
+	return b.flush(ctx)
+}
+
+// send sends positions to the tracker. Positions the tracker rejects are
+// logged but not retried.
+func (in *Ingester) send(ctx context.Context, key string, positions []trackersvc.DevicePosition) error {
+	if len(positions) == 0 {
+		return nil
+	}
+	out, err := in.updater.UpdatePositions(ctx, in.trackerName, positions)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	if in.log == nil {
+		return nil
+	}
+	for _, e := range out.Errors {
+		fields := logrus.Fields{
+			"deviceId":   aws.ToString(e.DeviceId),
+			"sampleTime": e.SampleTime,
+		}
+		if e.Error != nil {
+			fields["code"] = e.Error.Code
+			fields["message"] = aws.ToString(e.Error.Message)
+		}
+		in.log.WithFields(fields).Warn("unable to update device position")
+	}
+	in.log.WithFields(logrus.Fields{
+		"source": key,
+		"count":  len(positions) - len(out.Errors),
+		"errors": len(out.Errors),
+	}).Info("Updated device positions")
+	return nil
+}
+
+// S3 ingests the files under an S3 prefix in key order, which is delivery
+// order for the time-based prefixes of Firehose. Gzip files, with a .gz
+// extension or gzip content encoding, are decompressed. With follow it keeps
+// listing the prefix for new files until ctx is done.
+func (in *Ingester) S3(ctx context.Context, client S3Client, bucket string, prefix string, follow bool) error {
+	key := in.trackerName + "/s3/" + bucket + "/" + prefix
+	for {
+		input := &s3.ListObjectsV2Input{
+			Bucket: aws.String(bucket),
+			Prefix: aws.String(prefix),
+		}
+		if after := in.checkpoints.Get(key); after != "" {
+			input.StartAfter = aws.String(after)
+		}
+		paginator := s3.NewListObjectsV2Paginator(client, input)
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return err
+			}
+			for _, object := range page.Contents {
+				name := aws.ToString(object.Key)
+				if strings.HasSuffix(name, "/") {
+					continue
+				}
+				if err := in.ingestObject(ctx, client, bucket, name, key); err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+				if err := in.checkpoints.Set(key, name); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(in.pollInterval):
+		}
+	}
+}
+
+// ingestObject sends the positions of an S3 object.
+func (in *Ingester) ingestObject(ctx context.Context, client S3Client, bucket string, name string, key string) error {
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		return fmt.Errorf("s3://%s/%s: %w", bucket, name, err)
+	}
+	defer out.Body.Close()
+
+	var r io.Reader = out.Body
+	if strings.HasSuffix(name, ".gz") || aws.ToString(out.ContentEncoding) == "gzip" {
+		zr, err := gzip.NewReader(out.Body)
+		if err != nil {
+			return fmt.Errorf("s3://%s/%s: %w", bucket, name, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("s3://%s/%s: %w", bucket, name, err)
+	}
+
+	positions, err := in.decode(data)
+	if err != nil {
+		in.warn(err, key, name, "skipping malformed part of file")
+	}
+	return in.send(ctx, "s3://"+bucket+"/"+name, positions)
+}
+
+// warn logs a warning about a record if a logger is set.
+func (in *Ingester) warn(err error, key string, record string, msg string) {
+	if in.log == nil {
+		return
+	}
+	in.log.WithFields(logrus.Fields{
+		"error":  err,
+		"source": key,
+		"record": record,
+	}).Warn(msg)
+}
@@ -0,0 +1,114 @@
+// Package metrics counts the AWS API calls of the location services as
+// Prometheus metrics: requests, errors and latency per operation and place
+// index. The metrics are served by `loc serve` at /metrics, or pushed to a
+// Pushgateway once a batch job completes.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// namespace prefixes the names of the metrics.
+const namespace = "goawsloc"
+
+// Metrics holds the metrics of the AWS API calls in a registry of its own,
+// so only they are served or pushed.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// New returns empty metrics.
+func New() *Metrics {
+	labels := []string{"operation", "index"}
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "aws_requests_total",
+			Help:      "AWS API calls, including failed ones.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "aws_errors_total",
+			Help:      "Failed AWS API calls by error code.",
+		}, append(labels, "code")),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "aws_request_duration_seconds",
+			Help:      "Latency of AWS API calls, including retries.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+	}
+	m.registry.MustRegister(m.requests, m.errors, m.latency)
+	return m
+}
+
+// Middleware is client middleware which records every AWS API call. The
+// index label is the IndexName of the call, empty for calls on other
+// resources.
+func (m *Metrics) Middleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("Metrics", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		start := time.Now()
+		out, metadata, err := next.HandleInitialize(ctx, in)
+
+		operation := awsmiddleware.GetOperationName(ctx)
+		index := indexName(in.Parameters)
+		m.requests.WithLabelValues(operation, index).Inc()
+		m.latency.WithLabelValues(operation, index).Observe(time.Since(start).Seconds())
+		if err != nil {
+			m.errors.WithLabelValues(operation, index, errorCode(err)).Inc()
+		}
+		return out, metadata, err
+	}), middleware.After)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Push replaces the metrics of the job on the Pushgateway at url.
+func (m *Metrics) Push(ctx context.Context, url, job string) error {
+	return push.New(url, job).Gatherer(m.registry).PushContext(ctx)
+}
+
+// indexName returns the IndexName field of the input of an API call, or ""
+// if it has none.
+func indexName(params any) string {
+	v := reflect.ValueOf(params)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return ""
+	}
+	field := v.Elem().FieldByName("IndexName")
+	if !field.IsValid() || field.Kind() != reflect.Pointer || field.IsNil() || field.Elem().Kind() != reflect.String {
+		return ""
+	}
+	return field.Elem().String()
+}
+
+// errorCode returns the AWS error code of a failed call, or "client" for
+// errors which did not come from AWS, such as network errors and
+// cancellation.
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return "client"
+}
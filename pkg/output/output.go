@@ -0,0 +1,252 @@
+// Package output writes command results in one of several formats, so every
+// command supports the same set of output formats.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format.
+type Format string
+
+// Supported output formats.
+const (
+	CSV     Format = "csv"
+	GeoJSON Format = "geojson"
+	JSON    Format = "json"
+	NDJSON  Format = "ndjson"
+	Table   Format = "table"
+	YAML    Format = "yaml"
+)
+
+// Formats lists the supported output formats.
+var Formats = []Format{Table, JSON, YAML, CSV, GeoJSON, NDJSON}
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if strings.EqualFold(name, string(f)) {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("invalid output format %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// Rows is a list of items with the same columns, such as the entries of a
+// list command.
+type Rows struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Field is a named value of a single item.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Record is the fields of a single item, such as the result of a describe
+// command.
+type Record []Field
+
+// Result is the output of a command.
+type Result struct {
+	// Data is written by the json, yaml and ndjson formats. A slice is
+	// written as one ndjson line per element.
+	Data any
+
+	// Record and Rows are written by the table and csv formats. If both are
+	// set, the table format writes the record followed by the rows and the
+	// csv format writes only the rows.
+	Record Record
+	Rows   *Rows
+
+	// GeoJSON is written by the geojson format, typically a
+	// geojson.FeatureCollection. Results without geometry leave it nil.
+	GeoJSON any
+
+	// Items are the values a template is executed for by WriteTemplate,
+	// one line per element. Commands without template support leave it
+	// nil.
+	Items any
+}
+
+// Write writes the result to w in the given format.
+func Write(w io.Writer, format Format, result *Result) error {
+	switch format {
+	case CSV:
+		return writeCSV(w, result)
+	case GeoJSON:
+		if result.GeoJSON == nil {
+			return errors.New("geojson output is not supported by this command")
+		}
+		return writeJSON(w, result.GeoJSON)
+	case JSON:
+		return writeJSON(w, result.Data)
+	case NDJSON:
+		return writeNDJSON(w, result.Data)
+	case Table:
+		return writeTable(w, result)
+	case YAML:
+		return writeYAML(w, result.Data)
+	}
+	return fmt.Errorf("invalid output format %q", format)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// writeNDJSON writes each element of a slice or array as its own JSON line,
+// and any other value as a single line.
+func writeNDJSON(w io.Writer, v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() && (rv.Elem().Kind() == reflect.Slice || rv.Elem().Kind() == reflect.Array) {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return writeJSON(w, v)
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := writeJSON(w, rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeYAML writes the value as YAML. The value is encoded as JSON first, so
+// field names and omitted fields match the json format.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	blockStyle(&doc)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle clears the flow style and quoting JSON documents are parsed
+// with, so they are written as regular block YAML.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func writeTable(w io.Writer, result *Result) error {
+	if result.Record == nil && result.Rows == nil {
+		return errors.New("table output is not supported by this command")
+	}
+	if result.Record != nil {
+		tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+		for _, field := range result.Record {
+			fmt.Fprintf(tw, "%s:\t%s\n", field.Name, field.Value)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if result.Rows != nil {
+		if result.Record != nil {
+			fmt.Fprintln(w)
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.Debug)
+		if len(result.Rows.Header) > 0 {
+			fmt.Fprintln(tw, strings.Join(result.Rows.Header, "\t"))
+		}
+		for _, row := range result.Rows.Rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCSV writes the rows of the result, or the record as a header and a
+// single row.
+func writeCSV(w io.Writer, result *Result) error {
+	cw := csv.NewWriter(w)
+	switch {
+	case result.Rows != nil:
+		if len(result.Rows.Header) > 0 {
+			cw.Write(result.Rows.Header)
+		}
+		cw.WriteAll(result.Rows.Rows)
+	case result.Record != nil:
+		header := make([]string, len(result.Record))
+		row := make([]string, len(result.Record))
+		for i, field := range result.Record {
+			header[i] = field.Name
+			row[i] = field.Value
+		}
+		cw.WriteAll([][]string{header, row})
+	default:
+		return errors.New("csv output is not supported by this command")
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ParseTemplate parses a Go template for WriteTemplate, such as
+// "{{.Label}} -> {{.Position}}". Referring to a field the items do not have
+// is an error when the template is executed.
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// WriteTemplate executes the template once for each of the items of the
+// result, writing one line per item.
+func WriteTemplate(w io.Writer, tmpl *template.Template, result *Result) error {
+	if result.Items == nil {
+		return errors.New("template output is not supported by this command")
+	}
+	rv := reflect.ValueOf(result.Items)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return errors.New("template items must be a slice")
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := tmpl.Execute(w, rv.Index(i).Interface()); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
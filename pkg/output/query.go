@@ -0,0 +1,173 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is a single step of a query: a field name, an index, or a projection
+// over every element of an array.
+type step struct {
+	field string
+	index int
+	isIdx bool
+	all   bool
+}
+
+// Query selects a value from the json output of a command with a dotted path
+// such as Results[0].Place.PostalCode. Indexes may be negative to count from
+// the end, and [*] selects the rest of the path from every element of an
+// array, such as Results[*].Place.Label.
+type Query struct {
+	text  string
+	steps []step
+}
+
+// ParseQuery parses a query.
+func ParseQuery(text string) (*Query, error) {
+	q := &Query{text: text}
+	rest := strings.TrimPrefix(text, ".")
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid query %q: missing ]", text)
+			}
+			inner := rest[1:end]
+			if inner == "*" {
+				q.steps = append(q.steps, step{all: true})
+			} else {
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid query %q: invalid index %q", text, inner)
+				}
+				q.steps = append(q.steps, step{index: i, isIdx: true})
+			}
+			rest = rest[end+1:]
+		case rest[0] == '.':
+			rest = rest[1:]
+			if rest == "" || rest[0] == '.' || rest[0] == '[' {
+				return nil, fmt.Errorf("invalid query %q: empty field name", text)
+			}
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			q.steps = append(q.steps, step{field: rest[:end]})
+			rest = rest[end:]
+		}
+	}
+	return q, nil
+}
+
+// Select returns the value the query selects from v, which is encoded as JSON
+// first so field names match the json format. Selecting a field of null is
+// null.
+func (q *Query) Select(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return q.selectSteps(doc, q.steps, "")
+}
+
+func (q *Query) selectSteps(v any, steps []step, at string) (any, error) {
+	if len(steps) == 0 || v == nil {
+		return v, nil
+	}
+	s := steps[0]
+	switch {
+	case s.field != "":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("query %q: %s is not an object", q.text, describePath(at))
+		}
+		child, ok := obj[s.field]
+		if !ok {
+			return nil, fmt.Errorf("query %q: %s has no field %q", q.text, describePath(at), s.field)
+		}
+		return q.selectSteps(child, steps[1:], at+"."+s.field)
+	case s.isIdx:
+		arr, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("query %q: %s is not an array", q.text, describePath(at))
+		}
+		i := s.index
+		if i < 0 {
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			return nil, fmt.Errorf("query %q: index %d out of range, %s has %d elements", q.text, s.index, describePath(at), len(arr))
+		}
+		return q.selectSteps(arr[i], steps[1:], fmt.Sprintf("%s[%d]", at, s.index))
+	default:
+		arr, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("query %q: %s is not an array", q.text, describePath(at))
+		}
+		out := make([]any, 0, len(arr))
+		for i, e := range arr {
+			sel, err := q.selectSteps(e, steps[1:], fmt.Sprintf("%s[%d]", at, i))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, sel)
+		}
+		return out, nil
+	}
+}
+
+func describePath(at string) string {
+	if at == "" {
+		return "the output"
+	}
+	return strings.TrimPrefix(at, ".")
+}
+
+// Apply returns a result holding only the value the query selects from the
+// data of result. The table and csv formats write a string or number as is
+// and anything else as JSON, with one row per element of an array.
+func (q *Query) Apply(result *Result) (*Result, error) {
+	sel, err := q.Select(result.Data)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := sel.([]any)
+	if !ok {
+		values = []any{sel}
+	}
+	rows := &Rows{}
+	for _, value := range values {
+		cell, err := plain(value)
+		if err != nil {
+			return nil, err
+		}
+		rows.Rows = append(rows.Rows, []string{cell})
+	}
+	return &Result{Data: sel, Rows: rows}, nil
+}
+
+// plain formats a selected value for table and csv output.
+func plain(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,295 @@
+// Package render draws static maps from raster map tiles, with markers for a
+// set of positions and lines along paths such as routes, to check geocoding
+// and routing results at a glance.
+package render
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+)
+
+const (
+	// maxZoom is the highest zoom level used when fitting the view.
+	maxZoom = 18
+
+	// defaultZoom is used to show a single position.
+	defaultZoom = 15
+
+	// padding is the margin in pixels kept free around the markers when
+	// fitting the view.
+	padding = 32
+
+	// markerRadius is the radius of a marker in pixels.
+	markerRadius = 6
+
+	// lineRadius is half the width of a path in pixels.
+	lineRadius = 2
+
+	// maxLatitude is the latitude limit of the Web Mercator projection.
+	maxLatitude = 85.05112878
+)
+
+var (
+	background   = color.RGBA{R: 0xe0, G: 0xe0, B: 0xe0, A: 0xff}
+	markerFill   = color.RGBA{R: 0xd0, G: 0x20, B: 0x20, A: 0xff}
+	markerBorder = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	lineColor    = color.RGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff}
+
+	// Start, End and Waypoint are the marker colors of the positions of a
+	// route.
+	Start    = color.RGBA{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff}
+	End      = markerFill
+	Waypoint = color.RGBA{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff}
+)
+
+// TileFunc fetches the encoded PNG or JPEG image of a map tile.
+type TileFunc func(ctx context.Context, z int, x int, y int) ([]byte, error)
+
+// Point is a position to mark on the map. Color is the fill of its marker,
+// red if nil.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+	Color     color.Color
+}
+
+// Box is the area to show, from its south-west to its north-east corner.
+type Box struct {
+	South float64
+	West  float64
+	North float64
+	East  float64
+}
+
+// Options controls the size and area of the rendered map.
+type Options struct {
+	// Width and Height of the image in pixels.
+	Width  int
+	Height int
+
+	// Zoom is the zoom level of the tiles. Zero fits the view to Area, or to
+	// the markers if Area is not set.
+	Zoom int
+
+	// Area is the area to show. Nil shows the markers and paths.
+	Area *Box
+
+	// Paths are drawn as lines below the markers, such as the legs of a
+	// route.
+	Paths [][]Point
+}
+
+// Render draws the map tiles covering the view, a line along each path and a
+// marker for each point. Tiles must be raster images; vector tiles cannot be
+// rendered.
+func Render(ctx context.Context, tile TileFunc, points []Point, opts Options) (*image.RGBA, error) {
+	if opts.Width < 1 || opts.Height < 1 {
+		return nil, fmt.Errorf("invalid size %dx%d", opts.Width, opts.Height)
+	}
+	area := opts.Area
+	if area == nil {
+		all := points
+		for _, path := range opts.Paths {
+			all = append(all[:len(all):len(all)], path...)
+		}
+		if len(all) == 0 {
+			return nil, errors.New("no area and no points to show")
+		}
+		area = bounds(all)
+	}
+
+	// The tile size is not known up front, so learn it from the world tile.
+	world, err := fetch(ctx, tile, 0, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	tileSize := world.Bounds().Dx()
+
+	zoom := opts.Zoom
+	if zoom == 0 {
+		zoom = fit(area, tileSize, opts.Width, opts.Height)
+	}
+
+	scale := float64(tileSize) * math.Exp2(float64(zoom))
+	cx, cy := project((area.South+area.North)/2, (area.West+area.East)/2)
+	left := int(math.Floor(cx*scale)) - opts.Width/2
+	top := int(math.Floor(cy*scale)) - opts.Height/2
+
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	n := 1 << zoom
+	for ty := floorDiv(top, tileSize); ty <= floorDiv(top+opts.Height-1, tileSize); ty++ {
+		if ty < 0 || ty >= n {
+			continue
+		}
+		for tx := floorDiv(left, tileSize); tx <= floorDiv(left+opts.Width-1, tileSize); tx++ {
+			t := world
+			if zoom > 0 {
+				// Wrap around the antimeridian.
+				if t, err = fetch(ctx, tile, zoom, ((tx%n)+n)%n, ty); err != nil {
+					return nil, err
+				}
+			}
+			at := image.Pt(tx*tileSize-left, ty*tileSize-top)
+			draw.Draw(img, t.Bounds().Add(at), t, t.Bounds().Min, draw.Src)
+		}
+	}
+
+	pixel := func(p Point) (float64, float64) {
+		x, y := project(p.Latitude, p.Longitude)
+		return x*scale - float64(left), y*scale - float64(top)
+	}
+	for _, path := range opts.Paths {
+		for i := 1; i < len(path); i++ {
+			x1, y1 := pixel(path[i-1])
+			x2, y2 := pixel(path[i])
+			line(img, x1, y1, x2, y2)
+		}
+	}
+	for _, p := range points {
+		x, y := pixel(p)
+		fill := p.Color
+		if fill == nil {
+			fill = markerFill
+		}
+		marker(img, int(x), int(y), fill)
+	}
+	return img, nil
+}
+
+// fetch gets and decodes a tile.
+func fetch(ctx context.Context, tile TileFunc, z int, x int, y int) (image.Image, error) {
+	data, err := tile(ctx, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("tile %d/%d/%d is not a raster image: %w", z, x, y, err)
+	}
+	return img, nil
+}
+
+// bounds returns the smallest box containing the points.
+func bounds(points []Point) *Box {
+	box := &Box{South: points[0].Latitude, North: points[0].Latitude, West: points[0].Longitude, East: points[0].Longitude}
+	for _, p := range points[1:] {
+		box.South = math.Min(box.South, p.Latitude)
+		box.North = math.Max(box.North, p.Latitude)
+		box.West = math.Min(box.West, p.Longitude)
+		box.East = math.Max(box.East, p.Longitude)
+	}
+	return box
+}
+
+// fit returns the highest zoom level showing the whole box, or defaultZoom
+// for a single position.
+func fit(area *Box, tileSize int, width int, height int) int {
+	x1, y1 := project(area.North, area.West)
+	x2, y2 := project(area.South, area.East)
+	if x1 == x2 && y1 == y2 {
+		return defaultZoom
+	}
+	for z := maxZoom; z > 0; z-- {
+		scale := float64(tileSize) * math.Exp2(float64(z))
+		if (x2-x1)*scale <= float64(width-2*padding) && (y2-y1)*scale <= float64(height-2*padding) {
+			return z
+		}
+	}
+	return 0
+}
+
+// project converts a position into Web Mercator coordinates from 0 to 1,
+// with the origin in the north-west.
+func project(lat float64, lon float64) (float64, float64) {
+	lat = math.Max(-maxLatitude, math.Min(maxLatitude, lat))
+	sin := math.Sin(lat * math.Pi / 180)
+	x := (lon + 180) / 360
+	y := 0.5 - math.Log((1+sin)/(1-sin))/(4*math.Pi)
+	return x, y
+}
+
+// floorDiv divides rounding towards negative infinity.
+func floorDiv(a int, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// marker draws a filled circle with a border centered at x, y.
+func marker(img *image.RGBA, x int, y int, fill color.Color) {
+	outer := markerRadius + 2
+	for dy := -outer; dy <= outer; dy++ {
+		for dx := -outer; dx <= outer; dx++ {
+			d := dx*dx + dy*dy
+			switch {
+			case d <= markerRadius*markerRadius:
+				img.Set(x+dx, y+dy, fill)
+			case d <= outer*outer:
+				img.Set(x+dx, y+dy, markerBorder)
+			}
+		}
+	}
+}
+
+// line draws a line from x1, y1 to x2, y2, clipped to the image so segments
+// far outside the view cost nothing.
+func line(img *image.RGBA, x1 float64, y1 float64, x2 float64, y2 float64) {
+	b := img.Bounds().Inset(-lineRadius - 1)
+	t0, t1, ok := clip(x1, y1, x2, y2, float64(b.Min.X), float64(b.Min.Y), float64(b.Max.X), float64(b.Max.Y))
+	if !ok {
+		return
+	}
+	dx, dy := x2-x1, y2-y1
+	x1, y1, dx, dy = x1+t0*dx, y1+t0*dy, (t1-t0)*dx, (t1-t0)*dy
+	steps := int(math.Ceil(math.Max(math.Abs(dx), math.Abs(dy))))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		cx, cy := int(math.Round(x1+t*dx)), int(math.Round(y1+t*dy))
+		for oy := -lineRadius; oy <= lineRadius; oy++ {
+			for ox := -lineRadius; ox <= lineRadius; ox++ {
+				if ox*ox+oy*oy <= lineRadius*lineRadius {
+					img.Set(cx+ox, cy+oy, lineColor)
+				}
+			}
+		}
+	}
+}
+
+// clip returns the part of the segment from x1, y1 to x2, y2 inside the
+// rectangle as the range of its parameter from 0 to 1, with the algorithm of
+// Liang and Barsky, or false if it lies outside.
+func clip(x1 float64, y1 float64, x2 float64, y2 float64, minX float64, minY float64, maxX float64, maxY float64) (float64, float64, bool) {
+	t0, t1 := 0.0, 1.0
+	dx, dy := x2-x1, y2-y1
+	for _, e := range [4][2]float64{{-dx, x1 - minX}, {dx, maxX - x1}, {-dy, y1 - minY}, {dy, maxY - y1}} {
+		p, q := e[0], e[1]
+		if p == 0 {
+			if q < 0 {
+				return 0, 0, false
+			}
+			continue
+		}
+		r := q / p
+		if p < 0 {
+			t0 = math.Max(t0, r)
+		} else {
+			t1 = math.Min(t1, r)
+		}
+	}
+	return t0, t1, t0 <= t1
+}
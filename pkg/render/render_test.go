@@ -0,0 +1,77 @@
+package render
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	tests := []struct {
+		name  string
+		lat   float64
+		lon   float64
+		wantX float64
+		wantY float64
+	}{
+		{"origin", 0, 0, 0.5, 0.5},
+		{"north-west corner", maxLatitude, -180, 0, 0},
+		{"south-east corner", -maxLatitude, 180, 1, 1},
+		{"north pole clamped", 90, 0, 0.5, 0},
+		{"south pole clamped", -90, 0, 0.5, 1},
+		{"east", 0, 90, 0.75, 0.5},
+		{"north", 66.51326044311186, 0, 0.5, 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := project(tt.lat, tt.lon)
+			if math.Abs(x-tt.wantX) > 1e-9 || math.Abs(y-tt.wantY) > 1e-9 {
+				t.Errorf("project() = %v, %v, want %v, %v", x, y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	// tile is the width in degrees of longitude of a tile at zoom 10.
+	tile := 360.0 / 1024
+	tests := []struct {
+		name   string
+		area   *Box
+		width  int
+		height int
+		want   int
+	}{
+		{"single position", &Box{South: 47.6, West: -122.3, North: 47.6, East: -122.3}, 512, 512, defaultZoom},
+		{"whole world", &Box{South: -maxLatitude, West: -180, North: maxLatitude, East: 180}, 512, 512, 0},
+		{"one tile wide", &Box{West: 0, East: tile}, 256 + 2*padding, 512, 10},
+		{"a bit more than one tile wide", &Box{West: 0, East: tile * 1.01}, 256 + 2*padding, 512, 9},
+		{"twice as wide", &Box{West: 0, East: tile}, 512 + 2*padding, 512, 11},
+		{"limited by height", &Box{South: -tile * 0.49, West: 0, North: tile * 0.49, East: tile / 4}, 1024, 256 + 2*padding, 10},
+		{"tiny area", &Box{South: 47.6, West: -122.3, North: 47.6000001, East: -122.3000001}, 512, 512, maxZoom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fit(tt.area, 256, tt.width, tt.height); got != tt.want {
+				t.Errorf("fit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFloorDiv(t *testing.T) {
+	tests := []struct {
+		a, b, want int
+	}{
+		{7, 2, 3},
+		{6, 2, 3},
+		{-7, 2, -4},
+		{-6, 2, -3},
+		{0, 256, 0},
+		{-1, 256, -1},
+	}
+	for _, tt := range tests {
+		if got := floorDiv(tt.a, tt.b); got != tt.want {
+			t.Errorf("floorDiv(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrUnknownKey is returned by Keys.Allow for a missing or unknown key.
+	ErrUnknownKey = errors.New("missing or unknown API key")
+
+	// ErrRateLimited is returned by Keys.Allow when a key exceeds its rate.
+	ErrRateLimited = errors.New("rate limit exceeded")
+
+	// ErrQuotaExceeded is returned by Keys.Allow when a key has used up its
+	// daily quota.
+	ErrQuotaExceeded = errors.New("daily quota exceeded")
+
+	// errInvalidKey is returned when adding a key without a name or secret,
+	// or with a negative limit.
+	errInvalidKey = errors.New("invalid key")
+)
+
+// Key is an API key allowed to call the server. RequestsPerSecond limits
+// the rate of its requests with bursts of up to Burst requests, and
+// DailyQuota the requests per UTC day; zero means no limit. Admin keys may
+// also manage the keys.
+type Key struct {
+	Name              string  `yaml:"name" json:"name"`
+	Key               string  `yaml:"key" json:"key,omitempty"`
+	RequestsPerSecond float64 `yaml:"requestsPerSecond,omitempty" json:"requestsPerSecond,omitempty"`
+	Burst             int     `yaml:"burst,omitempty" json:"burst,omitempty"`
+	DailyQuota        int64   `yaml:"dailyQuota,omitempty" json:"dailyQuota,omitempty"`
+	Admin             bool    `yaml:"admin,omitempty" json:"admin,omitempty"`
+}
+
+// KeyUsage is a key without its secret, with the requests it made today.
+type KeyUsage struct {
+	Key
+	UsedToday int64 `json:"usedToday"`
+}
+
+// Keys are the API keys of a server with their rate limiters and usage.
+// Keys loaded from a file are saved back to it when they are changed.
+type Keys struct {
+	mu   sync.Mutex
+	keys map[string]*keyState
+	path string
+	now  func() time.Time
+}
+
+// keyState is a key with its limiter and the requests of the current day.
+type keyState struct {
+	Key
+
+	limiter *rate.Limiter
+	day     string
+	used    int64
+}
+
+// NewKeys returns the given keys. Names and keys must be unique.
+func NewKeys(keys []Key) (*Keys, error) {
+	k := &Keys{keys: map[string]*keyState{}, now: time.Now}
+	for _, key := range keys {
+		if err := k.add(key); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// LoadKeys reads the keys from a YAML file holding a list of keys. Keys
+// added or removed later are saved back to the file.
+func LoadKeys(path string) (*Keys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []Key
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	k, err := NewKeys(keys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	k.path = path
+	return k, nil
+}
+
+// Allow returns the key matching secret if it may make a request now, and
+// counts the request against its quota.
+func (k *Keys) Allow(secret string) (*Key, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	state := k.find(secret)
+	if state == nil {
+		return nil, ErrUnknownKey
+	}
+	if day := k.now().UTC().Format(time.DateOnly); day != state.day {
+		state.day = day
+		state.used = 0
+	}
+	if state.DailyQuota > 0 && state.used >= state.DailyQuota {
+		return nil, ErrQuotaExceeded
+	}
+	if state.limiter != nil && !state.limiter.AllowN(k.now(), 1) {
+		return nil, ErrRateLimited
+	}
+	state.used++
+	key := state.Key
+	return &key, nil
+}
+
+// find returns the key matching secret, comparing in constant time so the
+// keys cannot be guessed from response times.
+func (k *Keys) find(secret string) *keyState {
+	if secret == "" {
+		return nil
+	}
+	var found *keyState
+	for key, state := range k.keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(secret)) == 1 {
+			found = state
+		}
+	}
+	return found
+}
+
+// HasAdmin reports whether any of the keys is an admin key.
+func (k *Keys) HasAdmin() bool {
+	if k == nil {
+		return false
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, state := range k.keys {
+		if state.Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the keys sorted by name, without their secrets.
+func (k *Keys) List() []KeyUsage {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	day := k.now().UTC().Format(time.DateOnly)
+	usage := make([]KeyUsage, 0, len(k.keys))
+	for _, state := range k.keys {
+		u := KeyUsage{Key: state.Key}
+		u.Key.Key = ""
+		if state.day == day {
+			u.UsedToday = state.used
+		}
+		usage = append(usage, u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Name < usage[j].Name })
+	return usage
+}
+
+// Add adds a key, generating its secret if it has none, and returns it.
+func (k *Keys) Add(key Key) (*Key, error) {
+	if key.Key == "" {
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		key.Key = hex.EncodeToString(secret)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.add(key); err != nil {
+		return nil, err
+	}
+	if err := k.save(); err != nil {
+		delete(k.keys, key.Key)
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Remove removes the named key.
+func (k *Keys) Remove(name string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for secret, state := range k.keys {
+		if state.Name == name {
+			delete(k.keys, secret)
+			if err := k.save(); err != nil {
+				k.keys[secret] = state
+				return err
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no key named %q", errNotFound, name)
+}
+
+// add validates a key and adds it. The caller must hold the lock.
+func (k *Keys) add(key Key) error {
+	switch {
+	case key.Name == "":
+		return fmt.Errorf("%w: no name", errInvalidKey)
+	case key.Key == "":
+		return fmt.Errorf("%w %q: no secret", errInvalidKey, key.Name)
+	case key.RequestsPerSecond < 0 || key.Burst < 0 || key.DailyQuota < 0:
+		return fmt.Errorf("%w %q: negative limit", errInvalidKey, key.Name)
+	}
+	if _, ok := k.keys[key.Key]; ok {
+		return fmt.Errorf("%w: key %q has the secret of another key", errConflict, key.Name)
+	}
+	for _, state := range k.keys {
+		if state.Name == key.Name {
+			return fmt.Errorf("%w: a key named %q exists", errConflict, key.Name)
+		}
+	}
+
+	state := &keyState{Key: key}
+	if key.RequestsPerSecond > 0 {
+		state.limiter = rate.NewLimiter(rate.Limit(key.RequestsPerSecond), max(key.Burst, 1))
+	}
+	k.keys[key.Key] = state
+	return nil
+}
+
+// save writes the keys to their file, if they were loaded from one. The
+// file is replaced atomically, so a crash never leaves it half written. The
+// caller must hold the lock.
+func (k *Keys) save() error {
+	if k.path == "" {
+		return nil
+	}
+	keys := make([]Key, 0, len(k.keys))
+	for _, state := range k.keys {
+		keys = append(keys, state.Key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	data, err := yaml.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(k.path), ".keys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), k.path)
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// call is a request of a key at a time after the start of a test.
+type call struct {
+	secret  string
+	after   time.Duration
+	wantErr error
+}
+
+func TestKeysAllow(t *testing.T) {
+	// 23:00 UTC, so a quota is reset an hour later.
+	start := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		key   Key
+		calls []call
+	}{
+		{"unlimited", Key{Name: "app", Key: "secret"}, []call{
+			{"secret", 0, nil},
+			{"secret", 0, nil},
+			{"secret", 0, nil},
+		}},
+		{"missing key", Key{Name: "app", Key: "secret"}, []call{
+			{"", 0, ErrUnknownKey},
+		}},
+		{"unknown key", Key{Name: "app", Key: "secret"}, []call{
+			{"other", 0, ErrUnknownKey},
+			{"secre", 0, ErrUnknownKey},
+		}},
+		{"rate", Key{Name: "app", Key: "secret", RequestsPerSecond: 1}, []call{
+			{"secret", 0, nil},
+			{"secret", 0, ErrRateLimited},
+			{"secret", 500 * time.Millisecond, ErrRateLimited},
+			{"secret", time.Second, nil},
+		}},
+		{"burst", Key{Name: "app", Key: "secret", RequestsPerSecond: 1, Burst: 2}, []call{
+			{"secret", 0, nil},
+			{"secret", 0, nil},
+			{"secret", 0, ErrRateLimited},
+			{"secret", time.Second, nil},
+		}},
+		{"quota", Key{Name: "app", Key: "secret", DailyQuota: 2}, []call{
+			{"secret", 0, nil},
+			{"secret", 0, nil},
+			{"secret", 0, ErrQuotaExceeded},
+			{"secret", 59 * time.Minute, ErrQuotaExceeded},
+		}},
+		{"quota reset at midnight", Key{Name: "app", Key: "secret", DailyQuota: 1}, []call{
+			{"secret", 0, nil},
+			{"secret", 0, ErrQuotaExceeded},
+			{"secret", time.Hour, nil},
+			{"secret", time.Hour, ErrQuotaExceeded},
+		}},
+		{"rate limited requests leave the quota", Key{Name: "app", Key: "secret", RequestsPerSecond: 1, DailyQuota: 2}, []call{
+			{"secret", 0, nil},
+			{"secret", 0, ErrRateLimited},
+			{"secret", time.Second, nil},
+			{"secret", 2 * time.Second, ErrQuotaExceeded},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := NewKeys([]Key{tt.key})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, c := range tt.calls {
+				keys.now = func() time.Time { return start.Add(c.after) }
+				key, err := keys.Allow(c.secret)
+				if !errors.Is(err, c.wantErr) || (c.wantErr == nil && err != nil) {
+					t.Fatalf("call %d: Allow() error = %v, want %v", i, err, c.wantErr)
+				}
+				if err == nil && key.Name != tt.key.Name {
+					t.Errorf("call %d: Allow() = key %q, want %q", i, key.Name, tt.key.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestNewKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []Key
+		wantErr error
+	}{
+		{"valid", []Key{{Name: "a", Key: "1"}, {Name: "b", Key: "2", RequestsPerSecond: 1, DailyQuota: 10}}, nil},
+		{"no name", []Key{{Key: "1"}}, errInvalidKey},
+		{"no secret", []Key{{Name: "a"}}, errInvalidKey},
+		{"negative limit", []Key{{Name: "a", Key: "1", DailyQuota: -1}}, errInvalidKey},
+		{"duplicate name", []Key{{Name: "a", Key: "1"}, {Name: "a", Key: "2"}}, errConflict},
+		{"duplicate secret", []Key{{Name: "a", Key: "1"}, {Name: "b", Key: "1"}}, errConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeys(tt.keys)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("NewKeys() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	keys := []Key{
+		{Name: "app", Key: "app-secret"},
+		{Name: "admin", Key: "admin-secret", Admin: true},
+		{Name: "limited", Key: "limited-secret", DailyQuota: 1},
+	}
+	tests := []struct {
+		name       string
+		keys       []Key
+		admin      bool
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"no keys", nil, false, "", "", http.StatusOK},
+		{"no keys admin route", nil, true, "Authorization", "Bearer admin-secret", http.StatusNotFound},
+		{"missing key", keys, false, "", "", http.StatusUnauthorized},
+		{"unknown key", keys, false, "X-API-Key", "other", http.StatusUnauthorized},
+		{"bearer token", keys, false, "Authorization", "Bearer app-secret", http.StatusOK},
+		{"api key header", keys, false, "X-API-Key", "app-secret", http.StatusOK},
+		{"other scheme", keys, false, "Authorization", "Basic app-secret", http.StatusUnauthorized},
+		{"admin route", keys, true, "Authorization", "Bearer admin-secret", http.StatusOK},
+		{"admin route without admin key", keys, true, "Authorization", "Bearer app-secret", http.StatusForbidden},
+		{"quota exceeded", keys, false, "X-API-Key", "limited-secret", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{}
+			if tt.keys != nil {
+				k, err := NewKeys(tt.keys)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Use up the quota of the limited key.
+				k.Allow("limited-secret")
+				s.keys = k
+			}
+			handler := s.authorize(route{admin: tt.admin, handler: func(w http.ResponseWriter, r *http.Request) {}})
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			switch w.Code {
+			case http.StatusUnauthorized:
+				if w.Header().Get("WWW-Authenticate") != "Bearer" {
+					t.Errorf("WWW-Authenticate = %q, want Bearer", w.Header().Get("WWW-Authenticate"))
+				}
+			case http.StatusTooManyRequests:
+				if w.Header().Get("Retry-After") == "" {
+					t.Error("no Retry-After header")
+				}
+			}
+		})
+	}
+}
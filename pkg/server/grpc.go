@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/client"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// geocoder serves the searches of a server over gRPC.
+type geocoder struct {
+	client.UnimplementedGeocoderServer
+
+	places placesvc.PlaceIndexer
+}
+
+// NewGRPCServer returns a gRPC server serving the same searches as the REST
+// API, logging every call if a logger is set. If the server has keys, calls
+// must carry one in their authorization metadata as a bearer token, or in
+// their x-api-key metadata.
+func (s *Server) NewGRPCServer() *grpc.Server {
+	var interceptors []grpc.UnaryServerInterceptor
+	if s.log != nil {
+		interceptors = append(interceptors, s.logCall)
+	}
+	if s.keys != nil {
+		interceptors = append(interceptors, s.authorizeCall)
+	}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	client.RegisterGeocoderServer(srv, &geocoder{places: s.places})
+	return srv
+}
+
+// ServeGRPC serves gRPC on addr until ctx is done, then waits for the calls
+// in flight to complete.
+func (s *Server) ServeGRPC(ctx context.Context, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := s.NewGRPCServer()
+	go func() {
+		<-ctx.Done()
+		srv.GracefulStop()
+	}()
+	return srv.Serve(lis)
+}
+
+// logCall logs a gRPC call like ServeHTTP logs a request.
+func (s *Server) logCall(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	code := status.Code(err)
+	fields := logrus.Fields{
+		"method":   info.FullMethod,
+		"code":     code.String(),
+		"duration": time.Since(start),
+	}
+	if code == codes.Unavailable || code == codes.Internal {
+		fields["error"] = err
+	}
+	s.log.WithFields(fields).Info("call")
+	return resp, err
+}
+
+// authorizeCall checks the key of a gRPC call like authorize checks the key
+// of a request.
+func (s *Server) authorizeCall(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var key string
+	if values := md.Get("authorization"); len(values) > 0 {
+		key, _ = strings.CutPrefix(values[0], "Bearer ")
+	} else if values := md.Get("x-api-key"); len(values) > 0 {
+		key = values[0]
+	}
+	if _, err := s.keys.Allow(strings.TrimSpace(key)); err != nil {
+		return nil, grpcError(err)
+	}
+	return handler(ctx, req)
+}
+
+func (g *geocoder) Geocode(ctx context.Context, req *client.SearchRequest) (*client.SearchResponse, error) {
+	search, err := searchRequest(req).Search()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	out, err := g.places.SearchPlaceIndexForText(ctx, search)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	summary, results := placesvc.NewTextResults(out)
+	return &client.SearchResponse{Summary: pbSummary(summary), Results: pbResults(results)}, nil
+}
+
+func (g *geocoder) Reverse(ctx context.Context, req *client.ReverseRequest) (*client.SearchResponse, error) {
+	search := &ReverseRequest{
+		Position:   latLon(req.GetPosition()),
+		Language:   req.GetLanguage(),
+		MaxResults: req.GetMaxResults(),
+	}
+	out, err := g.places.SearchPlaceIndexForPosition(ctx, search.Search())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	summary, results := placesvc.NewPositionResults(out)
+	return &client.SearchResponse{Summary: pbSummary(summary), Results: pbResults(results)}, nil
+}
+
+func (g *geocoder) Suggest(ctx context.Context, req *client.SearchRequest) (*client.SuggestResponse, error) {
+	search, err := searchRequest(req).Search()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	out, err := g.places.SearchPlaceIndexForSuggestions(ctx, search)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	summary, suggestions := placesvc.NewSuggestionResults(out)
+	resp := &client.SuggestResponse{Summary: pbSummary(summary)}
+	for _, s := range suggestions {
+		resp.Results = append(resp.Results, &client.Suggestion{
+			Text:                   s.Text,
+			PlaceId:                s.PlaceID,
+			Categories:             s.Categories,
+			SupplementalCategories: s.SupplementalCategories,
+		})
+	}
+	return resp, nil
+}
+
+func (g *geocoder) GetPlace(ctx context.Context, req *client.GetPlaceRequest) (*client.PlaceResponse, error) {
+	out, err := g.places.GetPlace(ctx, req.GetPlaceId(), nil)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &client.PlaceResponse{PlaceId: req.GetPlaceId(), Place: pbPlace(placesvc.NewPlace(out.Place))}, nil
+}
+
+// grpcError converts an error into the gRPC status matching the HTTP status
+// of the REST API.
+func grpcError(err error) error {
+	code := codes.Internal
+	switch statusOf(err) {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case statusClientClosedRequest:
+		code = codes.Canceled
+	case http.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	case http.StatusBadGateway:
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
+
+// searchRequest converts a gRPC search request into its REST equivalent.
+func searchRequest(req *client.SearchRequest) *SearchRequest {
+	return &SearchRequest{
+		Text:       req.GetText(),
+		Bias:       latLon(req.GetBias()),
+		BBox:       req.GetBbox(),
+		Categories: req.GetCategories(),
+		Countries:  req.GetCountries(),
+		Language:   req.GetLanguage(),
+		MaxResults: req.GetMaxResults(),
+	}
+}
+
+func latLon(p *client.LatLon) *placesvc.LatLon {
+	if p == nil {
+		return nil
+	}
+	return &placesvc.LatLon{Latitude: p.GetLatitude(), Longitude: p.GetLongitude()}
+}
+
+func pbLatLon(p *placesvc.LatLon) *client.LatLon {
+	if p == nil {
+		return nil
+	}
+	return &client.LatLon{Latitude: p.Latitude, Longitude: p.Longitude}
+}
+
+func pbSummary(s *placesvc.SearchSummary) *client.SearchSummary {
+	if s == nil {
+		return nil
+	}
+	ret := &client.SearchSummary{
+		DataSource:       s.DataSource,
+		Language:         s.Language,
+		MaxResults:       s.MaxResults,
+		Text:             s.Text,
+		Position:         pbLatLon(s.Position),
+		BiasPosition:     pbLatLon(s.BiasPosition),
+		FilterCategories: s.FilterCategories,
+		FilterCountries:  s.FilterCountries,
+		ResultBbox:       s.ResultBBox,
+	}
+	if b := s.FilterBBox; b != nil {
+		ret.FilterBbox = []float64{b.X1, b.Y1, b.X2, b.Y2}
+	}
+	return ret
+}
+
+func pbResults(results []placesvc.SearchResult) []*client.SearchResult {
+	ret := make([]*client.SearchResult, 0, len(results))
+	for _, r := range results {
+		ret = append(ret, &client.SearchResult{
+			PlaceId:   r.PlaceID,
+			Place:     pbPlace(r.Place),
+			Distance:  r.Distance,
+			Relevance: r.Relevance,
+		})
+	}
+	return ret
+}
+
+func pbPlace(p *placesvc.Place) *client.Place {
+	if p == nil {
+		return nil
+	}
+	ret := &client.Place{
+		Label:                  p.Label,
+		Point:                  p.Point,
+		AddressNumber:          p.AddressNumber,
+		UnitType:               p.UnitType,
+		UnitNumber:             p.UnitNumber,
+		Street:                 p.Street,
+		Neighborhood:           p.Neighborhood,
+		SubMunicipality:        p.SubMunicipality,
+		Municipality:           p.Municipality,
+		SubRegion:              p.SubRegion,
+		Region:                 p.Region,
+		PostalCode:             p.PostalCode,
+		Country:                p.Country,
+		Categories:             p.Categories,
+		SupplementalCategories: p.SupplementalCategories,
+		Interpolated:           p.Interpolated,
+	}
+	if tz := p.TimeZone; tz != nil {
+		ret.TimeZone = &client.TimeZone{Name: tz.Name, Offset: tz.Offset}
+	}
+	return ret
+}
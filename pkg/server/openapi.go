@@ -0,0 +1,321 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/geoevents"
+)
+
+// route is an endpoint of the REST API. The routes register the handlers
+// and describe them in the OpenAPI document, so the document cannot drift
+// from what is served.
+type route struct {
+	method      string
+	path        string
+	summary     string
+	description string
+	request     any
+	response    any
+	status      int
+	errors      []int
+	admin       bool
+	handler     http.HandlerFunc
+}
+
+// routes lists the endpoints of the REST API. request and response are zero
+// values of the body types.
+func (s *Server) routes() []route {
+	searchErrors := []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusGatewayTimeout}
+	return []route{
+		{
+			method:      http.MethodPost,
+			path:        "/v1/geocode",
+			summary:     "Geocode free-form text",
+			description: "Geocodes free-form text, such as an address, name, city, or region.",
+			request:     SearchRequest{},
+			response:    SearchResponse{},
+			errors:      searchErrors,
+			handler:     s.geocode,
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/v1/reverse",
+			summary:     "Reverse geocode a position",
+			description: "Finds the places nearest to a position.",
+			request:     ReverseRequest{},
+			response:    SearchResponse{},
+			errors:      searchErrors,
+			handler:     s.reverse,
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/v1/suggest",
+			summary:     "Suggest places",
+			description: "Suggests addresses and points of interest for partial or misspelled free-form text.",
+			request:     SearchRequest{},
+			response:    SuggestResponse{},
+			errors:      searchErrors,
+			handler:     s.suggest,
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/v1/place/{id}",
+			summary:     "Get a place",
+			description: "Gets a place by the place ID returned by a search, in the language of the optional language query parameter.",
+			response:    PlaceResponse{},
+			errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusGatewayTimeout},
+			handler:     s.place,
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/v1/events",
+			summary:     "Forward an event to the webhooks",
+			description: "Accepts a geofence or device position event as delivered by an EventBridge API destination, and posts it to the webhooks of the server in the background. Needs an admin key, which the connection of the API destination sends as API key. Answers 404 if the server has no webhooks.",
+			request:     geoevents.EventBridgeEvent{},
+			status:      http.StatusAccepted,
+			errors:      []int{http.StatusBadRequest, http.StatusNotFound},
+			admin:       true,
+			handler:     s.event,
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/v1/keys",
+			summary:     "List API keys",
+			description: "Lists the API keys without their secrets, with the requests each made today. Needs an admin key.",
+			response:    KeysResponse{},
+			admin:       true,
+			handler:     s.listKeys,
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/v1/keys",
+			summary:     "Add an API key",
+			description: "Adds an API key, generating its secret if none is given, and returns it with the secret. Needs an admin key.",
+			request:     Key{},
+			response:    Key{},
+			status:      http.StatusCreated,
+			errors:      []int{http.StatusBadRequest, http.StatusConflict},
+			admin:       true,
+			handler:     s.addKey,
+		},
+		{
+			method:      http.MethodDelete,
+			path:        "/v1/keys/{name}",
+			summary:     "Remove an API key",
+			description: "Removes the named API key. Needs an admin key.",
+			status:      http.StatusNoContent,
+			errors:      []int{http.StatusNotFound},
+			admin:       true,
+			handler:     s.removeKey,
+		},
+	}
+}
+
+// openAPI returns the OpenAPI 3 document describing the routes.
+func openAPI(routes []route) map[string]any {
+	schemas := map[string]any{}
+	paths := map[string]any{}
+	for _, r := range routes {
+		status := r.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		responses := map[string]any{
+			strconv.Itoa(status): map[string]any{"description": http.StatusText(status)},
+		}
+		if r.response != nil {
+			responses[strconv.Itoa(status)] = jsonContent(http.StatusText(status), schemaRef(reflect.TypeOf(r.response), schemas))
+		}
+		// Every route answers 401 and 429 once the server has keys, and 500
+		// on unexpected errors.
+		errs := append([]int{http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError}, r.errors...)
+		if r.admin {
+			errs = append(errs, http.StatusForbidden)
+		}
+		for _, status := range errs {
+			responses[strconv.Itoa(status)] = jsonContent(http.StatusText(status), schemaRef(reflect.TypeOf(ErrorResponse{}), schemas))
+		}
+		op := map[string]any{
+			"summary":     r.summary,
+			"description": r.description,
+			"responses":   responses,
+		}
+		if r.request != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaRef(reflect.TypeOf(r.request), schemas)},
+				},
+			}
+		}
+		if params := pathParams(r.path); len(params) > 0 {
+			var parameters []any
+			for _, name := range params {
+				parameters = append(parameters, map[string]any{
+					"name":     name,
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]any{"type": "string"},
+				})
+			}
+			op["parameters"] = parameters
+		}
+
+		item, ok := paths[r.path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[r.path] = item
+		}
+		item[strings.ToLower(r.method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "goawsloc",
+			"description": "Searches of an Amazon Location Service place index. Once the server has API keys, every request needs one as bearer token or in an X-API-Key header; admin keys may also manage the keys and post events. Failed requests are answered with an ErrorResponse.",
+			"version":     "v1",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+				"apiKey": map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+		"security": []any{
+			map[string]any{"bearer": []string{}},
+			map[string]any{"apiKey": []string{}},
+		},
+	}
+}
+
+// OpenAPI returns the OpenAPI 3 document of the REST API.
+func OpenAPI() map[string]any {
+	return openAPI((&Server{}).routes())
+}
+
+func (s *Server) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.document)
+}
+
+// jsonContent describes a JSON response.
+func jsonContent(description string, schema map[string]any) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": schema},
+		},
+	}
+}
+
+// pathParams returns the names of the {parameters} of a path.
+func pathParams(path string) []string {
+	var params []string
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			params = append(params, strings.Trim(part, "{}"))
+		}
+	}
+	return params
+}
+
+// schemaRef returns the schema of a type, adding the schemas of structs to
+// schemas and referring to them by name.
+func schemaRef(t reflect.Type, schemas map[string]any) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float64:
+		return map[string]any{"type": "number", "format": "double"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	case reflect.Struct:
+		if _, ok := schemas[t.Name()]; !ok {
+			// Register the name first, so recursive types terminate.
+			schemas[t.Name()] = nil
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]any{}
+}
+
+// structSchema returns the object schema of a struct, named as encoding/json
+// names its fields.
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	addFields(t, true, properties, &required, schemas)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the JSON fields of the struct type to properties. Like
+// encoding/json, the fields of embedded structs without a JSON name are
+// flattened into the outer object, and fields of the outer struct win over
+// embedded ones of the same name. Fields of embedded pointers may be missing,
+// so they are only required if the pointer is not.
+func addFields(t reflect.Type, isRequired bool, properties map[string]any, required *[]string, schemas map[string]any) {
+	var embedded []reflect.StructField
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, f)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := properties[name]; ok {
+			continue
+		}
+		properties[name] = schemaRef(f.Type, schemas)
+		if isRequired && !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+	for _, f := range embedded {
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			addFields(ft.Elem(), false, properties, required, schemas)
+			continue
+		}
+		addFields(ft, isRequired, properties, required, schemas)
+	}
+}
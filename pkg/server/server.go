@@ -0,0 +1,514 @@
+// Package server serves the searches of a place index as a JSON REST API, so
+// internal apps can call one proxy holding the AWS credentials instead of
+// embedding them everywhere.
+//
+// The API has these endpoints:
+//
+//	POST   /v1/geocode       geocode free-form text, a SearchRequest
+//	POST   /v1/reverse       reverse geocode a position, a ReverseRequest
+//	POST   /v1/suggest       suggest places for partial text, a SearchRequest
+//	GET    /v1/place/{id}    get a place by the ID returned by a search, in
+//	                         the language of the language query parameter
+//	POST   /v1/events        forward an EventBridge geofence or device
+//	                         position event to the webhooks set with
+//	                         SetWebhooks; needs an admin key
+//	GET    /v1/keys          list the keys with their usage; admin only
+//	POST   /v1/keys          add a Key, generating its secret; admin only
+//	DELETE /v1/keys/{name}   remove a key; admin only
+//
+// Searches are answered with a SearchResponse, SuggestResponse or
+// PlaceResponse, and failed requests with an ErrorResponse of the form
+// {"error": "..."}. Invalid requests are answered with 400, throttled
+// searches with 429, and failures of AWS with 502 or 504.
+//
+// Without SetKeys the API is open. With keys, every request must carry one
+// as a bearer token or in an X-API-Key header, and is answered with 401
+// without one and with 429 and a Retry-After header once the rate or daily
+// quota of its key is exceeded. Only admin keys may call the admin
+// endpoints.
+//
+// GET /openapi.json returns the OpenAPI 3 document of the API, generated from
+// the same route table that registers the handlers, so clients in other
+// languages can be generated from it. GET /metrics serves the metrics set
+// with SetMetrics.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/geoevents"
+	"github.com/rmrfslashbin/goawsloc/pkg/webhook"
+
+	"github.com/aws/smithy-go"
+	"github.com/sirupsen/logrus"
+)
+
+// maxRequestBytes is the largest request body accepted.
+const maxRequestBytes = 1 << 20
+
+var (
+	// errInvalidRequest, errNotFound, errConflict and errForbidden are
+	// answered with 400, 404, 409 and 403.
+	errInvalidRequest = errors.New("invalid request")
+	errNotFound       = errors.New("not found")
+	errConflict       = errors.New("conflict")
+	errForbidden      = errors.New("forbidden")
+)
+
+// statusClientClosedRequest answers requests cancelled by the client, as
+// there is no standard status for them.
+const statusClientClosedRequest = 499
+
+// SearchRequest is the body of geocode and suggest requests. Bias and BBox
+// are mutually exclusive; BBox is west, south, east, north. Countries are
+// ISO 3166 codes or English names.
+type SearchRequest struct {
+	Text       string           `json:"text"`
+	Bias       *placesvc.LatLon `json:"bias,omitempty"`
+	BBox       []float64        `json:"bbox,omitempty"`
+	Categories []string         `json:"categories,omitempty"`
+	Countries  []string         `json:"countries,omitempty"`
+	Language   string           `json:"language,omitempty"`
+	MaxResults int32            `json:"maxResults,omitempty"`
+}
+
+// ReverseRequest is the body of reverse requests.
+type ReverseRequest struct {
+	Position   *placesvc.LatLon `json:"position"`
+	Language   string           `json:"language,omitempty"`
+	MaxResults int32            `json:"maxResults,omitempty"`
+}
+
+// SearchResponse answers geocode and reverse requests.
+type SearchResponse struct {
+	Summary *placesvc.SearchSummary `json:"summary,omitempty"`
+	Results []placesvc.SearchResult `json:"results"`
+}
+
+// SuggestResponse answers suggest requests.
+type SuggestResponse struct {
+	Summary *placesvc.SearchSummary `json:"summary,omitempty"`
+	Results []placesvc.Suggestion   `json:"results"`
+}
+
+// PlaceResponse answers place requests.
+type PlaceResponse struct {
+	PlaceID string          `json:"placeId"`
+	Place   *placesvc.Place `json:"place"`
+}
+
+// KeysResponse answers key list requests.
+type KeysResponse struct {
+	Keys []KeyUsage `json:"keys"`
+}
+
+// ErrorResponse answers failed requests.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type Option func(server *Server)
+
+// Server is an http.Handler serving the searches of one place index.
+type Server struct {
+	places   placesvc.PlaceIndexer
+	keys     *Keys
+	metrics  http.Handler
+	webhooks *webhook.Dispatcher
+	log      *logrus.Logger
+	mux      *http.ServeMux
+	document map[string]any
+}
+
+// New returns a server running searches with places.
+func New(places placesvc.PlaceIndexer, opts ...func(*Server)) *Server {
+	s := &Server{places: places, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	routes := s.routes()
+	for _, r := range routes {
+		s.mux.HandleFunc(r.method+" "+r.path, s.authorize(r))
+	}
+	s.document = openAPI(routes)
+	s.mux.HandleFunc("GET /openapi.json", s.serveOpenAPI)
+	if s.metrics != nil {
+		s.mux.Handle("GET /metrics", s.metrics)
+	}
+	return s
+}
+
+// SetLogger logs every request at info level and failed searches at error
+// level.
+func SetLogger(log *logrus.Logger) Option {
+	return func(server *Server) {
+		server.log = log
+	}
+}
+
+// SetMetrics serves the metrics handler at /metrics.
+func SetMetrics(handler http.Handler) Option {
+	return func(server *Server) {
+		server.metrics = handler
+	}
+}
+
+// SetKeys requires every request but those of the OpenAPI document and the
+// metrics to carry one of the keys, as a bearer token or in an X-API-Key
+// header, and enables the key management endpoints for admin keys. Without
+// keys the API is open and keys cannot be managed.
+func SetKeys(keys *Keys) Option {
+	return func(server *Server) {
+		server.keys = keys
+	}
+}
+
+// SetWebhooks forwards the events posted to /v1/events to the webhooks, so
+// an EventBridge API destination can deliver geofence and device position
+// events to the server. Events must carry an admin key, as the webhooks sign
+// whatever is posted, so they also need SetKeys. Without webhooks or keys
+// /v1/events answers 404.
+func SetWebhooks(webhooks *webhook.Dispatcher) Option {
+	return func(server *Server) {
+		server.webhooks = webhooks
+	}
+}
+
+// ServeHTTP answers a request, logging it if a logger is set.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.log == nil {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(rec, r)
+	s.log.WithFields(logrus.Fields{
+		"method":   r.Method,
+		"path":     r.URL.Path,
+		"status":   rec.status,
+		"duration": time.Since(start),
+	}).Info("request")
+}
+
+// ListenAndServe serves on addr until ctx is done, then waits up to ten
+// seconds for the requests in flight to complete.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	done := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		done <- srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-done
+}
+
+func (s *Server) geocode(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	if err := decode(r, &req); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	search, err := req.Search()
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	out, err := s.places.SearchPlaceIndexForText(r.Context(), search)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	summary, results := placesvc.NewTextResults(out)
+	writeJSON(w, http.StatusOK, &SearchResponse{Summary: summary, Results: results})
+}
+
+func (s *Server) reverse(w http.ResponseWriter, r *http.Request) {
+	var req ReverseRequest
+	if err := decode(r, &req); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	out, err := s.places.SearchPlaceIndexForPosition(r.Context(), req.Search())
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	summary, results := placesvc.NewPositionResults(out)
+	writeJSON(w, http.StatusOK, &SearchResponse{Summary: summary, Results: results})
+}
+
+func (s *Server) suggest(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	if err := decode(r, &req); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	search, err := req.Search()
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	out, err := s.places.SearchPlaceIndexForSuggestions(r.Context(), search)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	summary, results := placesvc.NewSuggestionResults(out)
+	writeJSON(w, http.StatusOK, &SuggestResponse{Summary: summary, Results: results})
+}
+
+func (s *Server) listKeys(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, &KeysResponse{Keys: s.keys.List()})
+}
+
+func (s *Server) addKey(w http.ResponseWriter, r *http.Request) {
+	var req Key
+	if err := decode(r, &req); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	key, err := s.keys.Add(req)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if s.log != nil {
+		s.log.WithFields(logrus.Fields{
+			"name": key.Name,
+		}).Info("Added API key")
+	}
+	writeJSON(w, http.StatusCreated, key)
+}
+
+func (s *Server) removeKey(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := s.keys.Remove(name); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if s.log != nil {
+		s.log.WithFields(logrus.Fields{
+			"name": name,
+		}).Info("Removed API key")
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// event accepts an EventBridge event and forwards it to the webhooks in the
+// background, so slow webhooks do not hold up the EventBridge delivery.
+func (s *Server) event(w http.ResponseWriter, r *http.Request) {
+	if s.webhooks == nil {
+		s.fail(w, r, fmt.Errorf("%w: webhooks are not enabled", errNotFound))
+		return
+	}
+	// Unknown fields are allowed, as EventBridge may add some.
+	var req geoevents.EventBridgeEvent
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		s.fail(w, r, fmt.Errorf("%w body: %w", errInvalidRequest, err))
+		return
+	}
+	event := req.Event()
+	if event == nil {
+		s.fail(w, r, fmt.Errorf("%w: %q event of %q is no geofence or device position event", errInvalidRequest, req.DetailType, req.Source))
+		return
+	}
+
+	ctx := context.WithoutCancel(r.Context())
+	go func() {
+		if err := s.webhooks.Send(ctx, event.EventType, event.ID, event); err != nil && s.log != nil {
+			s.log.WithFields(logrus.Fields{
+				"error": err,
+				"id":    event.ID,
+			}).Error("error forwarding event")
+		}
+	}()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) place(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	out, err := s.places.GetPlace(r.Context(), id, language(r.URL.Query().Get("language")))
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &PlaceResponse{PlaceID: id, Place: placesvc.NewPlace(out.Place)})
+}
+
+// Search converts the request into a search.
+func (req *SearchRequest) Search() (*placesvc.SuggestionSearch, error) {
+	search := &placesvc.SuggestionSearch{
+		Text:             &req.Text,
+		BiasPosition:     req.Bias,
+		FilterCategories: req.Categories,
+		Language:         language(req.Language),
+		MaxResults:       req.MaxResults,
+	}
+	if req.BBox != nil {
+		if len(req.BBox) != 4 {
+			return nil, fmt.Errorf("%w: bbox has %d values, must be west, south, east, north", errInvalidRequest, len(req.BBox))
+		}
+		search.FilterBBox = &placesvc.Box{X1: req.BBox[0], Y1: req.BBox[1], X2: req.BBox[2], Y2: req.BBox[3]}
+	}
+	for _, country := range req.Countries {
+		code, err := placesvc.CountryCode(country)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidRequest, err)
+		}
+		search.FilterCountries = append(search.FilterCountries, code)
+	}
+	return search, nil
+}
+
+// Search converts the request into a search.
+func (req *ReverseRequest) Search() *placesvc.PositionSearch {
+	return &placesvc.PositionSearch{
+		Position:   req.Position,
+		Language:   language(req.Language),
+		MaxResults: req.MaxResults,
+	}
+}
+
+// decode decodes the JSON request body into v, rejecting unknown fields so
+// misspelled parameters are not silently ignored.
+func decode(r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w body: %w", errInvalidRequest, err)
+	}
+	return nil
+}
+
+// language returns the language of a request, or nil to use the default.
+func language(language string) *string {
+	if language == "" {
+		return nil
+	}
+	return &language
+}
+
+// fail answers the request with the status matching err.
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, err error) {
+	status := statusOf(err)
+	if s.log != nil && status >= http.StatusInternalServerError {
+		s.log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  r.URL.Path,
+		}).Error("error searching")
+	}
+	writeJSON(w, status, &ErrorResponse{Error: err.Error()})
+}
+
+// statusOf returns the HTTP status of a failed search. Invalid requests are
+// answered with 400; errors of AWS itself are gateway errors, except for
+// those the client can act on. Any other error is an internal error.
+func statusOf(err error) int {
+	var apiErr smithy.APIError
+	var opErr *smithy.OperationError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, errInvalidRequest), errors.Is(err, errInvalidKey), errors.Is(err, placesvc.ErrInvalidSearch), errors.Is(err, placesvc.ErrInvalidCoordinates):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnknownKey):
+		return http.StatusUnauthorized
+	case errors.Is(err, errForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, errNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errConflict):
+		return http.StatusConflict
+	case errors.Is(err, placesvc.ErrIndexNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, placesvc.ErrThrottled):
+		return http.StatusTooManyRequests
+	case errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationException":
+		return http.StatusBadRequest
+	case errors.As(err, &opErr), errors.Is(err, placesvc.ErrAccessDenied):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// authorize returns the handler of a route, checking the key of each request
+// first if the server has keys.
+func (s *Server) authorize(rt route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.keys == nil {
+			if rt.admin {
+				s.fail(w, r, fmt.Errorf("%w: API keys are not enabled", errNotFound))
+				return
+			}
+			rt.handler(w, r)
+			return
+		}
+		key, err := s.keys.Allow(requestKey(r))
+		switch {
+		case errors.Is(err, ErrUnknownKey):
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		case errors.Is(err, ErrRateLimited):
+			w.Header().Set("Retry-After", "1")
+		case errors.Is(err, ErrQuotaExceeded):
+			tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+			w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(tomorrow).Seconds())+1))
+		case err == nil && rt.admin && !key.Admin:
+			err = fmt.Errorf("%w: key %q is not an admin key", errForbidden, key.Name)
+		}
+		if err != nil {
+			s.fail(w, r, err)
+			return
+		}
+		rt.handler(w, r)
+	}
+}
+
+// requestKey returns the API key of a request, given as a bearer token or
+// in an X-API-Key header.
+func requestKey(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// statusRecorder records the status of a response for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
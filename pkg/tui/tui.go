@@ -0,0 +1,554 @@
+// Package tui is an interactive terminal browser over the place searches of
+// a place index: suggestions are listed live while a query is typed, a text
+// search runs on Enter, and the full details of a place can be viewed and its
+// coordinates or place ID copied to the clipboard. Pick instead only lists
+// suggestions and returns the one chosen.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+
+	"github.com/atotto/clipboard"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Searcher is the subset of the place index service the browser uses.
+// placesvc.PlaceIndexer implements it.
+type Searcher interface {
+	GetPlace(ctx context.Context, placeID string, language *string) (*location.GetPlaceOutput, error)
+	SearchPlaceIndexForSuggestions(ctx context.Context, search *placesvc.SuggestionSearch) (*location.SearchPlaceIndexForSuggestionsOutput, error)
+	SearchPlaceIndexForText(ctx context.Context, search *placesvc.SuggestionSearch) (*location.SearchPlaceIndexForTextOutput, error)
+}
+
+var _ Searcher = placesvc.PlaceIndexer(nil)
+
+type Option func(browser *Browser)
+
+// Browser is the interactive search browser.
+type Browser struct {
+	searcher Searcher
+	search   placesvc.SuggestionSearch
+	title    string
+	query    string
+	output   io.Writer
+	debounce time.Duration
+	timeout  time.Duration
+	fields   func(place *placesvc.Place) [][2]string
+	copy     func(text string) error
+}
+
+// New returns a browser searching with the searcher.
+func New(searcher Searcher, opts ...func(*Browser)) (*Browser, error) {
+	b := &Browser{
+		searcher: searcher,
+		title:    "Search",
+		output:   os.Stdout,
+		debounce: 300 * time.Millisecond,
+		timeout:  10 * time.Second,
+		fields:   defaultFields,
+		copy:     copyText,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	if searcher == nil {
+		return nil, errors.New("no searcher")
+	}
+	if b.debounce < 0 {
+		return nil, errors.New("debounce must not be negative")
+	}
+	if b.timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+	return b, nil
+}
+
+// SetSearch sets the filters, bias and language of every search. Its Text is
+// replaced by the typed query.
+func SetSearch(search placesvc.SuggestionSearch) Option {
+	return func(browser *Browser) {
+		browser.search = search
+	}
+}
+
+// SetTitle sets the title shown above the query, e.g. the index name.
+// Defaults to "Search".
+func SetTitle(title string) Option {
+	return func(browser *Browser) {
+		browser.title = title
+	}
+}
+
+// SetQuery sets the query the browser starts with.
+func SetQuery(query string) Option {
+	return func(browser *Browser) {
+		browser.query = query
+	}
+}
+
+// SetOutput sets where the browser is drawn, e.g. stderr to keep stdout for
+// the result of Pick. It must be a terminal. Defaults to stdout.
+func SetOutput(output io.Writer) Option {
+	return func(browser *Browser) {
+		browser.output = output
+	}
+}
+
+// SetDebounce sets how long typing must pause before suggestions are
+// searched. Defaults to 300 milliseconds.
+func SetDebounce(debounce time.Duration) Option {
+	return func(browser *Browser) {
+		browser.debounce = debounce
+	}
+}
+
+// SetTimeout sets the timeout of each search. Defaults to 10 seconds.
+func SetTimeout(timeout time.Duration) Option {
+	return func(browser *Browser) {
+		browser.timeout = timeout
+	}
+}
+
+// SetFields sets the function returning the name, value pairs shown in the
+// details of a place. Defaults to its label, address and position.
+func SetFields(fields func(place *placesvc.Place) [][2]string) Option {
+	return func(browser *Browser) {
+		browser.fields = fields
+	}
+}
+
+// SetClipboard sets the function copying text. Defaults to the system
+// clipboard, falling back to the OSC 52 escape sequence of the terminal,
+// which also works over SSH.
+func SetClipboard(copy func(text string) error) Option {
+	return func(browser *Browser) {
+		browser.copy = copy
+	}
+}
+
+// Run runs the browser on the terminal until the user quits or ctx is done.
+func (b *Browser) Run(ctx context.Context) error {
+	_, err := b.run(ctx, newModel(ctx, b, false))
+	return err
+}
+
+// Pick lists the suggestions for the query as it is typed and returns the
+// one chosen with Enter, or the first if none is selected. It returns nil if
+// the user quits or ctx is done without choosing.
+func (b *Browser) Pick(ctx context.Context) (*placesvc.Suggestion, error) {
+	m, err := b.run(ctx, newModel(ctx, b, true))
+	if err != nil || m.picked == nil {
+		return nil, err
+	}
+	return &placesvc.Suggestion{
+		Text:       m.picked.label,
+		PlaceID:    m.picked.placeID,
+		Categories: m.picked.categories,
+	}, nil
+}
+
+// run runs the model on the terminal and returns its final state.
+func (b *Browser) run(ctx context.Context, m model) (model, error) {
+	final, err := tea.NewProgram(m, tea.WithContext(ctx), tea.WithAltScreen(), tea.WithOutput(b.output)).Run()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	return final.(model), nil
+}
+
+// defaultFields returns the label, address and position of a place.
+func defaultFields(place *placesvc.Place) [][2]string {
+	fields := [][2]string{{"Label", place.Label}, {"Address", place.FormattedAddress()}}
+	if position := coordinates(place); position != "" {
+		fields = append(fields, [2]string{"Position", position})
+	}
+	return fields
+}
+
+// copyText copies text to the system clipboard, or with OSC 52 if there is
+// none, such as over SSH.
+func copyText(text string) error {
+	if err := clipboard.WriteAll(text); err != nil {
+		termenv.Copy(text)
+	}
+	return nil
+}
+
+// coordinates returns the position of a place as lat,lon, or "" if it has
+// none.
+func coordinates(place *placesvc.Place) string {
+	if place == nil {
+		return ""
+	}
+	position := place.Coordinates()
+	if position == nil {
+		return ""
+	}
+	return fmt.Sprintf("%g,%g", position.Latitude, position.Longitude)
+}
+
+// item is a suggestion or text search result in the list. Suggestions have
+// no place until their details are looked up.
+type item struct {
+	label      string
+	placeID    string
+	place      *placesvc.Place
+	categories []string
+}
+
+// Messages of the model.
+type (
+	// debounceMsg fires when typing paused; seq is the query it was set for.
+	debounceMsg struct{ seq int }
+	// itemsMsg carries the results of the search of query seq.
+	itemsMsg struct {
+		seq     int
+		heading string
+		items   []item
+		err     error
+	}
+	// placeMsg carries the looked up place of a suggestion.
+	placeMsg struct {
+		item item
+		err  error
+	}
+)
+
+// Styles of the views.
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	headingStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("5"))
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+// model is the state of the browser. cursor is the selected item, or -1 while
+// the query is selected. Every change of the query increments seq, so results
+// of outdated searches are dropped. In pick mode Enter chooses a suggestion
+// and quits instead.
+type model struct {
+	ctx     context.Context
+	browser *Browser
+	pick    bool
+	picked  *item
+
+	input   textinput.Model
+	heading string
+	items   []item
+	cursor  int
+	seq     int
+	loading bool
+	status  string
+	err     error
+	detail  *item
+}
+
+func newModel(ctx context.Context, b *Browser, pick bool) model {
+	input := textinput.New()
+	input.Placeholder = "type an address or place"
+	input.SetValue(b.query)
+	input.Focus()
+	return model{ctx: ctx, browser: b, pick: pick, input: input, cursor: -1}
+}
+
+func (m model) Init() tea.Cmd {
+	if strings.TrimSpace(m.input.Value()) == "" {
+		return textinput.Blink
+	}
+	return tea.Batch(textinput.Blink, func() tea.Msg {
+		return debounceMsg{seq: m.seq}
+	})
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.input.Width = max(msg.Width-4, 10)
+		return m, nil
+
+	case debounceMsg:
+		if msg.seq != m.seq {
+			return m, nil
+		}
+		m.loading = true
+		return m, m.suggest(msg.seq, m.input.Value())
+
+	case itemsMsg:
+		if msg.seq != m.seq {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.heading = msg.heading
+			m.items = msg.items
+			m.cursor = -1
+		}
+		return m, nil
+
+	case placeMsg:
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.detail = &msg.item
+			m.status = ""
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+		if m.detail != nil {
+			return m.updateDetail(msg)
+		}
+		return m.updateSearch(msg)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// updateSearch handles a key in the search view. Arrows move through the
+// list, Enter opens the selected item or text-searches the query, and any
+// other key edits the query.
+func (m model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		if m.cursor >= 0 {
+			m.cursor = -1
+			return m, nil
+		}
+		return m, tea.Quit
+	case tea.KeyUp, tea.KeyCtrlP, tea.KeyShiftTab:
+		m.cursor = max(m.cursor-1, -1)
+		return m, nil
+	case tea.KeyDown, tea.KeyCtrlN, tea.KeyTab:
+		m.cursor = min(m.cursor+1, len(m.items)-1)
+		return m, nil
+	case tea.KeyEnter:
+		if m.pick {
+			if len(m.items) == 0 {
+				return m, nil
+			}
+			m.picked = &m.items[max(m.cursor, 0)]
+			return m, tea.Quit
+		}
+		if m.cursor >= 0 {
+			return m.open(m.items[m.cursor])
+		}
+		query := strings.TrimSpace(m.input.Value())
+		if query == "" {
+			return m, nil
+		}
+		m.seq++
+		m.loading = true
+		return m, m.searchText(m.seq, query)
+	}
+
+	before := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() == before {
+		return m, cmd
+	}
+	m.seq++
+	m.cursor = -1
+	m.err = nil
+	if strings.TrimSpace(m.input.Value()) == "" {
+		m.items = nil
+		m.heading = ""
+		m.loading = false
+		return m, cmd
+	}
+	seq := m.seq
+	return m, tea.Batch(cmd, tea.Tick(m.browser.debounce, func(time.Time) tea.Msg {
+		return debounceMsg{seq: seq}
+	}))
+}
+
+// updateDetail handles a key in the details view.
+func (m model) updateDetail(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "backspace", "left":
+		m.detail = nil
+		m.status = ""
+		m.err = nil
+	case "q":
+		return m, tea.Quit
+	case "c":
+		m.copy("coordinates", coordinates(m.detail.place))
+	case "p":
+		m.copy("place ID", m.detail.placeID)
+	}
+	return m, nil
+}
+
+// copy copies the value to the clipboard and reports it in the status line.
+func (m *model) copy(name string, value string) {
+	if value == "" {
+		m.status = fmt.Sprintf("No %s to copy", name)
+		return
+	}
+	if err := m.browser.copy(value); err != nil {
+		m.err = err
+		return
+	}
+	m.status = fmt.Sprintf("Copied %s %s", name, value)
+}
+
+// open shows the details of an item, looking up the place of a suggestion
+// first.
+func (m model) open(it item) (tea.Model, tea.Cmd) {
+	if it.place != nil {
+		m.detail = &it
+		m.status = ""
+		m.err = nil
+		return m, nil
+	}
+	if it.placeID == "" {
+		m.err = errors.New("the suggestion has no place ID, press Enter on the query to search its text")
+		return m, nil
+	}
+	m.loading = true
+	ctx, searcher, timeout, language := m.ctx, m.browser.searcher, m.browser.timeout, m.browser.search.Language
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		out, err := searcher.GetPlace(ctx, it.placeID, language)
+		if err != nil {
+			return placeMsg{err: err}
+		}
+		it.place = placesvc.NewPlace(out.Place)
+		return placeMsg{item: it}
+	}
+}
+
+// suggest searches suggestions for the query.
+func (m model) suggest(seq int, query string) tea.Cmd {
+	ctx, searcher, timeout := m.ctx, m.browser.searcher, m.browser.timeout
+	search := m.browser.search
+	search.Text = &query
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		out, err := searcher.SearchPlaceIndexForSuggestions(ctx, &search)
+		if err != nil {
+			return itemsMsg{seq: seq, err: err}
+		}
+		_, results := placesvc.NewSuggestionResults(out)
+		items := make([]item, 0, len(results))
+		for _, r := range results {
+			items = append(items, item{label: r.Text, placeID: r.PlaceID, categories: r.Categories})
+		}
+		return itemsMsg{seq: seq, heading: "Suggestions", items: items}
+	}
+}
+
+// searchText runs a text search for the query.
+func (m model) searchText(seq int, query string) tea.Cmd {
+	ctx, searcher, timeout := m.ctx, m.browser.searcher, m.browser.timeout
+	search := m.browser.search
+	search.Text = &query
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		out, err := searcher.SearchPlaceIndexForText(ctx, &search)
+		if err != nil {
+			return itemsMsg{seq: seq, err: err}
+		}
+		_, results := placesvc.NewTextResults(out)
+		items := make([]item, 0, len(results))
+		for _, r := range results {
+			items = append(items, item{label: r.Place.Label, placeID: r.PlaceID, place: r.Place, categories: r.Place.Categories})
+		}
+		return itemsMsg{seq: seq, heading: fmt.Sprintf("Results for %q", query), items: items}
+	}
+}
+
+func (m model) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.browser.title) + "\n\n")
+	if m.detail != nil {
+		m.viewDetail(&b)
+	} else {
+		m.viewSearch(&b)
+	}
+
+	b.WriteString("\n")
+	switch {
+	case m.err != nil:
+		b.WriteString(errorStyle.Render("Error: "+m.err.Error()) + "\n")
+	case m.loading:
+		b.WriteString(dimStyle.Render("Searching…") + "\n")
+	case m.status != "":
+		b.WriteString(m.status + "\n")
+	default:
+		b.WriteString("\n")
+	}
+	switch {
+	case m.detail != nil:
+		b.WriteString(dimStyle.Render("c copy coordinates • p copy place ID • esc back • q quit"))
+	case m.pick:
+		b.WriteString(dimStyle.Render("↑/↓ select • enter choose • esc clear or quit"))
+	default:
+		b.WriteString(dimStyle.Render("↑/↓ select • enter search or open • esc clear or quit"))
+	}
+	return b.String()
+}
+
+// viewSearch renders the query and the list of suggestions or results.
+func (m model) viewSearch(b *strings.Builder) {
+	b.WriteString(m.input.View() + "\n\n")
+	if m.heading == "" {
+		return
+	}
+	b.WriteString(headingStyle.Render(fmt.Sprintf("%s (%d)", m.heading, len(m.items))) + "\n")
+	if len(m.items) == 0 {
+		b.WriteString(dimStyle.Render("  nothing found") + "\n")
+	}
+	for i, it := range m.items {
+		line := it.label
+		if len(it.categories) > 0 {
+			line += dimStyle.Render("  " + strings.Join(it.categories, ", "))
+		}
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render("▸ "+it.label) + strings.TrimPrefix(line, it.label) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+}
+
+// viewDetail renders the fields of the opened place.
+func (m model) viewDetail(b *strings.Builder) {
+	fields := m.browser.fields(m.detail.place)
+	if m.detail.placeID != "" {
+		fields = append(fields, [2]string{"Place ID", m.detail.placeID})
+	}
+	width := 0
+	for _, f := range fields {
+		width = max(width, len(f[0]))
+	}
+	for _, f := range fields {
+		b.WriteString(headingStyle.Render(fmt.Sprintf("%-*s", width, f[0])) + "  " + f[1] + "\n")
+	}
+}
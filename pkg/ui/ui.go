@@ -0,0 +1,302 @@
+// Package ui serves a local web page showing search results, geofences,
+// routes and device positions on a MapLibre map, for quick visual QA.
+//
+// The map is drawn from an Amazon Location map resource: its style
+// descriptor, tiles, sprites and glyphs are fetched through the server, so
+// the page needs no AWS credentials or API key. The data shown on top of it
+// comes from layers, functions returning a GeoJSON FeatureCollection for the
+// query parameters of a request.
+package ui
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/geojson"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/smithy-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Names of the layers the page has controls for.
+const (
+	// LayerSearch takes the text to search for as text.
+	LayerSearch = "search"
+	// LayerGeofences takes no parameters.
+	LayerGeofences = "geofences"
+	// LayerRoute takes the departure and destination as from and to, each
+	// as lat,lon, and optionally a travelMode.
+	LayerRoute = "route"
+	// LayerPositions takes no parameters.
+	LayerPositions = "positions"
+)
+
+//go:embed index.html
+var indexHTML []byte
+
+// MapClient is the subset of the map service the server uses.
+type MapClient interface {
+	GetMapGlyphs(ctx context.Context, fontStack string, fontRange string) (*location.GetMapGlyphsOutput, error)
+	GetMapSprites(ctx context.Context, fileName string) (*location.GetMapSpritesOutput, error)
+	GetMapStyleDescriptor(ctx context.Context) (*location.GetMapStyleDescriptorOutput, error)
+	GetMapTile(ctx context.Context, z int, x int, y int) (*location.GetMapTileOutput, error)
+}
+
+// Layer returns the features of a layer for the query parameters of a
+// request. Errors returned before calling AWS are answered as invalid
+// requests.
+type Layer func(ctx context.Context, query url.Values) (*geojson.FeatureCollection, error)
+
+type Option func(ui *UI)
+
+// UI is an http.Handler serving the page, the map it draws and its layers.
+type UI struct {
+	maps   MapClient
+	layers map[string]Layer
+	log    *logrus.Logger
+	mux    *http.ServeMux
+}
+
+// New returns a UI drawing the map of maps.
+func New(maps MapClient, opts ...func(*UI)) (*UI, error) {
+	u := &UI{maps: maps, layers: map[string]Layer{}, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	if maps == nil {
+		return nil, errors.New("no map client")
+	}
+	for name := range u.layers {
+		switch name {
+		case LayerSearch, LayerGeofences, LayerRoute, LayerPositions:
+		default:
+			return nil, fmt.Errorf("unknown layer %q", name)
+		}
+	}
+
+	u.mux.HandleFunc("GET /{$}", u.index)
+	u.mux.HandleFunc("GET /style.json", u.style)
+	u.mux.HandleFunc("GET /tiles/{z}/{x}/{y}", u.tile)
+	u.mux.HandleFunc("GET /sprites/{file}", u.sprites)
+	u.mux.HandleFunc("GET /glyphs/{fontstack}/{range}", u.glyphs)
+	u.mux.HandleFunc("GET /layers", u.listLayers)
+	u.mux.HandleFunc("GET /layers/{name}", u.layer)
+	return u, nil
+}
+
+// SetLayer serves the layer under its name, one of the Layer constants.
+func SetLayer(name string, layer Layer) Option {
+	return func(ui *UI) {
+		ui.layers[name] = layer
+	}
+}
+
+// SetLogger logs failed requests at error level and every request at debug
+// level.
+func SetLogger(log *logrus.Logger) Option {
+	return func(ui *UI) {
+		ui.log = log
+	}
+}
+
+// ServeHTTP answers a request.
+func (u *UI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if u.log != nil {
+		u.log.WithFields(logrus.Fields{
+			"method": r.Method,
+			"path":   r.URL.Path,
+		}).Debug("request")
+	}
+	u.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves on addr until ctx is done, then waits up to ten
+// seconds for open requests to finish.
+func (u *UI) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           u,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	done := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		done <- srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-done
+}
+
+func (u *UI) index(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(indexHTML)
+}
+
+// style answers the style descriptor of the map with the URLs of its tiles,
+// sprites and glyphs pointed at the server.
+func (u *UI) style(w http.ResponseWriter, r *http.Request) {
+	out, err := u.maps.GetMapStyleDescriptor(r.Context())
+	if err != nil {
+		u.fail(w, r, err)
+		return
+	}
+	var style map[string]any
+	if err := json.Unmarshal(out.Blob, &style); err != nil {
+		u.fail(w, r, &smithy.DeserializationError{Err: err})
+		return
+	}
+	base := "http://" + r.Host
+	if r.TLS != nil {
+		base = "https://" + r.Host
+	}
+	rewriteStyle(style, base)
+	writeJSON(w, http.StatusOK, style)
+}
+
+// rewriteStyle points the tile sources, sprite and glyphs of a style at the
+// server at base.
+func rewriteStyle(style map[string]any, base string) {
+	if sources, ok := style["sources"].(map[string]any); ok {
+		for _, s := range sources {
+			source, ok := s.(map[string]any)
+			if !ok {
+				continue
+			}
+			if _, ok := source["tiles"]; !ok {
+				if _, ok := source["url"]; !ok {
+					continue
+				}
+			}
+			delete(source, "url")
+			source["tiles"] = []string{base + "/tiles/{z}/{x}/{y}"}
+		}
+	}
+	if sprite, ok := style["sprite"].(string); ok {
+		style["sprite"] = base + "/sprites/" + path.Base(sprite)
+	}
+	if _, ok := style["glyphs"]; ok {
+		style["glyphs"] = base + "/glyphs/{fontstack}/{range}"
+	}
+}
+
+func (u *UI) tile(w http.ResponseWriter, r *http.Request) {
+	var zxy [3]int
+	for i, name := range []string{"z", "x", "y"} {
+		n, err := strconv.Atoi(r.PathValue(name))
+		if err != nil {
+			u.fail(w, r, fmt.Errorf("invalid tile coordinate %q", r.PathValue(name)))
+			return
+		}
+		zxy[i] = n
+	}
+	out, err := u.maps.GetMapTile(r.Context(), zxy[0], zxy[1], zxy[2])
+	if err != nil {
+		u.fail(w, r, err)
+		return
+	}
+	writeBlob(w, out.Blob, out.ContentType, out.CacheControl)
+}
+
+func (u *UI) sprites(w http.ResponseWriter, r *http.Request) {
+	out, err := u.maps.GetMapSprites(r.Context(), r.PathValue("file"))
+	if err != nil {
+		u.fail(w, r, err)
+		return
+	}
+	writeBlob(w, out.Blob, out.ContentType, out.CacheControl)
+}
+
+func (u *UI) glyphs(w http.ResponseWriter, r *http.Request) {
+	out, err := u.maps.GetMapGlyphs(r.Context(), r.PathValue("fontstack"), r.PathValue("range"))
+	if err != nil {
+		u.fail(w, r, err)
+		return
+	}
+	writeBlob(w, out.Blob, out.ContentType, out.CacheControl)
+}
+
+// listLayers answers the names of the layers served, so the page only shows
+// controls for those.
+func (u *UI) listLayers(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(u.layers))
+	for name := range u.layers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (u *UI) layer(w http.ResponseWriter, r *http.Request) {
+	layer, ok := u.layers[r.PathValue("name")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, &errorResponse{Error: "no layer " + r.PathValue("name")})
+		return
+	}
+	fc, err := layer(r.Context(), r.URL.Query())
+	if err != nil {
+		u.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, fc)
+}
+
+// errorResponse is the body of failed requests.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// fail answers a failed request. Errors of AWS are gateway errors, all others
+// happened before calling AWS and are invalid requests.
+func (u *UI) fail(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusBadRequest
+	var apiErr smithy.APIError
+	var opErr *smithy.OperationError
+	var deserErr *smithy.DeserializationError
+	if errors.As(err, &apiErr) || errors.As(err, &opErr) || errors.As(err, &deserErr) {
+		status = http.StatusBadGateway
+	}
+	if r.Context().Err() != nil {
+		return
+	}
+	if u.log != nil {
+		u.log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  r.URL.Path,
+		}).Error("error answering request")
+	}
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+// writeBlob answers a tile, sprite or glyph file.
+func writeBlob(w http.ResponseWriter, blob []byte, contentType *string, cacheControl *string) {
+	if contentType != nil {
+		w.Header().Set("Content-Type", aws.ToString(contentType))
+	}
+	if cacheControl != nil {
+		w.Header().Set("Cache-Control", aws.ToString(cacheControl))
+	}
+	w.Write(blob)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
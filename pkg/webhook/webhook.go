@@ -0,0 +1,234 @@
+// Package webhook forwards events as JSON to user-defined HTTP endpoints,
+// retrying failed deliveries with exponential backoff.
+//
+// Deliveries carry the event type in an X-Goawsloc-Event header, an ID in
+// X-Goawsloc-Delivery and the Unix time they were sent in
+// X-Goawsloc-Timestamp. Endpoints with a secret also get an
+// X-Goawsloc-Signature header of "sha256=" followed by the hex HMAC-SHA256
+// of the timestamp, a dot and the body, keyed with the secret. Receivers
+// should compute the same signature, compare it in constant time, and reject
+// old timestamps to stop replays.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Headers of a delivery.
+const (
+	HeaderEvent     = "X-Goawsloc-Event"
+	HeaderDelivery  = "X-Goawsloc-Delivery"
+	HeaderTimestamp = "X-Goawsloc-Timestamp"
+	HeaderSignature = "X-Goawsloc-Signature"
+)
+
+// maxRetryDelay caps the backoff between attempts.
+const maxRetryDelay = time.Minute
+
+// Endpoint is an HTTP endpoint events are posted to. Headers are added to
+// every delivery, e.g. for authorization. Events limits the event types
+// forwarded to the endpoint; empty means all.
+type Endpoint struct {
+	URL     string            `yaml:"url" json:"url"`
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Secret  string            `yaml:"secret,omitempty" json:"secret,omitempty"`
+	Events  []string          `yaml:"events,omitempty" json:"events,omitempty"`
+}
+
+type Option func(dispatcher *Dispatcher)
+
+// Dispatcher delivers events to its endpoints.
+type Dispatcher struct {
+	endpoints  []Endpoint
+	client     *http.Client
+	retries    int
+	retryDelay time.Duration
+	log        *logrus.Logger
+	now        func() time.Time
+}
+
+// New returns a dispatcher delivering events to the endpoints.
+func New(endpoints []Endpoint, opts ...func(*Dispatcher)) (*Dispatcher, error) {
+	d := &Dispatcher{
+		endpoints:  endpoints,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		retries:    3,
+		retryDelay: time.Second,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, errors.New("no webhook endpoints")
+	}
+	for _, e := range endpoints {
+		u, err := url.Parse(e.URL)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %q: %w", e.URL, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("webhook %q is no http or https URL", e.URL)
+		}
+	}
+	if d.retries < 0 {
+		return nil, errors.New("webhook retries must not be negative")
+	}
+	return d, nil
+}
+
+// SetHTTPClient sets the client deliveries are sent with. Defaults to a
+// client with a timeout of 10 seconds.
+func SetHTTPClient(client *http.Client) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.client = client
+	}
+}
+
+// SetRetries retries a failed delivery up to retries times, waiting delay
+// before the first retry and twice as long before each further one, up to a
+// minute. A Retry-After header of the endpoint takes precedence. Defaults to
+// 3 retries starting at one second.
+func SetRetries(retries int, delay time.Duration) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.retries = retries
+		dispatcher.retryDelay = delay
+	}
+}
+
+// SetLogger logs failed deliveries.
+func SetLogger(log *logrus.Logger) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.log = log
+	}
+}
+
+// Send posts the payload as JSON to every endpoint taking the event type, in
+// parallel, and returns once each delivered it or ran out of retries.
+func (d *Dispatcher) Send(ctx context.Context, eventType string, id string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(d.endpoints))
+	for i, e := range d.endpoints {
+		if len(e.Events) > 0 && !slices.Contains(e.Events, eventType) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.deliver(ctx, e, eventType, id, body); err != nil {
+				errs[i] = fmt.Errorf("webhook %s: %w", e.URL, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// deliver posts the body to an endpoint, retrying network errors, timeouts,
+// throttling and server errors.
+func (d *Dispatcher) deliver(ctx context.Context, e Endpoint, eventType string, id string, body []byte) error {
+	delay := d.retryDelay
+	for attempt := 0; ; attempt++ {
+		wait, err := d.post(ctx, e, eventType, id, body)
+		if err == nil {
+			return nil
+		}
+		if wait < 0 || attempt >= d.retries {
+			return err
+		}
+		if wait == 0 {
+			wait = delay
+			delay = min(2*delay, maxRetryDelay)
+		}
+		if d.log != nil {
+			d.log.WithFields(logrus.Fields{
+				"error":    err,
+				"url":      e.URL,
+				"delivery": id,
+				"attempt":  attempt + 1,
+				"delay":    wait,
+			}).Warn("Webhook delivery failed, retrying")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// post sends one attempt of a delivery. On failure it returns how long to
+// wait before retrying: the Retry-After of the response, zero for the
+// backoff, or a negative duration if retrying is pointless.
+func (d *Dispatcher) post(ctx context.Context, e Endpoint, eventType string, id string, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	for name, value := range e.Headers {
+		req.Header.Set(name, value)
+	}
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "goawsloc-webhook")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if e.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(e.Secret, timestamp, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return 0, nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		var wait time.Duration
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			wait = min(time.Duration(seconds)*time.Second, maxRetryDelay)
+		}
+		return wait, fmt.Errorf("answered %s", resp.Status)
+	}
+	return -1, fmt.Errorf("answered %s", resp.Status)
+}
+
+// Sign returns the signature of a delivery: "sha256=" followed by the hex
+// HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
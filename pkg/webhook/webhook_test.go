@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{"body", "secret", "1700000000", `{"id":1}`, "sha256=3dd1b9aef568d75f6790a84bd2e5dfa1f44409eef3cbdbd3f10b837376100c11"},
+		{"other secret", "other", "1700000000", `{"id":1}`, "sha256=e0cb77fc6d5b2877ec062213c262d236b5dd5a833d29fdc5a058c5fbfa287b47"},
+		{"empty body", "secret", "1700000000", "", "sha256=4bc5f74d868b97888288889c5d9d65df02526f94c1592a79fdf4fe8b26e311e5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendSignsDeliveries(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"with secret", "secret"},
+		{"without secret", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header http.Header
+			var body []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				body, _ = io.ReadAll(r.Body)
+			}))
+			defer srv.Close()
+
+			d, err := New([]Endpoint{{URL: srv.URL, Secret: tt.secret}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			d.now = func() time.Time { return time.Unix(1700000000, 0) }
+			if err := d.Send(context.Background(), "ENTER", "id-1", map[string]int{"id": 1}); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := header.Get(HeaderTimestamp); got != "1700000000" {
+				t.Errorf("%s = %q, want 1700000000", HeaderTimestamp, got)
+			}
+			want := ""
+			if tt.secret != "" {
+				want = Sign(tt.secret, "1700000000", body)
+			}
+			if got := header.Get(HeaderSignature); got != want {
+				t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,508 @@
+// Package worker geocodes jobs consumed from an SQS queue with the batch
+// searches of placesvc, and writes their results to an output queue or to
+// objects under an S3 prefix. Geofence and device position events routed to
+// the queue by EventBridge are forwarded to webhooks.
+//
+// A job message holds a Job as JSON:
+//
+//	{"id": "job-1", "geocode": [{"text": "..."}], "reverse": [{"latitude": 47.6, "longitude": -122.3}]}
+//
+// whose geocode searches take the fields of a server.SearchRequest. Its
+// result is a JobResult with one item per search in job order:
+//
+//	{"id": "job-1", "geocode": [{"results": [...]}], "reverse": [{"results": [], "error": "..."}]}
+//
+// A job is retried by leaving its message on the queue with a visibility
+// timeout growing with every receive. Jobs which cannot succeed, because
+// their message is malformed or they were received too often, are moved to
+// a dead-letter queue with their error in the error message attribute.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/blob"
+	"github.com/rmrfslashbin/goawsloc/pkg/geoevents"
+	"github.com/rmrfslashbin/goawsloc/pkg/server"
+	"github.com/rmrfslashbin/goawsloc/pkg/webhook"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxMessageBytes is the largest message SQS accepts.
+	maxMessageBytes = 256 * 1024
+
+	// maxVisibilityTimeout is the longest visibility timeout SQS accepts.
+	maxVisibilityTimeout = 12 * time.Hour
+
+	// waitTime is how long a receive waits for a message.
+	waitTime = 20 * time.Second
+
+	// receiveErrorDelay is how long the worker pauses after a failed receive.
+	receiveErrorDelay = 5 * time.Second
+)
+
+// Job is the body of a job message: text to geocode and positions to reverse
+// geocode. ID names the result; it defaults to the message ID. IDs may only
+// hold letters, digits, '.', '_' and '-' and must not contain "..", as they
+// become part of the name of the result object.
+type Job struct {
+	ID      string                 `json:"id,omitempty"`
+	Geocode []server.SearchRequest `json:"geocode,omitempty"`
+	Reverse []placesvc.LatLon      `json:"reverse,omitempty"`
+}
+
+// JobResult is the result of a job, with one item per search in the order
+// of the job.
+type JobResult struct {
+	ID      string       `json:"id"`
+	Geocode []ItemResult `json:"geocode,omitempty"`
+	Reverse []ItemResult `json:"reverse,omitempty"`
+}
+
+// ItemResult is the result of one search of a job.
+type ItemResult struct {
+	Results []placesvc.SearchResult `json:"results"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+// SQSClient is the part of the SQS API the worker uses.
+type SQSClient interface {
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+type Option func(worker *Worker)
+
+// Worker consumes the jobs of a queue.
+type Worker struct {
+	sqs         SQSClient
+	places      placesvc.PlaceIndexer
+	queueURL    string
+	outputQueue string
+	outputURI   string
+	blobs       *blob.Opener
+	dlqURL      string
+	maxReceives int
+	visibility  time.Duration
+	retryDelay  time.Duration
+	batch       placesvc.BatchOptions
+	webhooks    *webhook.Dispatcher
+	log         *logrus.Logger
+}
+
+// New returns a worker consuming the jobs of the queue. Results must be
+// written somewhere, set with SetOutputQueue or SetOutputURI, unless the
+// queue only receives events for SetWebhooks.
+func New(client SQSClient, places placesvc.PlaceIndexer, queueURL string, opts ...func(*Worker)) (*Worker, error) {
+	w := &Worker{
+		sqs:        client,
+		places:     places,
+		queueURL:   queueURL,
+		visibility: time.Minute,
+		retryDelay: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	switch {
+	case queueURL == "":
+		return nil, errors.New("queue URL not set")
+	case w.outputQueue == "" && w.outputURI == "" && w.webhooks == nil:
+		return nil, errors.New("no output queue, URI or webhooks set")
+	case w.outputQueue != "" && w.outputURI != "":
+		return nil, errors.New("output queue and URI are mutually exclusive")
+	case w.outputURI != "" && w.blobs == nil:
+		return nil, errors.New("output URI set without an opener")
+	case w.visibility < 2*time.Second || w.visibility > maxVisibilityTimeout:
+		return nil, fmt.Errorf("visibility timeout %s out of range [2s, %s]", w.visibility, maxVisibilityTimeout)
+	}
+	return w, nil
+}
+
+// SetOutputQueue sends the result of each job as a message to the queue.
+// Results must fit into a message of 256 KiB.
+func SetOutputQueue(queueURL string) Option {
+	return func(worker *Worker) {
+		worker.outputQueue = queueURL
+	}
+}
+
+// SetOutputURI writes the result of each job to the object or file named
+// by the prefix followed by the job ID and .json, e.g.
+// s3://bucket/results/job-1.json for the prefix s3://bucket/results/.
+func SetOutputURI(prefix string, blobs *blob.Opener) Option {
+	return func(worker *Worker) {
+		worker.outputURI = prefix
+		worker.blobs = blobs
+	}
+}
+
+// SetDeadLetterQueue moves jobs to the queue whose message is malformed, or
+// which failed on their maxReceives-th receive. Without it, failed jobs are
+// retried until the redrive policy of the queue moves them.
+func SetDeadLetterQueue(queueURL string, maxReceives int) Option {
+	return func(worker *Worker) {
+		worker.dlqURL = queueURL
+		worker.maxReceives = maxReceives
+	}
+}
+
+// SetVisibilityTimeout hides a received job from other workers for the
+// timeout, extending it while the job is processed. Defaults to one minute.
+func SetVisibilityTimeout(timeout time.Duration) Option {
+	return func(worker *Worker) {
+		worker.visibility = timeout
+	}
+}
+
+// SetRetryDelay is how long a failed job stays hidden before it is retried,
+// doubling with every receive up to 12 hours. Defaults to 30 seconds.
+func SetRetryDelay(delay time.Duration) Option {
+	return func(worker *Worker) {
+		worker.retryDelay = delay
+	}
+}
+
+// SetBatchOptions sets how the searches of a job are fanned out.
+func SetBatchOptions(opts placesvc.BatchOptions) Option {
+	return func(worker *Worker) {
+		worker.batch = opts
+	}
+}
+
+// SetWebhooks forwards the geofence and device position events received on
+// the queue to the webhooks. Without webhooks, events are moved to the
+// dead-letter queue like malformed jobs.
+func SetWebhooks(webhooks *webhook.Dispatcher) Option {
+	return func(worker *Worker) {
+		worker.webhooks = webhooks
+	}
+}
+
+// SetLogger logs every job.
+func SetLogger(log *logrus.Logger) Option {
+	return func(worker *Worker) {
+		worker.log = log
+	}
+}
+
+// Run consumes jobs until ctx is done. A job in progress when ctx is done is
+// left on the queue to be received again.
+func (w *Worker) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		out, err := w.sqs.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:                    aws.String(w.queueURL),
+			MaxNumberOfMessages:         1,
+			WaitTimeSeconds:             int32(waitTime.Seconds()),
+			VisibilityTimeout:           int32(w.visibility.Seconds()),
+			MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameApproximateReceiveCount},
+		})
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			w.warn(err, "error receiving jobs")
+			select {
+			case <-ctx.Done():
+			case <-time.After(receiveErrorDelay):
+			}
+			continue
+		}
+		for _, msg := range out.Messages {
+			w.handle(ctx, msg)
+		}
+	}
+	return nil
+}
+
+// handle processes a job or forwards an event, and deletes its message once
+// done, retries it later if it failed, or moves it to the dead-letter queue.
+func (w *Worker) handle(ctx context.Context, msg types.Message) {
+	receives, _ := strconv.Atoi(msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)])
+	fields := logrus.Fields{
+		"messageId": aws.ToString(msg.MessageId),
+		"receives":  receives,
+	}
+
+	if event, err := geoevents.Parse([]byte(aws.ToString(msg.Body))); err == nil && event != nil {
+		fields["event"] = event.ID
+		w.run(ctx, msg, receives, fields, "Forwarded event", func(ctx context.Context) error {
+			if w.webhooks == nil {
+				return fmt.Errorf("%w: %s event received without webhooks", errPermanent, event.EventType)
+			}
+			return w.webhooks.Send(ctx, event.EventType, event.ID, event)
+		})
+		return
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(aws.ToString(msg.Body)), &job); err != nil {
+		w.deadLetter(ctx, msg, fmt.Errorf("malformed job: %w", err), fields)
+		return
+	}
+	if job.ID == "" {
+		job.ID = aws.ToString(msg.MessageId)
+	}
+	fields["job"] = job.ID
+	if !validJobID(job.ID) {
+		w.deadLetter(ctx, msg, fmt.Errorf("malformed job: invalid id %q, must only hold letters, digits, '.', '_' and '-'", job.ID), fields)
+		return
+	}
+	w.run(ctx, msg, receives, fields, "Completed job", func(ctx context.Context) error {
+		return w.process(ctx, &job)
+	})
+}
+
+// run calls do for a message, keeping the message hidden from other workers
+// while it runs, and deletes the message once do succeeds.
+func (w *Worker) run(ctx context.Context, msg types.Message, receives int, fields logrus.Fields, done string, do func(ctx context.Context) error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	heartbeat := make(chan struct{})
+	go func() {
+		defer close(heartbeat)
+		w.extendVisibility(runCtx, msg)
+	}()
+	err := do(runCtx)
+	cancel()
+	<-heartbeat
+
+	switch {
+	case ctx.Err() != nil:
+		// Interrupted; the message reappears once its visibility times out.
+		return
+	case err == nil:
+		if _, err := w.sqs.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+			QueueUrl:      aws.String(w.queueURL),
+			ReceiptHandle: msg.ReceiptHandle,
+		}); err != nil {
+			w.warn(err, "error deleting completed message")
+			return
+		}
+		if w.log != nil {
+			w.log.WithFields(fields).Info(done)
+		}
+	case errors.Is(err, errPermanent), w.dlqURL != "" && receives >= w.maxReceives:
+		w.deadLetter(ctx, msg, err, fields)
+	default:
+		w.retry(ctx, msg, receives, err, fields)
+	}
+}
+
+// extendVisibility extends the visibility timeout of a message every half
+// timeout until ctx is done.
+func (w *Worker) extendVisibility(ctx context.Context, msg types.Message) {
+	ticker := time.NewTicker(w.visibility / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.sqs.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+				QueueUrl:          aws.String(w.queueURL),
+				ReceiptHandle:     msg.ReceiptHandle,
+				VisibilityTimeout: int32(w.visibility.Seconds()),
+			}); err != nil && ctx.Err() == nil {
+				w.warn(err, "error extending job visibility")
+			}
+		}
+	}
+}
+
+// retry hides a failed message for the retry delay, doubled for every
+// earlier receive.
+func (w *Worker) retry(ctx context.Context, msg types.Message, receives int, err error, fields logrus.Fields) {
+	delay := w.retryDelay
+	for i := 1; i < receives && delay < maxVisibilityTimeout; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxVisibilityTimeout-time.Second)
+	if w.log != nil {
+		w.log.WithFields(fields).WithFields(logrus.Fields{
+			"error": err,
+			"delay": delay,
+		}).Warn("Job failed, retrying")
+	}
+	if _, err := w.sqs.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(w.queueURL),
+		ReceiptHandle:     msg.ReceiptHandle,
+		VisibilityTimeout: int32(delay.Seconds()),
+	}); err != nil {
+		w.warn(err, "error delaying failed job")
+	}
+}
+
+// deadLetter moves a message to the dead-letter queue, with the error as
+// message attribute. Without a dead-letter queue the message is retried.
+func (w *Worker) deadLetter(ctx context.Context, msg types.Message, err error, fields logrus.Fields) {
+	if w.dlqURL == "" {
+		w.retry(ctx, msg, 0, err, fields)
+		return
+	}
+	if w.log != nil {
+		w.log.WithFields(fields).WithFields(logrus.Fields{
+			"error": err,
+		}).Error("Job failed, moving it to the dead-letter queue")
+	}
+	if _, sendErr := w.sqs.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(w.dlqURL),
+		MessageBody: msg.Body,
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"error": {DataType: aws.String("String"), StringValue: aws.String(err.Error())},
+		},
+	}); sendErr != nil {
+		w.warn(sendErr, "error moving job to the dead-letter queue")
+		return
+	}
+	if _, err := w.sqs.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(w.queueURL),
+		ReceiptHandle: msg.ReceiptHandle,
+	}); err != nil {
+		w.warn(err, "error deleting dead-lettered job")
+	}
+}
+
+// jobID matches the IDs allowed in the name of a result object.
+var jobID = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// validJobID reports whether the ID can name a result object without
+// leaving the output prefix, by a path like ../x or a key like a/b.
+func validJobID(id string) bool {
+	return jobID.MatchString(id) && !strings.Contains(id, "..")
+}
+
+// errPermanent marks errors retrying cannot fix.
+var errPermanent = errors.New("permanent failure")
+
+// process runs the searches of a job and writes its result. Searches which
+// fail for good are reported in the result; the job fails if a search was
+// throttled or AWS could not be reached, so it is retried later.
+func (w *Worker) process(ctx context.Context, job *Job) error {
+	result := &JobResult{
+		ID:      job.ID,
+		Geocode: make([]ItemResult, len(job.Geocode)),
+		Reverse: make([]ItemResult, len(job.Reverse)),
+	}
+
+	var searches []placesvc.TextSearch
+	var indexes []int
+	for i := range job.Geocode {
+		search, err := job.Geocode[i].Search()
+		if err != nil {
+			result.Geocode[i].Error = err.Error()
+			continue
+		}
+		searches = append(searches, *search)
+		indexes = append(indexes, i)
+	}
+	// The batch channels must be drained, so the first transient error is
+	// kept until they are closed.
+	var failed error
+	fail := func(item *ItemResult, err error) {
+		if transient(err) {
+			if failed == nil {
+				failed = err
+			}
+			return
+		}
+		item.Error = err.Error()
+	}
+	for r := range w.places.BatchSearchText(ctx, searches, w.batch) {
+		item := &result.Geocode[indexes[r.Index]]
+		if r.Err != nil {
+			fail(item, r.Err)
+			continue
+		}
+		_, item.Results = placesvc.NewTextResults(r.Output)
+	}
+	if failed != nil {
+		return failed
+	}
+
+	for r := range w.places.BatchReverseGeocode(ctx, job.Reverse, w.batch) {
+		item := &result.Reverse[r.Index]
+		if r.Err != nil {
+			fail(item, r.Err)
+			continue
+		}
+		_, item.Results = placesvc.NewPositionResults(r.Output)
+	}
+	if failed != nil {
+		return failed
+	}
+
+	return w.write(ctx, result)
+}
+
+// write sends the result to the output queue or writes it to its object.
+func (w *Worker) write(ctx context.Context, result *JobResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errPermanent, err)
+	}
+
+	if w.outputQueue == "" && w.outputURI == "" {
+		return fmt.Errorf("%w: job received without an output queue or URI", errPermanent)
+	}
+	if w.outputQueue != "" {
+		if len(data) > maxMessageBytes {
+			return fmt.Errorf("%w: result of %d bytes exceeds the SQS message size, write results to S3 instead", errPermanent, len(data))
+		}
+		_, err := w.sqs.SendMessage(ctx, &sqs.SendMessageInput{
+			QueueUrl:    aws.String(w.outputQueue),
+			MessageBody: aws.String(string(data)),
+		})
+		return err
+	}
+
+	f, err := w.blobs.Create(ctx, w.outputURI+result.ID+".json")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// transient reports whether a search failed for a reason which may pass:
+// throttling, a fault of AWS, or no response at all.
+func transient(err error) bool {
+	if errors.Is(err, placesvc.ErrThrottled) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorFault() == smithy.FaultServer
+	}
+	var opErr *smithy.OperationError
+	return errors.As(err, &opErr)
+}
+
+// warn logs a warning if a logger is set.
+func (w *Worker) warn(err error, msg string) {
+	if w.log == nil {
+		return
+	}
+	w.log.WithFields(logrus.Fields{
+		"error": err,
+	}).Warn(msg)
+}
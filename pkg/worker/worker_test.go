@@ -0,0 +1,194 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/blob"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	queueURL  = "https://sqs.us-east-1.amazonaws.com/123456789012/jobs"
+	outputURL = "https://sqs.us-east-1.amazonaws.com/123456789012/results"
+	dlqURL    = "https://sqs.us-east-1.amazonaws.com/123456789012/jobs-dlq"
+)
+
+// fakeSQS is an SQSClient recording the calls made to it.
+type fakeSQS struct {
+	mu         sync.Mutex
+	sent       map[string]int
+	deleted    int
+	visibility []int32
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.visibility = append(f.visibility, params.VisibilityTimeout)
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (f *fakeSQS) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deleted++
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQS) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	panic("unexpected ReceiveMessage")
+}
+
+func (f *fakeSQS) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent[aws.ToString(params.QueueUrl)]++
+	return &sqs.SendMessageOutput{}, nil
+}
+
+// fakePlaces is a PlaceIndexer answering every text search with err, or
+// with an empty result if err is nil.
+type fakePlaces struct {
+	placesvc.PlaceIndexer
+	err error
+}
+
+func (f *fakePlaces) BatchSearchText(ctx context.Context, requests []placesvc.TextSearch, opts placesvc.BatchOptions) <-chan placesvc.BatchTextResult {
+	results := make(chan placesvc.BatchTextResult, len(requests))
+	for i := range requests {
+		r := placesvc.BatchTextResult{Index: i, Search: &requests[i], Err: f.err}
+		if f.err == nil {
+			r.Output = &location.SearchPlaceIndexForTextOutput{}
+		}
+		results <- r
+	}
+	close(results)
+	return results
+}
+
+func (f *fakePlaces) BatchReverseGeocode(ctx context.Context, points []placesvc.LatLon, opts placesvc.BatchOptions) <-chan placesvc.BatchPositionResult {
+	results := make(chan placesvc.BatchPositionResult)
+	close(results)
+	return results
+}
+
+// message returns a job message with the body, received the given number
+// of times.
+func message(body string, receives int) types.Message {
+	return types.Message{
+		MessageId:     aws.String("message-1"),
+		ReceiptHandle: aws.String("receipt-1"),
+		Body:          aws.String(body),
+		Attributes: map[string]string{
+			string(types.MessageSystemAttributeNameApproximateReceiveCount): strconv.Itoa(receives),
+		},
+	}
+}
+
+func TestHandle(t *testing.T) {
+	retryDelay := 10 * time.Second
+	tests := []struct {
+		name           string
+		body           string
+		receives       int
+		searchErr      error
+		dlq            bool
+		wantOutput     int
+		wantDeadLetter int
+		wantDeleted    int
+		wantVisibility []int32
+	}{
+		{"completed", `{"id": "job-1", "geocode": [{"text": "Berlin"}]}`, 1, nil, true, 1, 0, 1, nil},
+		{"default id", `{"geocode": [{"text": "Berlin"}]}`, 1, nil, true, 1, 0, 1, nil},
+		{"malformed", `{"geocode": `, 1, nil, true, 0, 1, 1, nil},
+		{"malformed without dead-letter queue", `{"geocode": `, 1, nil, false, 0, 0, 0, []int32{10}},
+		{"parent id", `{"id": "..", "geocode": [{"text": "Berlin"}]}`, 1, nil, true, 0, 1, 1, nil},
+		{"traversing id", `{"id": "../other/job-1", "geocode": [{"text": "Berlin"}]}`, 1, nil, true, 0, 1, 1, nil},
+		{"nested id", `{"id": "a/b", "geocode": [{"text": "Berlin"}]}`, 1, nil, true, 0, 1, 1, nil},
+		{"throttled", `{"id": "job-1", "geocode": [{"text": "Berlin"}]}`, 1, placesvc.ErrThrottled, true, 0, 0, 0, []int32{10}},
+		{"throttled again", `{"id": "job-1", "geocode": [{"text": "Berlin"}]}`, 3, placesvc.ErrThrottled, true, 0, 0, 0, []int32{40}},
+		{"throttled too often", `{"id": "job-1", "geocode": [{"text": "Berlin"}]}`, 5, placesvc.ErrThrottled, true, 0, 1, 1, nil},
+		{"throttled too often without dead-letter queue", `{"id": "job-1", "geocode": [{"text": "Berlin"}]}`, 5, placesvc.ErrThrottled, false, 0, 0, 0, []int32{160}},
+		{"search failed for good", `{"id": "job-1", "geocode": [{"text": "Berlin"}]}`, 1, placesvc.ErrAccessDenied, true, 1, 0, 1, nil},
+		{"event without webhooks", `{"source": "aws.geo", "detail-type": "Location Geofence Event", "id": "event-1", "detail": {"EventType": "ENTER"}}`, 1, nil, true, 0, 1, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeSQS{sent: map[string]int{}}
+			opts := []func(*Worker){SetOutputQueue(outputURL), SetRetryDelay(retryDelay)}
+			if tt.dlq {
+				opts = append(opts, SetDeadLetterQueue(dlqURL, 5))
+			}
+			w, err := New(client, &fakePlaces{err: tt.searchErr}, queueURL, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w.handle(context.Background(), message(tt.body, tt.receives))
+
+			if got := client.sent[outputURL]; got != tt.wantOutput {
+				t.Errorf("sent %d results, want %d", got, tt.wantOutput)
+			}
+			if got := client.sent[dlqURL]; got != tt.wantDeadLetter {
+				t.Errorf("dead-lettered %d messages, want %d", got, tt.wantDeadLetter)
+			}
+			if client.deleted != tt.wantDeleted {
+				t.Errorf("deleted %d messages, want %d", client.deleted, tt.wantDeleted)
+			}
+			if len(client.visibility) != len(tt.wantVisibility) {
+				t.Fatalf("changed visibility to %v, want %v", client.visibility, tt.wantVisibility)
+			}
+			for i := range client.visibility {
+				if client.visibility[i] != tt.wantVisibility[i] {
+					t.Errorf("changed visibility to %v, want %v", client.visibility, tt.wantVisibility)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryDelayIsCapped(t *testing.T) {
+	client := &fakeSQS{sent: map[string]int{}}
+	w, err := New(client, &fakePlaces{}, queueURL, SetOutputQueue(outputURL), SetRetryDelay(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.retry(context.Background(), message("{}", 10), 10, placesvc.ErrThrottled, nil)
+	if want := int32((maxVisibilityTimeout - time.Second).Seconds()); len(client.visibility) != 1 || client.visibility[0] != want {
+		t.Errorf("changed visibility to %v, want [%d]", client.visibility, want)
+	}
+}
+
+func TestWriteStaysUnderOutputURI(t *testing.T) {
+	dir := t.TempDir()
+	prefix := filepath.Join(dir, "results") + string(filepath.Separator)
+	if err := os.Mkdir(prefix, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	client := &fakeSQS{sent: map[string]int{}}
+	w, err := New(client, &fakePlaces{}, queueURL, SetOutputURI(prefix, &blob.Opener{}), SetDeadLetterQueue(dlqURL, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.handle(context.Background(), message(`{"id": "../escaped", "geocode": [{"text": "Berlin"}]}`, 1))
+	w.handle(context.Background(), message(`{"id": "job-1", "geocode": [{"text": "Berlin"}]}`, 1))
+
+	if _, err := os.Stat(filepath.Join(dir, "escaped.json")); !os.IsNotExist(err) {
+		t.Errorf("result written outside the output prefix: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(prefix, "job-1.json")); err != nil {
+		t.Errorf("result not written: %v", err)
+	}
+}
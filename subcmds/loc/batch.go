@@ -0,0 +1,698 @@
+package loc
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Formats of batch input and output files.
+const (
+	batchCSV   = "csv"
+	batchJSONL = "jsonl"
+)
+
+// batchOptions are the flags of the batch commands.
+type batchOptions struct {
+	*globalOptions
+	rateOptions
+
+	addressColumn string
+	apiKey        string
+	countries     []string
+	filePath      string
+	format        string
+	indexName     string
+	inputPath     string
+	latColumn     string
+	lonColumn     string
+}
+
+func newBatchCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "batch",
+		Short: "search many rows of a file at once",
+		Long:  "Runs a search for every row of a CSV or JSON Lines file and writes the rows back out with the results added. A failed row gets an error column instead of aborting the whole job",
+	}
+
+	cmd.AddCommand(
+		newBatchPositionCmd(g),
+		newBatchTextCmd(g),
+	)
+	return cmd
+}
+
+func newBatchTextCmd(g *globalOptions) *cobra.Command {
+	o := &batchOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "text",
+		Short: "geocode the addresses of a file",
+		Long:  "Geocodes the address column of every row of a CSV file with a header row, or the address field of every object of a JSON Lines file, and writes the rows in the same format with lat, lon, label, score and error columns added. Rows are written in input order as they complete, or with --output ndjson as JSON objects with their row number in the order the searches complete",
+		Example: `  loc batch text --index my-index --input addresses.csv --address-column addr -f results.csv
+  cat addresses.jsonl | loc batch text --index my-index --format jsonl --rps 10`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.checkBatchFlags(); err != nil {
+				return err
+			}
+			return parseCountries(o.countries)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runBatchText(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the searches with instead of IAM credentials")
+	cmd.Flags().StringVarP(&o.addressColumn, "address-column", "", "address", "column or field holding the address")
+	cmd.Flags().StringSliceVarP(&o.countries, "country", "", []string{}, "one or more countries to limit the searches to, as ISO 3166 code or name")
+	o.addBatchFlags(cmd)
+	cmd.MarkFlagRequired("index")
+	return cmd
+}
+
+func newBatchPositionCmd(g *globalOptions) *cobra.Command {
+	o := &batchOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "position",
+		Short: "reverse geocode the positions of a file",
+		Long:  "Reverse geocodes the latitude and longitude columns of every row of a CSV file with a header row, or the fields of every object of a JSON Lines file, and writes the rows in the same format with the address components, distance in meters and error columns added, or with --output ndjson as JSON objects with their row number in the order the searches complete. Reads stdin and writes stdout by default, so it can be used in pipelines",
+		Example: `  loc batch position --index my-index --input points.csv --lat-column lat --lon-column lon
+  cat points.jsonl | loc batch position --index my-index --format jsonl | jq .postalcode`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return o.checkBatchFlags()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runBatchPosition(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the searches with instead of IAM credentials")
+	cmd.Flags().StringVarP(&o.latColumn, "lat-column", "", "lat", "column or field holding the latitude")
+	cmd.Flags().StringVarP(&o.lonColumn, "lon-column", "", "lon", "column or field holding the longitude")
+	o.addBatchFlags(cmd)
+	cmd.MarkFlagRequired("index")
+	return cmd
+}
+
+// addBatchFlags adds the input, output and rate flags shared by the batch
+// commands.
+func (o *batchOptions) addBatchFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.inputPath, "input", "i", "-", "CSV or JSON Lines input file or s3://bucket/key object, - for stdin")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "output file or s3://bucket/key object (default stdout)")
+	cmd.Flags().StringVarP(&o.format, "format", "", "", "format of the input and output [csv|jsonl] (default from the --input extension, else csv)")
+	o.addRateFlags(cmd)
+}
+
+// checkBatchFlags validates the rate flags and --format, or picks the format
+// from the extension of --input.
+func (o *batchOptions) checkBatchFlags() error {
+	if err := o.checkRate(); err != nil {
+		return err
+	}
+	if o.format == "" {
+		switch strings.ToLower(path.Ext(o.inputPath)) {
+		case ".jsonl", ".ndjson":
+			o.format = batchJSONL
+		default:
+			o.format = batchCSV
+		}
+	}
+	if o.format != batchCSV && o.format != batchJSONL {
+		return fmt.Errorf("invalid format %q, must be %s or %s", o.format, batchCSV, batchJSONL)
+	}
+	return nil
+}
+
+// rateOptions are the flags of commands sending many searches, which keep
+// them under the requests per second quota of the account.
+type rateOptions struct {
+	concurrency int
+	retries     int
+	rps         float64
+}
+
+// addRateFlags adds the --concurrency, --rps and --retries flags.
+func (r *rateOptions) addRateFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&r.concurrency, "concurrency", "c", 5, "number of concurrent requests")
+	cmd.Flags().Float64VarP(&r.rps, "rps", "", 0, "maximum requests per second, e.g. the TPS quota of the account (default unlimited)")
+	cmd.Flags().IntVarP(&r.retries, "retries", "", 3, "number of times a throttled request is retried with backoff")
+}
+
+// checkRate validates the rate flags.
+func (r *rateOptions) checkRate() error {
+	if r.concurrency < 1 {
+		return errors.New("--concurrency must be at least 1")
+	}
+	if r.rps < 0 {
+		return errors.New("--rps must not be negative")
+	}
+	if r.retries < 0 {
+		return errors.New("--retries must not be negative")
+	}
+	return nil
+}
+
+// batchSearchOptions returns the batch search options of the flags, with
+// results delivered in input order if ordered is set.
+func (r *rateOptions) batchSearchOptions(ordered bool) placesvc.BatchOptions {
+	return placesvc.BatchOptions{
+		Concurrency:       r.concurrency,
+		RequestsPerSecond: r.rps,
+		Retries:           r.retries,
+		Ordered:           ordered,
+	}
+}
+
+// batchQueueSize is the number of rows read ahead of the oldest row not
+// written yet.
+const batchQueueSize = 256
+
+// batchReader reads the rows of a batch input file one at a time, either the
+// records of a CSV file below its header or the objects of a JSON Lines file.
+type batchReader struct {
+	format  string
+	header  []string
+	csv     *csv.Reader
+	scanner *bufio.Scanner
+	line    int
+	rows    int
+}
+
+// batchRow is a row of a batch input file. n counts the rows from 0.
+type batchRow struct {
+	n      int
+	record []string
+	object map[string]interface{}
+}
+
+// newBatchReader returns a reader of a batch input file in the given format,
+// reading the header row of CSV files.
+func newBatchReader(r io.Reader, format string) (*batchReader, error) {
+	br := &batchReader{format: format}
+	if format == batchCSV {
+		br.csv = csv.NewReader(r)
+		br.csv.FieldsPerRecord = -1
+		header, err := br.csv.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("input has no header row")
+		}
+		if err != nil {
+			return nil, err
+		}
+		br.header = header
+		return br, nil
+	}
+	br.scanner = bufio.NewScanner(r)
+	br.scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return br, nil
+}
+
+// next returns the next row, or io.EOF after the last one.
+func (br *batchReader) next() (*batchRow, error) {
+	if br.format == batchCSV {
+		record, err := br.csv.Read()
+		if err != nil {
+			return nil, err
+		}
+		br.rows++
+		return &batchRow{n: br.rows - 1, record: record}, nil
+	}
+
+	for br.scanner.Scan() {
+		br.line++
+		text := strings.TrimSpace(br.scanner.Text())
+		if text == "" {
+			continue
+		}
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &object); err != nil {
+			return nil, fmt.Errorf("line %d: %w", br.line, err)
+		}
+		br.rows++
+		return &batchRow{n: br.rows - 1, object: object}, nil
+	}
+	if err := br.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// column returns the index of a CSV column, or an error naming the columns
+// if the header has no such column. JSON Lines objects are checked per row.
+func (br *batchReader) column(name string) (int, error) {
+	if br.format != batchCSV {
+		return -1, nil
+	}
+	for i, h := range br.header {
+		if strings.EqualFold(strings.TrimSpace(h), name) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("input has no column %q, columns are: %s", name, strings.Join(br.header, ", "))
+}
+
+// value returns the value of a column of the row, with numbers of JSON Lines
+// objects formatted as written.
+func (r *batchRow) value(column int, name string) (string, error) {
+	if r.object == nil {
+		if column >= len(r.record) {
+			return "", fmt.Errorf("row has no %s column", name)
+		}
+		return strings.TrimSpace(r.record[column]), nil
+	}
+	switch v := r.object[name].(type) {
+	case nil:
+		return "", fmt.Errorf("object has no %s field", name)
+	case string:
+		return strings.TrimSpace(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("%s field is not a string or number", name)
+	}
+}
+
+// point returns the position in the latitude and longitude columns of the
+// row.
+func (r *batchRow) point(latColumn int, latName string, lonColumn int, lonName string) (*placesvc.LatLon, error) {
+	lat, err := r.value(latColumn, latName)
+	if err != nil {
+		return nil, err
+	}
+	lon, err := r.value(lonColumn, lonName)
+	if err != nil {
+		return nil, err
+	}
+	if lat == "" || lon == "" {
+		return nil, errors.New("empty position")
+	}
+	point := &placesvc.LatLon{}
+	if point.Latitude, err = strconv.ParseFloat(lat, 64); err != nil {
+		return nil, fmt.Errorf("invalid latitude %q", lat)
+	}
+	if point.Longitude, err = strconv.ParseFloat(lon, 64); err != nil {
+		return nil, fmt.Errorf("invalid longitude %q", lon)
+	}
+	if err := point.Validate(); err != nil {
+		return nil, err
+	}
+	return point, nil
+}
+
+// batchAddressColumns are the address components added by batch position.
+var batchAddressColumns = []string{"label", "number", "street", "neighborhood", "municipality", "subregion", "region", "postalcode", "country"}
+
+// batchAddress returns the values of the batchAddressColumns of a place.
+func batchAddress(p *placesvc.Place) []string {
+	return []string{p.Label, p.AddressNumber, p.Street, p.Neighborhood, p.Municipality, p.SubRegion, p.Region, p.PostalCode, p.Country}
+}
+
+// batchWriter writes the rows of a batch input file with columns added.
+type batchWriter struct {
+	header  []string
+	columns []string
+	csv     *csv.Writer
+	json    *json.Encoder
+
+	// stream writes every row as an NDJSON object with its row number, in
+	// the order the searches complete.
+	stream bool
+}
+
+// newBatchWriter returns a writer of the rows of br with the columns added,
+// and writes the CSV header. With --output ndjson, rows are streamed as JSON
+// objects whatever the input format.
+func (o *batchOptions) newBatchWriter(w io.Writer, br *batchReader, columns []string) (*batchWriter, error) {
+	bw := &batchWriter{header: br.header, columns: columns, stream: o.outputFormat == output.NDJSON}
+	if br.format == batchCSV && !bw.stream {
+		bw.csv = csv.NewWriter(w)
+		if err := bw.csv.Write(append(append([]string{}, br.header...), columns...)); err != nil {
+			return nil, err
+		}
+		return bw, nil
+	}
+	bw.json = json.NewEncoder(w)
+	return bw, nil
+}
+
+// write writes a row with the values of the added columns. JSON Lines
+// fields with empty values are left out.
+func (bw *batchWriter) write(row *batchRow, values []string) error {
+	if bw.csv != nil {
+		// Pad short rows so the added columns line up with the header.
+		record := make([]string, len(bw.header), len(bw.header)+len(values))
+		copy(record, row.record)
+		record = append(record, values...)
+		if err := bw.csv.Write(record); err != nil {
+			return err
+		}
+		// Flush every row so results stream into pipelines.
+		bw.csv.Flush()
+		return bw.csv.Error()
+	}
+
+	object := row.object
+	if object == nil {
+		// CSV columns become string fields.
+		object = make(map[string]interface{}, len(bw.header)+len(values)+1)
+		for i, name := range bw.header {
+			if i < len(row.record) {
+				object[name] = row.record[i]
+			}
+		}
+	}
+	if bw.stream {
+		object["row"] = row.n + 1
+	}
+	for i, column := range bw.columns {
+		if values[i] != "" {
+			object[column] = values[i]
+		}
+	}
+	return bw.json.Encode(object)
+}
+
+// openBatchInput opens the --input file and returns a reader of its rows in
+// the --format format, which is closed with the returned function.
+func (o *batchOptions) openBatchInput(ctx context.Context) (*batchReader, func() error, error) {
+	fh, err := o.blobs().Open(ctx, o.inputPath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.inputPath,
+		}).Error("error opening input file")
+		return nil, nil, err
+	}
+	br, err := newBatchReader(fh, o.format)
+	if err != nil {
+		fh.Close()
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.inputPath,
+		}).Error("error reading input file")
+		return nil, nil, err
+	}
+	return br, fh.Close, nil
+}
+
+// batchCounts are the numbers of rows written by a batch command.
+type batchCounts struct {
+	rows   int
+	ok     int
+	failed int
+}
+
+// streamBatch reads the rows of br one at a time, searches those request
+// returns a search for with search, and writes every row with the values of
+// its result added, so input of any length is processed in constant memory.
+// Rows request returns an error for are not searched and only get their
+// error. Rows are written in input order unless the writer streams, in which
+// case they are written as their searches complete. The last added column
+// must be the error column, which is how failed rows are counted.
+func streamBatch[S, R any](ctx context.Context, br *batchReader, bw *batchWriter, request func(*batchRow) (S, error), search func(context.Context, <-chan S) <-chan R, index func(R) int, values func(R) []string) (batchCounts, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The reader sends every row to queue, in input order, with the sequence
+	// number of its search or its error, and the searches to searches.
+	type queued struct {
+		row *batchRow
+		seq int
+		err error
+	}
+	queue := make(chan queued, batchQueueSize)
+	searches := make(chan S)
+	var readErr error
+	go func() {
+		defer close(queue)
+		defer close(searches)
+		seq := 0
+		for ctx.Err() == nil {
+			row, err := br.next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				readErr = err
+				return
+			}
+			s, err := request(row)
+			if err != nil {
+				queue <- queued{row: row, seq: -1, err: err}
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case searches <- s:
+			}
+			queue <- queued{row: row, seq: seq}
+			seq++
+		}
+	}()
+	results := search(ctx, searches)
+
+	var counts batchCounts
+	var err error
+	write := func(row *batchRow, v []string, message string) {
+		counts.rows++
+		if e := v[len(v)-1]; e != "" {
+			counts.failed++
+			log.WithFields(logrus.Fields{
+				"error": e,
+				"row":   row.n + 1,
+			}).Warn(message)
+		} else {
+			counts.ok++
+		}
+		if err == nil {
+			if err = bw.write(row, v); err != nil {
+				// Stop reading, the rows left are drained below.
+				cancel()
+			}
+		}
+	}
+	skipped := func(q queued) {
+		v := make([]string, len(bw.columns))
+		v[len(v)-1] = q.err.Error()
+		write(q.row, v, "row skipped")
+	}
+
+	if bw.stream {
+		// Results and their rows may arrive in either order, each waits for
+		// the other in a map no larger than the searches in flight.
+		rows := make(map[int]*batchRow)
+		early := make(map[int]R)
+		for queue != nil || results != nil {
+			select {
+			case q, ok := <-queue:
+				switch {
+				case !ok:
+					queue = nil
+				case q.seq < 0:
+					skipped(q)
+				default:
+					if r, ok := early[q.seq]; ok {
+						delete(early, q.seq)
+						write(q.row, values(r), "error searching row")
+					} else {
+						rows[q.seq] = q.row
+					}
+				}
+			case r, ok := <-results:
+				if !ok {
+					results = nil
+					continue
+				}
+				if row, ok := rows[index(r)]; ok {
+					delete(rows, index(r))
+					write(row, values(r), "error searching row")
+				} else {
+					early[index(r)] = r
+				}
+			}
+		}
+	} else {
+		// Results are ordered, so the result of the next searched row is the
+		// next one received.
+		for q := range queue {
+			if q.seq < 0 {
+				skipped(q)
+				continue
+			}
+			write(q.row, values(<-results), "error searching row")
+		}
+		for range results {
+			// Drain the results so the workers finish.
+		}
+	}
+
+	if readErr != nil {
+		return counts, readErr
+	}
+	return counts, err
+}
+
+// writeBatchOutput opens the --file output and runs streamBatch into it.
+func (o *batchOptions) writeBatchOutput(ctx context.Context, br *batchReader, columns []string, run func(bw *batchWriter) (batchCounts, error)) (batchCounts, error) {
+	w, err := o.blobs().Create(ctx, o.filePath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error opening output file")
+		return batchCounts{}, err
+	}
+	defer w.Close()
+
+	bw, err := o.newBatchWriter(w, br, columns)
+	if err != nil {
+		return batchCounts{}, err
+	}
+	counts, err := run(bw)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error processing rows")
+		return counts, err
+	}
+	return counts, ctx.Err()
+}
+
+func runBatchText(ctx context.Context, o *batchOptions) error {
+	br, closeInput, err := o.openBatchInput(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+	column, err := br.column(o.addressColumn)
+	if err != nil {
+		return err
+	}
+	svc := o.placeService(o.indexName, o.apiKey)
+
+	counts, err := o.writeBatchOutput(ctx, br, []string{"lat", "lon", "label", "score", "error"}, func(bw *batchWriter) (batchCounts, error) {
+		return streamBatch(ctx, br, bw,
+			func(row *batchRow) (placesvc.TextSearch, error) {
+				// Rows without an address are not searched.
+				address, err := row.value(column, o.addressColumn)
+				if err == nil && address == "" {
+					err = errors.New("empty address")
+				}
+				return placesvc.TextSearch{
+					Text:            aws.String(address),
+					FilterCountries: o.countries,
+					MaxResults:      1,
+				}, err
+			},
+			func(ctx context.Context, searches <-chan placesvc.TextSearch) <-chan placesvc.BatchTextResult {
+				return svc.StreamSearchText(ctx, searches, o.batchSearchOptions(!bw.stream))
+			},
+			func(r placesvc.BatchTextResult) int { return r.Index },
+			func(r placesvc.BatchTextResult) []string {
+				values := make([]string, 5)
+				switch {
+				case r.Err != nil:
+					values[4] = r.Err.Error()
+				case len(r.Output.Results) == 0 || r.Output.Results[0].Place == nil:
+					values[4] = "no match"
+				default:
+					result := r.Output.Results[0]
+					if point := result.Place.Geometry; point != nil && len(point.Point) == 2 {
+						values[0] = strconv.FormatFloat(point.Point[1], 'f', -1, 64)
+						values[1] = strconv.FormatFloat(point.Point[0], 'f', -1, 64)
+					}
+					values[2] = aws.ToString(result.Place.Label)
+					values[3] = formatFloat(result.Relevance, 2)
+				}
+				return values
+			})
+	})
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"rows":     counts.rows,
+		"geocoded": counts.ok,
+		"errors":   counts.failed,
+	}).Info("Geocoded rows")
+	return nil
+}
+
+func runBatchPosition(ctx context.Context, o *batchOptions) error {
+	br, closeInput, err := o.openBatchInput(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+	latColumn, err := br.column(o.latColumn)
+	if err != nil {
+		return err
+	}
+	lonColumn, err := br.column(o.lonColumn)
+	if err != nil {
+		return err
+	}
+	svc := o.placeService(o.indexName, o.apiKey)
+
+	columns := append(append([]string{}, batchAddressColumns...), "distance", "error")
+	counts, err := o.writeBatchOutput(ctx, br, columns, func(bw *batchWriter) (batchCounts, error) {
+		return streamBatch(ctx, br, bw,
+			func(row *batchRow) (placesvc.LatLon, error) {
+				// Rows without a valid position are not searched.
+				point, err := row.point(latColumn, o.latColumn, lonColumn, o.lonColumn)
+				if err != nil {
+					return placesvc.LatLon{}, err
+				}
+				return *point, nil
+			},
+			func(ctx context.Context, points <-chan placesvc.LatLon) <-chan placesvc.BatchPositionResult {
+				return svc.StreamReverseGeocode(ctx, points, o.batchSearchOptions(!bw.stream))
+			},
+			func(r placesvc.BatchPositionResult) int { return r.Index },
+			func(r placesvc.BatchPositionResult) []string {
+				values := make([]string, len(columns))
+				switch {
+				case r.Err != nil:
+					values[len(values)-1] = r.Err.Error()
+				case len(r.Output.Results) == 0 || r.Output.Results[0].Place == nil:
+					values[len(values)-1] = "no match"
+				default:
+					result := r.Output.Results[0]
+					copy(values, batchAddress(placesvc.NewPlace(result.Place)))
+					values[len(values)-2] = formatFloat(result.Distance, 3)
+				}
+				return values
+			})
+	})
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"rows":   counts.rows,
+		"found":  counts.ok,
+		"errors": counts.failed,
+	}).Info("Reverse geocoded rows")
+	return nil
+}
@@ -0,0 +1,221 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/smithy-go"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// benchOptions are the flags of the bench command.
+type benchOptions struct {
+	*globalOptions
+
+	concurrency int
+	countries   []string
+	indexName   string
+	operation   string
+	point       string
+	position    *placesvc.LatLon
+	requests    int
+	text        string
+}
+
+// BenchResult summarizes a benchmark run.
+type BenchResult struct {
+	Operation   string
+	Requests    int
+	Concurrency int
+	Errors      int
+	Throttled   int
+	Elapsed     time.Duration
+	TPS         float64
+	Latency     map[string]time.Duration
+}
+
+func newBenchCmd(g *globalOptions) *cobra.Command {
+	o := &benchOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "benchmark search requests",
+		Long:  "Fires a number of text, position or suggestion searches at a place index with the given concurrency and reports latency percentiles, error and throttle rates, and effective transactions per second",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			switch o.operation {
+			case "text", "suggestion":
+				if o.text == "" {
+					return fmt.Errorf("--text is required for %s", o.operation)
+				}
+			case "position":
+				if o.point == "" {
+					return errors.New("--point is required for position")
+				}
+				position, err := parsePoint(o.point)
+				if err != nil {
+					return err
+				}
+				o.position = position
+			default:
+				return fmt.Errorf("invalid operation %q, must be text, position or suggestion", o.operation)
+			}
+			if o.cache {
+				// Cached results would measure the cache instead of AWS.
+				return errors.New("--cache cannot be used with bench")
+			}
+			if o.requests < 1 || o.concurrency < 1 {
+				return errors.New("--requests and --concurrency must be at least 1")
+			}
+			return parseCountries(o.countries)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runBench(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.operation, "operation", "", "text", "[text|position|suggestion]")
+	cmd.Flags().IntVarP(&o.requests, "requests", "n", 100, "total number of requests")
+	cmd.Flags().IntVarP(&o.concurrency, "concurrency", "c", 5, "number of concurrent requests")
+	cmd.Flags().StringVarP(&o.text, "text", "", "", "text to search for (text and suggestion)")
+	cmd.Flags().StringSliceVarP(&o.countries, "country", "", []string{}, "one or more countries to limit the search to, as ISO 3166 code or name")
+	cmd.Flags().StringVarP(&o.point, "point", "", "", "position to search at, as \"lat,lon\" (position)")
+	cmd.MarkFlagRequired("index")
+	return cmd
+}
+
+// isThrottle reports whether the error is a throttling response from the service.
+func isThrottle(err error) bool {
+	var ae smithy.APIError
+	return errors.As(err, &ae) && ae.ErrorCode() == "ThrottlingException"
+}
+
+// percentile returns the p-th percentile of sorted latencies using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// benchRequest issues a single request of the benchmarked operation.
+func benchRequest(ctx context.Context, svc placesvc.PlaceIndexer, o *benchOptions) error {
+	search := &placesvc.SuggestionSearch{
+		Text:            &o.text,
+		FilterCountries: o.countries,
+	}
+	var err error
+	switch o.operation {
+	case "position":
+		_, err = svc.SearchPlaceIndexForPosition(ctx, &placesvc.PositionSearch{Position: o.position})
+	case "suggestion":
+		_, err = svc.SearchPlaceIndexForSuggestions(ctx, search)
+	case "text":
+		_, err = svc.SearchPlaceIndexForText(ctx, search)
+	}
+	return err
+}
+
+func runBench(ctx context.Context, o *benchOptions) error {
+	// Retries of the SDK would hide throttling and add their backoff to the
+	// latencies, so every request is sent exactly once.
+	client := location.New(o.mustClient("").Options(), func(opts *location.Options) {
+		opts.Retryer = retry.AddWithMaxAttempts(opts.Retryer, 1)
+	})
+	svc := o.placeService(o.indexName, "", placesvc.SetLocationClient(client))
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		failed    int
+		throttled int
+		wg        sync.WaitGroup
+	)
+
+	jobs := make(chan struct{})
+	start := time.Now()
+	for i := 0; i < o.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				t := time.Now()
+				err := benchRequest(ctx, svc, o)
+				elapsed := time.Since(t)
+
+				mu.Lock()
+				if err != nil {
+					failed++
+					if isThrottle(err) {
+						throttled++
+					}
+					log.WithFields(logrus.Fields{
+						"error": err,
+					}).Debug("request failed")
+				} else {
+					latencies = append(latencies, elapsed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for i := 0; i < o.requests; i++ {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result := &BenchResult{
+		Operation:   o.operation,
+		Requests:    o.requests,
+		Concurrency: o.concurrency,
+		Errors:      failed,
+		Throttled:   throttled,
+		Elapsed:     elapsed,
+		TPS:         float64(len(latencies)) / elapsed.Seconds(),
+		Latency: map[string]time.Duration{
+			"p50": percentile(latencies, 50),
+			"p90": percentile(latencies, 90),
+			"p95": percentile(latencies, 95),
+			"p99": percentile(latencies, 99),
+			"max": percentile(latencies, 100),
+		},
+	}
+
+	record := output.Record{
+		{Name: "Operation", Value: result.Operation},
+		{Name: "Requests", Value: fmt.Sprintf("%d (concurrency %d)", result.Requests, result.Concurrency)},
+		{Name: "Elapsed", Value: result.Elapsed.Round(time.Millisecond).String()},
+		{Name: "TPS", Value: fmt.Sprintf("%.2f", result.TPS)},
+		{Name: "Errors", Value: fmt.Sprintf("%d (%.1f%%)", result.Errors, 100*float64(result.Errors)/float64(result.Requests))},
+		{Name: "Throttled", Value: fmt.Sprintf("%d (%.1f%%)", result.Throttled, 100*float64(result.Throttled)/float64(result.Requests))},
+	}
+	for _, p := range []string{"p50", "p90", "p95", "p99", "max"} {
+		record = append(record, output.Field{Name: "Latency " + p, Value: result.Latency[p].Round(time.Millisecond).String()})
+	}
+	return o.writeResult(&output.Result{Data: result, Record: record})
+}
@@ -0,0 +1,171 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/clientmgr"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/cache"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Cache backends selected with --cache-backend.
+const (
+	cacheBackendFile     = "file"
+	cacheBackendDynamoDB = "dynamodb"
+)
+
+// resultCache is a search result cache of one of the backends.
+type resultCache interface {
+	placesvc.Cache
+	Purge(ctx context.Context, all bool) (int, error)
+	Stats(ctx context.Context) (*cache.Stats, error)
+	Close() error
+}
+
+// cacheOptions are the flags of the cache commands.
+type cacheOptions struct {
+	*globalOptions
+
+	all bool
+}
+
+func newCacheCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "manage the local search result cache",
+		Long:  "Searches run with --cache store their results and reuse them until --cache-ttl passes, so identical searches are only paid for once. Results are stored in a file under the user cache directory, or with --cache-backend dynamodb in the --cache-table table shared by every machine running batch jobs. The table needs a string partition key named key; enable time to live on its expires attribute to have DynamoDB delete expired entries",
+	}
+
+	cmd.AddCommand(
+		newCachePurgeCmd(g),
+		newCacheStatsCmd(g),
+	)
+	return cmd
+}
+
+func newCachePurgeCmd(g *globalOptions) *cobra.Command {
+	o := &cacheOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:         "purge",
+		Short:       "delete expired cache entries",
+		Long:        "Deletes the expired entries of the cache, or every entry with --all. Purging a DynamoDB cache scans the whole table",
+		Annotations: map[string]string{annotationNoSetup: "true"},
+		PreRun:      g.cacheConfig,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCachePurge(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.all, "all", "", false, "delete every entry, not only the expired ones")
+	return cmd
+}
+
+func newCacheStatsCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "stats",
+		Short:       "show the size of the cache",
+		Annotations: map[string]string{annotationNoSetup: "true"},
+		PreRun:      g.cacheConfig,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCacheStats(cmd.Context(), g); err != nil {
+				exit(err)
+			}
+		},
+	}
+	return cmd
+}
+
+func runCachePurge(ctx context.Context, o *cacheOptions) error {
+	f, err := o.openCache()
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error opening cache")
+		return err
+	}
+	deleted, err := f.Purge(ctx, o.all)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error purging cache")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"deleted": deleted,
+	}).Info("Purged cache")
+	return nil
+}
+
+func runCacheStats(ctx context.Context, g *globalOptions) error {
+	f, err := g.openCache()
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error opening cache")
+		return err
+	}
+	stats, err := f.Stats(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error reading cache")
+		return err
+	}
+	return g.writeResult(&output.Result{
+		Data: stats,
+		Record: output.Record{
+			{Name: "Location", Value: stats.Location},
+			{Name: "Entries", Value: fmt.Sprint(stats.Entries)},
+			{Name: "Expired", Value: fmt.Sprint(stats.Expired)},
+			{Name: "Size (bytes)", Value: fmt.Sprint(stats.Bytes)},
+		},
+	})
+}
+
+// cacheConfig loads the AWS configuration for the cache commands, which only
+// need it to reach a DynamoDB cache.
+func (g *globalOptions) cacheConfig(cmd *cobra.Command, args []string) {
+	if g.cacheBackend == cacheBackendDynamoDB {
+		g.loadConfig()
+	}
+}
+
+// openCache opens the cache of the --cache-backend on first use.
+func (g *globalOptions) openCache() (resultCache, error) {
+	if g.resultCache != nil {
+		return g.resultCache, nil
+	}
+	switch g.cacheBackend {
+	case cacheBackendFile:
+		path, err := cache.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+		f, err := cache.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		g.resultCache = f
+	case cacheBackendDynamoDB:
+		if g.cacheTable == "" {
+			return nil, errors.New("--cache-table is required with --cache-backend dynamodb")
+		}
+		c, err := clientmgr.Default.Config(g.clientKey())
+		if err != nil {
+			return nil, err
+		}
+		g.resultCache = cache.NewTable(dynamodb.NewFromConfig(c), g.cacheTable)
+	default:
+		return nil, fmt.Errorf("invalid cache backend %q, must be %s or %s", g.cacheBackend, cacheBackendFile, cacheBackendDynamoDB)
+	}
+	return g.resultCache, nil
+}
@@ -0,0 +1,247 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// calculatorOptions are the flags of the route calculator commands.
+type calculatorOptions struct {
+	*globalOptions
+	sortOptions
+
+	all            bool
+	calculatorName string
+	dataSource     string
+	description    string
+	maxItems       int
+	tags           []string
+}
+
+func newCalculatorCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "calculator",
+		Short: "manage route calculators",
+	}
+
+	cmd.AddCommand(
+		newCalculatorCreateCmd(g),
+		newCalculatorDeleteCmd(g),
+		newCalculatorDescribeCmd(g),
+		newCalculatorListCmd(g),
+		newCalculatorUpdateCmd(g),
+	)
+	return cmd
+}
+
+func newCalculatorCreateCmd(g *globalOptions) *cobra.Command {
+	o := &calculatorOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a route calculator",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCalculatorCreate(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.calculatorName, "calculator", "", "", "route calculator name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "route calculator description")
+	cmd.Flags().StringVarP(&o.dataSource, "data-source", "", placesvc.DataSourceHere, "data provider [Esri|Grab|Here], Grab is limited to ap-southeast-1")
+	cmd.Flags().StringSliceVarP(&o.tags, "tags", "", []string{}, "route calculator tags (key=value)")
+	cmd.MarkFlagRequired("calculator")
+	return cmd
+}
+
+func newCalculatorDeleteCmd(g *globalOptions) *cobra.Command {
+	o := &calculatorOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "delete a route calculator",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCalculatorDelete(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.calculatorName, "calculator", "", "", "route calculator name")
+	cmd.MarkFlagRequired("calculator")
+	return cmd
+}
+
+func newCalculatorDescribeCmd(g *globalOptions) *cobra.Command {
+	o := &calculatorOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "describe a route calculator",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCalculatorDescribe(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.calculatorName, "calculator", "", "", "route calculator name")
+	cmd.MarkFlagRequired("calculator")
+	return cmd
+}
+
+func newCalculatorListCmd(g *globalOptions) *cobra.Command {
+	o := &calculatorOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list route calculators",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.all && cmd.Flags().Changed("max-items") {
+				return errors.New("--all and --max-items are mutually exclusive")
+			}
+			if o.maxItems < 1 {
+				return errors.New("--max-items must be at least 1")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCalculatorList(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.all, "all", "", false, "list every route calculator, following all result pages")
+	cmd.Flags().IntVarP(&o.maxItems, "max-items", "", 100, "maximum number of route calculators to fetch")
+	cmd.Flags().StringVarP(&o.sort, "sort", "", "", "sort results by [label]")
+	cmd.Flags().IntVarP(&o.limit, "limit", "", 0, "maximum number of results to output")
+	return cmd
+}
+
+func newCalculatorUpdateCmd(g *globalOptions) *cobra.Command {
+	o := &calculatorOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "update a route calculator",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCalculatorUpdate(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.calculatorName, "calculator", "", "", "route calculator name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "route calculator description")
+	cmd.MarkFlagRequired("calculator")
+	return cmd
+}
+
+func runCalculatorCreate(ctx context.Context, o *calculatorOptions) error {
+	svc := o.routeService(o.calculatorName)
+	tags, err := parseTags(o.tags)
+	if err != nil {
+		return err
+	}
+	if ret, err := svc.CreateRouteCalculator(ctx, o.dataSource, o.description, &tags); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error creating route calculator")
+		return err
+	} else {
+		log.WithFields(logrus.Fields{
+			"createTime":     ret.CreateTime,
+			"calculatorARN":  *ret.CalculatorArn,
+			"calculatorName": *ret.CalculatorName,
+		}).Info("Created route calculator")
+	}
+	return nil
+}
+
+func runCalculatorDelete(ctx context.Context, o *calculatorOptions) error {
+	svc := o.routeService(o.calculatorName)
+	if _, err := svc.DeleteRouteCalculator(ctx); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error deleting route calculator")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"calculatorName": o.calculatorName,
+	}).Info("Deleted route calculator")
+	return nil
+}
+
+func runCalculatorDescribe(ctx context.Context, o *calculatorOptions) error {
+	svc := o.routeService(o.calculatorName)
+	ret, err := svc.DescribeRouteCalculator(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error describing route calculator")
+		return err
+	}
+
+	return o.writeResult(&output.Result{
+		Data: ret,
+		Record: output.Record{
+			{Name: "Calculator Name", Value: aws.ToString(ret.CalculatorName)},
+			{Name: "Description", Value: aws.ToString(ret.Description)},
+			{Name: "Data Source", Value: aws.ToString(ret.DataSource)},
+			{Name: "Create Time", Value: fmt.Sprint(ret.CreateTime)},
+			{Name: "Update Time", Value: fmt.Sprint(ret.UpdateTime)},
+			{Name: "Calculator ARN", Value: aws.ToString(ret.CalculatorArn)},
+			{Name: "Tags", Value: formatTags(ret.Tags)},
+		},
+	})
+}
+
+func runCalculatorList(ctx context.Context, o *calculatorOptions) error {
+	svc := o.routeService(o.calculatorName)
+	maxItems := o.maxItems
+	if o.all {
+		maxItems = 0
+	}
+	entries, err := svc.ListRouteCalculators(ctx, maxItems)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error listing route calculators")
+		return err
+	}
+	if entries, err = sortAndLimit(o.sortOptions, entries, func(e types.ListRouteCalculatorsResponseEntry) sortKey {
+		return sortKey{label: e.CalculatorName}
+	}, sortLabel); err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"count": len(entries),
+	}).Info("Listed route calculators")
+
+	rows := &output.Rows{Header: []string{"CTime", "MTime", "Calculator", "DataSource", "Description"}}
+	for _, entry := range entries {
+		rows.Rows = append(rows.Rows, []string{fmt.Sprint(entry.CreateTime), fmt.Sprint(entry.UpdateTime), aws.ToString(entry.CalculatorName), aws.ToString(entry.DataSource), aws.ToString(entry.Description)})
+	}
+	return o.writeResult(&output.Result{Data: entries, Rows: rows})
+}
+
+func runCalculatorUpdate(ctx context.Context, o *calculatorOptions) error {
+	svc := o.routeService(o.calculatorName)
+	if _, err := svc.UpdateRouteCalculator(ctx, o.description); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error updating route calculator")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"calculatorName": o.calculatorName,
+	}).Info("Updated route calculator")
+	return nil
+}
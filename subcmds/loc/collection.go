@@ -0,0 +1,248 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/geofencesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// collectionOptions are the flags of the geofence collection commands.
+type collectionOptions struct {
+	*globalOptions
+	sortOptions
+
+	all            bool
+	collectionName string
+	description    string
+	kmsKeyID       string
+	maxItems       int
+	tags           []string
+}
+
+func newCollectionCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "collection",
+		Short: "manage geofence collections",
+	}
+
+	cmd.AddCommand(
+		newCollectionCreateCmd(g),
+		newCollectionDeleteCmd(g),
+		newCollectionDescribeCmd(g),
+		newCollectionListCmd(g),
+		newCollectionUpdateCmd(g),
+	)
+	return cmd
+}
+
+func newCollectionCreateCmd(g *globalOptions) *cobra.Command {
+	o := &collectionOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a geofence collection",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCollectionCreate(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "geofence collection description")
+	cmd.Flags().StringVarP(&o.kmsKeyID, "kms-key-id", "", "", "customer managed KMS key to encrypt the collection data with")
+	cmd.Flags().StringSliceVarP(&o.tags, "tags", "", []string{}, "geofence collection tags (key=value)")
+	cmd.MarkFlagRequired("collection")
+	return cmd
+}
+
+func newCollectionDeleteCmd(g *globalOptions) *cobra.Command {
+	o := &collectionOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "delete a geofence collection",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCollectionDelete(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.MarkFlagRequired("collection")
+	return cmd
+}
+
+func newCollectionDescribeCmd(g *globalOptions) *cobra.Command {
+	o := &collectionOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "describe a geofence collection",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCollectionDescribe(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.MarkFlagRequired("collection")
+	return cmd
+}
+
+func newCollectionListCmd(g *globalOptions) *cobra.Command {
+	o := &collectionOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list geofence collections",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.all && cmd.Flags().Changed("max-items") {
+				return errors.New("--all and --max-items are mutually exclusive")
+			}
+			if o.maxItems < 1 {
+				return errors.New("--max-items must be at least 1")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCollectionList(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.all, "all", "", false, "list every geofence collection, following all result pages")
+	cmd.Flags().IntVarP(&o.maxItems, "max-items", "", 100, "maximum number of geofence collections to fetch")
+	cmd.Flags().StringVarP(&o.sort, "sort", "", "", "sort results by [label]")
+	cmd.Flags().IntVarP(&o.limit, "limit", "", 0, "maximum number of results to output")
+	return cmd
+}
+
+func newCollectionUpdateCmd(g *globalOptions) *cobra.Command {
+	o := &collectionOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "update a geofence collection",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCollectionUpdate(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "geofence collection description")
+	cmd.MarkFlagRequired("collection")
+	return cmd
+}
+
+func runCollectionCreate(ctx context.Context, o *collectionOptions) error {
+	svc := o.geofenceService(o.collectionName, geofencesvc.SetKMSKeyID(o.kmsKeyID))
+	tags, err := parseTags(o.tags)
+	if err != nil {
+		return err
+	}
+	if ret, err := svc.CreateGeofenceCollection(ctx, o.description, &tags); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error creating geofence collection")
+		return err
+	} else {
+		log.WithFields(logrus.Fields{
+			"createTime":     ret.CreateTime,
+			"collectionARN":  *ret.CollectionArn,
+			"collectionName": *ret.CollectionName,
+		}).Info("Created geofence collection")
+	}
+	return nil
+}
+
+func runCollectionDelete(ctx context.Context, o *collectionOptions) error {
+	svc := o.geofenceService(o.collectionName, geofencesvc.SetKMSKeyID(o.kmsKeyID))
+	if _, err := svc.DeleteGeofenceCollection(ctx); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error deleting geofence collection")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"collectionName": o.collectionName,
+	}).Info("Deleted geofence collection")
+	return nil
+}
+
+func runCollectionDescribe(ctx context.Context, o *collectionOptions) error {
+	svc := o.geofenceService(o.collectionName, geofencesvc.SetKMSKeyID(o.kmsKeyID))
+	ret, err := svc.DescribeGeofenceCollection(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error describing geofence collection")
+		return err
+	}
+
+	return o.writeResult(&output.Result{
+		Data: ret,
+		Record: output.Record{
+			{Name: "Collection Name", Value: aws.ToString(ret.CollectionName)},
+			{Name: "Description", Value: aws.ToString(ret.Description)},
+			{Name: "Geofences", Value: fmt.Sprint(aws.ToInt32(ret.GeofenceCount))},
+			{Name: "KMS Key ID", Value: aws.ToString(ret.KmsKeyId)},
+			{Name: "Create Time", Value: fmt.Sprint(ret.CreateTime)},
+			{Name: "Update Time", Value: fmt.Sprint(ret.UpdateTime)},
+			{Name: "Collection ARN", Value: aws.ToString(ret.CollectionArn)},
+			{Name: "Tags", Value: formatTags(ret.Tags)},
+		},
+	})
+}
+
+func runCollectionList(ctx context.Context, o *collectionOptions) error {
+	svc := o.geofenceService(o.collectionName, geofencesvc.SetKMSKeyID(o.kmsKeyID))
+	maxItems := o.maxItems
+	if o.all {
+		maxItems = 0
+	}
+	entries, err := svc.ListGeofenceCollections(ctx, maxItems)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error listing geofence collections")
+		return err
+	}
+	if entries, err = sortAndLimit(o.sortOptions, entries, func(e types.ListGeofenceCollectionsResponseEntry) sortKey {
+		return sortKey{label: e.CollectionName}
+	}, sortLabel); err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"count": len(entries),
+	}).Info("Listed geofence collections")
+
+	rows := &output.Rows{Header: []string{"CTime", "MTime", "Collection", "Description"}}
+	for _, entry := range entries {
+		rows.Rows = append(rows.Rows, []string{fmt.Sprint(entry.CreateTime), fmt.Sprint(entry.UpdateTime), aws.ToString(entry.CollectionName), aws.ToString(entry.Description)})
+	}
+	return o.writeResult(&output.Result{Data: entries, Rows: rows})
+}
+
+func runCollectionUpdate(ctx context.Context, o *collectionOptions) error {
+	svc := o.geofenceService(o.collectionName, geofencesvc.SetKMSKeyID(o.kmsKeyID))
+	if _, err := svc.UpdateGeofenceCollection(ctx, o.description); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error updating geofence collection")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"collectionName": o.collectionName,
+	}).Info("Updated geofence collection")
+	return nil
+}
@@ -0,0 +1,214 @@
+package loc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+)
+
+// searchRow is a single text, position or suggestion search result. Fields
+// a search does not return are left empty.
+type searchRow struct {
+	place      *placesvc.Place
+	text       string
+	placeID    string
+	distance   *float64
+	relevance  *float64
+	categories []string
+}
+
+// searchColumn is a column of the table and csv output of searches.
+type searchColumn struct {
+	header string
+	value  func(r *searchRow) string
+}
+
+// placeField returns a column showing a field of the place.
+func placeField(header string, field func(p *placesvc.Place) string) searchColumn {
+	return searchColumn{header: header, value: func(r *searchRow) string {
+		if r.place == nil {
+			return ""
+		}
+		return field(r.place)
+	}}
+}
+
+// searchColumns are the columns which can be selected with --columns.
+var searchColumns = map[string]searchColumn{
+	"label": {header: "Label", value: func(r *searchRow) string {
+		if r.place != nil {
+			return r.place.Label
+		}
+		return r.text
+	}},
+	"lat": {header: "Latitude", value: func(r *searchRow) string {
+		if r.place == nil || len(r.place.Point) != 2 {
+			return ""
+		}
+		return strconv.FormatFloat(r.place.Point[1], 'f', -1, 64)
+	}},
+	"lon": {header: "Longitude", value: func(r *searchRow) string {
+		if r.place == nil || len(r.place.Point) != 2 {
+			return ""
+		}
+		return strconv.FormatFloat(r.place.Point[0], 'f', -1, 64)
+	}},
+	"position": {header: "Position", value: func(r *searchRow) string {
+		if r.place == nil || len(r.place.Point) != 2 {
+			return ""
+		}
+		return fmt.Sprintf("%.6f,%.6f", r.place.Point[1], r.place.Point[0])
+	}},
+	"address":         placeField("Address", (*placesvc.Place).FormattedAddress),
+	"addressnumber":   placeField("AddressNumber", func(p *placesvc.Place) string { return p.AddressNumber }),
+	"street":          placeField("Street", func(p *placesvc.Place) string { return p.Street }),
+	"unit":            placeField("Unit", func(p *placesvc.Place) string { return strings.TrimSpace(p.UnitType + " " + p.UnitNumber) }),
+	"neighborhood":    placeField("Neighborhood", func(p *placesvc.Place) string { return p.Neighborhood }),
+	"submunicipality": placeField("SubMunicipality", func(p *placesvc.Place) string { return p.SubMunicipality }),
+	"municipality":    placeField("Municipality", func(p *placesvc.Place) string { return p.Municipality }),
+	"subregion":       placeField("SubRegion", func(p *placesvc.Place) string { return p.SubRegion }),
+	"region":          placeField("Region", func(p *placesvc.Place) string { return p.Region }),
+	"postalcode":      placeField("PostalCode", func(p *placesvc.Place) string { return p.PostalCode }),
+	"country":         placeField("Country", func(p *placesvc.Place) string { return p.Country }),
+	"timezone": placeField("TimeZone", func(p *placesvc.Place) string {
+		if p.TimeZone == nil {
+			return ""
+		}
+		return p.TimeZone.Name
+	}),
+	"categories": {header: "Categories", value: func(r *searchRow) string {
+		if r.place != nil {
+			return strings.Join(r.place.Categories, ", ")
+		}
+		return strings.Join(r.categories, ", ")
+	}},
+	"relevance": {header: "Relevance", value: func(r *searchRow) string { return formatFloat(r.relevance, 2) }},
+	"distance":  {header: "Distance", value: func(r *searchRow) string { return formatFloat(r.distance, 3) }},
+	"placeid":   {header: "PlaceId", value: func(r *searchRow) string { return r.placeID }},
+}
+
+// checkColumns validates the --columns, --wide and --format flags.
+func (o *indexOptions) checkColumns() error {
+	if len(o.columns) > 0 && o.wide {
+		return errors.New("--columns and --wide are mutually exclusive")
+	}
+	if o.template != "" && o.query != "" {
+		return errors.New("--format and --query are mutually exclusive")
+	}
+	if o.template != "" {
+		tmpl, err := output.ParseTemplate(o.template)
+		if err != nil {
+			return err
+		}
+		o.outputTemplate = tmpl
+	}
+	for _, name := range o.columns {
+		if _, ok := searchColumns[strings.ToLower(name)]; !ok {
+			names := make([]string, 0, len(searchColumns))
+			for name := range searchColumns {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("invalid column %q, must be one of: %s", name, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// searchTable lays out search results in the columns selected with --columns,
+// the wide columns with --wide, or else the default columns. Distances are
+// shown in the unit selected with --units in tables, and kept in meters in
+// csv output, like in the structured formats.
+func (o *indexOptions) searchTable(results []searchRow, defaults []string, wide []string) *output.Rows {
+	table := o.outputFormat == output.Table
+	columns := defaults
+	switch {
+	case len(o.columns) > 0:
+		columns = o.columns
+	case o.wide:
+		columns = wide
+	}
+	rows := &output.Rows{}
+	for _, name := range columns {
+		column := searchColumns[strings.ToLower(name)]
+		header := column.header
+		if table && strings.EqualFold(name, "distance") {
+			header += " (" + o.distanceUnit() + ")"
+		}
+		rows.Header = append(rows.Header, header)
+	}
+	for i := range results {
+		r := results[i]
+		if table {
+			r.distance = o.fromMeters(r.distance)
+		}
+		row := make([]string, 0, len(columns))
+		for _, name := range columns {
+			row = append(row, searchColumns[strings.ToLower(name)].value(&r))
+		}
+		rows.Rows = append(rows.Rows, row)
+	}
+	return rows
+}
+
+// searchItems converts search results into the values of a --format
+// template, which has every column as a field named by its header.
+func searchItems(results []searchRow) []map[string]string {
+	items := make([]map[string]string, 0, len(results))
+	for i := range results {
+		item := make(map[string]string, len(searchColumns))
+		for _, column := range searchColumns {
+			item[column.header] = column.value(&results[i])
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// textRows converts text search results into search rows.
+func textRows(results []types.SearchForTextResult) []searchRow {
+	rows := make([]searchRow, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, searchRow{
+			place:     placesvc.NewPlace(r.Place),
+			placeID:   aws.ToString(r.PlaceId),
+			distance:  r.Distance,
+			relevance: r.Relevance,
+		})
+	}
+	return rows
+}
+
+// positionRows converts position search results into search rows.
+func positionRows(results []types.SearchForPositionResult) []searchRow {
+	rows := make([]searchRow, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, searchRow{
+			place:    placesvc.NewPlace(r.Place),
+			placeID:  aws.ToString(r.PlaceId),
+			distance: r.Distance,
+		})
+	}
+	return rows
+}
+
+// suggestionRows converts suggestion search results into search rows.
+func suggestionRows(results []types.SearchForSuggestionsResult) []searchRow {
+	rows := make([]searchRow, 0, len(results))
+	for _, r := range results {
+		rows = append(rows, searchRow{
+			text:       aws.ToString(r.Text),
+			placeID:    aws.ToString(r.PlaceId),
+			categories: r.Categories,
+		})
+	}
+	return rows
+}
@@ -0,0 +1,518 @@
+package loc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/clientmgr"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/mattn/go-isatty"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// configOptions are the flags of the config commands.
+type configOptions struct {
+	*globalOptions
+
+	force     bool
+	indexName string
+}
+
+// starterConfig is the config file written by config init.
+type starterConfig struct {
+	AwsProfile   string `yaml:"AwsProfile"`
+	AwsRegion    string `yaml:"AwsRegion"`
+	DefaultIndex string `yaml:"DefaultIndex,omitempty"`
+}
+
+// Keys of the named contexts of the config file. Contexts maps each name to
+// the settings which override the top-level ones when the context is used:
+//
+//	CurrentContext: dev
+//	Contexts:
+//	  dev:
+//	    AwsProfile: dev
+//	    AwsRegion: us-east-1
+//	    DefaultIndex: dev-index
+//	  prod:
+//	    AwsProfile: prod
+//	    AwsRegion: eu-west-1
+//	    DataSource: Esri
+const (
+	currentContextKey = "CurrentContext"
+	contextsKey       = "Contexts"
+)
+
+// contextSettings are the settings a context may set.
+var contextSettings = []string{"AwsProfile", "AwsRegion", "DefaultIndex", "DataSource"}
+
+// contextInfo is a context listed by config get-contexts.
+type contextInfo struct {
+	Name         string `json:"name"`
+	Current      bool   `json:"current"`
+	AwsProfile   string `json:"awsProfile,omitempty"`
+	AwsRegion    string `json:"awsRegion,omitempty"`
+	DefaultIndex string `json:"defaultIndex,omitempty"`
+	DataSource   string `json:"dataSource,omitempty"`
+}
+
+// Statuses of the checks of config validate.
+const (
+	checkOK      = "ok"
+	checkFailed  = "failed"
+	checkSkipped = "skipped"
+)
+
+// userConfigDir returns the goawsloc directory in the user config directory,
+// such as ~/.config/goawsloc on Linux.
+func userConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goawsloc"), nil
+}
+
+// applyContext overrides the top-level settings of the config file with
+// those of the named context. Context names are not case sensitive.
+func applyContext(name string) error {
+	if name == "" {
+		return nil
+	}
+	settings := viper.Sub(contextsKey + "." + name)
+	if strings.Contains(name, ".") || settings == nil {
+		return fmt.Errorf("context %q not found in config file", name)
+	}
+	for _, key := range contextSettings {
+		if settings.IsSet(key) {
+			viper.Set(key, settings.Get(key))
+		}
+	}
+	return nil
+}
+
+// applyConfigDefaults sets the --index flag of the command to the
+// DefaultIndex of the config file and its --data-source flag to the
+// DataSource, if they were not given.
+func applyConfigDefaults(cmd *cobra.Command) {
+	defaults := map[string]string{"data-source": "DataSource"}
+	if cmd.Annotations[annotationNoDefaultIndex] == "" {
+		defaults["index"] = "DefaultIndex"
+	}
+	for flag, key := range defaults {
+		if f := cmd.Flags().Lookup(flag); f != nil && !f.Changed && viper.IsSet(key) {
+			cmd.Flags().Set(flag, viper.GetString(key))
+		}
+	}
+}
+
+func newConfigCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "manage the config file",
+	}
+
+	cmd.AddCommand(
+		newConfigGetContextsCmd(g),
+		newConfigInitCmd(g),
+		newConfigUseContextCmd(g),
+		newConfigValidateCmd(g),
+	)
+	return cmd
+}
+
+func newConfigInitCmd(g *globalOptions) *cobra.Command {
+	o := &configOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:         "init",
+		Short:       "write a starter config file",
+		Long:        "Asks for the AWS profile, region and default index and writes them to config.yaml in the goawsloc user config directory, such as ~/.config/goawsloc on Linux, or to the --config file. --profile, --region and --index set the suggested answers, which default to $AWS_PROFILE or default and $AWS_REGION or us-east-1; without a terminal they are written as given. The default index is used by commands whose --index is not given, except create, delete and update. An existing file is only replaced with --force or after confirming",
+		Annotations: map[string]string{annotationNoSetup: "true"},
+		Example: `  loc config init
+  loc config init --profile dev --region eu-west-1 --index my-index --force`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runConfigInit(o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "default place index")
+	cmd.Flags().BoolVarP(&o.force, "force", "", false, "replace an existing config file without asking")
+	return cmd
+}
+
+func newConfigValidateCmd(g *globalOptions) *cobra.Command {
+	o := &configOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:         "validate",
+		Short:       "check the config file, credentials and default index",
+		Long:        "Reads the config file and checks that the AWS credentials of its profile are valid, that Amazon Location Service and the data source of the index are available in its region, and that the index exists. The index is --index or else the DefaultIndex of the config file. Each check is printed with its result; the command fails if any check failed",
+		Annotations: map[string]string{annotationNoSetup: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runConfigValidate(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "place index to check (default the DefaultIndex of the config file)")
+	return cmd
+}
+
+func newConfigGetContextsCmd(g *globalOptions) *cobra.Command {
+	o := &configOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:         "get-contexts",
+		Short:       "list the named contexts of the config file",
+		Annotations: map[string]string{annotationNoSetup: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runConfigGetContexts(o); err != nil {
+				exit(err)
+			}
+		},
+	}
+	return cmd
+}
+
+func newConfigUseContextCmd(g *globalOptions) *cobra.Command {
+	o := &configOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:         "use-context <name>",
+		Short:       "set the current context of the config file",
+		Long:        "Sets the CurrentContext of the config file, so its AWS profile, region, default index and data source are used until another context is chosen. --context overrides it for a single command",
+		Annotations: map[string]string{annotationNoSetup: "true"},
+		Args:        cobra.ExactArgs(1),
+		Example: `  loc config use-context prod
+  loc text --text "Seattle"
+  loc --context dev text --text "Seattle"`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runConfigUseContext(o, args[0]); err != nil {
+				exit(err)
+			}
+		},
+	}
+	return cmd
+}
+
+func runConfigGetContexts(o *configOptions) error {
+	if err := o.findConfig(); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error reading config file")
+		return err
+	}
+	current := strings.ToLower(firstNonEmpty(o.contextName, viper.GetString(currentContextKey)))
+
+	names := make([]string, 0)
+	for name := range viper.GetStringMap(contextsKey) {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	contexts := make([]contextInfo, 0, len(names))
+	rows := &output.Rows{Header: []string{"Current", "Name", "Profile", "Region", "Default Index", "Data Source"}}
+	for _, name := range names {
+		settings := viper.Sub(contextsKey + "." + name)
+		if settings == nil {
+			continue
+		}
+		c := contextInfo{
+			Name:         name,
+			Current:      name == current,
+			AwsProfile:   settings.GetString("AwsProfile"),
+			AwsRegion:    settings.GetString("AwsRegion"),
+			DefaultIndex: settings.GetString("DefaultIndex"),
+			DataSource:   settings.GetString("DataSource"),
+		}
+		marker := ""
+		if c.Current {
+			marker = "*"
+		}
+		contexts = append(contexts, c)
+		rows.Rows = append(rows.Rows, []string{marker, c.Name, c.AwsProfile, c.AwsRegion, c.DefaultIndex, c.DataSource})
+	}
+	return o.writeResult(&output.Result{Data: contexts, Rows: rows})
+}
+
+func runConfigUseContext(o *configOptions, name string) error {
+	if err := o.findConfig(); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error reading config file")
+		return err
+	}
+	if strings.Contains(name, ".") || viper.Sub(contextsKey+"."+name) == nil {
+		err := fmt.Errorf("context %q not found in config file", name)
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  viper.ConfigFileUsed(),
+		}).Error("error switching context")
+		return err
+	}
+
+	configPath := viper.ConfigFileUsed()
+	if err := setConfigValue(configPath, currentContextKey, name); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  configPath,
+		}).Error("error writing config file")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"context": name,
+		"path":    configPath,
+	}).Info("Switched context")
+	return nil
+}
+
+// setConfigValue sets a top-level key of a YAML config file, keeping its
+// other content and comments.
+func setConfigValue(configPath string, key string, value string) error {
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".yaml", ".yml":
+	default:
+		return errors.New("only YAML config files can be changed")
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return errors.New("the config file is not a YAML mapping")
+	}
+
+	root := doc.Content[0]
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if strings.EqualFold(root.Content[i].Value, key) {
+			root.Content[i+1].SetString(value)
+			found = true
+		}
+	}
+	if !found {
+		k, v := &yaml.Node{}, &yaml.Node{}
+		k.SetString(key)
+		v.SetString(value)
+		root.Content = append(root.Content, k, v)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(configPath, buf.Bytes(), info.Mode().Perm())
+}
+
+func runConfigInit(o *configOptions) error {
+	configPath := o.configPath
+	if configPath == "" {
+		dir, err := userConfigDir()
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error locating user config directory, set the path with --config")
+			return err
+		}
+		configPath = filepath.Join(dir, "config.yaml")
+	}
+
+	config := starterConfig{
+		AwsProfile:   firstNonEmpty(o.profile, os.Getenv("AWS_PROFILE"), "default"),
+		AwsRegion:    firstNonEmpty(o.region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		DefaultIndex: o.indexName,
+	}
+	interactive := isatty.IsTerminal(os.Stdin.Fd())
+	if interactive {
+		in := bufio.NewReader(os.Stdin)
+		for _, q := range []struct {
+			question string
+			value    *string
+		}{
+			{"AWS profile", &config.AwsProfile},
+			{"AWS region", &config.AwsRegion},
+			{"Default place index (optional)", &config.DefaultIndex},
+		} {
+			answer, err := ask(in, q.question, *q.value)
+			if err != nil {
+				return err
+			}
+			*q.value = answer
+		}
+	}
+	if config.AwsProfile == "" || config.AwsRegion == "" {
+		return errors.New("the AWS profile and region must not be empty")
+	}
+	if err := placesvc.CheckRegion("", config.AwsRegion); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Warn("region may not support Amazon Location Service")
+	}
+
+	if _, err := os.Stat(configPath); err == nil && !o.force {
+		if !interactive || !confirm(fmt.Sprintf("Replace %s?", configPath)) {
+			return fmt.Errorf("%s already exists, use --force to replace it", configPath)
+		}
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	data, err := yaml.Marshal(&config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o700); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  configPath,
+		}).Error("error creating config directory")
+		return err
+	}
+	if err := os.WriteFile(configPath, data, 0o600); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  configPath,
+		}).Error("error writing config file")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"path": configPath,
+	}).Info("Wrote config file, check it with `loc config validate`")
+	return nil
+}
+
+// ask asks a question on stderr and returns the answer read from in, or the
+// default if the answer is empty.
+func ask(in *bufio.Reader, question string, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+	}
+	answer, err := in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+// firstNonEmpty returns the first value which is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func runConfigValidate(ctx context.Context, o *configOptions) error {
+	rows := &output.Rows{Header: []string{"Check", "Result", "Detail"}}
+	var checks []map[string]string
+	failed := false
+	add := func(check string, result string, detail string) {
+		rows.Rows = append(rows.Rows, []string{check, result, detail})
+		checks = append(checks, map[string]string{"check": check, "result": result, "detail": detail})
+		failed = failed || result == checkFailed
+	}
+	write := func() error {
+		if err := o.writeResult(&output.Result{Data: checks, Rows: rows}); err != nil {
+			return err
+		}
+		if failed {
+			return errors.New("configuration is invalid")
+		}
+		return nil
+	}
+
+	if err := o.readConfig(); err != nil {
+		add("config file", checkFailed, err.Error())
+		return write()
+	}
+	if viper.ConfigFileUsed() == "" {
+		add("config file", checkSkipped, "none found, using --profile, --region and the environment")
+	} else if name := firstNonEmpty(o.contextName, viper.GetString(currentContextKey)); name != "" {
+		add("config file", checkOK, fmt.Sprintf("%s, context %s", viper.ConfigFileUsed(), name))
+	} else {
+		add("config file", checkOK, viper.ConfigFileUsed())
+	}
+
+	// the region is checked for the data source of the index once it is
+	// known, or else only for Amazon Location Service
+	dataSource := ""
+	checkRegion := func() {
+		if err := placesvc.CheckRegion(dataSource, o.awsRegion); err != nil {
+			add("region", checkFailed, err.Error())
+		} else if dataSource != "" {
+			add("region", checkOK, fmt.Sprintf("%s supports %s", o.awsRegion, dataSource))
+		} else {
+			add("region", checkOK, o.awsRegion)
+		}
+	}
+
+	c, err := clientmgr.Default.Config(o.clientKey())
+	if err == nil {
+		var identity *sts.GetCallerIdentityOutput
+		if identity, err = sts.NewFromConfig(c).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err == nil {
+			add("credentials", checkOK, fmt.Sprintf("profile %s as %s", o.awsProfile, aws.ToString(identity.Arn)))
+		}
+	}
+	if err != nil {
+		add("credentials", checkFailed, fmt.Sprintf("profile %s: %v", o.awsProfile, err))
+		checkRegion()
+		add("index", checkSkipped, "no valid credentials")
+		return write()
+	}
+
+	indexName := firstNonEmpty(o.indexName, viper.GetString("DefaultIndex"))
+	if indexName == "" {
+		checkRegion()
+		add("index", checkSkipped, "neither --index nor DefaultIndex set")
+		return write()
+	}
+	index, err := o.placeService(indexName, "").DescribePlaceIndex(ctx, indexName)
+	if err != nil {
+		checkRegion()
+		add("index", checkFailed, fmt.Sprintf("%s: %v", indexName, err))
+		return write()
+	}
+	dataSource = aws.ToString(index.DataSource)
+	checkRegion()
+	detail := fmt.Sprintf("%s (%s)", indexName, dataSource)
+	if index.DataSourceConfiguration != nil {
+		detail = fmt.Sprintf("%s (%s, %s)", indexName, dataSource, index.DataSourceConfiguration.IntendedUse)
+	}
+	add("index", checkOK, detail)
+	return write()
+}
@@ -0,0 +1,177 @@
+package loc
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/geojson"
+
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// enrichOptions are the flags of the enrich commands.
+type enrichOptions struct {
+	*globalOptions
+	rateOptions
+
+	filePath  string
+	indexName string
+	inputPath string
+	prefix    string
+}
+
+func newEnrichCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "enrich",
+		Short: "add place data to existing files",
+	}
+
+	cmd.AddCommand(
+		newEnrichGeoJSONCmd(g),
+	)
+	return cmd
+}
+
+func newEnrichGeoJSONCmd(g *globalOptions) *cobra.Command {
+	o := &enrichOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "geojson",
+		Short: "reverse geocode the Point features of a GeoJSON file",
+		Long:  "Reverse geocodes every Point feature of a GeoJSON FeatureCollection and writes the address into the feature properties, keeping all other geometry and properties",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return o.checkRate()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runEnrichGeoJSON(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.inputPath, "input", "i", "", "GeoJSON FeatureCollection file")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "output file (default stdout)")
+	cmd.Flags().StringVarP(&o.prefix, "prefix", "", "address_", "prefix of the added property names")
+	o.addRateFlags(cmd)
+	cmd.MarkFlagRequired("index")
+	cmd.MarkFlagRequired("input")
+	return cmd
+}
+
+// addressProperties sets the address fields of a place as feature properties.
+// Fields missing from the place are left out.
+func addressProperties(properties map[string]interface{}, prefix string, place *types.Place) {
+	fields := map[string]*string{
+		"label":        place.Label,
+		"number":       place.AddressNumber,
+		"street":       place.Street,
+		"neighborhood": place.Neighborhood,
+		"municipality": place.Municipality,
+		"subregion":    place.SubRegion,
+		"region":       place.Region,
+		"postalcode":   place.PostalCode,
+		"country":      place.Country,
+	}
+	for name, value := range fields {
+		if value != nil {
+			properties[prefix+name] = *value
+		}
+	}
+}
+
+func runEnrichGeoJSON(ctx context.Context, o *enrichOptions) error {
+	svc := o.placeService(o.indexName, "")
+	fh, err := os.Open(path.Clean(o.inputPath))
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.inputPath,
+		}).Error("error opening input file")
+		return err
+	}
+	fc, err := geojson.Read(fh)
+	fh.Close()
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.inputPath,
+		}).Error("error reading GeoJSON")
+		return err
+	}
+
+	var (
+		features []*geojson.Feature
+		points   []placesvc.LatLon
+		skipped  int
+		failed   int
+	)
+	for _, feature := range fc.Features {
+		if feature.Geometry == nil || feature.Geometry.Type != geojson.TypePoint {
+			skipped++
+			continue
+		}
+		position, err := feature.Geometry.Point()
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+				"id":    feature.ID,
+			}).Warn("invalid point")
+			failed++
+			continue
+		}
+		if feature.Properties == nil {
+			feature.Properties = map[string]interface{}{}
+		}
+		features = append(features, feature)
+		points = append(points, placesvc.LatLon{Latitude: position[1], Longitude: position[0]})
+	}
+
+	enriched := 0
+	for r := range svc.BatchReverseGeocode(ctx, points, o.batchSearchOptions(false)) {
+		feature := features[r.Index]
+		if r.Err != nil {
+			failed++
+			log.WithFields(logrus.Fields{
+				"error": r.Err,
+				"id":    feature.ID,
+			}).Warn("error searching position")
+		} else if len(r.Output.Results) > 0 && r.Output.Results[0].Place != nil {
+			enriched++
+			addressProperties(feature.Properties, o.prefix, r.Output.Results[0].Place)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"enriched": enriched,
+		"errors":   failed,
+		"skipped":  skipped,
+	}).Info("Enriched GeoJSON")
+
+	w, err := openOutput(o.filePath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error opening output file")
+		return err
+	}
+	defer w.Close()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(fc); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error writing GeoJSON")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/clientmgr"
+	"github.com/rmrfslashbin/goawsloc/pkg/geoevents"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// eventsOptions are the flags of the geofence events command.
+type eventsOptions struct {
+	*globalOptions
+	webhookOptions
+
+	collectionName string
+	eventTypes     []string
+	keep           bool
+	name           string
+	queueURL       string
+}
+
+func newGeofenceEventsCmd(g *globalOptions) *cobra.Command {
+	o := &eventsOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "stream geofence ENTER and EXIT events",
+		Long:  "Streams the geofence events of a collection live until interrupted, to watch breaches while testing. Amazon Location Service publishes the events to EventBridge, so an EventBridge rule routing them to a new SQS queue is created, and both are deleted again on exit unless --keep is set. With --queue-url, events are read from a queue which already receives them, such as one kept before, instead. Each event is printed as a row, or posted as JSON to the --webhook URLs or the Webhooks of the config file; events a webhook fails to take after retries are received again after the visibility timeout of the queue",
+		Example: `  loc geofence events --collection my-collection
+  loc geofence events --collection my-collection --type ENTER --webhook http://localhost:9000/breach
+  loc geofence events --queue-url https://sqs.us-east-1.amazonaws.com/123456789012/goawsloc-events-1a2b3c4d -o ndjson`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if (o.collectionName == "") == (o.queueURL == "") {
+				return errors.New("exactly one of --collection and --queue-url must be set")
+			}
+			for i, t := range o.eventTypes {
+				o.eventTypes[i] = strings.ToUpper(t)
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runGeofenceEvents(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.Flags().StringSliceVarP(&o.eventTypes, "type", "", []string{}, "event types to stream [ENTER|EXIT] (default both)")
+	cmd.Flags().StringVarP(&o.name, "name", "", "", "name of the rule and queue to create (default goawsloc-events- with a random suffix)")
+	cmd.Flags().BoolVarP(&o.keep, "keep", "", false, "keep the rule and queue on exit, to read them later with --queue-url")
+	cmd.Flags().StringVarP(&o.queueURL, "queue-url", "", "", "URL of an SQS queue already receiving geofence events")
+	o.addWebhookFlags(cmd)
+	return cmd
+}
+
+func runGeofenceEvents(ctx context.Context, o *eventsOptions) error {
+	webhooks, err := o.dispatcher()
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error configuring webhooks")
+		return err
+	}
+	c, err := clientmgr.Default.Config(o.clientKey())
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error loading AWS config")
+		return err
+	}
+
+	var sub *geoevents.Subscription
+	if o.queueURL != "" {
+		sub = geoevents.Subscribe(sqs.NewFromConfig(c), o.queueURL, geoevents.SetLogger(log))
+	} else {
+		collection, err := o.geofenceService(o.collectionName).DescribeGeofenceCollection(ctx)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error describing geofence collection")
+			return err
+		}
+		sub, err = geoevents.Create(ctx, eventbridge.NewFromConfig(c), sqs.NewFromConfig(c), o.name, aws.ToString(collection.CollectionArn), o.eventTypes, geoevents.SetLogger(log))
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error creating event rule and queue")
+			return err
+		}
+		log.WithFields(logrus.Fields{
+			"rule":  sub.RuleName,
+			"queue": sub.QueueURL,
+		}).Info("Created event rule and queue")
+		defer deleteSubscription(ctx, o, sub)
+	}
+
+	var handle func(*geoevents.Event) error
+	if webhooks != nil {
+		handle = func(e *geoevents.Event) error {
+			if err := webhooks.Send(ctx, e.EventType, e.ID, e); err != nil {
+				return err
+			}
+			log.WithFields(logrus.Fields{
+				"id":         e.ID,
+				"eventType":  e.EventType,
+				"geofenceId": e.GeofenceID,
+				"deviceId":   e.DeviceID,
+			}).Info("Posted event")
+			return nil
+		}
+	} else {
+		handle = func(e *geoevents.Event) error {
+			return o.writeResult(&output.Result{
+				Data:  e,
+				Rows:  &output.Rows{Rows: [][]string{eventRow(e)}},
+				Items: []*geoevents.Event{e},
+			})
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"queue": sub.QueueURL,
+	}).Info("Waiting for events")
+	if err := sub.Receive(ctx, handle); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error receiving events")
+		return err
+	}
+	return nil
+}
+
+// deleteSubscription deletes the rule and queue created for the events, or
+// tells how to read them again with --keep. It runs after an interrupt, so it
+// does not use the cancelled context.
+func deleteSubscription(ctx context.Context, o *eventsOptions, sub *geoevents.Subscription) {
+	if o.keep {
+		log.WithFields(logrus.Fields{
+			"rule":  sub.RuleName,
+			"queue": sub.QueueURL,
+		}).Info("Kept event rule and queue, read them with --queue-url")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	if err := sub.Delete(ctx); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"rule":  sub.RuleName,
+			"queue": sub.QueueURL,
+		}).Error("error deleting event rule and queue")
+		return
+	}
+	log.WithFields(logrus.Fields{
+		"rule":  sub.RuleName,
+		"queue": sub.QueueURL,
+	}).Info("Deleted event rule and queue")
+}
+
+// eventRow returns the table row of an event: sample time, event type,
+// geofence, device and position as lat,lon.
+func eventRow(e *geoevents.Event) []string {
+	position := ""
+	if len(e.Position) == 2 {
+		position = fmt.Sprintf("%v,%v", e.Position[1], e.Position[0])
+	}
+	return []string{e.SampleTime.Format(time.RFC3339), e.EventType, e.GeofenceID, e.DeviceID, position}
+}
@@ -0,0 +1,67 @@
+package loc
+
+import (
+	"errors"
+	"os"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+
+	"github.com/aws/smithy-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Exit codes of failed commands, so scripts can tell common failures apart.
+const (
+	exitFailure       = 1
+	exitNotFound      = 3
+	exitAlreadyExists = 4
+	exitThrottled     = 5
+	exitAccessDenied  = 6
+	exitCredentials   = 7
+)
+
+// exitCodes maps the typed errors of the service packages, and the error
+// codes of AWS for the services which return them unwrapped, to an exit code
+// and a message explaining the failure.
+var exitCodes = []struct {
+	err     error
+	apiCode string
+	code    int
+	message string
+}{
+	{placesvc.ErrIndexNotFound, "ResourceNotFoundException", exitNotFound, "resource not found, check the name and region"},
+	{placesvc.ErrIndexAlreadyExists, "ConflictException", exitAlreadyExists, "resource already exists, choose another name"},
+	{placesvc.ErrThrottled, "ThrottlingException", exitThrottled, "request throttled by AWS, retry later or lower the request rate"},
+	{placesvc.ErrAccessDenied, "AccessDeniedException", exitAccessDenied, "access denied, check the IAM permissions of the profile"},
+}
+
+// exitCode returns the exit code matching err and a message explaining the
+// failure, or exitFailure and an empty message for other errors.
+func exitCode(err error) (int, string) {
+	var credErr *placesvc.CredentialsError
+	if errors.As(err, &credErr) {
+		return exitCredentials, "unable to load AWS credentials, check the profile or run `loc login`"
+	}
+	var apiErr smithy.APIError
+	isAPIErr := errors.As(err, &apiErr)
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) || (isAPIErr && apiErr.ErrorCode() == e.apiCode) {
+			return e.code, e.message
+		}
+	}
+	return exitFailure, ""
+}
+
+// exit logs err, finishes the command and exits with the code matching err.
+func exit(err error) {
+	code, message := exitCode(err)
+	if message == "" {
+		log.Error(err)
+	} else {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error(message)
+	}
+	finish()
+	os.Exit(code)
+}
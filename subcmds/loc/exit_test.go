@@ -0,0 +1,44 @@
+package loc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"plain error", errors.New("boom"), exitFailure},
+		{"not found", placesvc.ErrIndexNotFound, exitNotFound},
+		{"wrapped not found", fmt.Errorf("describing index: %w", placesvc.ErrIndexNotFound), exitNotFound},
+		{"already exists", placesvc.ErrIndexAlreadyExists, exitAlreadyExists},
+		{"throttled", placesvc.ErrThrottled, exitThrottled},
+		{"access denied", placesvc.ErrAccessDenied, exitAccessDenied},
+		{"api not found", &smithy.GenericAPIError{Code: "ResourceNotFoundException"}, exitNotFound},
+		{"api conflict", &smithy.GenericAPIError{Code: "ConflictException"}, exitAlreadyExists},
+		{"api throttling", fmt.Errorf("tracker: %w", &smithy.GenericAPIError{Code: "ThrottlingException"}), exitThrottled},
+		{"api access denied", &smithy.GenericAPIError{Code: "AccessDeniedException"}, exitAccessDenied},
+		{"api validation", &smithy.GenericAPIError{Code: "ValidationException"}, exitFailure},
+		{"credentials", &placesvc.CredentialsError{Profile: "dev", Err: errors.New("no profile")}, exitCredentials},
+		{"wrapped credentials", fmt.Errorf("client: %w", &placesvc.CredentialsError{Err: errors.New("expired")}), exitCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, message := exitCode(tt.err)
+			if code != tt.want {
+				t.Errorf("exitCode() = %d, want %d", code, tt.want)
+			}
+			if (message == "") != (tt.want == exitFailure) {
+				t.Errorf("exitCode() message = %q for code %d", message, code)
+			}
+		})
+	}
+}
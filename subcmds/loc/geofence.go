@@ -0,0 +1,712 @@
+package loc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/geofencesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/geojson"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// geofenceOptions are the flags of the geofence commands.
+type geofenceOptions struct {
+	*globalOptions
+	sortOptions
+
+	all            bool
+	circle         string
+	collectionName string
+	filePath       string
+	format         string
+	geofenceID     string
+	geofenceIDs    []string
+	idsPath        string
+	polygonPath    string
+	properties     []string
+	timeout        time.Duration
+	wait           bool
+	yes            bool
+}
+
+func newGeofenceCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "geofence",
+		Short: "geofence collections and geofences",
+	}
+
+	cmd.AddCommand(
+		newCollectionCmd(g),
+		newGeofenceDeleteCmd(g),
+		newGeofenceEventsCmd(g),
+		newGeofenceExportCmd(g),
+		newGeofenceGetCmd(g),
+		newGeofenceImportCmd(g),
+		newGeofenceListCmd(g),
+		newGeofencePutCmd(g),
+		newGeofenceWaitCmd(g),
+	)
+	return cmd
+}
+
+func newGeofenceDeleteCmd(g *globalOptions) *cobra.Command {
+	o := &geofenceOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "delete geofences from a collection",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			set := 0
+			for _, name := range []string{"ids", "ids-file", "all"} {
+				if cmd.Flags().Changed(name) {
+					set++
+				}
+			}
+			if set != 1 {
+				return errors.New("exactly one of --ids, --ids-file or --all must be set")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runGeofenceDelete(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.Flags().StringSliceVarP(&o.geofenceIDs, "ids", "", []string{}, "geofence IDs to delete")
+	cmd.Flags().StringVarP(&o.idsPath, "ids-file", "", "", "file with one geofence ID per line")
+	cmd.Flags().BoolVarP(&o.all, "all", "", false, "delete every geofence in the collection")
+	cmd.Flags().BoolVarP(&o.yes, "yes", "y", false, "do not ask for confirmation")
+	cmd.MarkFlagRequired("collection")
+	return cmd
+}
+
+func newGeofenceExportCmd(g *globalOptions) *cobra.Command {
+	o := &geofenceOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "write the geofences of a collection as GeoJSON",
+		Long:  "Writes every geofence of a collection as a GeoJSON FeatureCollection with the geofence ID as feature id and the status, times and geofence properties as feature properties. Circles are written as the Point at their center with a radius property in meters",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runGeofenceExport(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "output file (default stdout)")
+	cmd.MarkFlagRequired("collection")
+	return cmd
+}
+
+func newGeofenceGetCmd(g *globalOptions) *cobra.Command {
+	o := &geofenceOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "get a single geofence",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.format != "geojson" && o.format != "wkt" {
+				return fmt.Errorf("invalid format %q, must be geojson or wkt", o.format)
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runGeofenceGet(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.Flags().StringVarP(&o.geofenceID, "id", "", "", "geofence ID")
+	cmd.Flags().StringVarP(&o.format, "format", "", "geojson", "geometry format [geojson|wkt]")
+	cmd.MarkFlagRequired("collection")
+	cmd.MarkFlagRequired("id")
+	return cmd
+}
+
+func newGeofenceImportCmd(g *globalOptions) *cobra.Command {
+	o := &geofenceOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "create or replace geofences from a GeoJSON file",
+		Long:  "Puts the Polygon and MultiPolygon features of a GeoJSON FeatureCollection into a collection. The feature id becomes the geofence ID, the polygons of a MultiPolygon are stored as <id>-1, <id>-2 and so on. All features are validated before any geofence is stored",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runGeofenceImport(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "GeoJSON FeatureCollection file")
+	cmd.Flags().BoolVarP(&o.wait, "wait", "", false, "wait until the geofences are no longer pending")
+	cmd.Flags().DurationVarP(&o.timeout, "timeout", "", 5*time.Minute, "maximum time to wait")
+	cmd.MarkFlagRequired("collection")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func newGeofenceListCmd(g *globalOptions) *cobra.Command {
+	o := &geofenceOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the geofences of a collection",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runGeofenceList(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.Flags().StringVarP(&o.sort, "sort", "", "", "sort results by [label]")
+	cmd.Flags().IntVarP(&o.limit, "limit", "", 0, "maximum number of results to output")
+	cmd.MarkFlagRequired("collection")
+	return cmd
+}
+
+func newGeofencePutCmd(g *globalOptions) *cobra.Command {
+	o := &geofenceOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "put",
+		Short: "create or replace a geofence",
+		Long:  "Creates a geofence, or replaces the geometry and properties of an existing one. The area is either a GeoJSON Polygon read from --polygon, which may also be a Feature or a FeatureCollection with a single feature, or a circle given as lat,lon,radius with the radius in kilometers, or in miles with --units imperial",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if (o.polygonPath == "") == (o.circle == "") {
+				return errors.New("exactly one of --polygon or --circle must be set")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runGeofencePut(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.Flags().StringVarP(&o.geofenceID, "id", "", "", "geofence ID")
+	cmd.Flags().StringVarP(&o.polygonPath, "polygon", "", "", "GeoJSON file with the polygon")
+	cmd.Flags().StringVarP(&o.circle, "circle", "", "", "circle as lat,lon,radius with the radius in kilometers, or miles with --units imperial")
+	cmd.Flags().StringSliceVarP(&o.properties, "property", "", []string{}, "geofence property (key=value), up to 3")
+	cmd.Flags().BoolVarP(&o.wait, "wait", "", false, "wait until the geofence is no longer pending")
+	cmd.Flags().DurationVarP(&o.timeout, "timeout", "", 5*time.Minute, "maximum time to wait")
+	cmd.MarkFlagRequired("collection")
+	cmd.MarkFlagRequired("id")
+	return cmd
+}
+
+func newGeofenceWaitCmd(g *globalOptions) *cobra.Command {
+	o := &geofenceOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "wait",
+		Short: "wait until geofences are no longer pending",
+		Long:  "Polls a geofence collection until the given geofences report ACTIVE or FAILED, then summarizes any failures",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("ids") == cmd.Flags().Changed("ids-file") {
+				return errors.New("exactly one of --ids or --ids-file must be set")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runGeofenceWait(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection name")
+	cmd.Flags().StringSliceVarP(&o.geofenceIDs, "ids", "", []string{}, "geofence IDs to wait for")
+	cmd.Flags().StringVarP(&o.idsPath, "ids-file", "", "", "file with one geofence ID per line")
+	cmd.Flags().DurationVarP(&o.timeout, "timeout", "", 5*time.Minute, "maximum time to wait")
+	cmd.MarkFlagRequired("collection")
+	return cmd
+}
+
+// geometryGeoJSON converts a geofence geometry into a GeoJSON geometry.
+// GeoJSON has no circles, a circle is converted into the Point at its center.
+func geometryGeoJSON(geometry *types.GeofenceGeometry) *geojson.Geometry {
+	if geometry.Circle != nil {
+		return geojson.NewPoint(geometry.Circle.Center)
+	}
+	return geojson.NewPolygon(geometry.Polygon)
+}
+
+// geometryWKT converts a geofence geometry into Well-Known Text. WKT has no
+// circles, a circle is converted into the POINT at its center.
+func geometryWKT(geometry *types.GeofenceGeometry) string {
+	if geometry.Circle != nil {
+		return fmt.Sprintf("POINT (%v %v)", geometry.Circle.Center[0], geometry.Circle.Center[1])
+	}
+	rings := make([]string, 0, len(geometry.Polygon))
+	for _, ring := range geometry.Polygon {
+		points := make([]string, 0, len(ring))
+		for _, point := range ring {
+			points = append(points, fmt.Sprintf("%v %v", point[0], point[1]))
+		}
+		rings = append(rings, "("+strings.Join(points, ", ")+")")
+	}
+	return "POLYGON (" + strings.Join(rings, ", ") + ")"
+}
+
+func runGeofenceDelete(ctx context.Context, o *geofenceOptions) error {
+	svc := o.geofenceService(o.collectionName)
+	geofenceIDs := o.geofenceIDs
+	switch {
+	case o.idsPath != "":
+		var err error
+		if geofenceIDs, err = readIDs(o.idsPath); err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+				"path":  o.idsPath,
+			}).Error("error reading geofence IDs file")
+			return err
+		}
+	case o.all:
+		entries, err := svc.ListGeofences(ctx)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error listing geofences")
+			return err
+		}
+		for _, entry := range entries {
+			geofenceIDs = append(geofenceIDs, aws.ToString(entry.GeofenceId))
+		}
+	}
+
+	if len(geofenceIDs) == 0 {
+		log.Info("No geofences to delete")
+		return nil
+	}
+
+	if !o.yes && !confirm(fmt.Sprintf("Delete %d geofence(s) from collection %s?", len(geofenceIDs), o.collectionName)) {
+		log.Info("Delete cancelled")
+		return nil
+	}
+
+	if ret, err := svc.BatchDeleteGeofences(ctx, geofenceIDs); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error deleting geofences")
+		return err
+	} else {
+		for _, e := range ret.Errors {
+			fields := logrus.Fields{
+				"geofenceId": aws.ToString(e.GeofenceId),
+			}
+			if e.Error != nil {
+				fields["code"] = e.Error.Code
+				fields["message"] = aws.ToString(e.Error.Message)
+			}
+			log.WithFields(fields).Warn("unable to delete geofence")
+		}
+		log.WithFields(logrus.Fields{
+			"count":  len(geofenceIDs) - len(ret.Errors),
+			"errors": len(ret.Errors),
+		}).Info("Deleted geofences")
+	}
+	return nil
+}
+
+// geofencesGeoJSON converts geofences into a FeatureCollection.
+func geofencesGeoJSON(entries []types.ListGeofenceResponseEntry) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, entry := range entries {
+		if entry.Geometry == nil {
+			continue
+		}
+		properties := map[string]interface{}{
+			"status":     aws.ToString(entry.Status),
+			"createTime": entry.CreateTime,
+			"updateTime": entry.UpdateTime,
+		}
+		for k, v := range entry.GeofenceProperties {
+			properties[k] = v
+		}
+		if entry.Geometry.Circle != nil {
+			properties["radius"] = aws.ToFloat64(entry.Geometry.Circle.Radius)
+		}
+		feature := geojson.NewFeature(geometryGeoJSON(entry.Geometry), properties)
+		feature.ID = aws.ToString(entry.GeofenceId)
+		fc.AddFeature(feature)
+	}
+	return fc
+}
+
+func runGeofenceExport(ctx context.Context, o *geofenceOptions) error {
+	svc := o.geofenceService(o.collectionName)
+	entries, err := svc.ListGeofences(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error listing geofences")
+		return err
+	}
+
+	w, err := openOutput(o.filePath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error opening output file")
+		return err
+	}
+	defer w.Close()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(geofencesGeoJSON(entries)); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error writing GeoJSON")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"count": len(entries),
+	}).Info("Exported geofences")
+	return nil
+}
+
+func runGeofenceGet(ctx context.Context, o *geofenceOptions) error {
+	svc := o.geofenceService(o.collectionName)
+	ret, err := svc.GetGeofence(ctx, o.geofenceID)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error getting geofence")
+		return err
+	}
+
+	record := output.Record{
+		{Name: "Geofence ID", Value: aws.ToString(ret.GeofenceId)},
+		{Name: "Status", Value: aws.ToString(ret.Status)},
+		{Name: "Create Time", Value: fmt.Sprint(ret.CreateTime)},
+		{Name: "Update Time", Value: fmt.Sprint(ret.UpdateTime)},
+	}
+	switch o.format {
+	case "geojson":
+		data, err := json.Marshal(geometryGeoJSON(ret.Geometry))
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error marshalling json")
+			return err
+		}
+		record = append(record, output.Field{Name: "Geometry", Value: string(data)})
+	case "wkt":
+		record = append(record, output.Field{Name: "Geometry", Value: geometryWKT(ret.Geometry)})
+	}
+	if ret.Geometry.Circle != nil {
+		record = append(record, output.Field{Name: "Radius", Value: fmt.Sprintf("%v %s", aws.ToFloat64(o.fromMeters(ret.Geometry.Circle.Radius)), o.distanceUnit())})
+	}
+	return o.writeResult(&output.Result{
+		Data:   ret,
+		Record: record,
+		GeoJSON: geofencesGeoJSON([]types.ListGeofenceResponseEntry{{
+			CreateTime:         ret.CreateTime,
+			GeofenceId:         ret.GeofenceId,
+			Geometry:           ret.Geometry,
+			Status:             ret.Status,
+			UpdateTime:         ret.UpdateTime,
+			GeofenceProperties: ret.GeofenceProperties,
+		}}),
+	})
+}
+
+// parseCircle parses a circle given as lat,lon,radius.
+func parseCircle(value string) (*geofencesvc.Circle, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid circle %q, must be lat,lon,radius", value)
+	}
+	center, err := parseLatLon(parts[0] + "," + parts[1])
+	if err != nil {
+		return nil, err
+	}
+	radius, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid radius in %q", value)
+	}
+	return &geofencesvc.Circle{Latitude: center.Latitude, Longitude: center.Longitude, Radius: radius}, nil
+}
+
+// readPolygon reads a Polygon from a GeoJSON file holding a Polygon geometry,
+// a Feature or a FeatureCollection with a single feature.
+func readPolygon(filename string) ([][][]float64, error) {
+	data, err := os.ReadFile(path.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	var object struct {
+		Type     string            `json:"type"`
+		Geometry *geojson.Geometry `json:"geometry"`
+		Features []*geojson.Feature
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	var geometry *geojson.Geometry
+	switch object.Type {
+	case "FeatureCollection":
+		if len(object.Features) != 1 {
+			return nil, fmt.Errorf("%s: FeatureCollection has %d features, need exactly 1", filename, len(object.Features))
+		}
+		geometry = object.Features[0].Geometry
+	case "Feature":
+		geometry = object.Geometry
+	default:
+		geometry = &geojson.Geometry{}
+		if err := json.Unmarshal(data, geometry); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	if geometry == nil {
+		return nil, fmt.Errorf("%s: feature has no geometry", filename)
+	}
+	rings, err := geometry.Polygon()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return rings, nil
+}
+
+// featureID returns the id of a feature as a string, or "" if it has none.
+func featureID(feature *geojson.Feature) string {
+	switch id := feature.ID.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	}
+	return ""
+}
+
+// geofenceEntries converts the Polygon and MultiPolygon features of a
+// FeatureCollection into geofences. Features without an id are named after
+// their position in the collection.
+func geofenceEntries(fc *geojson.FeatureCollection) ([]geofencesvc.GeofenceEntry, error) {
+	var entries []geofencesvc.GeofenceEntry
+	for i, feature := range fc.Features {
+		id := featureID(feature)
+		if id == "" {
+			id = strconv.Itoa(i + 1)
+		}
+		if feature.Geometry == nil {
+			return nil, fmt.Errorf("feature %s has no geometry", id)
+		}
+		switch feature.Geometry.Type {
+		case geojson.TypePolygon:
+			rings, err := feature.Geometry.Polygon()
+			if err != nil {
+				return nil, fmt.Errorf("feature %s: %w", id, err)
+			}
+			entries = append(entries, geofencesvc.GeofenceEntry{ID: id, Geometry: &geofencesvc.Geometry{Polygon: rings}})
+		case geojson.TypeMultiPolygon:
+			polygons, err := feature.Geometry.MultiPolygon()
+			if err != nil {
+				return nil, fmt.Errorf("feature %s: %w", id, err)
+			}
+			for j, rings := range polygons {
+				entries = append(entries, geofencesvc.GeofenceEntry{ID: fmt.Sprintf("%s-%d", id, j+1), Geometry: &geofencesvc.Geometry{Polygon: rings}})
+			}
+		default:
+			return nil, fmt.Errorf("feature %s is a %s, must be a %s or %s", id, feature.Geometry.Type, geojson.TypePolygon, geojson.TypeMultiPolygon)
+		}
+	}
+	return entries, nil
+}
+
+func runGeofenceImport(ctx context.Context, o *geofenceOptions) error {
+	svc := o.geofenceService(o.collectionName)
+	fh, err := os.Open(path.Clean(o.filePath))
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error opening input file")
+		return err
+	}
+	fc, err := geojson.Read(fh)
+	fh.Close()
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error reading GeoJSON")
+		return err
+	}
+
+	entries, err := geofenceEntries(fc)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error converting features")
+		return err
+	}
+
+	ret, err := svc.BatchPutGeofences(ctx, entries)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error importing geofences")
+		return err
+	}
+	for _, e := range ret.Errors {
+		fields := logrus.Fields{
+			"geofenceId": aws.ToString(e.GeofenceId),
+		}
+		if e.Error != nil {
+			fields["code"] = e.Error.Code
+			fields["message"] = aws.ToString(e.Error.Message)
+		}
+		log.WithFields(fields).Warn("unable to put geofence")
+	}
+	log.WithFields(logrus.Fields{
+		"count":  len(ret.Successes),
+		"errors": len(ret.Errors),
+	}).Info("Imported geofences")
+
+	if o.wait && len(ret.Successes) > 0 {
+		geofenceIDs := make([]string, 0, len(ret.Successes))
+		for _, success := range ret.Successes {
+			geofenceIDs = append(geofenceIDs, aws.ToString(success.GeofenceId))
+		}
+		return waitForGeofences(ctx, svc, geofenceIDs, o.timeout)
+	}
+	return nil
+}
+
+func runGeofenceList(ctx context.Context, o *geofenceOptions) error {
+	svc := o.geofenceService(o.collectionName)
+	entries, err := svc.ListGeofences(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error listing geofences")
+		return err
+	}
+	if entries, err = sortAndLimit(o.sortOptions, entries, func(e types.ListGeofenceResponseEntry) sortKey {
+		return sortKey{label: e.GeofenceId}
+	}, sortLabel); err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"count": len(entries),
+	}).Info("Listed geofences")
+
+	rows := &output.Rows{Header: []string{"CTime", "MTime", "Geofence", "Status", "Shape"}}
+	for _, entry := range entries {
+		shape := "polygon"
+		if entry.Geometry != nil && entry.Geometry.Circle != nil {
+			shape = "circle"
+		}
+		rows.Rows = append(rows.Rows, []string{fmt.Sprint(entry.CreateTime), fmt.Sprint(entry.UpdateTime), aws.ToString(entry.GeofenceId), aws.ToString(entry.Status), shape})
+	}
+	return o.writeResult(&output.Result{Data: entries, Rows: rows, GeoJSON: geofencesGeoJSON(entries)})
+}
+
+func runGeofencePut(ctx context.Context, o *geofenceOptions) error {
+	svc := o.geofenceService(o.collectionName)
+	geometry := &geofencesvc.Geometry{}
+	if o.circle != "" {
+		circle, err := parseCircle(o.circle)
+		if err != nil {
+			return err
+		}
+		circle.Radius = o.toMeters(circle.Radius)
+		geometry.Circle = circle
+	} else {
+		rings, err := readPolygon(o.polygonPath)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+				"path":  o.polygonPath,
+			}).Error("error reading polygon")
+			return err
+		}
+		geometry.Polygon = rings
+	}
+	properties, err := parseTags(o.properties)
+	if err != nil {
+		return err
+	}
+
+	if ret, err := svc.PutGeofence(ctx, o.geofenceID, geometry, properties); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error putting geofence")
+		return err
+	} else {
+		log.WithFields(logrus.Fields{
+			"geofenceId": aws.ToString(ret.GeofenceId),
+			"createTime": ret.CreateTime,
+			"updateTime": ret.UpdateTime,
+		}).Info("Put geofence")
+	}
+
+	if o.wait {
+		return waitForGeofences(ctx, svc, []string{o.geofenceID}, o.timeout)
+	}
+	return nil
+}
+
+// waitForGeofences waits for the geofences to leave the PENDING state and
+// logs every geofence which did not become active.
+func waitForGeofences(ctx context.Context, svc *geofencesvc.Config, geofenceIDs []string, timeout time.Duration) error {
+	statuses, err := svc.WaitForGeofences(ctx, geofenceIDs, 2*time.Second, timeout)
+	failed := 0
+	for id, status := range statuses {
+		if status != geofencesvc.StatusActive {
+			failed++
+			log.WithFields(logrus.Fields{
+				"geofenceId": id,
+				"status":     status,
+			}).Warn("geofence is not active")
+		}
+	}
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error waiting for geofences")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"active":   len(statuses) - failed,
+		"inactive": failed,
+	}).Info("Geofences settled")
+	return nil
+}
+
+func runGeofenceWait(ctx context.Context, o *geofenceOptions) error {
+	geofenceIDs := o.geofenceIDs
+	if o.idsPath != "" {
+		var err error
+		if geofenceIDs, err = readIDs(o.idsPath); err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+				"path":  o.idsPath,
+			}).Error("error reading geofence IDs file")
+			return err
+		}
+	}
+	return waitForGeofences(ctx, o.geofenceService(o.collectionName), geofenceIDs, o.timeout)
+}
@@ -0,0 +1,146 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/clientmgr"
+	"github.com/rmrfslashbin/goawsloc/pkg/blob"
+	"github.com/rmrfslashbin/goawsloc/pkg/ingest"
+
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Positions a stream is read from without a checkpoint.
+const (
+	ingestFromTrimHorizon = "trim-horizon"
+	ingestFromLatest      = "latest"
+)
+
+// ingestOptions are the flags of the tracker ingest command.
+type ingestOptions struct {
+	*globalOptions
+
+	batchSize      int
+	checkpointPath string
+	flushInterval  time.Duration
+	follow         bool
+	from           string
+	pollInterval   time.Duration
+	s3URI          string
+	stream         string
+	trackerName    string
+}
+
+func newTrackerIngestCmd(g *globalOptions) *cobra.Command {
+	o := &ingestOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "ingest",
+		Short: "send device positions from Kinesis or S3 to a tracker",
+		Long:  "Reads device positions from the records of a Kinesis data stream, or from the files Kinesis Data Firehose delivers under an S3 prefix, and sends them to a tracker in batches of --batch-size positions, or sooner once the oldest waited --flush-interval. Records and files hold JSON objects in the format of the JSON Lines files of tracker push, separated by newlines or concatenated as Firehose writes them; gzip files are decompressed. Invalid objects are logged and skipped. Every shard of a stream is read until interrupted, child shards of a resharding once their parents ended; S3 files are read in key order, and with --follow new files are picked up. How far each shard or prefix was sent is saved to the --checkpoint file after every batch, so a restarted ingest resumes there. Streams without a checkpoint are read from --from",
+		Example: `  loc tracker ingest --tracker my-tracker --stream positions
+  loc tracker ingest --tracker my-tracker --stream positions --from trim-horizon --batch-size 50
+  loc tracker ingest --tracker my-tracker --s3 s3://bucket/firehose/2025/ --follow`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if (o.stream == "") == (o.s3URI == "") {
+				return errors.New("exactly one of --stream and --s3 must be set")
+			}
+			if o.from != ingestFromTrimHorizon && o.from != ingestFromLatest {
+				return errors.New("--from must be " + ingestFromTrimHorizon + " or " + ingestFromLatest)
+			}
+			if o.s3URI != "" {
+				if bucket, _, _ := strings.Cut(strings.TrimPrefix(o.s3URI, "s3://"), "/"); !blob.IsS3(o.s3URI) || bucket == "" {
+					return errors.New("--s3 must be an s3://bucket/prefix URI")
+				}
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerIngest(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.stream, "stream", "", "", "name of the Kinesis data stream to read")
+	cmd.Flags().StringVarP(&o.s3URI, "s3", "", "", "s3://bucket/prefix of Firehose files to read")
+	cmd.Flags().StringVarP(&o.from, "from", "", ingestFromLatest, "where to read shards without a checkpoint from [trim-horizon|latest]")
+	cmd.Flags().BoolVarP(&o.follow, "follow", "", false, "keep reading new files under --s3 until interrupted")
+	cmd.Flags().DurationVarP(&o.pollInterval, "poll-interval", "", 30*time.Second, "how often --follow lists new files")
+	cmd.Flags().IntVarP(&o.batchSize, "batch-size", "", 10, "number of positions to send at once")
+	cmd.Flags().DurationVarP(&o.flushInterval, "flush-interval", "", 5*time.Second, "longest time a position waits for its batch to fill")
+	cmd.Flags().StringVarP(&o.checkpointPath, "checkpoint", "", "", "file to keep the checkpoints in (default checkpoints.json in the goawsloc user cache directory)")
+	cmd.MarkFlagRequired("tracker")
+	return cmd
+}
+
+func runTrackerIngest(ctx context.Context, o *ingestOptions) error {
+	path := o.checkpointPath
+	if path == "" {
+		var err error
+		if path, err = ingest.DefaultCheckpointPath(); err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error locating checkpoint file")
+			return err
+		}
+	}
+	checkpoints, err := ingest.LoadCheckpoints(path)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  path,
+		}).Error("error reading checkpoint file")
+		return err
+	}
+	c, err := clientmgr.Default.Config(o.clientKey())
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error loading AWS config")
+		return err
+	}
+
+	in, err := ingest.New(o.trackerService(o.trackerName), o.trackerName, decodePositions,
+		ingest.SetLogger(log),
+		ingest.SetCheckpoints(checkpoints),
+		ingest.SetBatch(o.batchSize, o.flushInterval),
+		ingest.SetLatest(o.from == ingestFromLatest),
+		ingest.SetPollInterval(o.pollInterval),
+	)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error creating ingester")
+		return err
+	}
+
+	if o.stream != "" {
+		log.WithFields(logrus.Fields{
+			"stream":  o.stream,
+			"tracker": o.trackerName,
+		}).Info("Ingesting stream")
+		err = in.Kinesis(ctx, kinesis.NewFromConfig(c), o.stream)
+	} else {
+		// Unlike objects, a prefix may be empty to read the whole bucket.
+		bucket, prefix, _ := strings.Cut(strings.TrimPrefix(o.s3URI, "s3://"), "/")
+		log.WithFields(logrus.Fields{
+			"s3":      o.s3URI,
+			"tracker": o.trackerName,
+		}).Info("Ingesting files")
+		err = in.S3(ctx, s3.NewFromConfig(c), bucket, prefix, o.follow)
+	}
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error ingesting device positions")
+		return err
+	}
+	return nil
+}
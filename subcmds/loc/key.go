@@ -0,0 +1,346 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/keysvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// keyOptions are the flags of the API key commands.
+type keyOptions struct {
+	*globalOptions
+	sortOptions
+
+	actions     []string
+	all         bool
+	description string
+	expire      string
+	force       bool
+	keyName     string
+	maxItems    int
+	noExpiry    bool
+	referers    []string
+	resources   []string
+	tags        []string
+}
+
+func newKeyCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "key",
+		Short: "manage API keys",
+		Long:  "Create, rotate and retire the API keys used by frontends to call Amazon Location Service without AWS credentials",
+	}
+
+	cmd.AddCommand(
+		newKeyCreateCmd(g),
+		newKeyDeleteCmd(g),
+		newKeyDescribeCmd(g),
+		newKeyListCmd(g),
+		newKeyUpdateCmd(g),
+	)
+	return cmd
+}
+
+func newKeyCreateCmd(g *globalOptions) *cobra.Command {
+	o := &keyOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create an API key",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runKeyCreate(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.keyName, "key", "", "", "API key name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "API key description")
+	cmd.Flags().StringSliceVarP(&o.actions, "actions", "", []string{}, "allowed actions, such as geo:GetMap* or geo:SearchPlaceIndexForText")
+	cmd.Flags().StringSliceVarP(&o.resources, "resources", "", []string{}, "ARNs of the resources the key may be used with")
+	cmd.Flags().StringSliceVarP(&o.referers, "referers", "", []string{}, "allowed HTTP referers, such as https://example.com/* (default any)")
+	cmd.Flags().StringVarP(&o.expire, "expire", "", "", "expiry as YYYY-MM-DD, RFC3339 or a duration from now like 90d (default never)")
+	cmd.Flags().StringSliceVarP(&o.tags, "tags", "", []string{}, "API key tags (key=value)")
+	cmd.MarkFlagRequired("key")
+	cmd.MarkFlagRequired("actions")
+	cmd.MarkFlagRequired("resources")
+	return cmd
+}
+
+func newKeyDeleteCmd(g *globalOptions) *cobra.Command {
+	o := &keyOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "delete an API key",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runKeyDelete(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.keyName, "key", "", "", "API key name")
+	cmd.Flags().BoolVarP(&o.force, "force", "", false, "delete the key even if it has not expired or was used in the last 7 days")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+func newKeyDescribeCmd(g *globalOptions) *cobra.Command {
+	o := &keyOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "describe an API key, including its value",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runKeyDescribe(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.keyName, "key", "", "", "API key name")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+func newKeyListCmd(g *globalOptions) *cobra.Command {
+	o := &keyOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list API keys",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.all && cmd.Flags().Changed("max-items") {
+				return errors.New("--all and --max-items are mutually exclusive")
+			}
+			if o.maxItems < 1 {
+				return errors.New("--max-items must be at least 1")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runKeyList(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.all, "all", "", false, "list every API key, following all result pages")
+	cmd.Flags().IntVarP(&o.maxItems, "max-items", "", 100, "maximum number of API keys to fetch")
+	cmd.Flags().StringVarP(&o.sort, "sort", "", "", "sort results by [label]")
+	cmd.Flags().IntVarP(&o.limit, "limit", "", 0, "maximum number of results to output")
+	return cmd
+}
+
+func newKeyUpdateCmd(g *globalOptions) *cobra.Command {
+	o := &keyOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "update the description, restrictions or expiry of an API key",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.expire != "" && o.noExpiry {
+				return errors.New("--expire and --no-expiry are mutually exclusive")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runKeyUpdate(cmd, o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.keyName, "key", "", "", "API key name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "API key description (default unchanged)")
+	cmd.Flags().StringSliceVarP(&o.actions, "actions", "", []string{}, "allowed actions (default unchanged)")
+	cmd.Flags().StringSliceVarP(&o.resources, "resources", "", []string{}, "ARNs of the resources the key may be used with (default unchanged)")
+	cmd.Flags().StringSliceVarP(&o.referers, "referers", "", []string{}, "allowed HTTP referers (default unchanged)")
+	cmd.Flags().StringVarP(&o.expire, "expire", "", "", "expiry as YYYY-MM-DD, RFC3339 or a duration from now like 90d (default unchanged)")
+	cmd.Flags().BoolVarP(&o.noExpiry, "no-expiry", "", false, "make the key never expire")
+	cmd.Flags().BoolVarP(&o.force, "force", "", false, "update the key even if it was used in the last 7 days")
+	cmd.MarkFlagRequired("key")
+	return cmd
+}
+
+func runKeyCreate(ctx context.Context, o *keyOptions) error {
+	svc := o.keyService(o.keyName)
+	tags, err := parseTags(o.tags)
+	if err != nil {
+		return err
+	}
+	expire, err := parseOptionalTime(o.expire)
+	if err != nil {
+		return err
+	}
+	restrictions := &keysvc.Restrictions{
+		AllowActions:   o.actions,
+		AllowResources: o.resources,
+		AllowReferers:  o.referers,
+	}
+	ret, err := svc.CreateKey(ctx, o.description, restrictions, expire, &tags)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error creating API key")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"createTime": ret.CreateTime,
+		"keyARN":     aws.ToString(ret.KeyArn),
+		"keyName":    aws.ToString(ret.KeyName),
+	}).Info("Created API key")
+	return o.writeResult(&output.Result{
+		Data: ret,
+		Record: output.Record{
+			{Name: "Key Name", Value: aws.ToString(ret.KeyName)},
+			{Name: "Key", Value: aws.ToString(ret.Key)},
+			{Name: "Create Time", Value: fmt.Sprint(ret.CreateTime)},
+			{Name: "Key ARN", Value: aws.ToString(ret.KeyArn)},
+		},
+	})
+}
+
+func runKeyDelete(ctx context.Context, o *keyOptions) error {
+	svc := o.keyService(o.keyName)
+	if _, err := svc.DeleteKey(ctx, o.force); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error deleting API key")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"keyName": o.keyName,
+	}).Info("Deleted API key")
+	return nil
+}
+
+func runKeyDescribe(ctx context.Context, o *keyOptions) error {
+	svc := o.keyService(o.keyName)
+	ret, err := svc.DescribeKey(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error describing API key")
+		return err
+	}
+
+	restrictions := ret.Restrictions
+	if restrictions == nil {
+		restrictions = &types.ApiKeyRestrictions{}
+	}
+	return o.writeResult(&output.Result{
+		Data: ret,
+		Record: output.Record{
+			{Name: "Key Name", Value: aws.ToString(ret.KeyName)},
+			{Name: "Description", Value: aws.ToString(ret.Description)},
+			{Name: "Key", Value: aws.ToString(ret.Key)},
+			{Name: "Actions", Value: strings.Join(restrictions.AllowActions, ", ")},
+			{Name: "Resources", Value: strings.Join(restrictions.AllowResources, ", ")},
+			{Name: "Referers", Value: referers(restrictions)},
+			{Name: "Expire Time", Value: fmt.Sprint(ret.ExpireTime)},
+			{Name: "Create Time", Value: fmt.Sprint(ret.CreateTime)},
+			{Name: "Update Time", Value: fmt.Sprint(ret.UpdateTime)},
+			{Name: "Key ARN", Value: aws.ToString(ret.KeyArn)},
+			{Name: "Tags", Value: formatTags(ret.Tags)},
+		},
+	})
+}
+
+func runKeyList(ctx context.Context, o *keyOptions) error {
+	svc := o.keyService(o.keyName)
+	maxItems := o.maxItems
+	if o.all {
+		maxItems = 0
+	}
+	entries, err := svc.ListKeys(ctx, maxItems)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error listing API keys")
+		return err
+	}
+	if entries, err = sortAndLimit(o.sortOptions, entries, func(e types.ListKeysResponseEntry) sortKey {
+		return sortKey{label: e.KeyName}
+	}, sortLabel); err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"count": len(entries),
+	}).Info("Listed API keys")
+
+	rows := &output.Rows{Header: []string{"CTime", "MTime", "Expires", "Key", "Description"}}
+	for _, entry := range entries {
+		rows.Rows = append(rows.Rows, []string{fmt.Sprint(entry.CreateTime), fmt.Sprint(entry.UpdateTime), fmt.Sprint(entry.ExpireTime), aws.ToString(entry.KeyName), aws.ToString(entry.Description)})
+	}
+	return o.writeResult(&output.Result{Data: entries, Rows: rows})
+}
+
+func runKeyUpdate(cmd *cobra.Command, o *keyOptions) error {
+	svc := o.keyService(o.keyName)
+	ctx := cmd.Context()
+	update := &keysvc.KeyUpdate{
+		NoExpiry: o.noExpiry,
+		Force:    o.force,
+	}
+	if cmd.Flags().Changed("description") {
+		update.Description = aws.String(o.description)
+	}
+	var err error
+	if update.ExpireTime, err = parseOptionalTime(o.expire); err != nil {
+		return err
+	}
+
+	// The API replaces the restrictions as a whole, so start from the
+	// current ones and only change what was given.
+	if cmd.Flags().Changed("actions") || cmd.Flags().Changed("resources") || cmd.Flags().Changed("referers") {
+		current, err := svc.DescribeKey(ctx)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error describing API key")
+			return err
+		}
+		update.Restrictions = &keysvc.Restrictions{}
+		if current.Restrictions != nil {
+			update.Restrictions.AllowActions = current.Restrictions.AllowActions
+			update.Restrictions.AllowResources = current.Restrictions.AllowResources
+			update.Restrictions.AllowReferers = current.Restrictions.AllowReferers
+		}
+		if cmd.Flags().Changed("actions") {
+			update.Restrictions.AllowActions = o.actions
+		}
+		if cmd.Flags().Changed("resources") {
+			update.Restrictions.AllowResources = o.resources
+		}
+		if cmd.Flags().Changed("referers") {
+			update.Restrictions.AllowReferers = o.referers
+		}
+	}
+
+	if _, err := svc.UpdateKey(ctx, update); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error updating API key")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"keyName": o.keyName,
+	}).Info("Updated API key")
+	return nil
+}
+
+// referers formats the allowed referers of a key, which allows any referer
+// if none are set.
+func referers(r *types.ApiKeyRestrictions) string {
+	if len(r.AllowReferers) == 0 {
+		return "(any)"
+	}
+	return strings.Join(r.AllowReferers, ", ")
+}
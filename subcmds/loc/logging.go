@@ -0,0 +1,79 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// Log formats selected with --log-format.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// setLogFormat sets the formatter of the logger.
+func setLogFormat(format string) error {
+	switch format {
+	case logFormatText:
+		log.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+		})
+	case logFormatJSON:
+		log.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("invalid log format %q, must be %s or %s", format, logFormatText, logFormatJSON)
+	}
+	return nil
+}
+
+// commandHook adds the name of the running command to every log entry, so
+// scraped logs can be attributed to a command.
+type commandHook struct {
+	command string
+}
+
+func (h *commandHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *commandHook) Fire(entry *logrus.Entry) error {
+	entry.Data["command"] = h.command
+	return nil
+}
+
+// logAPICalls is client middleware which logs every AWS API call at debug
+// level, with its request ID and latency including retries.
+func logAPICalls(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("LogAPICalls", func(
+		ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler,
+	) (middleware.InitializeOutput, middleware.Metadata, error) {
+		start := time.Now()
+		out, metadata, err := next.HandleInitialize(ctx, in)
+
+		fields := logrus.Fields{
+			"operation": awsmiddleware.GetOperationName(ctx),
+			"latencyMs": time.Since(start).Milliseconds(),
+		}
+		if requestID, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+			fields["requestId"] = requestID
+		}
+		var respErr *awshttp.ResponseError
+		if errors.As(err, &respErr) && respErr.ServiceRequestID() != "" {
+			fields["requestId"] = respErr.ServiceRequestID()
+		}
+		if err != nil {
+			fields["error"] = err
+			log.WithFields(fields).Debug("AWS API call failed")
+		} else {
+			log.WithFields(fields).Debug("AWS API call")
+		}
+		return out, metadata, err
+	}), middleware.After)
+}
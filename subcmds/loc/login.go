@@ -0,0 +1,51 @@
+package loc
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/clientmgr"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/ssologin"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newLoginCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "login",
+		Short:       "sign in to AWS SSO (IAM Identity Center) for the configured profile",
+		Annotations: map[string]string{annotationNoSetup: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runLogin(cmd.Context(), g); err != nil {
+				exit(err)
+			}
+		},
+	}
+	return cmd
+}
+
+func runLogin(ctx context.Context, g *globalOptions) error {
+	g.loadConfig()
+	awsProfile := g.awsProfile
+
+	expiresAt, err := ssologin.Login(ctx, awsProfile, func(verificationURI, userCode string) {
+		fmt.Fprintf(os.Stderr, "Open %s in a browser and confirm the code %s\n", verificationURI, userCode)
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error":   err,
+			"profile": awsProfile,
+		}).Error("error signing in")
+		return err
+	}
+
+	// drop clients holding the expired session
+	clientmgr.Default.Reset()
+	log.WithFields(logrus.Fields{
+		"profile": awsProfile,
+		"expires": expiresAt,
+	}).Info("Signed in")
+	return nil
+}
@@ -0,0 +1,452 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/mapsvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// mapOptions are the flags of the map commands.
+type mapOptions struct {
+	*globalOptions
+	sortOptions
+
+	all           bool
+	apiKey        string
+	customLayers  []string
+	description   string
+	filePath      string
+	fontRange     string
+	fontStack     string
+	mapName       string
+	maxItems      int
+	politicalView string
+	spriteFile    string
+	style         string
+	tags          []string
+	tileX         int
+	tileY         int
+	tileZ         int
+}
+
+func newMapCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "map",
+		Short: "map resources",
+	}
+
+	cmd.AddCommand(
+		newMapCreateCmd(g),
+		newMapDeleteCmd(g),
+		newMapDescribeCmd(g),
+		newMapGlyphsCmd(g),
+		newMapListCmd(g),
+		newMapSpritesCmd(g),
+		newMapStyleCmd(g),
+		newMapTileCmd(g),
+		newMapUpdateCmd(g),
+	)
+	return cmd
+}
+
+func newMapCreateCmd(g *globalOptions) *cobra.Command {
+	o := &mapOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a map resource",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMapCreate(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.mapName, "map", "", "", "map name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "map description")
+	cmd.Flags().StringVarP(&o.style, "style", "", "", "map style, e.g. VectorEsriNavigation")
+	cmd.Flags().StringVarP(&o.politicalView, "political-view", "", "", "political view, e.g. IND")
+	cmd.Flags().StringSliceVarP(&o.customLayers, "custom-layers", "", []string{}, "custom layers to enable, e.g. POI")
+	cmd.Flags().StringSliceVarP(&o.tags, "tags", "", []string{}, "map tags (key=value)")
+	cmd.MarkFlagRequired("map")
+	cmd.MarkFlagRequired("style")
+	return cmd
+}
+
+func newMapDeleteCmd(g *globalOptions) *cobra.Command {
+	o := &mapOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "delete a map resource",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMapDelete(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.mapName, "map", "", "", "map name")
+	cmd.MarkFlagRequired("map")
+	return cmd
+}
+
+func newMapDescribeCmd(g *globalOptions) *cobra.Command {
+	o := &mapOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "describe a map resource",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMapDescribe(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.mapName, "map", "", "", "map name")
+	cmd.MarkFlagRequired("map")
+	return cmd
+}
+
+func newMapGlyphsCmd(g *globalOptions) *cobra.Command {
+	o := &mapOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "glyphs",
+		Short: "fetch the glyphs of a font stack",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMapGlyphs(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.mapName, "map", "", "", "map name")
+	cmd.Flags().StringVarP(&o.fontStack, "font-stack", "", "", "font stack, e.g. \"Noto Sans Regular\"")
+	cmd.Flags().StringVarP(&o.fontRange, "range", "", "0-255", "range of 256 code points")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "output file (default stdout)")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the request with instead of IAM credentials")
+	cmd.MarkFlagRequired("map")
+	cmd.MarkFlagRequired("font-stack")
+	return cmd
+}
+
+func newMapListCmd(g *globalOptions) *cobra.Command {
+	o := &mapOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list map resources",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.all && cmd.Flags().Changed("max-items") {
+				return errors.New("--all and --max-items are mutually exclusive")
+			}
+			if o.maxItems < 1 {
+				return errors.New("--max-items must be at least 1")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMapList(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.all, "all", "", false, "list every map, following all result pages")
+	cmd.Flags().IntVarP(&o.maxItems, "max-items", "", 100, "maximum number of maps to fetch")
+	cmd.Flags().StringVarP(&o.sort, "sort", "", "", "sort results by [label]")
+	cmd.Flags().IntVarP(&o.limit, "limit", "", 0, "maximum number of results to output")
+	return cmd
+}
+
+func newMapSpritesCmd(g *globalOptions) *cobra.Command {
+	o := &mapOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "sprites",
+		Short: "fetch a sprite sheet or its index",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMapSprites(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.mapName, "map", "", "", "map name")
+	cmd.Flags().StringVarP(&o.spriteFile, "file-name", "", "sprites.png", "[sprites.png|sprites.json|sprites@2x.png|sprites@2x.json]")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "output file (default stdout)")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the request with instead of IAM credentials")
+	cmd.MarkFlagRequired("map")
+	return cmd
+}
+
+func newMapStyleCmd(g *globalOptions) *cobra.Command {
+	o := &mapOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "style",
+		Short: "fetch the style descriptor of a map",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMapStyle(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.mapName, "map", "", "", "map name")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "output file (default stdout)")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the request with instead of IAM credentials")
+	cmd.MarkFlagRequired("map")
+	return cmd
+}
+
+func newMapTileCmd(g *globalOptions) *cobra.Command {
+	o := &mapOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "tile",
+		Short: "fetch a map tile",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMapTile(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.mapName, "map", "", "", "map name")
+	cmd.Flags().IntVarP(&o.tileZ, "z", "", 0, "zoom level")
+	cmd.Flags().IntVarP(&o.tileX, "x", "", 0, "tile column")
+	cmd.Flags().IntVarP(&o.tileY, "y", "", 0, "tile row")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "output file, e.g. tile.pbf (default stdout)")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the request with instead of IAM credentials")
+	cmd.MarkFlagRequired("map")
+	cmd.MarkFlagRequired("z")
+	cmd.MarkFlagRequired("x")
+	cmd.MarkFlagRequired("y")
+	return cmd
+}
+
+func newMapUpdateCmd(g *globalOptions) *cobra.Command {
+	o := &mapOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "update a map resource",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runMapUpdate(cmd.Context(), cmd, o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.mapName, "map", "", "", "map name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "map description")
+	cmd.Flags().StringVarP(&o.politicalView, "political-view", "", "", "political view, e.g. IND (empty to remove)")
+	cmd.Flags().StringSliceVarP(&o.customLayers, "custom-layers", "", []string{}, "custom layers to enable, e.g. POI (empty to remove)")
+	cmd.MarkFlagRequired("map")
+	return cmd
+}
+
+func runMapCreate(ctx context.Context, o *mapOptions) error {
+	svc := o.mapService(o.mapName, o.apiKey)
+	tags, err := parseTags(o.tags)
+	if err != nil {
+		return err
+	}
+	if ret, err := svc.CreateMap(ctx, o.description, &mapsvc.MapConfiguration{
+		Style:         o.style,
+		PoliticalView: o.politicalView,
+		CustomLayers:  o.customLayers,
+	}, &tags); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error creating map")
+		return err
+	} else {
+		log.WithFields(logrus.Fields{
+			"createTime": ret.CreateTime,
+			"mapARN":     *ret.MapArn,
+			"mapName":    *ret.MapName,
+		}).Info("Created map")
+	}
+	return nil
+}
+
+func runMapDelete(ctx context.Context, o *mapOptions) error {
+	svc := o.mapService(o.mapName, o.apiKey)
+	if _, err := svc.DeleteMap(ctx); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error deleting map")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"mapName": o.mapName,
+	}).Info("Deleted map")
+	return nil
+}
+
+func runMapDescribe(ctx context.Context, o *mapOptions) error {
+	svc := o.mapService(o.mapName, o.apiKey)
+	ret, err := svc.DescribeMap(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error describing map")
+		return err
+	}
+
+	record := output.Record{
+		{Name: "Map Name", Value: aws.ToString(ret.MapName)},
+		{Name: "Description", Value: aws.ToString(ret.Description)},
+		{Name: "Data Source", Value: aws.ToString(ret.DataSource)},
+	}
+	if ret.Configuration != nil {
+		record = append(record,
+			output.Field{Name: "Style", Value: aws.ToString(ret.Configuration.Style)},
+			output.Field{Name: "Political View", Value: aws.ToString(ret.Configuration.PoliticalView)},
+			output.Field{Name: "Custom Layers", Value: strings.Join(ret.Configuration.CustomLayers, ",")},
+		)
+	}
+	record = append(record,
+		output.Field{Name: "Create Time", Value: fmt.Sprint(ret.CreateTime)},
+		output.Field{Name: "Update Time", Value: fmt.Sprint(ret.UpdateTime)},
+		output.Field{Name: "Map ARN", Value: aws.ToString(ret.MapArn)},
+		output.Field{Name: "Tags", Value: formatTags(ret.Tags)},
+	)
+	return o.writeResult(&output.Result{Data: ret, Record: record})
+}
+
+func runMapList(ctx context.Context, o *mapOptions) error {
+	svc := o.mapService(o.mapName, o.apiKey)
+	maxItems := o.maxItems
+	if o.all {
+		maxItems = 0
+	}
+	entries, err := svc.ListMaps(ctx, maxItems)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error listing maps")
+		return err
+	}
+	if entries, err = sortAndLimit(o.sortOptions, entries, func(e types.ListMapsResponseEntry) sortKey {
+		return sortKey{label: e.MapName}
+	}, sortLabel); err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"count": len(entries),
+	}).Info("Listed maps")
+
+	rows := &output.Rows{Header: []string{"CTime", "MTime", "Map", "DataSource", "Description"}}
+	for _, entry := range entries {
+		rows.Rows = append(rows.Rows, []string{fmt.Sprint(entry.CreateTime), fmt.Sprint(entry.UpdateTime), aws.ToString(entry.MapName), aws.ToString(entry.DataSource), aws.ToString(entry.Description)})
+	}
+	return o.writeResult(&output.Result{Data: entries, Rows: rows})
+}
+
+func runMapUpdate(ctx context.Context, cmd *cobra.Command, o *mapOptions) error {
+	svc := o.mapService(o.mapName, o.apiKey)
+	// Only send what was given on the command line, so unset options keep their value.
+	update := &mapsvc.MapConfigurationUpdate{}
+	if cmd.Flags().Changed("political-view") {
+		update.PoliticalView = &o.politicalView
+	}
+	if cmd.Flags().Changed("custom-layers") {
+		update.CustomLayers = o.customLayers
+	}
+	var description *string
+	if cmd.Flags().Changed("description") {
+		description = &o.description
+	}
+
+	if ret, err := svc.UpdateMap(ctx, description, update); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error updating map")
+		return err
+	} else {
+		log.WithFields(logrus.Fields{
+			"mapName":    *ret.MapName,
+			"updateTime": ret.UpdateTime,
+		}).Info("Updated map")
+	}
+	return nil
+}
+
+// writeBlob writes fetched map data to the --file output.
+func (o *mapOptions) writeBlob(blob []byte, contentType *string) error {
+	w, err := openOutput(o.filePath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error opening output file")
+		return err
+	}
+	defer w.Close()
+
+	if _, err := w.Write(blob); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error writing output")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"bytes":       len(blob),
+		"contentType": aws.ToString(contentType),
+	}).Debug("Wrote map data")
+	return nil
+}
+
+func runMapGlyphs(ctx context.Context, o *mapOptions) error {
+	svc := o.mapService(o.mapName, o.apiKey)
+	ret, err := svc.GetMapGlyphs(ctx, o.fontStack, o.fontRange)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error getting map glyphs")
+		return err
+	}
+	return o.writeBlob(ret.Blob, ret.ContentType)
+}
+
+func runMapSprites(ctx context.Context, o *mapOptions) error {
+	svc := o.mapService(o.mapName, o.apiKey)
+	ret, err := svc.GetMapSprites(ctx, o.spriteFile)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error getting map sprites")
+		return err
+	}
+	return o.writeBlob(ret.Blob, ret.ContentType)
+}
+
+func runMapStyle(ctx context.Context, o *mapOptions) error {
+	svc := o.mapService(o.mapName, o.apiKey)
+	ret, err := svc.GetMapStyleDescriptor(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error getting map style descriptor")
+		return err
+	}
+	return o.writeBlob(ret.Blob, ret.ContentType)
+}
+
+func runMapTile(ctx context.Context, o *mapOptions) error {
+	svc := o.mapService(o.mapName, o.apiKey)
+	ret, err := svc.GetMapTile(ctx, o.tileZ, o.tileX, o.tileY)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error getting map tile")
+		return err
+	}
+	return o.writeBlob(ret.Blob, ret.ContentType)
+}
@@ -0,0 +1,190 @@
+package loc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Values accepted by the --units flag.
+const (
+	unitsImperial = "imperial"
+	unitsMetric   = "metric"
+
+	metersPerKilometer = 1000
+	metersPerMile      = 1609.344
+)
+
+// Keys accepted by the --sort flag.
+const (
+	sortDistance  = "distance"
+	sortLabel     = "label"
+	sortRelevance = "relevance"
+)
+
+// sortKey holds the attributes of a single result which can be sorted on.
+type sortKey struct {
+	distance  *float64
+	label     *string
+	relevance *float64
+}
+
+// less reports whether a sorts before b for the given key.
+// Relevance sorts highest first, distance and label lowest first.
+// Results missing the attribute always sort last.
+func (a sortKey) less(b sortKey, key string) bool {
+	switch key {
+	case sortDistance:
+		if a.distance == nil || b.distance == nil {
+			return a.distance != nil
+		}
+		return *a.distance < *b.distance
+	case sortLabel:
+		if a.label == nil || b.label == nil {
+			return a.label != nil
+		}
+		return strings.ToLower(*a.label) < strings.ToLower(*b.label)
+	case sortRelevance:
+		if a.relevance == nil || b.relevance == nil {
+			return a.relevance != nil
+		}
+		return *a.relevance > *b.relevance
+	}
+	return false
+}
+
+// sortOptions are the --sort and --limit flags of commands listing results.
+type sortOptions struct {
+	sort  string
+	limit int
+}
+
+// sortAndLimit orders items by the --sort flag and trims them to --limit.
+// The supported list names the sort keys which make sense for the items.
+func sortAndLimit[T any](s sortOptions, items []T, keyFn func(T) sortKey, supported ...string) ([]T, error) {
+	if s.sort != "" {
+		ok := false
+		for _, key := range supported {
+			if key == s.sort {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid sort key %q, must be one of: %s", s.sort, strings.Join(supported, ", "))
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			return keyFn(items[i]).less(keyFn(items[j]), s.sort)
+		})
+	}
+	if s.limit < 0 {
+		return nil, fmt.Errorf("invalid limit %d, must not be negative", s.limit)
+	}
+	if s.limit > 0 && s.limit < len(items) {
+		items = items[:s.limit]
+	}
+	return items, nil
+}
+
+// nopCloser keeps stdout open when the writer returned by openOutput is closed.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// openOutput returns a writer for the named file, or stdout if no file is given.
+func openOutput(filename string) (io.WriteCloser, error) {
+	if filename == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path.Clean(filename))
+}
+
+// distanceUnit returns the name of the distance unit selected with --units,
+// as used by the route calculation API.
+func (g *globalOptions) distanceUnit() string {
+	if g.units == unitsImperial {
+		return "Miles"
+	}
+	return "Kilometers"
+}
+
+// fromMeters converts a distance in meters into the unit selected with --units.
+func (g *globalOptions) fromMeters(meters *float64) *float64 {
+	if meters == nil {
+		return nil
+	}
+	d := *meters / metersPerKilometer
+	if g.units == unitsImperial {
+		d = *meters / metersPerMile
+	}
+	return &d
+}
+
+// toMeters converts a distance in the unit selected with --units into meters.
+func (g *globalOptions) toMeters(d float64) float64 {
+	if g.units == unitsImperial {
+		return d * metersPerMile
+	}
+	return d * metersPerKilometer
+}
+
+// writeResult writes a command result to stdout in the format selected with
+// --output.
+func (g *globalOptions) writeResult(result *output.Result) error {
+	return g.writeResultTo(os.Stdout, result)
+}
+
+// writeResultTo writes the part of a command result selected with --query to
+// w, using the --format template or else in the format selected with --output.
+func (g *globalOptions) writeResultTo(w io.Writer, result *output.Result) error {
+	if g.outputQuery != nil {
+		var err error
+		if result, err = g.outputQuery.Apply(result); err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error applying query")
+			return err
+		}
+	}
+	if g.outputTemplate != nil {
+		if err := output.WriteTemplate(w, g.outputTemplate, result); err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error writing template output")
+			return err
+		}
+		return nil
+	}
+	if err := output.Write(w, g.outputFormat, result); err != nil {
+		log.WithFields(logrus.Fields{
+			"error":  err,
+			"output": g.outputFormat,
+		}).Error("error writing output")
+		return err
+	}
+	return nil
+}
+
+// formatTags formats resource tags as key=value pairs sorted by key.
+func formatTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return "(none)"
+	}
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
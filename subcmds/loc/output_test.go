@@ -0,0 +1,84 @@
+package loc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFromMeters(t *testing.T) {
+	tests := []struct {
+		name   string
+		units  string
+		meters *float64
+		want   *float64
+	}{
+		{"nil", unitsMetric, nil, nil},
+		{"metric", unitsMetric, float(1500), float(1.5)},
+		{"imperial", unitsImperial, float(1609.344), float(1)},
+		{"zero", unitsImperial, float(0), float(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &globalOptions{units: tt.units}
+			got := g.fromMeters(tt.meters)
+			if (got == nil) != (tt.want == nil) || (got != nil && math.Abs(*got-*tt.want) > 1e-9) {
+				t.Errorf("fromMeters() = %v, want %v", deref(got), deref(tt.want))
+			}
+		})
+	}
+}
+
+func TestToMeters(t *testing.T) {
+	tests := []struct {
+		name  string
+		units string
+		d     float64
+		want  float64
+	}{
+		{"metric", unitsMetric, 1.5, 1500},
+		{"imperial", unitsImperial, 2, 3218.688},
+		{"default", "", 1, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &globalOptions{units: tt.units}
+			if got := g.toMeters(tt.d); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("toMeters() = %v, want %v", got, tt.want)
+			}
+			if got := *g.fromMeters(float(g.toMeters(tt.d))); math.Abs(got-tt.d) > 1e-9 {
+				t.Errorf("fromMeters(toMeters(%v)) = %v", tt.d, got)
+			}
+		})
+	}
+}
+
+func TestDistanceUnit(t *testing.T) {
+	tests := []struct {
+		units string
+		want  string
+	}{
+		{unitsMetric, "Kilometers"},
+		{unitsImperial, "Miles"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.units, func(t *testing.T) {
+			g := &globalOptions{units: tt.units}
+			if got := g.distanceUnit(); got != tt.want {
+				t.Errorf("distanceUnit() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// float returns a pointer to the value.
+func float(v float64) *float64 {
+	return &v
+}
+
+// deref returns the value of a pointer for messages, or nil.
+func deref(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
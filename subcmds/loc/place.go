@@ -0,0 +1,124 @@
+package loc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/geojson"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// placeOptions are the flags of the place commands.
+type placeOptions struct {
+	*globalOptions
+
+	apiKey    string
+	indexName string
+	language  string
+	placeID   string
+}
+
+func newPlaceCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "place",
+		Short: "places returned by searches",
+	}
+
+	cmd.AddCommand(
+		newPlaceGetCmd(g),
+	)
+	return cmd
+}
+
+func newPlaceGetCmd(g *globalOptions) *cobra.Command {
+	o := &placeOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "get a place by its place ID",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runPlaceGet(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the request with instead of IAM credentials")
+	cmd.Flags().StringVarP(&o.placeID, "place-id", "", "", "place ID from a text or suggestion search")
+	cmd.Flags().StringVarP(&o.language, "language", "", "", "language of the place, e.g. fr (defaults to en)")
+	cmd.MarkFlagRequired("index")
+	cmd.MarkFlagRequired("place-id")
+	return cmd
+}
+
+func runPlaceGet(ctx context.Context, o *placeOptions) error {
+	return o.writePlace(ctx, o.placeService(o.indexName, o.apiKey), o.placeID, &o.language)
+}
+
+// writePlace gets a place by its place ID in the language, or the default
+// one if it is empty, and writes its details.
+func (g *globalOptions) writePlace(ctx context.Context, svc placesvc.PlaceIndexer, placeID string, language *string) error {
+	ret, err := svc.GetPlace(ctx, placeID, language)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error":   err,
+			"placeID": placeID,
+		}).Error("error getting place")
+		return err
+	}
+
+	place := placesvc.NewPlace(ret.Place)
+	var record output.Record
+	for _, field := range placeFields(place) {
+		record = append(record, output.Field{Name: field[0], Value: field[1]})
+	}
+	result := &output.Result{Data: ret.Place, Record: record}
+	if feature := placeFeature(ret.Place, map[string]interface{}{"placeId": placeID}); feature != nil {
+		fc := geojson.NewFeatureCollection()
+		fc.AddFeature(feature)
+		result.GeoJSON = fc
+	}
+	return g.writeResult(result)
+}
+
+// placeFields returns the set attributes of a place as name, value pairs.
+func placeFields(place *placesvc.Place) [][2]string {
+	var fields [][2]string
+	add := func(name string, value string) {
+		if value != "" {
+			fields = append(fields, [2]string{name, value})
+		}
+	}
+
+	add("Label", place.Label)
+	add("Address", place.FormattedAddress())
+	if position := place.Coordinates(); position != nil {
+		add("Position", fmt.Sprintf("%g,%g", position.Latitude, position.Longitude))
+	}
+	add("Address number", place.AddressNumber)
+	add("Unit type", place.UnitType)
+	add("Unit number", place.UnitNumber)
+	add("Street", place.Street)
+	add("Neighborhood", place.Neighborhood)
+	add("Sub-municipality", place.SubMunicipality)
+	add("Municipality", place.Municipality)
+	add("Sub-region", place.SubRegion)
+	add("Region", place.Region)
+	add("Postal code", place.PostalCode)
+	add("Country", place.Country)
+	if place.TimeZone != nil {
+		add("Time zone", place.TimeZone.Name)
+		add("UTC offset", fmt.Sprintf("%+d seconds", place.TimeZone.Offset))
+	}
+	add("Categories", strings.Join(place.Categories, ", "))
+	add("Supplemental categories", strings.Join(place.SupplementalCategories, ", "))
+	if place.Interpolated {
+		add("Interpolated", "true")
+	}
+	return fields
+}
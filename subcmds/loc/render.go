@@ -0,0 +1,277 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"image/png"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/routesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/render"
+
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// renderOptions are the flags of the render command.
+type renderOptions struct {
+	*globalOptions
+	searchAreaOptions
+
+	apiKey         string
+	calculatorName string
+	countries      []string
+	filePath       string
+	from           string
+	height         int
+	indexName      string
+	mapName        string
+	maxResults     int32
+	point          string
+	text           string
+	to             string
+	travelMode     string
+	via            []string
+	width          int
+	zoom           int
+
+	position *placesvc.LatLon
+}
+
+func newRenderCmd(g *globalOptions) *cobra.Command {
+	o := &renderOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "draw search results or a route on a static map",
+		Long:  "Searches a place index or calculates a route and writes a PNG map of the results. With --text, a marker is drawn for every text search result, biased towards --bias; with --point, for the places found at that position. With --calculator, the route from --from to --to, via the --via waypoints, is drawn as a line with a green marker at the start, orange markers at the waypoints and a red one at the end. Both can be shown on the same map. The view fits the results unless --bbox gives the area. The map resource must use a raster style, such as RasterEsriImagery, as vector tiles cannot be rendered",
+		Example: `  loc render --map my-raster-map --index my-index --text "coffee" --bias -122.33,47.61 -f coffee.png
+  loc render --map my-raster-map --index my-index --point 47.61,-122.33 -f here.png
+  loc render --map my-raster-map --calculator my-calculator --from 47.61,-122.33 --to 47.25,-122.44 --via 47.45,-122.30 -f route.png`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.parseSearchArea(); err != nil {
+				return err
+			}
+			if o.point != "" {
+				if o.text != "" {
+					return errors.New("--point and --text are mutually exclusive, bias a text search with --bias")
+				}
+				position, err := parsePoint(o.point)
+				if err != nil {
+					return err
+				}
+				o.position = position
+			}
+			if o.biasPosition != nil && o.text == "" {
+				return errors.New("--bias only biases a --text search, search at a position with --point")
+			}
+			search := o.text != "" || o.position != nil
+			if !search && o.calculatorName == "" {
+				return errors.New("--text or --point, or --calculator is required")
+			}
+			if search && o.indexName == "" {
+				return errors.New("--index is required to search")
+			}
+			if o.calculatorName != "" && (o.from == "" || o.to == "") {
+				return errors.New("--from and --to are required with --calculator")
+			}
+			return parseCountries(o.countries)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runRender(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.mapName, "map", "", "", "map name, must use a raster style")
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name, to search")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the search and tiles with instead of IAM credentials")
+	cmd.Flags().StringVarP(&o.text, "text", "", "", "text to search for")
+	cmd.Flags().StringSliceVarP(&o.countries, "country", "", []string{}, "one or more countries to limit the search to, as ISO 3166 code or name")
+	cmd.Flags().StringVarP(&o.bias, "bias", "", "", "position to bias --text results towards, as \"lon,lat\"")
+	cmd.Flags().StringVarP(&o.point, "point", "", "", "position to search at instead of --text, as \"lat,lon\"")
+	cmd.Flags().StringVarP(&o.bbox, "bbox", "", "", "bounding box to limit results to and show, as \"west,south,east,north\"")
+	cmd.Flags().Int32VarP(&o.maxResults, "max-results", "", 0, "maximum number of results to request from AWS [1-50]")
+	cmd.Flags().StringVarP(&o.calculatorName, "calculator", "", "", "route calculator name, to draw a route")
+	cmd.Flags().StringVarP(&o.from, "from", "", "", "departure position of the route (lat,lon)")
+	cmd.Flags().StringVarP(&o.to, "to", "", "", "destination position of the route (lat,lon)")
+	cmd.Flags().StringArrayVarP(&o.via, "via", "", []string{}, "waypoint position of the route (lat,lon), repeatable, in travel order")
+	cmd.Flags().StringVarP(&o.travelMode, "mode", "", routesvc.TravelModeCar, "travel mode of the route [Car|Truck|Walking|Bicycle|Motorcycle]")
+	cmd.Flags().IntVarP(&o.width, "width", "", 800, "image width in pixels")
+	cmd.Flags().IntVarP(&o.height, "height", "", 600, "image height in pixels")
+	cmd.Flags().IntVarP(&o.zoom, "zoom", "", 0, "zoom level (default fit the results)")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "PNG output file (default stdout)")
+	cmd.MarkFlagRequired("map")
+	return cmd
+}
+
+// renderPoints searches the place index and returns the positions of the results.
+func renderPoints(ctx context.Context, o *renderOptions) ([]render.Point, error) {
+	svc := o.placeService(o.indexName, o.apiKey)
+	var places []*types.Place
+	if o.text != "" {
+		ret, err := svc.SearchPlaceIndexForText(ctx, &placesvc.SuggestionSearch{
+			Text:            &o.text,
+			BiasPosition:    o.biasPosition,
+			FilterBBox:      o.filterBBox,
+			FilterCountries: o.countries,
+			MaxResults:      o.maxResults,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range ret.Results {
+			places = append(places, result.Place)
+		}
+	} else {
+		ret, err := svc.SearchPlaceIndexForPosition(ctx, &placesvc.PositionSearch{
+			Position:   o.position,
+			MaxResults: o.maxResults,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range ret.Results {
+			places = append(places, result.Place)
+		}
+	}
+
+	var points []render.Point
+	for _, place := range places {
+		if place == nil || place.Geometry == nil || len(place.Geometry.Point) < 2 {
+			continue
+		}
+		points = append(points, render.Point{Latitude: place.Geometry.Point[1], Longitude: place.Geometry.Point[0]})
+	}
+	return points, nil
+}
+
+// renderRoute calculates the route and returns the lines of its legs and the
+// markers of its start, waypoints and end.
+func renderRoute(ctx context.Context, o *renderOptions) ([][]render.Point, []render.Point, error) {
+	from, err := parseLatLon(o.from)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := parseLatLon(o.to)
+	if err != nil {
+		return nil, nil, err
+	}
+	markers := []render.Point{{Latitude: from.Latitude, Longitude: from.Longitude, Color: render.Start}}
+	var waypoints []routesvc.LatLon
+	for _, value := range o.via {
+		waypoint, err := parseLatLon(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		waypoints = append(waypoints, *waypoint)
+		markers = append(markers, render.Point{Latitude: waypoint.Latitude, Longitude: waypoint.Longitude, Color: render.Waypoint})
+	}
+	markers = append(markers, render.Point{Latitude: to.Latitude, Longitude: to.Longitude, Color: render.End})
+
+	ret, err := o.routeService(o.calculatorName).CalculateRoute(ctx, &routesvc.RouteRequest{
+		Departure:          from,
+		Destination:        to,
+		DistanceUnit:       o.distanceUnit(),
+		IncludeLegGeometry: true,
+		TravelMode:         o.travelMode,
+		Waypoints:          waypoints,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var paths [][]render.Point
+	for _, leg := range ret.Legs {
+		if leg.Geometry == nil {
+			continue
+		}
+		path := make([]render.Point, 0, len(leg.Geometry.LineString))
+		for _, position := range leg.Geometry.LineString {
+			if len(position) >= 2 {
+				path = append(path, render.Point{Latitude: position[1], Longitude: position[0]})
+			}
+		}
+		paths = append(paths, path)
+	}
+	return paths, markers, nil
+}
+
+func runRender(ctx context.Context, o *renderOptions) error {
+	svc := o.mapService(o.mapName, o.apiKey)
+	opts := render.Options{Width: o.width, Height: o.height, Zoom: o.zoom}
+	var points []render.Point
+	if o.text != "" || o.position != nil {
+		found, err := renderPoints(ctx, o)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error searching places")
+			return err
+		}
+		log.WithFields(logrus.Fields{
+			"count": len(found),
+		}).Info("Found places")
+		points = found
+	}
+	if o.calculatorName != "" {
+		paths, markers, err := renderRoute(ctx, o)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error calculating route")
+			return err
+		}
+		log.WithFields(logrus.Fields{
+			"legs": len(paths),
+		}).Info("Calculated route")
+		opts.Paths = paths
+		points = append(points, markers...)
+	}
+
+	if o.filterBBox != nil {
+		opts.Area = &render.Box{South: o.filterBBox.Y1, West: o.filterBBox.X1, North: o.filterBBox.Y2, East: o.filterBBox.X2}
+	} else if len(points) == 0 {
+		center := o.position
+		if center == nil {
+			center = o.biasPosition
+		}
+		if center == nil {
+			return errors.New("no places found")
+		}
+		// Nothing found, show where the search was made.
+		opts.Area = &render.Box{South: center.Latitude, West: center.Longitude, North: center.Latitude, East: center.Longitude}
+	}
+
+	img, err := render.Render(ctx, func(ctx context.Context, z int, x int, y int) ([]byte, error) {
+		ret, err := svc.GetMapTile(ctx, z, x, y)
+		if err != nil {
+			return nil, err
+		}
+		return ret.Blob, nil
+	}, points, opts)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error rendering map")
+		return err
+	}
+
+	w, err := openOutput(o.filePath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error opening output file")
+		return err
+	}
+	defer w.Close()
+
+	if err := png.Encode(w, img); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error writing PNG")
+		return err
+	}
+	return nil
+}
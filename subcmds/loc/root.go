@@ -1,50 +1,139 @@
 package loc
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/clientmgr"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/geofencesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/keysvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/mapsvc"
 	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/routesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/ssologin"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/trackersvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/blob"
+	"github.com/rmrfslashbin/goawsloc/pkg/metrics"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
 
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-// Flags struct contains settings for the root command
-type Flags struct {
+// indexOptions are the flags of the place index and search commands.
+type indexOptions struct {
+	*globalOptions
+	sortOptions
+	searchAreaOptions
+
+	all         bool
+	apiKey      string
+	categories  []string
+	columns     []string
 	countries   []string
+	dataSource  string
+	debounce    time.Duration
 	description string
-	dotenvPath  string
 	indexName   string
-	json        bool
-	lat         float64
-	loglevel    string
-	lon         float64
-	text        string
-	x1          float64
-	x2          float64
-	y1          float64
-	y2          float64
+	intendedUse string
+	interactive bool
+	language    string
+	point       string
+	position    *placesvc.LatLon
+	maxItems    int
+	maxResults  int32
 	tags        []string
+	template    string
+	text        string
+	wide        bool
 }
 
-type Sercices struct {
-	location *placesvc.Config
+// globalOptions are the persistent flags of the root command, shared by
+// every command, and the AWS settings loaded from the config file.
+type globalOptions struct {
+	cache          bool
+	cacheBackend   string
+	cacheTable     string
+	cacheTTL       time.Duration
+	contextName    string
+	configPath     string
+	externalID     string
+	json           bool
+	logFormat      string
+	loglevel       string
+	output         string
+	profile        string
+	pushJob        string
+	pushgateway    string
+	outputFormat   output.Format
+	outputQuery    *output.Query
+	outputTemplate *template.Template
+	query          string
+	quiet          bool
+	region         string
+	roleARN        string
+	units          string
+
+	awsProfile  string
+	awsRegion   string
+	resultCache resultCache
 }
 
-var (
-	flags *Flags
-	log   *logrus.Logger
-	svc   *Sercices
+// annotationNoSetup marks commands which must not load the AWS clients before running.
+const annotationNoSetup = "noSetup"
+
+// annotationNoDefaultIndex marks commands whose --index must be given, not
+// taken from the DefaultIndex of the config file, such as delete.
+const annotationNoDefaultIndex = "noDefaultIndex"
 
-	// rootCmd is the Viper root command
-	RootCmd = &cobra.Command{
+var log *logrus.Logger
+
+// apiMetrics records every AWS API call, for /metrics of the serve command
+// and --pushgateway.
+var apiMetrics *metrics.Metrics
+
+// finish is called once the command is done, by PersistentPostRun or, as
+// os.Exit skips that, by exit, so failed commands close the result cache
+// and push their metrics too.
+var finish = func() {}
+
+// finish closes the result cache and pushes the metrics of the AWS API calls
+// to --pushgateway.
+func (g *globalOptions) finish(ctx context.Context) {
+	if g.resultCache != nil {
+		g.resultCache.Close()
+	}
+	if g.pushgateway != "" {
+		if err := apiMetrics.Push(ctx, g.pushgateway, g.pushJob); err != nil {
+			log.WithFields(logrus.Fields{
+				"error":       err,
+				"pushgateway": g.pushgateway,
+			}).Warn("error pushing metrics")
+		}
+	}
+}
+
+// NewRootCmd builds the root command and all of its subcommands. Every
+// command has its own options, so flags of one command never affect another.
+func NewRootCmd() *cobra.Command {
+	g := &globalOptions{}
+	cmd := &cobra.Command{
 		Use: "loc-main",
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			finish = sync.OnceFunc(func() { g.finish(cmd.Context()) })
+
 			// Set the log level
-			switch flags.loglevel {
+			switch g.loglevel {
 			case "error":
 				log.SetLevel(logrus.ErrorLevel)
 			case "warn":
@@ -58,277 +147,600 @@ var (
 			default:
 				log.SetLevel(logrus.InfoLevel)
 			}
-			setup()
+			if g.quiet {
+				log.SetLevel(logrus.ErrorLevel)
+			}
+			if err := setLogFormat(g.logFormat); err != nil {
+				log.WithFields(logrus.Fields{
+					"error": err,
+				}).Fatal("invalid log format")
+			}
+			log.ReplaceHooks(logrus.LevelHooks{})
+			log.AddHook(&commandHook{command: cmd.CommandPath()})
+			if g.units != unitsMetric && g.units != unitsImperial {
+				log.WithFields(logrus.Fields{
+					"units": g.units,
+				}).Fatal("units must be metric or imperial")
+			}
+			format, err := output.ParseFormat(g.output)
+			if err != nil {
+				log.WithFields(logrus.Fields{
+					"error": err,
+				}).Fatal("invalid output format")
+			}
+			if g.json && !cmd.Flags().Changed("output") {
+				format = output.JSON
+			}
+			g.outputFormat = format
+			if g.query != "" {
+				if g.outputQuery, err = output.ParseQuery(g.query); err != nil {
+					log.WithFields(logrus.Fields{
+						"error": err,
+					}).Fatal("invalid query")
+				}
+			}
+			// commands such as login run before valid credentials exist
+			if cmd.Annotations[annotationNoSetup] == "" {
+				g.loadConfig()
+				applyConfigDefaults(cmd)
+			}
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			finish()
 		},
 	}
 
-	cmdCreate = &cobra.Command{
-		Use:   "create",
-		Short: "create location services",
+	cmd.PersistentFlags().StringVarP(&g.loglevel, "loglevel", "", "info", "[error|warn|info|debug|trace]")
+	cmd.PersistentFlags().StringVarP(&g.logFormat, "log-format", "", logFormatText, "log format [text|json]; at debug level the request ID and latency of every AWS API call is logged")
+	cmd.PersistentFlags().BoolVarP(&g.quiet, "quiet", "q", false, "only log errors, overriding --loglevel")
+	cmd.PersistentFlags().StringVarP(&g.configPath, "config", "", "", "config file to use instead of config.yaml in the current directory or the goawsloc user config directory, such as ~/.config/goawsloc")
+	cmd.PersistentFlags().StringVarP(&g.configPath, "dotenv", "", "", "dotenv path")
+	cmd.PersistentFlags().MarkDeprecated("dotenv", "use --config instead")
+	cmd.PersistentFlags().StringVarP(&g.profile, "profile", "", "", "AWS profile, overriding $AWS_PROFILE and the config file")
+	cmd.PersistentFlags().StringVarP(&g.region, "region", "", "", "AWS region, overriding $AWS_REGION and the config file")
+	cmd.PersistentFlags().StringVarP(&g.contextName, "context", "", "", "named context of the config file to use instead of its CurrentContext")
+	cmd.PersistentFlags().StringVarP(&g.output, "output", "o", string(output.Table), "output format [table|json|yaml|csv|geojson|ndjson]")
+	cmd.PersistentFlags().StringVarP(&g.query, "query", "", "", "select part of the json output with a path such as Results[0].Place.PostalCode or Results[*].Place.Label")
+	cmd.PersistentFlags().BoolVarP(&g.json, "json", "j", false, "output json")
+	cmd.PersistentFlags().MarkDeprecated("json", "use --output json instead")
+	cmd.PersistentFlags().StringVarP(&g.units, "units", "", unitsMetric, "distance units of routes, tables and geofence radii [metric|imperial]; distances of places in structured output stay in meters")
+	cmd.PersistentFlags().StringVarP(&g.roleARN, "role-arn", "", "", "IAM role to assume, e.g. for another account")
+	cmd.PersistentFlags().StringVarP(&g.externalID, "external-id", "", "", "external ID required to assume --role-arn")
+	cmd.PersistentFlags().BoolVarP(&g.cache, "cache", "", false, "reuse stored search results and store new ones; only allowed for Storage indexes, as results of SingleUse indexes must not be stored")
+	cmd.PersistentFlags().StringVarP(&g.cacheBackend, "cache-backend", "", cacheBackendFile, "where --cache stores results [file|dynamodb]")
+	cmd.PersistentFlags().StringVarP(&g.cacheTable, "cache-table", "", "", "DynamoDB table of --cache-backend dynamodb")
+	cmd.PersistentFlags().DurationVarP(&g.cacheTTL, "cache-ttl", "", 30*24*time.Hour, "how long cached search results are reused, 0 for ever")
+	cmd.PersistentFlags().StringVarP(&g.pushgateway, "pushgateway", "", "", "Prometheus Pushgateway URL to push the AWS API call metrics to once the command completes, e.g. for batch jobs")
+	cmd.PersistentFlags().StringVarP(&g.pushJob, "push-job", "", "goawsloc", "job name of the metrics pushed to --pushgateway")
+
+	cmd.AddCommand(
+		newBatchCmd(g),
+		newBenchCmd(g),
+		newCacheCmd(g),
+		newConfigCmd(g),
+		newEnrichCmd(g),
+		newGeofenceCmd(g),
+		newKeyCmd(g),
+		newLoginCmd(g),
+		newMapCmd(g),
+		newPlaceCmd(g),
+		newRenderCmd(g),
+		newCreateCmd(g),
+		newDeleteCmd(g),
+		newDescribeCmd(g),
+		newListCmd(g),
+		newPositionCmd(g),
+		newSuggestionCmd(g),
+		newTextCmd(g),
+		newUpdateCmd(g),
+		newRouteCmd(g),
+		newServeCmd(g),
+		newTagsCmd(g),
+		newTrackerCmd(g),
+		newTUICmd(g),
+		newUICmd(g),
+		newWorkerCmd(g),
+	)
+	return cmd
+}
+
+func newCreateCmd(g *globalOptions) *cobra.Command {
+	o := &indexOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:         "create",
+		Short:       "create location services",
+		Annotations: map[string]string{annotationNoDefaultIndex: "true"},
 		Run: func(cmd *cobra.Command, args []string) {
-			setup()
-			if err := runCreatePlaceIndex(); err != nil {
-				log.Fatal(err)
-				os.Exit(1)
+			if err := runCreatePlaceIndex(cmd.Context(), o); err != nil {
+				exit(err)
 			}
 		},
 	}
 
-	cmdDelete = &cobra.Command{
-		Use:   "delete",
-		Short: "delete location services",
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "index description")
+	cmd.Flags().StringVarP(&o.dataSource, "data-source", "", placesvc.DataSourceHere, "data provider [Esri|Grab|Here], Grab is limited to ap-southeast-1")
+	cmd.Flags().StringVarP(&o.intendedUse, "intended-use", "", "", "whether results may be stored [SingleUse|Storage], Storage is billed at a higher rate")
+	cmd.Flags().StringSliceVarP(&o.tags, "tags", "", []string{}, "index tags (key,value)")
+	cmd.MarkFlagRequired("index")
+	return cmd
+}
+
+func newDeleteCmd(g *globalOptions) *cobra.Command {
+	o := &indexOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:         "delete",
+		Short:       "delete location services",
+		Annotations: map[string]string{annotationNoDefaultIndex: "true"},
 		Run: func(cmd *cobra.Command, args []string) {
-			setup()
-			if err := runDeletePlaceIndex(); err != nil {
-				log.Fatal(err)
-				os.Exit(1)
+			if err := runDeletePlaceIndex(cmd.Context(), o); err != nil {
+				exit(err)
 			}
 		},
 	}
 
-	cmdDescribe = &cobra.Command{
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.MarkFlagRequired("index")
+	return cmd
+}
+
+func newDescribeCmd(g *globalOptions) *cobra.Command {
+	o := &indexOptions{globalOptions: g}
+	cmd := &cobra.Command{
 		Use:   "describe",
 		Short: "describe an index",
 		Run: func(cmd *cobra.Command, args []string) {
-			setup()
-			if err := runDescribeIndex(); err != nil {
-				log.Fatal(err)
-				os.Exit(1)
+			if err := runDescribeIndex(cmd.Context(), o); err != nil {
+				exit(err)
 			}
 		},
 	}
 
-	cmdList = &cobra.Command{
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.MarkFlagRequired("index")
+	return cmd
+}
+
+func newListCmd(g *globalOptions) *cobra.Command {
+	o := &indexOptions{globalOptions: g}
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "list indexes",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.all && cmd.Flags().Changed("max-items") {
+				return errors.New("--all and --max-items are mutually exclusive")
+			}
+			if o.maxItems < 1 {
+				return errors.New("--max-items must be at least 1")
+			}
+			return nil
+		},
 		Run: func(cmd *cobra.Command, args []string) {
-			setup()
-			if err := runListIndexes(); err != nil {
-				log.Fatal(err)
-				os.Exit(1)
+			if err := runListIndexes(cmd.Context(), o); err != nil {
+				exit(err)
 			}
 		},
 	}
 
-	cmdPosition = &cobra.Command{
-		Use:   "position",
+	cmd.Flags().BoolVarP(&o.all, "all", "", false, "list every index, following all result pages")
+	cmd.Flags().IntVarP(&o.maxItems, "max-items", "", 100, "maximum number of indexes to fetch")
+	cmd.Flags().StringVarP(&o.sort, "sort", "", "", "sort results by [label]")
+	cmd.Flags().IntVarP(&o.limit, "limit", "", 0, "maximum number of results to output")
+	return cmd
+}
+
+func newPositionCmd(g *globalOptions) *cobra.Command {
+	o := &indexOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "position [lat,lon]",
 		Short: "search coordinate, get a legible address",
-		Long:  "Reverse geocodes a given coordinate and returns a legible address. Allows you to search for Places or points of interest near a given position",
+		Long:  "Reverse geocodes a given coordinate and returns a legible address. Allows you to search for Places or points of interest near a given position. The position is given as an argument or with --point, as \"lat,lon\" or \"lat lon\", or pasted from Google Maps, such as \"38.8977° N, 77.0365° W\". Put -- before a negative latitude argument so it is not read as a flag. Use --output geojson to write the results as a FeatureCollection of points",
+		Example: `  loc position --index my-index 38.8977,-77.0365
+  loc position --index my-index --point "38.8977° N, 77.0365° W"
+  loc position --index my-index -- -33.8568 151.2153`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			point := o.point
+			switch {
+			case len(args) > 0 && point != "":
+				return errors.New("the position argument and --point are mutually exclusive")
+			case len(args) > 0:
+				point = strings.Join(args, " ")
+			case point == "":
+				return errors.New("a position argument or --point is required")
+			}
+			position, err := parsePoint(point)
+			if err != nil {
+				return err
+			}
+			o.position = position
+			return o.checkColumns()
+		},
 		Run: func(cmd *cobra.Command, args []string) {
-			setup()
-			if err := runSearchPosition(); err != nil {
-				log.Fatal(err)
-				os.Exit(1)
+			if err := runSearchPosition(cmd.Context(), o); err != nil {
+				exit(err)
 			}
 		},
 	}
 
-	cmdSuggestion = &cobra.Command{
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the search with instead of IAM credentials")
+	cmd.Flags().StringVarP(&o.point, "point", "", "", "position to search at, as \"lat,lon\", instead of the argument")
+	cmd.Flags().StringVarP(&o.sort, "sort", "", "", "sort results by [distance|label]")
+	cmd.Flags().IntVarP(&o.limit, "limit", "", 0, "maximum number of results to output")
+	cmd.Flags().StringSliceVarP(&o.columns, "columns", "", []string{}, "columns for table and csv output, e.g. label,lat,lon,postalcode,country [label|lat|lon|position|address|addressnumber|street|unit|neighborhood|submunicipality|municipality|subregion|region|postalcode|country|timezone|categories|relevance|distance|placeid]")
+	cmd.Flags().BoolVarP(&o.wide, "wide", "", false, "show extra columns, such as the address fields and place ID")
+	cmd.Flags().StringVarP(&o.template, "format", "", "", "Go template printed for each result instead of --output, e.g. '{{.Label}} -> {{.Position}}'; fields are the column headers, such as Label, Address, Position, Latitude, Longitude, PostalCode, Country, Categories, Distance, Relevance and PlaceId")
+	cmd.Flags().StringVarP(&o.language, "language", "", "", "language of the results, e.g. fr (defaults to en)")
+	cmd.Flags().Int32VarP(&o.maxResults, "max-results", "", 0, "maximum number of results to request from AWS [1-50]")
+	cmd.MarkFlagRequired("index")
+	return cmd
+}
+
+func newSuggestionCmd(g *globalOptions) *cobra.Command {
+	o := &indexOptions{globalOptions: g}
+	cmd := &cobra.Command{
 		Use:   "suggestion",
 		Short: "search free-form text",
-		Long:  "Generates suggestions for addresses and points of interest based on partial or misspelled free-form text. This operation is also known as autocomplete, autosuggest, or fuzzy matching",
+		Long:  "Generates suggestions for addresses and points of interest based on partial or misspelled free-form text. This operation is also known as autocomplete, autosuggest, or fuzzy matching. Suggestions have no position, so --output geojson writes features without geometry. With --interactive, suggestions are searched again as the text is typed and listed live on the terminal, starting from --text if set; Enter writes the details of the selected suggestion, as place get does",
+		Example: `  loc suggestion --index my-index --text "eiffel tow"
+  loc suggestion --index my-index --interactive
+  loc suggestion --index my-index --interactive --text "pike pl" -o json | jq .Geometry`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if flags.lat != 0 && flags.lon == 0 {
-				return errors.New("latitude is set but longitude is not")
-			}
-			if flags.lat == 0 && flags.lon != 0 {
-				return errors.New("longitude is set but latitude is not")
-			}
-			if flags.x1 != 0 && (flags.x2 == 0 || flags.y1 == 0 || flags.y2 == 0) {
-				return errors.New("x1 is set but x2 or y1 or y2 is not")
+			if o.interactive {
+				if err := checkTerminal(os.Stderr); err != nil {
+					return err
+				}
+				if o.debounce < 0 {
+					return errors.New("--debounce must not be negative")
+				}
+			} else if o.text == "" {
+				return errors.New("--text must be set unless --interactive is")
 			}
-			if flags.x2 != 0 && (flags.x1 == 0 || flags.y1 == 0 || flags.y2 == 0) {
-				return errors.New("x2 is set but x1 or y1 or y2 is not")
+			if err := parseCountries(o.countries); err != nil {
+				return err
 			}
-			if flags.y1 != 0 && (flags.x1 == 0 || flags.x2 == 0 || flags.y2 == 0) {
-				return errors.New("y1 is set but x1 or x2 or y2 is not")
+			if err := o.checkColumns(); err != nil {
+				return err
 			}
-			if flags.y2 != 0 && (flags.x1 == 0 || flags.x2 == 0 || flags.y1 == 0) {
-				return errors.New("y2 is set but x1 or x2 or y1 is not")
-			}
-			return nil
+			return o.parseSearchArea()
 		},
 		Run: func(cmd *cobra.Command, args []string) {
-			setup()
-			if err := runSearchSuggestion(); err != nil {
-				log.Fatal(err)
-				os.Exit(1)
+			if err := runSearchSuggestion(cmd.Context(), o); err != nil {
+				exit(err)
 			}
 		},
 	}
 
-	cmdText = &cobra.Command{
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the search with instead of IAM credentials")
+	cmd.Flags().StringVarP(&o.text, "text", "", "", "text")
+	cmd.Flags().StringSliceVarP(&o.categories, "category", "", []string{}, "one or more place categories to limit the search to, such as HotelMotel or GasStation")
+	cmd.Flags().StringSliceVarP(&o.countries, "country", "", []string{}, "one or more countries to limit the search to, as ISO 3166 code or name")
+	cmd.Flags().StringVarP(&o.bias, "bias", "", "", "position to bias results towards, as \"lon,lat\"")
+	cmd.Flags().StringVarP(&o.bbox, "bbox", "", "", "bounding box to limit results to, as \"west,south,east,north\"")
+	cmd.Flags().StringVarP(&o.sort, "sort", "", "", "sort results by [label]")
+	cmd.Flags().IntVarP(&o.limit, "limit", "", 0, "maximum number of results to output")
+	cmd.Flags().StringSliceVarP(&o.columns, "columns", "", []string{}, "columns for table and csv output, e.g. label,lat,lon,postalcode,country [label|lat|lon|position|address|addressnumber|street|unit|neighborhood|submunicipality|municipality|subregion|region|postalcode|country|timezone|categories|relevance|distance|placeid]")
+	cmd.Flags().BoolVarP(&o.wide, "wide", "", false, "show extra columns, such as the address fields and place ID")
+	cmd.Flags().StringVarP(&o.template, "format", "", "", "Go template printed for each result instead of --output, e.g. '{{.Label}} -> {{.Position}}'; fields are the column headers, such as Label, Address, Position, Latitude, Longitude, PostalCode, Country, Categories, Distance, Relevance and PlaceId")
+	cmd.Flags().StringVarP(&o.language, "language", "", "", "language of the results, e.g. fr (defaults to en)")
+	cmd.Flags().Int32VarP(&o.maxResults, "max-results", "", 0, "maximum number of results to request from AWS [1-15]")
+	cmd.Flags().BoolVarP(&o.interactive, "interactive", "", false, "choose a suggestion while typing on the terminal and write its details")
+	cmd.Flags().DurationVarP(&o.debounce, "debounce", "", 300*time.Millisecond, "how long typing must pause before --interactive searches suggestions")
+	cmd.MarkFlagRequired("index")
+	cmd.MarkFlagRequired("country")
+	return cmd
+}
+
+func newTextCmd(g *globalOptions) *cobra.Command {
+	o := &indexOptions{globalOptions: g}
+	cmd := &cobra.Command{
 		Use:   "text",
 		Short: "geocode free-form text",
-		Long:  "Geocodes free-form text, such as an address, name, city, or region to allow you to search for Places or points of interest",
+		Long:  "Geocodes free-form text, such as an address, name, city, or region to allow you to search for Places or points of interest. Use --output geojson to write the results as a FeatureCollection of points",
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if flags.lat != 0 && flags.lon == 0 {
-				return errors.New("latitude is set but longitude is not")
-			}
-			if flags.lat == 0 && flags.lon != 0 {
-				return errors.New("longitude is set but latitude is not")
+			if err := parseCountries(o.countries); err != nil {
+				return err
 			}
-			if flags.x1 != 0 && (flags.x2 == 0 || flags.y1 == 0 || flags.y2 == 0) {
-				return errors.New("x1 is set but x2 or y1 or y2 is not")
+			if err := o.checkColumns(); err != nil {
+				return err
 			}
-			if flags.x2 != 0 && (flags.x1 == 0 || flags.y1 == 0 || flags.y2 == 0) {
-				return errors.New("x2 is set but x1 or y1 or y2 is not")
-			}
-			if flags.y1 != 0 && (flags.x1 == 0 || flags.x2 == 0 || flags.y2 == 0) {
-				return errors.New("y1 is set but x1 or x2 or y2 is not")
-			}
-			if flags.y2 != 0 && (flags.x1 == 0 || flags.x2 == 0 || flags.y1 == 0) {
-				return errors.New("y2 is set but x1 or x2 or y1 is not")
-			}
-			return nil
+			return o.parseSearchArea()
 		},
 		Run: func(cmd *cobra.Command, args []string) {
-			setup()
-			if err := runSearchText(); err != nil {
-				log.Fatal(err)
-				os.Exit(1)
+			if err := runSearchText(cmd.Context(), o); err != nil {
+				exit(err)
 			}
 		},
 	}
 
-	cmdUpdate = &cobra.Command{
-		Use:   "update",
-		Short: "update location services",
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the search with instead of IAM credentials")
+	cmd.Flags().StringVarP(&o.text, "text", "", "", "text")
+	cmd.Flags().StringSliceVarP(&o.categories, "category", "", []string{}, "one or more place categories to limit the search to, such as HotelMotel or GasStation")
+	cmd.Flags().StringSliceVarP(&o.countries, "country", "", []string{}, "one or more countries to limit the search to, as ISO 3166 code or name")
+	cmd.Flags().StringVarP(&o.bias, "bias", "", "", "position to bias results towards, as \"lon,lat\"")
+	cmd.Flags().StringVarP(&o.bbox, "bbox", "", "", "bounding box to limit results to, as \"west,south,east,north\"")
+	cmd.Flags().StringVarP(&o.sort, "sort", "", "", "sort results by [relevance|label|distance]")
+	cmd.Flags().IntVarP(&o.limit, "limit", "", 0, "maximum number of results to output")
+	cmd.Flags().StringSliceVarP(&o.columns, "columns", "", []string{}, "columns for table and csv output, e.g. label,lat,lon,postalcode,country [label|lat|lon|position|address|addressnumber|street|unit|neighborhood|submunicipality|municipality|subregion|region|postalcode|country|timezone|categories|relevance|distance|placeid]")
+	cmd.Flags().BoolVarP(&o.wide, "wide", "", false, "show extra columns, such as the address fields and place ID")
+	cmd.Flags().StringVarP(&o.template, "format", "", "", "Go template printed for each result instead of --output, e.g. '{{.Label}} -> {{.Position}}'; fields are the column headers, such as Label, Address, Position, Latitude, Longitude, PostalCode, Country, Categories, Distance, Relevance and PlaceId")
+	cmd.Flags().StringVarP(&o.language, "language", "", "", "language of the results, e.g. fr (defaults to en)")
+	cmd.Flags().Int32VarP(&o.maxResults, "max-results", "", 0, "maximum number of results to request from AWS [1-50]")
+	cmd.MarkFlagRequired("index")
+	cmd.MarkFlagRequired("text")
+	return cmd
+}
+
+func newUpdateCmd(g *globalOptions) *cobra.Command {
+	o := &indexOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:         "update",
+		Short:       "update location services",
+		Annotations: map[string]string{annotationNoDefaultIndex: "true"},
 		Run: func(cmd *cobra.Command, args []string) {
-			setup()
-			if err := runUpdatePlaceIndex(); err != nil {
-				log.Fatal(err)
-				os.Exit(1)
+			if err := runUpdatePlaceIndex(cmd.Context(), o); err != nil {
+				exit(err)
 			}
 		},
 	}
-)
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "index description")
+	cmd.Flags().StringVarP(&o.intendedUse, "intended-use", "", "", "whether results may be stored [SingleUse|Storage], Storage is billed at a higher rate")
+	cmd.MarkFlagRequired("index")
+	return cmd
+}
 
 func init() {
-	flags = &Flags{}
-	svc = &Sercices{}
+	// Logs go to stderr, so stdout only carries command output and stays
+	// machine-parseable.
 	log = logrus.New()
+	log.SetOutput(os.Stderr)
 	log.SetLevel(logrus.InfoLevel)
 	log.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp: true,
 	})
+	apiMetrics = metrics.New()
+	clientmgr.Default.AddAPIOptions(logAPICalls, apiMetrics.Middleware)
+}
 
-	RootCmd.PersistentFlags().StringVarP(&flags.loglevel, "loglevel", "", "info", "[error|warn|info|debug|trace]")
-	RootCmd.PersistentFlags().StringVarP(&flags.dotenvPath, "dotenv", "", "", "dotenv path")
-	RootCmd.PersistentFlags().BoolVarP(&flags.json, "json", "j", false, "output json")
-
-	cmdCreate.Flags().StringVarP(&flags.indexName, "index", "", "", "index name")
-	cmdCreate.Flags().StringVarP(&flags.description, "description", "", "", "index description")
-	cmdCreate.Flags().StringSliceVarP(&flags.tags, "tags", "", []string{}, "index tags (key,value)")
-	cmdCreate.MarkFlagRequired("index")
-
-	cmdDelete.Flags().StringVarP(&flags.indexName, "index", "", "", "index name")
-	cmdDelete.MarkFlagRequired("index")
-
-	cmdDescribe.Flags().StringVarP(&flags.indexName, "index", "", "", "index name")
-	cmdDelete.MarkFlagRequired("index")
-
-	cmdPosition.Flags().StringVarP(&flags.indexName, "index", "", "", "index name")
-	cmdPosition.Flags().Float64VarP(&flags.lat, "lat", "", 0, "latitude")
-	cmdPosition.Flags().Float64VarP(&flags.lon, "lon", "", 0, "longitude")
-	cmdPosition.MarkFlagRequired("index")
-	cmdPosition.MarkFlagRequired("lat")
-	cmdPosition.MarkFlagRequired("lon")
-
-	cmdSuggestion.Flags().StringVarP(&flags.indexName, "index", "", "", "index name")
-	cmdSuggestion.Flags().StringVarP(&flags.text, "text", "", "", "text")
-	cmdSuggestion.Flags().StringSliceVarP(&flags.countries, "country", "", []string{}, "one or more countries to limit the search to")
-	cmdSuggestion.Flags().Float64VarP(&flags.lat, "lat", "", 0, "latitude")
-	cmdSuggestion.Flags().Float64VarP(&flags.lon, "lon", "", 0, "longitude")
-	cmdSuggestion.Flags().Float64VarP(&flags.x1, "x1", "", 0, "x1")
-	cmdSuggestion.Flags().Float64VarP(&flags.x2, "x2", "", 0, "x2")
-	cmdSuggestion.Flags().Float64VarP(&flags.y1, "y1", "", 0, "y1")
-	cmdSuggestion.Flags().Float64VarP(&flags.y2, "y2", "", 0, "y2")
-	cmdSuggestion.MarkFlagRequired("index")
-	cmdSuggestion.MarkFlagRequired("text")
-	cmdSuggestion.MarkFlagRequired("country")
-
-	cmdText.Flags().StringVarP(&flags.indexName, "index", "", "", "index name")
-	cmdText.Flags().StringVarP(&flags.text, "text", "", "", "text")
-	cmdText.Flags().StringSliceVarP(&flags.countries, "country", "", []string{}, "one or more countries to limit the search to")
-	cmdText.Flags().Float64VarP(&flags.lat, "lat", "", 0, "latitude")
-	cmdText.Flags().Float64VarP(&flags.lon, "lon", "", 0, "longitude")
-	cmdText.Flags().Float64VarP(&flags.x1, "x1", "", 0, "x1")
-	cmdText.Flags().Float64VarP(&flags.x2, "x2", "", 0, "x2")
-	cmdText.Flags().Float64VarP(&flags.y1, "y1", "", 0, "y1")
-	cmdText.Flags().Float64VarP(&flags.y2, "y2", "", 0, "y2")
-	cmdText.MarkFlagRequired("index")
-	cmdText.MarkFlagRequired("text")
-
-	cmdUpdate.Flags().StringVarP(&flags.indexName, "index", "", "", "index name")
-	cmdUpdate.Flags().StringVarP(&flags.description, "description", "", "", "index description")
-	cmdUpdate.MarkFlagRequired("index")
-
-	RootCmd.AddCommand(
-		cmdCreate,
-		cmdDelete,
-		cmdDescribe,
-		cmdList,
-		cmdPosition,
-		cmdSuggestion,
-		cmdText,
-		cmdUpdate,
-	)
+// client returns the AWS Location client for the profile, region and role of
+// the command, or a *placesvc.CredentialsError if the AWS configuration or
+// credentials cannot be loaded. Clients for requests authorized with an API
+// key send unsigned requests and need no credentials, so they ignore
+// --role-arn.
+func (g *globalOptions) client(apiKey string) (*location.Client, error) {
+	key := g.clientKey()
+	if apiKey != "" {
+		key = clientmgr.Key{Region: key.Region, Profile: key.Profile, Anonymous: true}
+	}
+	client, err := clientmgr.Default.Client(key)
+	if err != nil {
+		return nil, &placesvc.CredentialsError{Profile: g.awsProfile, Err: err}
+	}
+
+	if _, err := client.Options().Credentials.Retrieve(context.TODO()); err != nil && apiKey == "" {
+		if ssologin.IsExpiredToken(err) {
+			err = fmt.Errorf("AWS SSO session expired or not signed in, run `loc login` to sign in again: %w", err)
+		}
+		return nil, &placesvc.CredentialsError{Profile: g.awsProfile, Err: err}
+	}
+	return client, nil
 }
 
-func setup() {
-	if flags.dotenvPath == "" {
-		/*
-			// get platform specific user config directory
-			configHome, err := os.UserConfigDir()
-			if err != nil {
-				log.WithFields(logrus.Fields{
-					"error": err,
-				}).Fatal("could not get user config directory and dotenv file not set")
-			}
-			viper.AddConfigPath(path.Join(configHome, "tndx"))
-		*/
-		viper.SetConfigName("config")
-		viper.SetConfigType("yaml")
-		viper.AddConfigPath(".")
-	} else {
-		flags.dotenvPath = path.Clean(flags.dotenvPath)
-		viper.SetConfigFile(flags.dotenvPath)
-		if _, err := os.Stat(flags.dotenvPath); err != nil {
+// mustClient returns the client of client, exiting with the credentials exit
+// code if it cannot be created.
+func (g *globalOptions) mustClient(apiKey string) *location.Client {
+	client, err := g.client(apiKey)
+	if err != nil {
+		exit(err)
+	}
+	return client
+}
+
+// clientKey returns the key of the AWS configuration of the profile, region
+// and role.
+func (g *globalOptions) clientKey() clientmgr.Key {
+	return clientmgr.Key{
+		Region:     g.awsRegion,
+		Profile:    g.awsProfile,
+		RoleARN:    g.roleARN,
+		ExternalID: g.externalID,
+	}
+}
+
+// blobs returns the opener of the files batch commands read and write,
+// which reads and writes S3 objects with the credentials of the location
+// client.
+func (g *globalOptions) blobs() *blob.Opener {
+	return &blob.Opener{S3: func() (*s3.Client, error) {
+		c, err := clientmgr.Default.Config(g.clientKey())
+		if err != nil {
+			return nil, err
+		}
+		return s3.NewFromConfig(c), nil
+	}}
+}
+
+// placeService returns the place index service for the index, authorized
+// with the API key if one is given.
+func (g *globalOptions) placeService(indexName string, apiKey string, opts ...func(*placesvc.Config)) placesvc.PlaceIndexer {
+	svc, err := placesvc.New(append([]func(*placesvc.Config){
+		placesvc.SetLogger(log),
+		placesvc.SetLocationClient(g.mustClient(apiKey)),
+		placesvc.SetAWSProfile(g.awsProfile),
+		placesvc.SetAWSRegion(g.awsRegion),
+		placesvc.SetIndexName(indexName),
+		placesvc.SetAPIKey(apiKey),
+	}, opts...)...)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Fatal("failed to create location service")
+	}
+	if g.cache && indexName != "" {
+		// Results of SingleUse indexes must not be stored, so refuse to
+		// cache them rather than rely on the user to check.
+		if err := svc.CheckCacheable(context.TODO(), indexName); err != nil {
+			exit(err)
+		}
+		f, err := g.openCache()
+		if err != nil {
 			log.WithFields(logrus.Fields{
-				"path":  flags.dotenvPath,
 				"error": err,
-			}).Fatal("unable to load dotenv")
+			}).Fatal("failed to open cache")
 		}
+		placesvc.SetCache(f, g.cacheTTL)(svc)
 	}
+	return svc
+}
 
-	if err := viper.ReadInConfig(); err != nil {
+// trackerService returns the tracker service for the tracker.
+func (g *globalOptions) trackerService(trackerName string, opts ...func(*trackersvc.Config)) *trackersvc.Config {
+	svc, err := trackersvc.New(append([]func(*trackersvc.Config){
+		trackersvc.SetLogger(log),
+		trackersvc.SetLocationClient(g.mustClient("")),
+		trackersvc.SetAWSProfile(g.awsProfile),
+		trackersvc.SetAWSRegion(g.awsRegion),
+		trackersvc.SetTrackerName(trackerName),
+	}, opts...)...)
+	if err != nil {
 		log.WithFields(logrus.Fields{
-			"path": flags.dotenvPath,
-			"err":  err,
-		}).Fatal("failed to read dotenv file")
+			"error": err,
+		}).Fatal("failed to create tracker service")
 	}
+	return svc
+}
 
-	awsProfile := viper.GetString("AwsProfile")
-	awsRegion := viper.GetString("AwsRegion")
+// geofenceService returns the geofence service for the geofence collection.
+func (g *globalOptions) geofenceService(collectionName string, opts ...func(*geofencesvc.Config)) *geofencesvc.Config {
+	svc, err := geofencesvc.New(append([]func(*geofencesvc.Config){
+		geofencesvc.SetLogger(log),
+		geofencesvc.SetLocationClient(g.mustClient("")),
+		geofencesvc.SetAWSProfile(g.awsProfile),
+		geofencesvc.SetAWSRegion(g.awsRegion),
+		geofencesvc.SetCollectionName(collectionName),
+	}, opts...)...)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Fatal("failed to create geofence service")
+	}
+	return svc
+}
 
-	if awsProfile == "" {
-		log.Fatal("AwsProfile not set")
+// routeService returns the route service for the route calculator.
+func (g *globalOptions) routeService(calculatorName string) *routesvc.Config {
+	svc, err := routesvc.New(
+		routesvc.SetLogger(log),
+		routesvc.SetLocationClient(g.mustClient("")),
+		routesvc.SetAWSProfile(g.awsProfile),
+		routesvc.SetAWSRegion(g.awsRegion),
+		routesvc.SetCalculatorName(calculatorName),
+	)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Fatal("failed to create route service")
 	}
-	if awsRegion == "" {
-		log.Fatal("AwsRegion not set in yaml config file")
+	return svc
+}
+
+// mapService returns the map service for the map, authorized with the API
+// key if one is given.
+func (g *globalOptions) mapService(mapName string, apiKey string) *mapsvc.Config {
+	svc, err := mapsvc.New(
+		mapsvc.SetLogger(log),
+		mapsvc.SetLocationClient(g.mustClient(apiKey)),
+		mapsvc.SetAWSProfile(g.awsProfile),
+		mapsvc.SetAWSRegion(g.awsRegion),
+		mapsvc.SetMapName(mapName),
+		mapsvc.SetAPIKey(apiKey),
+	)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Fatal("failed to create map service")
 	}
+	return svc
+}
 
-	var err error
-	svc.location, err = placesvc.New(
-		placesvc.SetLogger(log),
-		placesvc.SetAWSProfile(awsProfile),
-		placesvc.SetAWSRegion(awsRegion),
-		placesvc.SetIndexName(flags.indexName),
+// keyService returns the API key service for the API key.
+func (g *globalOptions) keyService(keyName string) *keysvc.Config {
+	svc, err := keysvc.New(
+		keysvc.SetLogger(log),
+		keysvc.SetLocationClient(g.mustClient("")),
+		keysvc.SetAWSProfile(g.awsProfile),
+		keysvc.SetAWSRegion(g.awsRegion),
+		keysvc.SetKeyName(keyName),
 	)
 	if err != nil {
 		log.WithFields(logrus.Fields{
 			"error": err,
-		}).Fatal("failed to create location service")
+		}).Fatal("failed to create key service")
+	}
+	return svc
+}
+
+// loadConfig reads the config file, exiting if it cannot be read or lacks
+// the AWS profile or region.
+func (g *globalOptions) loadConfig() {
+	if err := g.readConfig(); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  g.configPath,
+		}).Fatal("failed to read config file")
+	}
+}
+
+// readConfig reads the AWS profile and region from the config file, taking
+// them from the context given with --context or the CurrentContext of the
+// file if set. --profile and --region override the config file, as do the
+// AWS_PROFILE and AWS_REGION environment variables, so without a config file
+// they must be given.
+func (g *globalOptions) readConfig() error {
+	if err := g.findConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return err
+		}
+	}
+	if err := applyContext(firstNonEmpty(g.contextName, viper.GetString(currentContextKey))); err != nil {
+		return err
+	}
+
+	g.awsProfile = firstNonEmpty(g.profile, os.Getenv("AWS_PROFILE"), viper.GetString("AwsProfile"))
+	g.awsRegion = firstNonEmpty(g.region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), viper.GetString("AwsRegion"))
+
+	if g.awsProfile == "" {
+		return errors.New("AwsProfile not set, set it in the config file or with --profile")
+	}
+	if g.awsRegion == "" {
+		return errors.New("AwsRegion not set, set it in the config file or with --region")
+	}
+	return nil
+}
+
+// findConfig reads the config file given with --config, or else
+// config.yaml in the current directory or the goawsloc user config
+// directory.
+func (g *globalOptions) findConfig() error {
+	if g.configPath == "" {
+		viper.SetConfigName("config")
+		viper.SetConfigType("yaml")
+		viper.AddConfigPath(".")
+		// without a user config directory, only the current one is searched
+		if dir, err := userConfigDir(); err == nil {
+			viper.AddConfigPath(dir)
+		}
+	} else {
+		g.configPath = path.Clean(g.configPath)
+		viper.SetConfigFile(g.configPath)
+		if _, err := os.Stat(g.configPath); err != nil {
+			return fmt.Errorf("unable to load config file: %w", err)
+		}
 	}
+	return viper.ReadInConfig()
 }
@@ -0,0 +1,709 @@
+package loc
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/routesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/geojson"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// routeOptions are the flags of the route commands.
+type routeOptions struct {
+	*globalOptions
+
+	avoid            []string
+	calculatorName   string
+	cell             string
+	depart           string
+	destinations     []string
+	destinationsFile string
+	filePath         string
+	from             string
+	includeLegs      bool
+	includeSteps     bool
+	matrixFormat     string
+	origins          []string
+	originsFile      string
+	to               string
+	travelMode       string
+	truckHeight      float64
+	truckLength      float64
+	truckWeight      float64
+	truckWidth       float64
+	via              []string
+}
+
+// RouteResult is the route summary with the optional leg and step details.
+type RouteResult struct {
+	Summary *types.CalculateRouteSummary
+	Legs    []RouteLeg `json:",omitempty"`
+}
+
+type RouteLeg struct {
+	StartPosition   []float64
+	EndPosition     []float64
+	Distance        *float64
+	DurationSeconds *float64
+	Steps           []RouteStep `json:",omitempty"`
+}
+
+type RouteStep struct {
+	StartPosition   []float64
+	EndPosition     []float64
+	Distance        *float64
+	DurationSeconds *float64
+	Geometry        [][]float64 `json:",omitempty"`
+}
+
+func newRouteCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "route",
+		Short: "calculate routes",
+	}
+
+	cmd.AddCommand(
+		newCalculatorCmd(g),
+		newRouteCalcCmd(g),
+		newRouteMatrixCmd(g),
+	)
+	return cmd
+}
+
+func newRouteCalcCmd(g *globalOptions) *cobra.Command {
+	o := &routeOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "calc",
+		Short: "calculate a route between two positions, optionally via waypoints",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runRouteCalc(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.calculatorName, "calculator", "", "", "route calculator name")
+	cmd.Flags().StringVarP(&o.from, "from", "", "", "departure position (lat,lon)")
+	cmd.Flags().StringVarP(&o.to, "to", "", "", "destination position (lat,lon)")
+	cmd.Flags().StringArrayVarP(&o.via, "via", "", []string{}, "waypoint position (lat,lon), repeatable, in travel order")
+	cmd.Flags().StringVarP(&o.travelMode, "mode", "", routesvc.TravelModeCar, "travel mode [Car|Truck|Walking|Bicycle|Motorcycle]")
+	cmd.Flags().StringVarP(&o.depart, "depart", "", "", "departure time (now, YYYY-MM-DD, RFC3339 or relative like +2h)")
+	cmd.Flags().StringSliceVarP(&o.avoid, "avoid", "", []string{}, "road features to avoid [ferries,tolls]; areas, highways, tunnels and U-turns cannot be avoided")
+	cmd.Flags().Float64VarP(&o.truckHeight, "truck-height", "", 0, "truck height in meters, or feet with --units imperial (Truck mode)")
+	cmd.Flags().Float64VarP(&o.truckLength, "truck-length", "", 0, "truck length in meters, or feet with --units imperial (Truck mode)")
+	cmd.Flags().Float64VarP(&o.truckWidth, "truck-width", "", 0, "truck width in meters, or feet with --units imperial (Truck mode)")
+	cmd.Flags().Float64VarP(&o.truckWeight, "truck-weight", "", 0, "total truck weight in kilograms, or pounds with --units imperial (Truck mode)")
+	cmd.Flags().BoolVarP(&o.includeLegs, "include-legs", "", false, "include per-leg details")
+	cmd.Flags().BoolVarP(&o.includeSteps, "include-steps", "", false, "include per-step details and geometry (implies --include-legs)")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "output file (default stdout)")
+	cmd.MarkFlagRequired("calculator")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+func newRouteMatrixCmd(g *globalOptions) *cobra.Command {
+	o := &routeOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "matrix",
+		Short: "calculate travel distances and durations between many positions",
+		Long:  "Calculates the routes between every origin and destination and writes the durations or distances as a labeled matrix with origins as rows and destinations as columns. --format lays out the matrix of the default table output; with another --output, the response of AWS is written in that format instead",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("format") && o.outputFormat != output.Table {
+				return fmt.Errorf("--format only applies to the table output, not to --output %s", o.outputFormat)
+			}
+			if o.cell != "duration" && o.cell != "distance" {
+				return fmt.Errorf("invalid cell %q, must be duration or distance", o.cell)
+			}
+			if o.matrixFormat != "csv" && o.matrixFormat != "html" && o.matrixFormat != "json" {
+				return fmt.Errorf("invalid format %q, must be csv, html or json", o.matrixFormat)
+			}
+			if len(o.origins) == 0 && o.originsFile == "" {
+				return errors.New("--origin or --origins-file is required")
+			}
+			if len(o.destinations) == 0 && o.destinationsFile == "" {
+				return errors.New("--destination or --destinations-file is required")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runRouteMatrix(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.calculatorName, "calculator", "", "", "route calculator name")
+	cmd.Flags().StringArrayVarP(&o.origins, "origin", "", []string{}, "origin position ([label=]lat,lon), repeatable")
+	cmd.Flags().StringArrayVarP(&o.destinations, "destination", "", []string{}, "destination position ([label=]lat,lon), repeatable")
+	cmd.Flags().StringSliceVarP(&o.avoid, "avoid", "", []string{}, "road features to avoid [ferries,tolls]; areas, highways, tunnels and U-turns cannot be avoided")
+	cmd.Flags().StringVarP(&o.cell, "cell", "", "duration", "matrix cell value [duration|distance]")
+	cmd.Flags().StringVarP(&o.originsFile, "origins-file", "", "", "CSV file of origins ([label,]lat,lon per row)")
+	cmd.Flags().StringVarP(&o.destinationsFile, "destinations-file", "", "", "CSV file of destinations ([label,]lat,lon per row)")
+	cmd.Flags().StringVarP(&o.matrixFormat, "format", "", "csv", "layout of the matrix of the table output [csv|html|json], not combinable with another --output")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "output file (default stdout)")
+	cmd.MarkFlagRequired("calculator")
+	return cmd
+}
+
+// parseLatLon parses a position given as "lat,lon".
+func parseLatLon(value string) (*routesvc.LatLon, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid position %q, must be lat,lon", value)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude in %q", value)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude in %q", value)
+	}
+	return &routesvc.LatLon{Latitude: lat, Longitude: lon}, nil
+}
+
+// parseLabeledLatLon parses a position given as "label=lat,lon" or "lat,lon".
+// Without a label, the position itself is used as the label.
+func parseLabeledLatLon(value string) (string, *routesvc.LatLon, error) {
+	label := value
+	if i := strings.LastIndex(value, "="); i >= 0 {
+		label = value[:i]
+		value = value[i+1:]
+	}
+	latLon, err := parseLatLon(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return label, latLon, nil
+}
+
+// parseLabeledLatLons parses a list of positions into their labels and positions.
+func parseLabeledLatLons(values []string) ([]string, []routesvc.LatLon, error) {
+	labels := make([]string, 0, len(values))
+	latLons := make([]routesvc.LatLon, 0, len(values))
+	for _, value := range values {
+		label, latLon, err := parseLabeledLatLon(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		labels = append(labels, label)
+		latLons = append(latLons, *latLon)
+	}
+	return labels, latLons, nil
+}
+
+// truckOptions builds the truck options from the --truck-* flags, in the
+// units selected with --units. It returns nil if no truck flag is set.
+func (o *routeOptions) truckOptions() *routesvc.TruckOptions {
+	if o.truckHeight == 0 && o.truckLength == 0 && o.truckWidth == 0 && o.truckWeight == 0 {
+		return nil
+	}
+	truck := &routesvc.TruckOptions{
+		Height:        o.truckHeight,
+		Length:        o.truckLength,
+		Width:         o.truckWidth,
+		DimensionUnit: routesvc.DimensionUnitMeters,
+		Weight:        o.truckWeight,
+		WeightUnit:    routesvc.WeightUnitKilograms,
+	}
+	if o.units == unitsImperial {
+		truck.DimensionUnit = routesvc.DimensionUnitFeet
+		truck.WeightUnit = routesvc.WeightUnitPounds
+	}
+	return truck
+}
+
+// parseAvoidance builds the avoidance options from the --avoid flag.
+func parseAvoidance(features []string) (*routesvc.AvoidanceOptions, error) {
+	if len(features) == 0 {
+		return nil, nil
+	}
+	avoid := &routesvc.AvoidanceOptions{}
+	for _, feature := range features {
+		switch strings.ToLower(strings.TrimSpace(feature)) {
+		case "ferries":
+			avoid.Ferries = true
+		case "tolls":
+			avoid.Tolls = true
+		default:
+			return nil, fmt.Errorf("invalid avoid option %q, must be ferries or tolls; the CalculateRoute API cannot avoid areas, controlled-access highways, tunnels or U-turns", feature)
+		}
+	}
+	return avoid, nil
+}
+
+// formatSeconds renders a duration in seconds as e.g. 1h2m3s.
+func formatSeconds(seconds *float64) string {
+	if seconds == nil {
+		return ""
+	}
+	return time.Duration(*seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+// formatPositions renders a list of positions as space separated lon,lat pairs.
+func formatPositions(positions [][]float64) string {
+	parts := make([]string, 0, len(positions))
+	for _, pos := range positions {
+		parts = append(parts, fmt.Sprintf("%.5f,%.5f", pos[0], pos[1]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// routeResult collects the requested level of detail from a calculated route.
+// Step geometry is cut from the leg geometry using the step geometry offsets.
+func (o *routeOptions) routeResult(ret *types.CalculateRouteSummary, legs []types.Leg) *RouteResult {
+	result := &RouteResult{Summary: ret}
+	// routes via waypoints always show their legs
+	if !o.includeLegs && !o.includeSteps && len(o.via) == 0 {
+		return result
+	}
+
+	for _, leg := range legs {
+		routeLeg := RouteLeg{
+			StartPosition:   leg.StartPosition,
+			EndPosition:     leg.EndPosition,
+			Distance:        leg.Distance,
+			DurationSeconds: leg.DurationSeconds,
+		}
+		if o.includeSteps {
+			for i, step := range leg.Steps {
+				routeStep := RouteStep{
+					StartPosition:   step.StartPosition,
+					EndPosition:     step.EndPosition,
+					Distance:        step.Distance,
+					DurationSeconds: step.DurationSeconds,
+				}
+				if leg.Geometry != nil && step.GeometryOffset != nil {
+					start := int(*step.GeometryOffset)
+					end := len(leg.Geometry.LineString)
+					if i+1 < len(leg.Steps) && leg.Steps[i+1].GeometryOffset != nil {
+						end = int(*leg.Steps[i+1].GeometryOffset) + 1
+					}
+					if start < end && end <= len(leg.Geometry.LineString) {
+						routeStep.Geometry = leg.Geometry.LineString[start:end]
+					}
+				}
+				routeLeg.Steps = append(routeLeg.Steps, routeStep)
+			}
+		}
+		result.Legs = append(result.Legs, routeLeg)
+	}
+	return result
+}
+
+// routeGeoJSON converts the leg geometry of a route into a FeatureCollection
+// with one LineString per leg. The leg number, distance and duration are set
+// as properties, and the first feature also carries the route summary.
+func routeGeoJSON(summary *types.CalculateRouteSummary, legs []types.Leg) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for i, leg := range legs {
+		if leg.Geometry == nil || len(leg.Geometry.LineString) < 2 {
+			continue
+		}
+		properties := map[string]interface{}{
+			"leg":             i + 1,
+			"distance":        aws.ToFloat64(leg.Distance),
+			"distanceUnit":    summary.DistanceUnit,
+			"durationSeconds": aws.ToFloat64(leg.DurationSeconds),
+		}
+		if len(fc.Features) == 0 {
+			properties["dataSource"] = aws.ToString(summary.DataSource)
+			properties["routeDistance"] = aws.ToFloat64(summary.Distance)
+			properties["routeDurationSeconds"] = aws.ToFloat64(summary.DurationSeconds)
+		}
+		fc.AddFeature(geojson.NewFeature(geojson.NewLineString(leg.Geometry.LineString), properties))
+	}
+	return fc
+}
+
+func runRouteCalc(ctx context.Context, o *routeOptions) error {
+	svc := o.routeService(o.calculatorName)
+	from, err := parseLatLon(o.from)
+	if err != nil {
+		return err
+	}
+	to, err := parseLatLon(o.to)
+	if err != nil {
+		return err
+	}
+	avoid, err := parseAvoidance(o.avoid)
+	if err != nil {
+		return err
+	}
+	var waypoints []routesvc.LatLon
+	for _, value := range o.via {
+		waypoint, err := parseLatLon(value)
+		if err != nil {
+			return err
+		}
+		waypoints = append(waypoints, *waypoint)
+	}
+
+	request := &routesvc.RouteRequest{
+		Avoid:              avoid,
+		Departure:          from,
+		Destination:        to,
+		DistanceUnit:       o.distanceUnit(),
+		IncludeLegGeometry: o.includeSteps || o.outputFormat == output.GeoJSON,
+		TravelMode:         o.travelMode,
+		Truck:              o.truckOptions(),
+		Waypoints:          waypoints,
+	}
+	switch o.depart {
+	case "":
+	case "now":
+		request.DepartNow = true
+	default:
+		departure, err := parseTime(o.depart)
+		if err != nil {
+			return err
+		}
+		request.DepartureTime = &departure
+	}
+
+	ret, err := svc.CalculateRoute(ctx, request)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error calculating route")
+		return err
+	}
+	if ret.Summary == nil {
+		return errors.New("route calculation returned no summary")
+	}
+
+	w, err := openOutput(o.filePath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error opening output file")
+		return err
+	}
+	defer w.Close()
+
+	result := o.routeResult(ret.Summary, ret.Legs)
+	rows := &output.Rows{Header: []string{"Leg", "Step", "Start", "End", "Distance", "Duration", "Geometry"}}
+	for i, leg := range result.Legs {
+		rows.Rows = append(rows.Rows, []string{strconv.Itoa(i + 1), "", formatPositions([][]float64{leg.StartPosition}), formatPositions([][]float64{leg.EndPosition}), formatFloat(leg.Distance, 3), formatSeconds(leg.DurationSeconds), ""})
+		for j, step := range leg.Steps {
+			rows.Rows = append(rows.Rows, []string{strconv.Itoa(i + 1), strconv.Itoa(j + 1), formatPositions([][]float64{step.StartPosition}), formatPositions([][]float64{step.EndPosition}), formatFloat(step.Distance, 3), formatSeconds(step.DurationSeconds), formatPositions(step.Geometry)})
+		}
+	}
+	out := &output.Result{
+		Data: result,
+		Record: output.Record{
+			{Name: "Data Source", Value: aws.ToString(result.Summary.DataSource)},
+			{Name: "Distance", Value: fmt.Sprintf("%.3f %s", aws.ToFloat64(result.Summary.Distance), result.Summary.DistanceUnit)},
+			{Name: "Duration", Value: formatSeconds(result.Summary.DurationSeconds)},
+		},
+		GeoJSON: routeGeoJSON(ret.Summary, ret.Legs),
+	}
+	if len(result.Legs) > 0 {
+		out.Rows = rows
+	}
+	return o.writeResultTo(w, out)
+}
+
+// matrixCell returns the value selected with --cell of a matrix entry, or nil
+// if the route could not be calculated.
+func (o *routeOptions) matrixCell(entry types.RouteMatrixEntry) *float64 {
+	if entry.Error != nil {
+		return nil
+	}
+	if o.cell == "distance" {
+		return entry.Distance
+	}
+	return entry.DurationSeconds
+}
+
+// writeMatrixCSV writes the matrix as CSV with a header row of destination
+// labels and the origin label in the first column of every row.
+func (o *routeOptions) writeMatrixCSV(w io.Writer, origins []string, destinations []string, matrix [][]types.RouteMatrixEntry) error {
+	return output.Write(w, output.CSV, &output.Result{Rows: o.matrixRows(origins, destinations, matrix)})
+}
+
+// matrixRows lays out the matrix with a row per origin and a column per
+// destination, leaving cells empty where no route was found.
+func (o *routeOptions) matrixRows(origins []string, destinations []string, matrix [][]types.RouteMatrixEntry) *output.Rows {
+	rows := &output.Rows{Header: append([]string{""}, destinations...)}
+	for i, row := range matrix {
+		record := make([]string, 0, len(row)+1)
+		record = append(record, origins[i])
+		for _, entry := range row {
+			record = append(record, formatFloat(o.matrixCell(entry), 3))
+		}
+		rows.Rows = append(rows.Rows, record)
+	}
+	return rows
+}
+
+// matrixTemplate renders the matrix as a table with cells shaded from green
+// (shortest) to red (longest).
+var matrixTemplate = template.Must(template.New("matrix").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Route matrix</title>
+<style>
+table { border-collapse: collapse; font-family: sans-serif; font-size: 0.9em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<p>{{.Title}}</p>
+<table>
+<tr><th></th>{{range .Destinations}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr><th>{{.Label}}</th>{{range .Cells}}<td style="background: {{.Color}}">{{.Value}}</td>{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type matrixHTMLCell struct {
+	Value string
+	Color template.CSS
+}
+
+type matrixHTMLRow struct {
+	Label string
+	Cells []matrixHTMLCell
+}
+
+// writeMatrixHTML writes the matrix as a standalone HTML heatmap.
+func (o *routeOptions) writeMatrixHTML(w io.Writer, origins []string, destinations []string, matrix [][]types.RouteMatrixEntry, unit types.DistanceUnit) error {
+	min, max := 0.0, 0.0
+	first := true
+	for _, row := range matrix {
+		for _, entry := range row {
+			if v := o.matrixCell(entry); v != nil {
+				if first || *v < min {
+					min = *v
+				}
+				if first || *v > max {
+					max = *v
+				}
+				first = false
+			}
+		}
+	}
+
+	rows := make([]matrixHTMLRow, 0, len(matrix))
+	for i, row := range matrix {
+		htmlRow := matrixHTMLRow{Label: origins[i]}
+		for _, entry := range row {
+			v := o.matrixCell(entry)
+			if v == nil {
+				htmlRow.Cells = append(htmlRow.Cells, matrixHTMLCell{Value: "n/a", Color: "#fff"})
+				continue
+			}
+			ratio := 0.0
+			if max > min {
+				ratio = (*v - min) / (max - min)
+			}
+			value := strconv.FormatFloat(*v, 'f', 3, 64)
+			if o.cell == "duration" {
+				value = formatSeconds(v)
+			}
+			htmlRow.Cells = append(htmlRow.Cells, matrixHTMLCell{
+				Value: value,
+				// hue 120 is green, 0 is red
+				Color: template.CSS(fmt.Sprintf("hsl(%d, 70%%, 75%%)", int(120*(1-ratio)))),
+			})
+		}
+		rows = append(rows, htmlRow)
+	}
+
+	title := "Travel duration"
+	if o.cell == "distance" {
+		title = fmt.Sprintf("Travel distance (%s)", unit)
+	}
+	return matrixTemplate.Execute(w, struct {
+		Title        string
+		Destinations []string
+		Rows         []matrixHTMLRow
+	}{
+		Title:        title,
+		Destinations: destinations,
+		Rows:         rows,
+	})
+}
+
+// readLabeledLatLons reads positions from a CSV file with either label,lat,lon
+// or lat,lon rows. A first row whose latitude is not a number is taken as a
+// header and skipped.
+func readLabeledLatLons(filename string) ([]string, []routesvc.LatLon, error) {
+	fh, err := os.Open(path.Clean(filename))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer fh.Close()
+
+	r := csv.NewReader(fh)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	var values []string
+	for i, record := range records {
+		var value string
+		switch len(record) {
+		case 2:
+			value = record[0] + "," + record[1]
+		case 3:
+			value = record[0] + "=" + record[1] + "," + record[2]
+		default:
+			return nil, nil, fmt.Errorf("%s: row %d has %d columns, must be [label,]lat,lon", filename, i+1, len(record))
+		}
+		if i == 0 {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(record[len(record)-2]), 64); err != nil {
+				continue
+			}
+		}
+		values = append(values, value)
+	}
+	return parseLabeledLatLons(values)
+}
+
+// matrixPositions combines the positions given as flags and in a CSV file.
+func matrixPositions(values []string, filename string) ([]string, []routesvc.LatLon, error) {
+	labels, latLons, err := parseLabeledLatLons(values)
+	if err != nil || filename == "" {
+		return labels, latLons, err
+	}
+	fileLabels, fileLatLons, err := readLabeledLatLons(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(labels, fileLabels...), append(latLons, fileLatLons...), nil
+}
+
+// MatrixResult is the JSON form of a route matrix, with cells indexed by
+// origin then destination.
+type MatrixResult struct {
+	Origins      []string
+	Destinations []string
+	DistanceUnit types.DistanceUnit
+	Cells        [][]MatrixCell
+}
+
+type MatrixCell struct {
+	Distance        *float64 `json:",omitempty"`
+	DurationSeconds *float64 `json:",omitempty"`
+	Error           string   `json:",omitempty"`
+}
+
+// matrixResult converts the matrix returned by AWS into its JSON form.
+func matrixResult(origins []string, destinations []string, matrix [][]types.RouteMatrixEntry, unit types.DistanceUnit) *MatrixResult {
+	result := &MatrixResult{Origins: origins, Destinations: destinations, DistanceUnit: unit}
+	for _, row := range matrix {
+		cells := make([]MatrixCell, 0, len(row))
+		for _, entry := range row {
+			cell := MatrixCell{Distance: entry.Distance, DurationSeconds: entry.DurationSeconds}
+			if entry.Error != nil {
+				cell.Error = string(entry.Error.Code)
+			}
+			cells = append(cells, cell)
+		}
+		result.Cells = append(result.Cells, cells)
+	}
+	return result
+}
+
+func runRouteMatrix(ctx context.Context, o *routeOptions) error {
+	svc := o.routeService(o.calculatorName)
+	originLabels, origins, err := matrixPositions(o.origins, o.originsFile)
+	if err != nil {
+		return err
+	}
+	destinationLabels, destinations, err := matrixPositions(o.destinations, o.destinationsFile)
+	if err != nil {
+		return err
+	}
+	avoid, err := parseAvoidance(o.avoid)
+	if err != nil {
+		return err
+	}
+
+	ret, err := svc.CalculateRouteMatrix(ctx, &routesvc.RouteMatrixRequest{
+		Avoid:        avoid,
+		Departures:   origins,
+		Destinations: destinations,
+		DistanceUnit: o.distanceUnit(),
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error calculating route matrix")
+		return err
+	}
+	for i, row := range ret.RouteMatrix {
+		for j, entry := range row {
+			if entry.Error != nil {
+				log.WithFields(logrus.Fields{
+					"origin":      originLabels[i],
+					"destination": destinationLabels[j],
+					"code":        entry.Error.Code,
+				}).Warn("unable to calculate route")
+			}
+		}
+	}
+
+	w, err := openOutput(o.filePath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error opening output file")
+		return err
+	}
+	defer w.Close()
+
+	// The unit is the requested one unless AWS reports otherwise.
+	unit := types.DistanceUnit(o.distanceUnit())
+	if ret.Summary != nil && ret.Summary.DistanceUnit != "" {
+		unit = ret.Summary.DistanceUnit
+	}
+	if o.outputFormat != output.Table {
+		err = output.Write(w, o.outputFormat, &output.Result{
+			Data: ret,
+			Rows: o.matrixRows(originLabels, destinationLabels, ret.RouteMatrix),
+		})
+	} else if o.matrixFormat == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(matrixResult(originLabels, destinationLabels, ret.RouteMatrix, unit))
+	} else if o.matrixFormat == "html" {
+		err = o.writeMatrixHTML(w, originLabels, destinationLabels, ret.RouteMatrix, unit)
+	} else {
+		err = o.writeMatrixCSV(w, originLabels, destinationLabels, ret.RouteMatrix)
+	}
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error writing route matrix")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+	"github.com/rmrfslashbin/goawsloc/pkg/server"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// serveOptions are the flags of the serve command.
+type serveOptions struct {
+	*globalOptions
+	webhookOptions
+
+	addr      string
+	apiKey    string
+	grpcAddr  string
+	indexName string
+	keysFile  string
+}
+
+func newServeCmd(g *globalOptions) *cobra.Command {
+	o := &serveOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "serve searches as a REST API",
+		Long:  "Serves the searches of a place index as a JSON REST API, and with --grpc over gRPC as well, so internal apps can call one proxy instead of embedding AWS credentials everywhere. Requests need one of the API keys of --keys-file or ServeKeys of the config file if any are set; the endpoints, key settings and webhooks are described in the README and by loc serve openapi",
+		Example: `  loc serve --index my-index --listen localhost:8080
+  curl -d '{"text": "1600 Pennsylvania Ave"}' localhost:8080/v1/geocode
+  loc serve --index my-index --grpc :9090`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runServe(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the searches with instead of IAM credentials")
+	cmd.Flags().StringVarP(&o.addr, "listen", "", "localhost:8080", "address to listen on")
+	cmd.Flags().StringVarP(&o.grpcAddr, "grpc", "", "", "address to serve gRPC on as well, e.g. :9090")
+	cmd.Flags().StringVarP(&o.keysFile, "keys-file", "", "", "YAML file of the API keys requests must carry, instead of ServeKeys of the config file")
+	o.addWebhookFlags(cmd)
+	cmd.MarkFlagRequired("index")
+
+	cmd.AddCommand(
+		newServeOpenAPICmd(g),
+	)
+	return cmd
+}
+
+func newServeOpenAPICmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "openapi",
+		Short:       "print the OpenAPI document of the REST API",
+		Long:        "Prints the OpenAPI 3 document served at /openapi.json, as JSON or with --output yaml as YAML, so clients in other languages can be generated without running the server",
+		Annotations: map[string]string{annotationNoSetup: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runServeOpenAPI(g); err != nil {
+				exit(err)
+			}
+		},
+	}
+	return cmd
+}
+
+func runServeOpenAPI(g *globalOptions) error {
+	format := output.JSON
+	if g.outputFormat == output.YAML {
+		format = output.YAML
+	}
+	if err := output.Write(os.Stdout, format, &output.Result{Data: server.OpenAPI()}); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error writing OpenAPI document")
+		return err
+	}
+	return nil
+}
+
+func runServe(ctx context.Context, o *serveOptions) error {
+	opts := []func(*server.Server){server.SetLogger(log), server.SetMetrics(apiMetrics.Handler())}
+	keys, err := o.serveKeys()
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error loading API keys")
+		return err
+	}
+	if keys != nil {
+		opts = append(opts, server.SetKeys(keys))
+	}
+	webhooks, err := o.dispatcher()
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error configuring webhooks")
+		return err
+	}
+	if webhooks != nil {
+		// Anyone who can post to /v1/events could otherwise have made-up
+		// events signed and sent to the webhooks.
+		if !keys.HasAdmin() {
+			err := errors.New("webhooks need an admin API key in --keys-file or ServeKeys to post events with")
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error configuring webhooks")
+			return err
+		}
+		opts = append(opts, server.SetWebhooks(webhooks))
+	}
+	srv := server.New(o.placeService(o.indexName, o.apiKey), opts...)
+	log.WithFields(logrus.Fields{
+		"listen":   o.addr,
+		"grpc":     o.grpcAddr,
+		"index":    o.indexName,
+		"auth":     keys != nil,
+		"webhooks": webhooks != nil,
+	}).Info("Serving")
+
+	// Stop both servers once either fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make(chan error, 2)
+	servers := 1
+	go func() {
+		errs <- srv.ListenAndServe(ctx, o.addr)
+	}()
+	if o.grpcAddr != "" {
+		servers++
+		go func() {
+			errs <- srv.ServeGRPC(ctx, o.grpcAddr)
+		}()
+	}
+
+	for range servers {
+		if e := <-errs; e != nil && err == nil {
+			err = e
+			cancel()
+		}
+	}
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error serving")
+	}
+	return err
+}
+
+// serveKeys returns the API keys of --keys-file or the config file, or nil
+// if neither has any.
+func (o *serveOptions) serveKeys() (*server.Keys, error) {
+	if o.keysFile != "" {
+		return server.LoadKeys(o.keysFile)
+	}
+	if !viper.IsSet("ServeKeys") {
+		return nil, nil
+	}
+	var keys []server.Key
+	if err := viper.UnmarshalKey("ServeKeys", &keys); err != nil {
+		return nil, err
+	}
+	return server.NewKeys(keys)
+}
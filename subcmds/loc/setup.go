@@ -1,19 +1,26 @@
 package loc
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"regexp"
+	"strconv"
 	"strings"
-	"text/tabwriter"
 
 	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/geojson"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/location/types"
-	"github.com/davecgh/go-spew/spew"
 	"github.com/sirupsen/logrus"
 )
 
+type IndexListResults struct {
+	Entries []types.ListPlaceIndexesResponseEntry
+}
+
 type PositionSummaryResults struct {
 	Summary *types.SearchPlaceIndexForPositionSummary
 	Results []types.SearchForPositionResult
@@ -29,19 +36,166 @@ type TextSummaryResults struct {
 	Results []types.SearchForTextResult
 }
 
-func runCreatePlaceIndex() error {
-	tags := make(map[string]string, len(flags.tags))
-	for _, tag := range flags.tags {
+// parseTags converts key=value tag flags into a tag map.
+func parseTags(values []string) (map[string]string, error) {
+	tags := make(map[string]string, len(values))
+	for _, tag := range values {
 		parts := strings.Split(tag, "=")
 		if len(parts) != 2 {
 			log.WithFields(logrus.Fields{
 				"tag": tag,
 			}).Error("invalid tag")
-			return fmt.Errorf("invalid tag: %s", tag)
+			return nil, fmt.Errorf("invalid tag: %s", tag)
 		}
 		tags[parts[0]] = parts[1]
 	}
-	if ret, err := svc.location.CreatePlaceIndex(flags.description, &tags); err != nil {
+	return tags, nil
+}
+
+// parseCountries converts the --country values, which may be alpha-2 or
+// alpha-3 codes or country names, into the alpha-3 codes the API expects.
+func parseCountries(countries []string) error {
+	for i, country := range countries {
+		code, err := placesvc.CountryCode(country)
+		if err != nil {
+			return err
+		}
+		countries[i] = code
+	}
+	return nil
+}
+
+// searchAreaOptions are the flags biasing a search towards a position or
+// limiting it to a bounding box.
+type searchAreaOptions struct {
+	bias string
+	bbox string
+
+	biasPosition *placesvc.LatLon
+	filterBBox   *placesvc.Box
+}
+
+// parseCoordinates parses a comma separated list of numbers, one for each of
+// the names, which are used in the errors.
+func parseCoordinates(flag string, value string, names ...string) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != len(names) {
+		return nil, fmt.Errorf("invalid --%s %q, must be %s", flag, value, strings.Join(names, ","))
+	}
+	values := make([]float64, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in --%s %q", names[i], flag, value)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// parseSearchArea sets the bias position from --bias "lon,lat" or the
+// bounding box from --bbox "west,south,east,north", in the longitude first
+// order of GeoJSON and the API.
+func (o *searchAreaOptions) parseSearchArea() error {
+	o.biasPosition = nil
+	o.filterBBox = nil
+
+	if o.bias != "" && o.bbox != "" {
+		return errors.New("--bias and --bbox are mutually exclusive")
+	}
+	if o.bias != "" {
+		v, err := parseCoordinates("bias", o.bias, "lon", "lat")
+		if err != nil {
+			return err
+		}
+		position := &placesvc.LatLon{Latitude: v[1], Longitude: v[0]}
+		if err := position.Validate(); err != nil {
+			return fmt.Errorf("invalid --bias: %w", err)
+		}
+		o.biasPosition = position
+	}
+	if o.bbox != "" {
+		v, err := parseCoordinates("bbox", o.bbox, "west", "south", "east", "north")
+		if err != nil {
+			return err
+		}
+		box := &placesvc.Box{X1: v[0], Y1: v[1], X2: v[2], Y2: v[3]}
+		if err := box.Validate(); err != nil {
+			return fmt.Errorf("invalid --bbox: %w", err)
+		}
+		o.filterBBox = box
+	}
+	return nil
+}
+
+// pointPart matches a coordinate of a point, with an optional degree sign
+// and hemisphere, such as -77.0365 or 38.8977° N.
+var pointPart = regexp.MustCompile(`([-+]?\d+(?:\.\d+)?)\s*°?\s*([NSEWnsew])?`)
+
+// parsePoint parses a position given as "lat,lon" or "lat lon", as well as
+// coordinates pasted from Google Maps, such as "38.8977° N, 77.0365° W" or a
+// maps URL with "@38.8977,-77.0365,17z" in its path.
+func parsePoint(value string) (*placesvc.LatLon, error) {
+	text := value
+	if i := strings.Index(text, "@"); i >= 0 {
+		// Keep lat,lon of the URL and drop the zoom and the rest of the path.
+		text = text[i+1:]
+		if end := strings.IndexAny(text, "/?"); end >= 0 {
+			text = text[:end]
+		}
+		if parts := strings.Split(text, ","); len(parts) > 2 {
+			text = strings.Join(parts[:2], ",")
+		}
+	}
+	text = strings.Trim(strings.TrimSpace(text), "()")
+
+	matches := pointPart.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) != 2 || strings.Trim(pointPart.ReplaceAllString(text, ""), ", \t") != "" {
+		return nil, fmt.Errorf("invalid point %q, must be lat,lon", value)
+	}
+	coords := make([]float64, 2)
+	hemispheres := make([]string, 2)
+	for i, m := range matches {
+		v, err := strconv.ParseFloat(text[m[2]:m[3]], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid point %q, must be lat,lon", value)
+		}
+		if m[4] >= 0 {
+			hemispheres[i] = strings.ToUpper(text[m[4]:m[5]])
+			if hemispheres[i] == "S" || hemispheres[i] == "W" {
+				v = -v
+			}
+		}
+		coords[i] = v
+	}
+	// With hemispheres, longitude may come first, as in 77.0365° W 38.8977° N.
+	if (hemispheres[0] == "E" || hemispheres[0] == "W") && (hemispheres[1] == "N" || hemispheres[1] == "S") {
+		coords[0], coords[1] = coords[1], coords[0]
+	}
+	point := &placesvc.LatLon{Latitude: coords[0], Longitude: coords[1]}
+	if err := point.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid point %q: %w", value, err)
+	}
+	return point, nil
+}
+
+// warnStorage warns that storing results is billed differently.
+func warnStorage(intendedUse string) {
+	if intendedUse == placesvc.IntendedUseStorage {
+		log.WithFields(logrus.Fields{
+			"intendedUse": intendedUse,
+		}).Warn("results of Storage indexes may be stored but every request is billed at a higher rate than SingleUse, see https://aws.amazon.com/location/pricing/")
+	}
+}
+
+func runCreatePlaceIndex(ctx context.Context, o *indexOptions) error {
+	svc := o.placeService(o.indexName, o.apiKey, placesvc.SetIntendedUse(o.intendedUse), placesvc.SetIndexService(o.dataSource))
+	tags, err := parseTags(o.tags)
+	if err != nil {
+		return err
+	}
+	warnStorage(o.intendedUse)
+	if ret, err := svc.CreatePlaceIndex(ctx, o.description, &tags); err != nil {
 		log.WithFields(logrus.Fields{
 			"error": err,
 		}).Error("error creating index")
@@ -51,13 +205,15 @@ func runCreatePlaceIndex() error {
 			"createTime": ret.CreateTime,
 			"indexARN":   *ret.IndexArn,
 			"indexName":  *ret.IndexName,
+			"dataSource": o.dataSource,
 		}).Info("Created index")
 	}
 	return nil
 }
 
-func runDeletePlaceIndex() error {
-	if _, err := svc.location.DeletePlaceIndex(); err != nil {
+func runDeletePlaceIndex(ctx context.Context, o *indexOptions) error {
+	svc := o.placeService(o.indexName, o.apiKey, placesvc.SetIntendedUse(o.intendedUse), placesvc.SetIndexService(o.dataSource))
+	if _, err := svc.DeletePlaceIndex(ctx); err != nil {
 		log.WithFields(logrus.Fields{
 			"error": err,
 		}).Error("error deleting index")
@@ -68,168 +224,267 @@ func runDeletePlaceIndex() error {
 	return nil
 }
 
-func runDescribeIndex() error {
-	if ret, err := svc.location.DescribePlaceIndex(flags.indexName); err != nil {
+func runDescribeIndex(ctx context.Context, o *indexOptions) error {
+	svc := o.placeService(o.indexName, o.apiKey, placesvc.SetIntendedUse(o.intendedUse), placesvc.SetIndexService(o.dataSource))
+	ret, err := svc.DescribePlaceIndex(ctx, o.indexName)
+	if err != nil {
 		log.WithFields(logrus.Fields{
 			"error": err,
 		}).Error("error describing index")
 		return err
-	} else {
-		if flags.json {
-			if data, err := json.Marshal(ret); err != nil {
-				log.WithFields(logrus.Fields{
-					"error": err,
-				}).Error("error marshalling json")
-				return err
-			} else {
-				fmt.Println(string(data))
-			}
-		} else {
-			fmt.Printf("Index Name:   %s\n", *ret.IndexName)
-			fmt.Printf("Description:  %s\n", *ret.Description)
-			fmt.Printf("Pricing Plan: %s\n", ret.PricingPlan)
-			fmt.Printf("Data Source:  %s\n", *ret.DataSource)
-			fmt.Printf("Data Storage: %s\n", ret.DataSourceConfiguration.IntendedUse.Values())
-			fmt.Printf("Create Time:  %s\n", ret.CreateTime)
-			fmt.Printf("Update Time:  %s\n", ret.UpdateTime)
-			fmt.Printf("Index ARN:    %s\n", *ret.IndexArn)
-			if len(ret.Tags) > 0 {
-				w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
-				fmt.Fprintln(w, "Tags\tValue")
-				for k, v := range ret.Tags {
-					fmt.Fprintf(w, "%s\t%s\n", k, v)
-				}
-				w.Flush()
-			} else {
-				fmt.Println("Tags:        (none)")
-			}
-		}
+	}
 
+	var intendedUse types.IntendedUse
+	if ret.DataSourceConfiguration != nil {
+		intendedUse = ret.DataSourceConfiguration.IntendedUse
 	}
-	return nil
+	return o.writeResult(&output.Result{
+		Data: ret,
+		Record: output.Record{
+			{Name: "Index Name", Value: aws.ToString(ret.IndexName)},
+			{Name: "Description", Value: aws.ToString(ret.Description)},
+			{Name: "Pricing Plan", Value: string(ret.PricingPlan)},
+			{Name: "Data Source", Value: aws.ToString(ret.DataSource)},
+			{Name: "Data Storage", Value: string(intendedUse)},
+			{Name: "Create Time", Value: fmt.Sprint(ret.CreateTime)},
+			{Name: "Update Time", Value: fmt.Sprint(ret.UpdateTime)},
+			{Name: "Index ARN", Value: aws.ToString(ret.IndexArn)},
+			{Name: "Tags", Value: formatTags(ret.Tags)},
+		},
+	})
 }
 
-func runListIndexes() error {
-	if ret, err := svc.location.ListPlaceIndexes(); err != nil {
+func runListIndexes(ctx context.Context, o *indexOptions) error {
+	svc := o.placeService(o.indexName, o.apiKey, placesvc.SetIntendedUse(o.intendedUse), placesvc.SetIndexService(o.dataSource))
+	maxItems := o.maxItems
+	if o.all {
+		maxItems = 0
+	}
+	entries, err := svc.ListPlaceIndexes(ctx, maxItems)
+	if err != nil {
 		log.WithFields(logrus.Fields{
 			"error": err,
 		}).Error("error listing indexes")
 		return err
-	} else {
-		if flags.json {
-			if data, err := json.Marshal(ret); err != nil {
-				log.WithFields(logrus.Fields{
-					"error": err,
-				}).Error("error marshalling json")
-				return err
-			} else {
-				fmt.Println(string(data))
-			}
-		} else {
-			log.WithFields(logrus.Fields{
-				"count": len(ret.Entries),
-			}).Info("Listed indexes")
-			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.Debug)
-			fmt.Fprintln(w, "CTime\tMTime\tIndex\tPricing\tDataSource\tDescription")
-			for _, entry := range ret.Entries {
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", entry.CreateTime, entry.UpdateTime, *entry.IndexName, entry.PricingPlan, *entry.DataSource, *entry.Description)
-			}
-			w.Flush()
-			fmt.Println()
+	}
+	ret := &IndexListResults{Entries: entries}
+	if ret.Entries, err = sortAndLimit(o.sortOptions, ret.Entries, func(e types.ListPlaceIndexesResponseEntry) sortKey {
+		return sortKey{label: e.IndexName}
+	}, sortLabel); err != nil {
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"count": len(ret.Entries),
+	}).Info("Listed indexes")
+
+	rows := &output.Rows{Header: []string{"CTime", "MTime", "Index", "Pricing", "DataSource", "Description"}}
+	for _, entry := range ret.Entries {
+		rows.Rows = append(rows.Rows, []string{fmt.Sprint(entry.CreateTime), fmt.Sprint(entry.UpdateTime), aws.ToString(entry.IndexName), string(entry.PricingPlan), aws.ToString(entry.DataSource), aws.ToString(entry.Description)})
+	}
+	return o.writeResult(&output.Result{Data: ret, Rows: rows})
+}
+
+// placeFeature returns a GeoJSON point feature for a search result, with the
+// label and address fields of the place added to the properties, or nil if the
+// place has no position.
+func placeFeature(place *types.Place, properties map[string]interface{}) *geojson.Feature {
+	if place == nil || place.Geometry == nil || len(place.Geometry.Point) != 2 {
+		return nil
+	}
+	p := placesvc.NewPlace(place)
+	add := func(name string, value string) {
+		if value != "" {
+			properties[name] = value
 		}
 	}
-	return nil
+	add("label", p.Label)
+	add("addressNumber", p.AddressNumber)
+	add("unitType", p.UnitType)
+	add("unitNumber", p.UnitNumber)
+	add("street", p.Street)
+	add("neighborhood", p.Neighborhood)
+	add("subMunicipality", p.SubMunicipality)
+	add("municipality", p.Municipality)
+	add("subRegion", p.SubRegion)
+	add("region", p.Region)
+	add("postalCode", p.PostalCode)
+	add("country", p.Country)
+	if p.TimeZone != nil {
+		add("timeZone", p.TimeZone.Name)
+	}
+	if len(p.Categories) > 0 {
+		properties["categories"] = p.Categories
+	}
+	feature := geojson.NewFeature(geojson.NewPoint(place.Geometry.Point), properties)
+	if id, ok := properties["placeId"].(string); ok && id != "" {
+		feature.ID = id
+	}
+	return feature
 }
 
-func runSearchPosition() error {
-	if ret, err := svc.location.SearchPlaceIndexForPosition(&placesvc.LatLon{Latitude: flags.lat, Longitude: flags.lon}); err != nil {
+// formatFloat formats an optional number, or returns an empty string if it is
+// not set.
+func formatFloat(v *float64, precision int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', precision, 64)
+}
+
+func runSearchPosition(ctx context.Context, o *indexOptions) error {
+	svc := o.placeService(o.indexName, o.apiKey, placesvc.SetIntendedUse(o.intendedUse), placesvc.SetIndexService(o.dataSource))
+	ret, err := svc.SearchPlaceIndexForPosition(ctx, &placesvc.PositionSearch{
+		Position:   o.position,
+		Language:   &o.language,
+		MaxResults: o.maxResults,
+	})
+	if err != nil {
 		log.WithFields(logrus.Fields{
 			"error": err,
 		}).Error("error searching position")
 		return err
-	} else {
-		log.Info("Searched position")
-		if flags.json {
-			if data, err := json.Marshal(&PositionSummaryResults{Summary: ret.Summary, Results: ret.Results}); err != nil {
-				log.WithFields(logrus.Fields{
-					"error": err,
-				}).Error("error marshalling json")
-				return err
-			} else {
-				fmt.Println(string(data))
-			}
-		} else {
-			spew.Dump(ret.Summary)
-			spew.Dump(ret.Results)
+	}
+	log.Info("Searched position")
+	if ret.Results, err = sortAndLimit(o.sortOptions, ret.Results, func(r types.SearchForPositionResult) sortKey {
+		return sortKey{distance: r.Distance, label: r.Place.Label}
+	}, sortDistance, sortLabel); err != nil {
+		return err
+	}
+
+	fc := geojson.NewFeatureCollection()
+	for _, r := range ret.Results {
+		if feature := placeFeature(r.Place, map[string]interface{}{
+			"distance": aws.ToFloat64(r.Distance),
+			"placeId":  aws.ToString(r.PlaceId),
+		}); feature != nil {
+			fc.AddFeature(feature)
 		}
 	}
-	return nil
+	rows := positionRows(ret.Results)
+	return o.writeResult(&output.Result{
+		Data: &PositionSummaryResults{Summary: ret.Summary, Results: ret.Results},
+		Rows: o.searchTable(rows,
+			[]string{"label", "address", "position", "distance", "categories"},
+			[]string{"label", "address", "position", "distance", "categories", "neighborhood", "municipality", "subregion", "region", "postalcode", "country", "timezone", "placeid"},
+		),
+		GeoJSON: fc,
+		Items:   searchItems(rows),
+	})
 }
 
-func runSearchSuggestion() error {
-	fmt.Println(flags.countries)
-	if ret, err := svc.location.SearchPlaceIndexForSuggestions(
+func runSearchSuggestion(ctx context.Context, o *indexOptions) error {
+	svc := o.placeService(o.indexName, o.apiKey, placesvc.SetIntendedUse(o.intendedUse), placesvc.SetIndexService(o.dataSource))
+	if o.interactive {
+		return runSuggestionPick(ctx, o, svc)
+	}
+	ret, err := svc.SearchPlaceIndexForSuggestions(ctx,
 		&placesvc.SuggestionSearch{
-			Text:            &flags.text,
-			BiasPosition:    &placesvc.LatLon{Latitude: flags.lat, Longitude: flags.lon},
-			FilterBBox:      &placesvc.Box{X1: flags.x1, Y1: flags.y1, X2: flags.x2, Y2: flags.y2},
-			FilterCountries: flags.countries,
-		}); err != nil {
+			Text:             &o.text,
+			BiasPosition:     o.biasPosition,
+			FilterBBox:       o.filterBBox,
+			FilterCategories: o.categories,
+			FilterCountries:  o.countries,
+			Language:         &o.language,
+			MaxResults:       o.maxResults,
+		})
+	if err != nil {
 		log.WithFields(logrus.Fields{
 			"error": err,
 		}).Error("error searching suggestion")
 		return err
-	} else {
-		if flags.json {
-			if data, err := json.Marshal(&SuggestionSummaryResults{Summary: ret.Summary, Results: ret.Results}); err != nil {
-				log.WithFields(logrus.Fields{
-					"error": err,
-				}).Error("error marshalling json")
-				return err
-			} else {
-				fmt.Println(string(data))
-			}
-		} else {
-			log.Info("Searched suggestion")
-			spew.Dump(ret.Summary)
-			spew.Dump(ret.Results)
+	}
+	log.Info("Searched suggestion")
+	if ret.Results, err = sortAndLimit(o.sortOptions, ret.Results, func(r types.SearchForSuggestionsResult) sortKey {
+		return sortKey{label: r.Text}
+	}, sortLabel); err != nil {
+		return err
+	}
+
+	fc := geojson.NewFeatureCollection()
+	for _, r := range ret.Results {
+		// Suggestions have no position, so their features have no geometry.
+		// Use "loc place get" with the place ID to look up the position.
+		properties := map[string]interface{}{
+			"label": aws.ToString(r.Text),
+		}
+		if len(r.Categories) > 0 {
+			properties["categories"] = r.Categories
+		}
+		feature := geojson.NewFeature(nil, properties)
+		if r.PlaceId != nil {
+			properties["placeId"] = *r.PlaceId
+			feature.ID = *r.PlaceId
 		}
+		fc.AddFeature(feature)
 	}
-	return nil
+	rows := suggestionRows(ret.Results)
+	return o.writeResult(&output.Result{
+		Data: &SuggestionSummaryResults{Summary: ret.Summary, Results: ret.Results},
+		Rows: o.searchTable(rows,
+			[]string{"label", "categories"},
+			[]string{"label", "categories", "placeid"},
+		),
+		GeoJSON: fc,
+		Items:   searchItems(rows),
+	})
+}
+
+// textGeoJSON converts text search results into a FeatureCollection of
+// points with their place ID and relevance.
+func textGeoJSON(results []types.SearchForTextResult) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, r := range results {
+		if feature := placeFeature(r.Place, map[string]interface{}{
+			"placeId":   aws.ToString(r.PlaceId),
+			"relevance": aws.ToFloat64(r.Relevance),
+		}); feature != nil {
+			fc.AddFeature(feature)
+		}
+	}
+	return fc
 }
 
-func runSearchText() error {
-	if ret, err := svc.location.SearchPlaceIndexForText(&placesvc.SuggestionSearch{
-		Text:            &flags.text,
-		BiasPosition:    &placesvc.LatLon{Latitude: flags.lat, Longitude: flags.lon},
-		FilterBBox:      &placesvc.Box{X1: flags.x1, Y1: flags.y1, X2: flags.x2, Y2: flags.y2},
-		FilterCountries: flags.countries,
-	}); err != nil {
+func runSearchText(ctx context.Context, o *indexOptions) error {
+	svc := o.placeService(o.indexName, o.apiKey, placesvc.SetIntendedUse(o.intendedUse), placesvc.SetIndexService(o.dataSource))
+	ret, err := svc.SearchPlaceIndexForText(ctx, &placesvc.SuggestionSearch{
+		Text:             &o.text,
+		BiasPosition:     o.biasPosition,
+		FilterBBox:       o.filterBBox,
+		FilterCategories: o.categories,
+		FilterCountries:  o.countries,
+		Language:         &o.language,
+		MaxResults:       o.maxResults,
+	})
+	if err != nil {
 		log.WithFields(logrus.Fields{
 			"error": err,
 		}).Error("error searching text")
 		return err
-	} else {
-		if flags.json {
-			if data, err := json.Marshal(&TextSummaryResults{Summary: ret.Summary, Results: ret.Results}); err != nil {
-				log.WithFields(logrus.Fields{
-					"error": err,
-				}).Error("error marshalling json")
-				return err
-			} else {
-				fmt.Println(string(data))
-			}
-		} else {
-			log.Info("Searched text")
-			spew.Dump(ret.Summary)
-			spew.Dump(ret.Results)
-		}
 	}
-	return nil
+	log.Info("Searched text")
+	if ret.Results, err = sortAndLimit(o.sortOptions, ret.Results, func(r types.SearchForTextResult) sortKey {
+		return sortKey{distance: r.Distance, label: r.Place.Label, relevance: r.Relevance}
+	}, sortRelevance, sortLabel, sortDistance); err != nil {
+		return err
+	}
+
+	fc := textGeoJSON(ret.Results)
+	rows := textRows(ret.Results)
+	return o.writeResult(&output.Result{
+		Data: &TextSummaryResults{Summary: ret.Summary, Results: ret.Results},
+		Rows: o.searchTable(rows,
+			[]string{"label", "address", "position", "relevance", "categories"},
+			[]string{"label", "address", "position", "relevance", "distance", "categories", "neighborhood", "municipality", "subregion", "region", "postalcode", "country", "timezone", "placeid"},
+		),
+		GeoJSON: fc,
+		Items:   searchItems(rows),
+	})
 }
 
-func runUpdatePlaceIndex() error {
-	if _, err := svc.location.UpdatePlaceIndex(flags.description); err != nil {
+func runUpdatePlaceIndex(ctx context.Context, o *indexOptions) error {
+	svc := o.placeService(o.indexName, o.apiKey, placesvc.SetIntendedUse(o.intendedUse), placesvc.SetIndexService(o.dataSource))
+	warnStorage(o.intendedUse)
+	if _, err := svc.UpdatePlaceIndex(ctx, o.description); err != nil {
 		log.WithFields(logrus.Fields{
 			"error": err,
 		}).Error("error updating index")
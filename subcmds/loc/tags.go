@@ -0,0 +1,149 @@
+package loc
+
+import (
+	"context"
+	"sort"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// tagsOptions are the flags of the tags commands.
+type tagsOptions struct {
+	*globalOptions
+
+	arn     string
+	tagKeys []string
+	tags    []string
+}
+
+func newTagsCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tags",
+		Short: "manage tags on existing resources",
+	}
+
+	cmd.AddCommand(
+		newTagsAddCmd(g),
+		newTagsListCmd(g),
+		newTagsRemoveCmd(g),
+	)
+	return cmd
+}
+
+func newTagsAddCmd(g *globalOptions) *cobra.Command {
+	o := &tagsOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "add or overwrite tags on a resource",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTagsAdd(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.arn, "arn", "", "", "resource ARN")
+	cmd.Flags().StringSliceVarP(&o.tags, "tags", "", []string{}, "tags to add (key=value)")
+	cmd.MarkFlagRequired("arn")
+	cmd.MarkFlagRequired("tags")
+	return cmd
+}
+
+func newTagsListCmd(g *globalOptions) *cobra.Command {
+	o := &tagsOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the tags of a resource",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTagsList(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.arn, "arn", "", "", "resource ARN")
+	cmd.MarkFlagRequired("arn")
+	return cmd
+}
+
+func newTagsRemoveCmd(g *globalOptions) *cobra.Command {
+	o := &tagsOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "remove",
+		Short: "remove tags from a resource",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTagsRemove(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.arn, "arn", "", "", "resource ARN")
+	cmd.Flags().StringSliceVarP(&o.tagKeys, "keys", "", []string{}, "tag keys to remove")
+	cmd.MarkFlagRequired("arn")
+	cmd.MarkFlagRequired("keys")
+	return cmd
+}
+
+func runTagsAdd(ctx context.Context, o *tagsOptions) error {
+	svc := o.placeService("", "")
+	tags, err := parseTags(o.tags)
+	if err != nil {
+		return err
+	}
+	if _, err := svc.TagResource(ctx, o.arn, tags); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"arn":   o.arn,
+		}).Error("error tagging resource")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"arn":  o.arn,
+		"tags": tags,
+	}).Info("Tagged resource")
+	return nil
+}
+
+func runTagsList(ctx context.Context, o *tagsOptions) error {
+	svc := o.placeService("", "")
+	ret, err := svc.ListTagsForResource(ctx, o.arn)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"arn":   o.arn,
+		}).Error("error listing tags")
+		return err
+	}
+
+	keys := make([]string, 0, len(ret.Tags))
+	for key := range ret.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := &output.Rows{Header: []string{"Key", "Value"}}
+	for _, key := range keys {
+		rows.Rows = append(rows.Rows, []string{key, ret.Tags[key]})
+	}
+	return o.writeResult(&output.Result{Data: ret.Tags, Rows: rows})
+}
+
+func runTagsRemove(ctx context.Context, o *tagsOptions) error {
+	svc := o.placeService("", "")
+	if _, err := svc.UntagResource(ctx, o.arn, o.tagKeys); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"arn":   o.arn,
+		}).Error("error untagging resource")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"arn":  o.arn,
+		"keys": o.tagKeys,
+	}).Info("Removed tags from resource")
+	return nil
+}
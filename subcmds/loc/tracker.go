@@ -0,0 +1,1269 @@
+package loc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/trackersvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/geojson"
+	"github.com/rmrfslashbin/goawsloc/pkg/gpx"
+	"github.com/rmrfslashbin/goawsloc/pkg/output"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/location"
+	"github.com/aws/aws-sdk-go-v2/service/location/types"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// trackerOptions are the flags of the tracker commands.
+type trackerOptions struct {
+	*globalOptions
+	sortOptions
+
+	all               bool
+	before            string
+	collectionArn     string
+	description       string
+	deviceID          string
+	deviceIDs         []string
+	devicesPath       string
+	filePath          string
+	format            string
+	from              string
+	inputPath         string
+	kmsKeyID          string
+	maxItems          int
+	positionFiltering string
+	sample            time.Duration
+	tags              []string
+	to                string
+	trackerName       string
+	yes               bool
+}
+
+// service returns the tracker service for --tracker.
+func (o *trackerOptions) service(opts ...func(*trackersvc.Config)) *trackersvc.Config {
+	return o.trackerService(o.trackerName, opts...)
+}
+
+func newTrackerCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tracker",
+		Short: "tracker resources and device positions",
+	}
+
+	cmd.AddCommand(
+		newTrackerConsumersCmd(g),
+		newTrackerCreateCmd(g),
+		newTrackerDeleteCmd(g),
+		newTrackerDescribeCmd(g),
+		newTrackerGetCmd(g),
+		newTrackerHistoryCmd(g),
+		newTrackerIngestCmd(g),
+		newTrackerLinkCmd(g),
+		newTrackerListCmd(g),
+		newTrackerPositionsCmd(g),
+		newTrackerPurgeCmd(g),
+		newTrackerPushCmd(g),
+		newTrackerUpdateCmd(g),
+		newTrackerVerifyCmd(g),
+	)
+	return cmd
+}
+
+func newTrackerConsumersCmd(g *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "consumers",
+		Short: "geofence collections consuming tracker positions",
+	}
+
+	cmd.AddCommand(
+		newTrackerConsumersListCmd(g),
+		newTrackerConsumersUnlinkCmd(g),
+	)
+	return cmd
+}
+
+func newTrackerConsumersListCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the geofence collections associated with a tracker",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerConsumersList(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.MarkFlagRequired("tracker")
+	return cmd
+}
+
+func newTrackerConsumersUnlinkCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "unlink",
+		Short: "disassociate a geofence collection from a tracker",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerConsumersUnlink(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.collectionArn, "collection-arn", "", "", "geofence collection ARN")
+	cmd.MarkFlagRequired("tracker")
+	cmd.MarkFlagRequired("collection-arn")
+	return cmd
+}
+
+func newTrackerCreateCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a tracker",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerCreate(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "tracker description")
+	cmd.Flags().StringVarP(&o.positionFiltering, "position-filtering", "", "", "[TimeBased|DistanceBased|AccuracyBased] (default TimeBased)")
+	cmd.Flags().StringVarP(&o.kmsKeyID, "kms-key-id", "", "", "customer managed KMS key to encrypt the tracker data with")
+	cmd.Flags().StringSliceVarP(&o.tags, "tags", "", []string{}, "tracker tags (key=value)")
+	cmd.MarkFlagRequired("tracker")
+	return cmd
+}
+
+func newTrackerDeleteCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "delete",
+		Short: "delete a tracker",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerDelete(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.MarkFlagRequired("tracker")
+	return cmd
+}
+
+func newTrackerDescribeCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "describe",
+		Short: "describe a tracker",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerDescribe(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.MarkFlagRequired("tracker")
+	return cmd
+}
+
+func newTrackerGetCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "get the latest position of a device",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerGet(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.deviceID, "device-id", "", "", "device ID")
+	cmd.MarkFlagRequired("tracker")
+	cmd.MarkFlagRequired("device-id")
+	return cmd
+}
+
+func newTrackerHistoryCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "device position history",
+		Long:  "Lists the positions a device reported between --from and --to, oldest first. Use the export subcommand to write the history as GPX or GeoJSON",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.deviceID == "" {
+				return errors.New("--device is required")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerHistory(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.deviceID, "device", "", "", "device ID")
+	cmd.Flags().StringVarP(&o.deviceID, "device-id", "", "", "device ID")
+	cmd.Flags().MarkDeprecated("device-id", "use --device instead")
+	cmd.Flags().StringVarP(&o.from, "from", "", "", "start of the time range (YYYY-MM-DD, RFC3339 or relative like -24h)")
+	cmd.Flags().StringVarP(&o.to, "to", "", "", "end of the time range (YYYY-MM-DD, RFC3339 or relative like -1h)")
+	cmd.Flags().StringVarP(&o.from, "since", "", "", "start of the time range")
+	cmd.Flags().MarkDeprecated("since", "use --from instead")
+	cmd.Flags().StringVarP(&o.to, "until", "", "", "end of the time range")
+	cmd.Flags().MarkDeprecated("until", "use --to instead")
+	cmd.Flags().DurationVarP(&o.sample, "sample", "", 0, "keep at most one position per interval, e.g. 1m")
+	cmd.MarkFlagRequired("tracker")
+
+	cmd.AddCommand(
+		newTrackerHistoryExportCmd(g),
+	)
+	return cmd
+}
+
+func newTrackerHistoryExportCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "export the position history of a device as a track file",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.deviceID == "" {
+				return errors.New("--device is required")
+			}
+			if o.format != "gpx" && o.format != "geojson" {
+				return fmt.Errorf("invalid format %q, must be gpx or geojson", o.format)
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerHistoryExport(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.deviceID, "device", "", "", "device ID")
+	cmd.Flags().StringVarP(&o.deviceID, "device-id", "", "", "device ID")
+	cmd.Flags().MarkDeprecated("device-id", "use --device instead")
+	cmd.Flags().StringVarP(&o.from, "from", "", "", "start of the time range (YYYY-MM-DD, RFC3339 or relative like -24h)")
+	cmd.Flags().StringVarP(&o.to, "to", "", "", "end of the time range (YYYY-MM-DD, RFC3339 or relative like -1h)")
+	cmd.Flags().StringVarP(&o.from, "since", "", "", "start of the time range")
+	cmd.Flags().MarkDeprecated("since", "use --from instead")
+	cmd.Flags().StringVarP(&o.to, "until", "", "", "end of the time range")
+	cmd.Flags().MarkDeprecated("until", "use --to instead")
+	cmd.Flags().DurationVarP(&o.sample, "sample", "", 0, "keep at most one position per interval, e.g. 1m")
+	cmd.Flags().StringVarP(&o.format, "format", "", "geojson", "[gpx|geojson]")
+	cmd.Flags().StringVarP(&o.filePath, "file", "f", "", "output file (default stdout)")
+	cmd.MarkFlagRequired("tracker")
+	return cmd
+}
+
+func newTrackerLinkCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "link",
+		Short: "associate a geofence collection with a tracker",
+		Long:  "Associates a geofence collection with a tracker so the position updates of the tracker are evaluated against its geofences. Use consumers unlink to remove the association",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerLink(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.collectionArn, "collection-arn", "", "", "geofence collection ARN")
+	cmd.MarkFlagRequired("tracker")
+	cmd.MarkFlagRequired("collection-arn")
+	return cmd
+}
+
+func newTrackerListCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list trackers",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.all && cmd.Flags().Changed("max-items") {
+				return errors.New("--all and --max-items are mutually exclusive")
+			}
+			if o.maxItems < 1 {
+				return errors.New("--max-items must be at least 1")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerList(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.all, "all", "", false, "list every tracker, following all result pages")
+	cmd.Flags().IntVarP(&o.maxItems, "max-items", "", 100, "maximum number of trackers to fetch")
+	cmd.Flags().StringVarP(&o.sort, "sort", "", "", "sort results by [label]")
+	cmd.Flags().IntVarP(&o.limit, "limit", "", 0, "maximum number of results to output")
+	return cmd
+}
+
+func newTrackerPositionsCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "positions",
+		Short: "get the latest position of many devices",
+		Long:  "Gets the latest position of the devices listed in --devices, or of every device of the tracker if --devices is not given",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerPositions(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.devicesPath, "devices", "", "", "file with one device ID per line (default all devices)")
+	cmd.MarkFlagRequired("tracker")
+	return cmd
+}
+
+func newTrackerPurgeCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "delete the position history of devices",
+		Long:  "Deletes the entire position history of the given devices. With --before, only devices whose latest position has a sample time, the time the device recorded it rather than the time AWS received it, strictly before the cutoff are purged; devices without a reported position are kept",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if o.devicesPath == "" && len(o.deviceIDs) == 0 {
+				return errors.New("--device-id or --devices is required")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerPurge(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringSliceVarP(&o.deviceIDs, "device-id", "", []string{}, "one or more device IDs")
+	cmd.Flags().StringVarP(&o.devicesPath, "devices", "", "", "file with one device ID per line")
+	cmd.Flags().StringVarP(&o.before, "before", "", "", "only purge devices whose latest sample time is strictly before this time (YYYY-MM-DD, RFC3339 or relative like -30d)")
+	cmd.Flags().BoolVarP(&o.yes, "yes", "y", false, "do not ask for confirmation")
+	cmd.MarkFlagRequired("tracker")
+	return cmd
+}
+
+func newTrackerPushCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "push",
+		Short: "send device position updates to a tracker",
+		Long:  "Sends the device positions of a JSON Lines or CSV file to a tracker. JSON Lines objects have deviceId, lat, lon and optional sampleTime, accuracy and properties fields. CSV rows are device,lat,lon[,sampleTime[,accuracy]], with an optional header row. Files ending in .csv are read as CSV. A missing sample time means now",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerPush(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.inputPath, "input", "i", "", "JSON Lines or CSV file of device positions")
+	cmd.MarkFlagRequired("tracker")
+	cmd.MarkFlagRequired("input")
+	return cmd
+}
+
+func newTrackerUpdateCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "update a tracker",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerUpdate(cmd.Context(), cmd, o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.description, "description", "", "", "tracker description (default unchanged)")
+	cmd.Flags().StringVarP(&o.positionFiltering, "position-filtering", "", "", "[TimeBased|DistanceBased|AccuracyBased] (default unchanged)")
+	cmd.MarkFlagRequired("tracker")
+	return cmd
+}
+
+func newTrackerVerifyCmd(g *globalOptions) *cobra.Command {
+	o := &trackerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "verify recorded device positions",
+		Long:  "Checks the positions reported by devices against the cellular, Wi-Fi and IP signals recorded with them, to detect spoofed GPS positions. The input holds one or more device states as JSON objects or a JSON array, in the shape of the DeviceState of the VerifyDevicePosition API: DeviceId, Position [lon, lat], SampleTime and optional Accuracy, CellSignals, Ipv4Address and WiFiAccessPoints. Deviations are reported in the unit selected with --units",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTrackerVerify(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker name")
+	cmd.Flags().StringVarP(&o.inputPath, "input", "i", "", "JSON file of recorded device states")
+	cmd.MarkFlagRequired("tracker")
+	cmd.MarkFlagRequired("input")
+	return cmd
+}
+
+// parseTime parses a time given as YYYY-MM-DD, RFC3339, "now" or a duration
+// relative to now such as "-24h" or "-7d".
+func parseTime(value string) (time.Time, error) {
+	if value == "now" {
+		return time.Now(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if d, err := parseDuration(value); err == nil {
+		return time.Now().Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, must be YYYY-MM-DD, RFC3339, now or a relative duration like -24h", value)
+}
+
+// parseDuration extends time.ParseDuration with a "d" unit for whole days.
+func parseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// confirm asks the user a yes/no question on stdin, defaulting to no.
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// parseOptionalTime parses a time flag, returning nil if the flag is empty.
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := parseTime(value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// readIDs reads IDs from a file, one per line.
+// Blank lines and lines starting with # are skipped.
+func readIDs(filename string) ([]string, error) {
+	fh, err := os.Open(path.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ids = append(ids, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// positionUpdate is a line of a JSON Lines position file.
+type positionUpdate struct {
+	DeviceID   string            `json:"deviceId"`
+	Lat        *float64          `json:"lat"`
+	Lon        *float64          `json:"lon"`
+	SampleTime string            `json:"sampleTime"`
+	Accuracy   *float64          `json:"accuracy"`
+	Properties map[string]string `json:"properties"`
+}
+
+// devicePosition validates the fields of a position file entry and converts
+// them into a position update.
+func devicePosition(deviceID string, lat float64, lon float64, sampleTime string, accuracy *float64) (trackersvc.DevicePosition, error) {
+	if deviceID == "" {
+		return trackersvc.DevicePosition{}, errors.New("device ID missing")
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return trackersvc.DevicePosition{}, fmt.Errorf("position %f,%f out of range", lat, lon)
+	}
+	when := time.Now()
+	if sampleTime != "" {
+		t, err := parseTime(sampleTime)
+		if err != nil {
+			return trackersvc.DevicePosition{}, err
+		}
+		when = t
+	}
+	return trackersvc.DevicePosition{
+		DeviceID:   deviceID,
+		Latitude:   lat,
+		Longitude:  lon,
+		SampleTime: when,
+		Accuracy:   accuracy,
+	}, nil
+}
+
+// readPositionUpdates reads device positions from a JSON Lines file, or from
+// a CSV file if the name ends in .csv.
+func readPositionUpdates(filename string) ([]trackersvc.DevicePosition, error) {
+	fh, err := os.Open(path.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var updates []trackersvc.DevicePosition
+	if strings.EqualFold(path.Ext(filename), ".csv") {
+		r := csv.NewReader(fh)
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		for i, record := range records {
+			if len(record) < 3 || len(record) > 5 {
+				return nil, fmt.Errorf("%s: row %d has %d columns, must be device,lat,lon[,sampleTime[,accuracy]]", filename, i+1, len(record))
+			}
+			lat, latErr := strconv.ParseFloat(record[1], 64)
+			lon, lonErr := strconv.ParseFloat(record[2], 64)
+			if latErr != nil || lonErr != nil {
+				if i == 0 {
+					// header row
+					continue
+				}
+				return nil, fmt.Errorf("%s: row %d: invalid position %s,%s", filename, i+1, record[1], record[2])
+			}
+			var sampleTime string
+			if len(record) > 3 {
+				sampleTime = record[3]
+			}
+			var accuracy *float64
+			if len(record) > 4 && record[4] != "" {
+				a, err := strconv.ParseFloat(record[4], 64)
+				if err != nil {
+					return nil, fmt.Errorf("%s: row %d: invalid accuracy %q", filename, i+1, record[4])
+				}
+				accuracy = &a
+			}
+			update, err := devicePosition(record[0], lat, lon, sampleTime, accuracy)
+			if err != nil {
+				return nil, fmt.Errorf("%s: row %d: %w", filename, i+1, err)
+			}
+			updates = append(updates, update)
+		}
+		return updates, nil
+	}
+
+	scanner := bufio.NewScanner(fh)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var entry positionUpdate
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("%s: line %d: %w", filename, line, err)
+		}
+		if entry.Lat == nil || entry.Lon == nil {
+			return nil, fmt.Errorf("%s: line %d: lat and lon are required", filename, line)
+		}
+		update, err := devicePosition(entry.DeviceID, *entry.Lat, *entry.Lon, entry.SampleTime, entry.Accuracy)
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %w", filename, line, err)
+		}
+		update.Properties = entry.Properties
+		updates = append(updates, update)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// decodePositions decodes device positions given as JSON objects in the
+// format of the JSON Lines files of tracker push, separated by newlines or,
+// as Firehose concatenates records, not at all. Invalid objects are skipped
+// and reported in the error.
+func decodePositions(data []byte) ([]trackersvc.DevicePosition, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var updates []trackersvc.DevicePosition
+	var errs []error
+	for i := 1; ; i++ {
+		var entry positionUpdate
+		if err := dec.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			// The rest of the data cannot be decoded after a syntax error.
+			errs = append(errs, fmt.Errorf("object %d: %w", i, err))
+			break
+		}
+		if entry.Lat == nil || entry.Lon == nil {
+			errs = append(errs, fmt.Errorf("object %d: lat and lon are required", i))
+			continue
+		}
+		update, err := devicePosition(entry.DeviceID, *entry.Lat, *entry.Lon, entry.SampleTime, entry.Accuracy)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("object %d: %w", i, err))
+			continue
+		}
+		update.Properties = entry.Properties
+		updates = append(updates, update)
+	}
+	return updates, errors.Join(errs...)
+}
+
+func runTrackerPush(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	updates, err := readPositionUpdates(o.inputPath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.inputPath,
+		}).Error("error reading positions file")
+		return err
+	}
+
+	ret, err := svc.UpdatePositions(ctx, o.trackerName, updates)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error updating device positions")
+		return err
+	}
+	for _, e := range ret.Errors {
+		fields := logrus.Fields{
+			"deviceId":   aws.ToString(e.DeviceId),
+			"sampleTime": e.SampleTime,
+		}
+		if e.Error != nil {
+			fields["code"] = e.Error.Code
+			fields["message"] = aws.ToString(e.Error.Message)
+		}
+		log.WithFields(fields).Warn("unable to update device position")
+	}
+	log.WithFields(logrus.Fields{
+		"count":  len(updates) - len(ret.Errors),
+		"errors": len(ret.Errors),
+	}).Info("Updated device positions")
+	return nil
+}
+
+// writeDevicePositions prints device positions as JSON or as a table.
+func (o *trackerOptions) writeDevicePositions(positions []types.DevicePosition) error {
+	rows := &output.Rows{Header: []string{"Device", "SampleTime", "ReceivedTime", "Latitude", "Longitude", "Accuracy"}}
+	for _, pos := range positions {
+		accuracy := ""
+		if pos.Accuracy != nil && pos.Accuracy.Horizontal != nil {
+			accuracy = fmt.Sprintf("%.1f", *pos.Accuracy.Horizontal)
+		}
+		receivedTime := ""
+		if pos.ReceivedTime != nil {
+			receivedTime = pos.ReceivedTime.String()
+		}
+		rows.Rows = append(rows.Rows, []string{aws.ToString(pos.DeviceId), fmt.Sprint(pos.SampleTime), receivedTime, fmt.Sprintf("%f", pos.Position[1]), fmt.Sprintf("%f", pos.Position[0]), accuracy})
+	}
+	return o.writeResult(&output.Result{Data: positions, Rows: rows, GeoJSON: devicePositionsGeoJSON(positions)})
+}
+
+// listedPositions converts the entries of ListDevicePositions into device
+// positions.
+func listedPositions(entries []types.ListDevicePositionsResponseEntry) []types.DevicePosition {
+	positions := make([]types.DevicePosition, 0, len(entries))
+	for _, entry := range entries {
+		positions = append(positions, types.DevicePosition{
+			Accuracy:           entry.Accuracy,
+			DeviceId:           entry.DeviceId,
+			Position:           entry.Position,
+			PositionProperties: entry.PositionProperties,
+			SampleTime:         entry.SampleTime,
+		})
+	}
+	return positions
+}
+
+// devicePositionsGeoJSON converts device positions into a FeatureCollection
+// of points with the device ID, sample time, accuracy and position
+// properties.
+func devicePositionsGeoJSON(positions []types.DevicePosition) *geojson.FeatureCollection {
+	fc := geojson.NewFeatureCollection()
+	for _, pos := range positions {
+		properties := map[string]interface{}{
+			"deviceId":   aws.ToString(pos.DeviceId),
+			"sampleTime": pos.SampleTime,
+		}
+		if pos.Accuracy != nil && pos.Accuracy.Horizontal != nil {
+			properties["accuracy"] = *pos.Accuracy.Horizontal
+		}
+		for k, v := range pos.PositionProperties {
+			properties[k] = v
+		}
+		fc.AddFeature(geojson.NewFeature(geojson.NewPoint(pos.Position), properties))
+	}
+	return fc
+}
+
+func runTrackerGet(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	ret, err := svc.GetDevicePosition(ctx, o.deviceID)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error":    err,
+			"deviceId": o.deviceID,
+		}).Error("error getting device position")
+		return err
+	}
+	return o.writeDevicePositions([]types.DevicePosition{{
+		Accuracy:           ret.Accuracy,
+		DeviceId:           ret.DeviceId,
+		Position:           ret.Position,
+		PositionProperties: ret.PositionProperties,
+		ReceivedTime:       ret.ReceivedTime,
+		SampleTime:         ret.SampleTime,
+	}})
+}
+
+func runTrackerPositions(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	if o.devicesPath == "" {
+		entries, err := svc.ListDevicePositions(ctx)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error listing device positions")
+			return err
+		}
+		positions := listedPositions(entries)
+		log.WithFields(logrus.Fields{
+			"count": len(positions),
+		}).Info("Listed device positions")
+		return o.writeDevicePositions(positions)
+	}
+
+	deviceIDs, err := readIDs(o.devicesPath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.devicesPath,
+		}).Error("error reading devices file")
+		return err
+	}
+
+	ret, err := svc.BatchGetDevicePositions(ctx, deviceIDs)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error getting device positions")
+		return err
+	}
+	for _, e := range ret.Errors {
+		fields := logrus.Fields{
+			"deviceId": aws.ToString(e.DeviceId),
+		}
+		if e.Error != nil {
+			fields["code"] = e.Error.Code
+			fields["message"] = aws.ToString(e.Error.Message)
+		}
+		log.WithFields(fields).Warn("unable to get device position")
+	}
+	log.WithFields(logrus.Fields{
+		"count":  len(ret.DevicePositions),
+		"errors": len(ret.Errors),
+	}).Info("Got device positions")
+	return o.writeDevicePositions(ret.DevicePositions)
+}
+
+func runTrackerPurge(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	deviceIDs := append([]string{}, o.deviceIDs...)
+	if o.devicesPath != "" {
+		ids, err := readIDs(o.devicesPath)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+				"path":  o.devicesPath,
+			}).Error("error reading devices file")
+			return err
+		}
+		deviceIDs = append(deviceIDs, ids...)
+	}
+
+	if o.before != "" {
+		before, err := parseTime(o.before)
+		if err != nil {
+			return err
+		}
+
+		// The API always deletes the whole history, so select the devices
+		// which have not reported a position since the cutoff.
+		ret, err := svc.BatchGetDevicePositions(ctx, deviceIDs)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("error getting device positions")
+			return err
+		}
+		deviceIDs = deviceIDs[:0]
+		for _, pos := range ret.DevicePositions {
+			if pos.SampleTime != nil && pos.SampleTime.Before(before) {
+				deviceIDs = append(deviceIDs, aws.ToString(pos.DeviceId))
+			}
+		}
+	}
+
+	if len(deviceIDs) == 0 {
+		log.Info("No devices to purge")
+		return nil
+	}
+
+	if !o.yes && !confirm(fmt.Sprintf("Delete the position history of %d device(s) from tracker %s?", len(deviceIDs), o.trackerName)) {
+		log.Info("Purge cancelled")
+		return nil
+	}
+
+	if ret, err := svc.BatchDeleteDevicePositionHistory(ctx, deviceIDs); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error purging device position history")
+		return err
+	} else {
+		for _, e := range ret.Errors {
+			fields := logrus.Fields{
+				"deviceId": aws.ToString(e.DeviceId),
+			}
+			if e.Error != nil {
+				fields["code"] = e.Error.Code
+				fields["message"] = aws.ToString(e.Error.Message)
+			}
+			log.WithFields(fields).Warn("unable to purge device position history")
+		}
+		log.WithFields(logrus.Fields{
+			"count":  len(deviceIDs) - len(ret.Errors),
+			"errors": len(ret.Errors),
+		}).Info("Purged device position history")
+	}
+	return nil
+}
+
+// historyGeoJSON converts a position history into a FeatureCollection holding
+// the track as a LineString, with the sample times in the coordTimes property.
+func historyGeoJSON(deviceID string, positions []types.DevicePosition) *geojson.FeatureCollection {
+	coordinates := make([][]float64, 0, len(positions))
+	times := make([]string, 0, len(positions))
+	for _, pos := range positions {
+		coordinates = append(coordinates, pos.Position)
+		times = append(times, pos.SampleTime.Format(time.RFC3339))
+	}
+
+	properties := map[string]interface{}{
+		"deviceId":   deviceID,
+		"coordTimes": times,
+	}
+
+	fc := geojson.NewFeatureCollection()
+	if len(coordinates) == 1 {
+		// A LineString needs at least two positions.
+		fc.AddFeature(geojson.NewFeature(geojson.NewPoint(coordinates[0]), properties))
+	} else if len(coordinates) > 1 {
+		fc.AddFeature(geojson.NewFeature(geojson.NewLineString(coordinates), properties))
+	}
+	return fc
+}
+
+// historyGPX converts a position history into a GPX document with a single track.
+func historyGPX(deviceID string, positions []types.DevicePosition) *gpx.GPX {
+	segment := &gpx.TrackSegment{}
+	for _, pos := range positions {
+		segment.Points = append(segment.Points, &gpx.TrackPoint{
+			Lat:  pos.Position[1],
+			Lon:  pos.Position[0],
+			Time: pos.SampleTime,
+		})
+	}
+
+	doc := gpx.New("goawsloc")
+	doc.AddTrack(&gpx.Track{
+		Name:     deviceID,
+		Segments: []*gpx.TrackSegment{segment},
+	})
+	return doc
+}
+
+func runTrackerConsumersList(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	ret, err := svc.ListTrackerConsumers(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error listing tracker consumers")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"count": len(ret),
+	}).Info("Listed tracker consumers")
+
+	rows := &output.Rows{Header: []string{"Consumer"}}
+	for _, arn := range ret {
+		rows.Rows = append(rows.Rows, []string{arn})
+	}
+	return o.writeResult(&output.Result{Data: ret, Rows: rows})
+}
+
+func runTrackerLink(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	if _, err := svc.AssociateTrackerConsumer(ctx, o.collectionArn); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error associating tracker consumer")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"collectionArn": o.collectionArn,
+		"tracker":       o.trackerName,
+	}).Info("Associated tracker consumer")
+	return nil
+}
+
+func runTrackerConsumersUnlink(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	if _, err := svc.DisassociateTrackerConsumer(ctx, o.collectionArn); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error disassociating tracker consumer")
+		return err
+	} else {
+		log.WithFields(logrus.Fields{
+			"collectionArn": o.collectionArn,
+			"tracker":       o.trackerName,
+		}).Info("Disassociated tracker consumer")
+	}
+	return nil
+}
+
+func runTrackerHistory(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	from, err := parseOptionalTime(o.from)
+	if err != nil {
+		return err
+	}
+	to, err := parseOptionalTime(o.to)
+	if err != nil {
+		return err
+	}
+
+	positions, err := svc.GetDevicePositionHistory(ctx, o.deviceID, from, to)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error getting device position history")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"count":    len(positions),
+		"deviceId": o.deviceID,
+	}).Info("Got device position history")
+
+	// Downsample also sorts by sample time, an interval of 0 keeps every position.
+	positions = trackersvc.Downsample(positions, o.sample)
+	return o.writeDevicePositions(positions)
+}
+
+func runTrackerHistoryExport(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	from, err := parseOptionalTime(o.from)
+	if err != nil {
+		return err
+	}
+	to, err := parseOptionalTime(o.to)
+	if err != nil {
+		return err
+	}
+
+	positions, err := svc.GetDevicePositionHistory(ctx, o.deviceID, from, to)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error getting device position history")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"count":    len(positions),
+		"deviceId": o.deviceID,
+	}).Info("Got device position history")
+
+	if o.sample > 0 {
+		positions = trackersvc.Downsample(positions, o.sample)
+		log.WithFields(logrus.Fields{
+			"count":    len(positions),
+			"interval": o.sample,
+		}).Debug("Downsampled device position history")
+	}
+
+	w, err := openOutput(o.filePath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.filePath,
+		}).Error("error opening output file")
+		return err
+	}
+	defer w.Close()
+
+	switch o.format {
+	case "gpx":
+		err = historyGPX(o.deviceID, positions).Write(w)
+	case "geojson":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(historyGeoJSON(o.deviceID, positions))
+	}
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error writing track file")
+		return err
+	}
+	return nil
+}
+
+func runTrackerCreate(ctx context.Context, o *trackerOptions) error {
+	svc := o.service(trackersvc.SetPositionFiltering(o.positionFiltering), trackersvc.SetKMSKeyID(o.kmsKeyID))
+	tags, err := parseTags(o.tags)
+	if err != nil {
+		return err
+	}
+	if ret, err := svc.CreateTracker(ctx, o.description, &tags); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error creating tracker")
+		return err
+	} else {
+		log.WithFields(logrus.Fields{
+			"createTime":  ret.CreateTime,
+			"trackerARN":  *ret.TrackerArn,
+			"trackerName": *ret.TrackerName,
+		}).Info("Created tracker")
+	}
+	return nil
+}
+
+func runTrackerDelete(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	if _, err := svc.DeleteTracker(ctx); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error deleting tracker")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"trackerName": o.trackerName,
+	}).Info("Deleted tracker")
+	return nil
+}
+
+func runTrackerDescribe(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	ret, err := svc.DescribeTracker(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error describing tracker")
+		return err
+	}
+
+	return o.writeResult(&output.Result{
+		Data: ret,
+		Record: output.Record{
+			{Name: "Tracker Name", Value: aws.ToString(ret.TrackerName)},
+			{Name: "Description", Value: aws.ToString(ret.Description)},
+			{Name: "Position Filtering", Value: string(ret.PositionFiltering)},
+			{Name: "KMS Key ID", Value: aws.ToString(ret.KmsKeyId)},
+			{Name: "EventBridge", Value: fmt.Sprint(aws.ToBool(ret.EventBridgeEnabled))},
+			{Name: "Create Time", Value: fmt.Sprint(ret.CreateTime)},
+			{Name: "Update Time", Value: fmt.Sprint(ret.UpdateTime)},
+			{Name: "Tracker ARN", Value: aws.ToString(ret.TrackerArn)},
+			{Name: "Tags", Value: formatTags(ret.Tags)},
+		},
+	})
+}
+
+func runTrackerList(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	maxItems := o.maxItems
+	if o.all {
+		maxItems = 0
+	}
+	entries, err := svc.ListTrackers(ctx, maxItems)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error listing trackers")
+		return err
+	}
+	if entries, err = sortAndLimit(o.sortOptions, entries, func(e types.ListTrackersResponseEntry) sortKey {
+		return sortKey{label: e.TrackerName}
+	}, sortLabel); err != nil {
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"count": len(entries),
+	}).Info("Listed trackers")
+
+	rows := &output.Rows{Header: []string{"CTime", "MTime", "Tracker", "Description"}}
+	for _, entry := range entries {
+		rows.Rows = append(rows.Rows, []string{fmt.Sprint(entry.CreateTime), fmt.Sprint(entry.UpdateTime), aws.ToString(entry.TrackerName), aws.ToString(entry.Description)})
+	}
+	return o.writeResult(&output.Result{Data: entries, Rows: rows})
+}
+
+func runTrackerUpdate(ctx context.Context, cmd *cobra.Command, o *trackerOptions) error {
+	svc := o.service(trackersvc.SetPositionFiltering(o.positionFiltering))
+	// Only send the description if given, so it is not cleared otherwise.
+	var description *string
+	if cmd.Flags().Changed("description") {
+		description = &o.description
+	}
+	if _, err := svc.UpdateTracker(ctx, description); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error updating tracker")
+		return err
+	}
+	log.WithFields(logrus.Fields{
+		"trackerName": o.trackerName,
+	}).Info("Updated tracker")
+	return nil
+}
+
+// readDeviceStates reads recorded device states from a file holding a JSON
+// array or a sequence of JSON objects, such as JSON Lines.
+func readDeviceStates(filename string) ([]types.DeviceState, error) {
+	data, err := os.ReadFile(path.Clean(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	var states []types.DeviceState
+	if data = bytes.TrimSpace(data); bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &states); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for dec.More() {
+			var state types.DeviceState
+			if err := dec.Decode(&state); err != nil {
+				return nil, fmt.Errorf("%s: device state %d: %w", filename, len(states)+1, err)
+			}
+			states = append(states, state)
+		}
+	}
+	if len(states) == 0 {
+		return nil, fmt.Errorf("%s: no device states", filename)
+	}
+	return states, nil
+}
+
+func runTrackerVerify(ctx context.Context, o *trackerOptions) error {
+	svc := o.service()
+	states, err := readDeviceStates(o.inputPath)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  o.inputPath,
+		}).Error("error reading device states file")
+		return err
+	}
+
+	results := make([]*location.VerifyDevicePositionOutput, 0, len(states))
+	for i := range states {
+		ret, err := svc.VerifyDevicePosition(ctx, &states[i], types.DistanceUnit(o.distanceUnit()))
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error":    err,
+				"deviceId": aws.ToString(states[i].DeviceId),
+			}).Error("error verifying device position")
+			return err
+		}
+		results = append(results, ret)
+	}
+
+	rows := &output.Rows{Header: []string{"Device", "SampleTime", "Reported", "Inferred", "Deviation (" + o.distanceUnit() + ")", "Accuracy", "Proxy"}}
+	fc := geojson.NewFeatureCollection()
+	for i, ret := range results {
+		properties := map[string]interface{}{
+			"deviceId":   aws.ToString(ret.DeviceId),
+			"sampleTime": ret.SampleTime,
+		}
+		inferred, deviation, accuracy, proxy := "", "", "", ""
+		if s := ret.InferredState; s != nil {
+			if len(s.Position) == 2 {
+				inferred = fmt.Sprintf("%f,%f", s.Position[1], s.Position[0])
+				properties["inferredPosition"] = s.Position
+			}
+			if s.DeviationDistance != nil {
+				deviation = fmt.Sprintf("%.3f", *s.DeviationDistance)
+				properties["deviationDistance"] = *s.DeviationDistance
+			}
+			if s.Accuracy != nil && s.Accuracy.Horizontal != nil {
+				accuracy = fmt.Sprintf("%.1f", *s.Accuracy.Horizontal)
+				properties["accuracy"] = *s.Accuracy.Horizontal
+			}
+			if s.ProxyDetected != nil {
+				proxy = strconv.FormatBool(*s.ProxyDetected)
+				properties["proxyDetected"] = *s.ProxyDetected
+			}
+		}
+		reported := fmt.Sprintf("%f,%f", states[i].Position[1], states[i].Position[0])
+		rows.Rows = append(rows.Rows, []string{aws.ToString(ret.DeviceId), fmt.Sprint(ret.SampleTime), reported, inferred, deviation, accuracy, proxy})
+		fc.AddFeature(geojson.NewFeature(geojson.NewPoint(states[i].Position), properties))
+	}
+	return o.writeResult(&output.Result{Data: results, Rows: rows, GeoJSON: fc})
+}
@@ -0,0 +1,149 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/tui"
+
+	"github.com/mattn/go-isatty"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func newTUICmd(g *globalOptions) *cobra.Command {
+	o := &indexOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "browse search results interactively",
+		Long:  "Opens an interactive search of a place index in the terminal. Suggestions for the query are listed while typing; Enter on the query runs a text search instead. Arrow through the list and press Enter to view the full details of a place, then c to copy its coordinates or p to copy its place ID. Esc goes back, Ctrl+C quits. Copying uses the system clipboard, or the OSC 52 escape sequence of the terminal where there is none, such as over SSH",
+		Example: `  loc tui --index my-index
+  loc tui --index my-index --country USA --bias -122.33,47.61`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkTerminal(os.Stdout); err != nil {
+				return err
+			}
+			if o.debounce < 0 {
+				return errors.New("--debounce must not be negative")
+			}
+			if err := parseCountries(o.countries); err != nil {
+				return err
+			}
+			return o.parseSearchArea()
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runTUI(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the searches with instead of IAM credentials")
+	cmd.Flags().StringSliceVarP(&o.categories, "category", "", []string{}, "one or more place categories to limit the searches to, such as HotelMotel or GasStation")
+	cmd.Flags().StringSliceVarP(&o.countries, "country", "", []string{}, "one or more countries to limit the searches to, as ISO 3166 code or name")
+	cmd.Flags().StringVarP(&o.bias, "bias", "", "", "position to bias results towards, as \"lon,lat\"")
+	cmd.Flags().StringVarP(&o.bbox, "bbox", "", "", "bounding box to limit results to, as \"west,south,east,north\"")
+	cmd.Flags().StringVarP(&o.language, "language", "", "", "language of the results, e.g. fr (defaults to en)")
+	cmd.Flags().Int32VarP(&o.maxResults, "max-results", "", 0, "maximum number of suggestions and results to request from AWS [1-15]")
+	cmd.Flags().DurationVarP(&o.debounce, "debounce", "", 300*time.Millisecond, "how long typing must pause before suggestions are searched")
+	cmd.MarkFlagRequired("index")
+	return cmd
+}
+
+// checkTerminal returns an error unless stdin and out are terminals, which
+// the interactive commands are drawn on.
+func checkTerminal(out *os.File) error {
+	if !isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(out.Fd()) {
+		return errors.New("an interactive terminal is required")
+	}
+	return nil
+}
+
+// browserSearch sets the filters, bias and language flags on the searches of
+// the browser.
+func (o *indexOptions) browserSearch() tui.Option {
+	return tui.SetSearch(placesvc.SuggestionSearch{
+		BiasPosition:     o.biasPosition,
+		FilterBBox:       o.filterBBox,
+		FilterCategories: o.categories,
+		FilterCountries:  o.countries,
+		Language:         &o.language,
+		MaxResults:       o.maxResults,
+	})
+}
+
+func runTUI(ctx context.Context, o *indexOptions) error {
+	browser, err := tui.New(o.placeService(o.indexName, o.apiKey),
+		tui.SetTitle("Search "+o.indexName),
+		o.browserSearch(),
+		tui.SetDebounce(o.debounce),
+		tui.SetFields(placeFields),
+	)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error creating browser")
+		return err
+	}
+
+	// Log lines would scramble the screen, so they are dropped while the
+	// browser runs; errors of searches are shown in it instead.
+	out := log.Out
+	log.SetOutput(io.Discard)
+	err = browser.Run(ctx)
+	log.SetOutput(out)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error running browser")
+		return err
+	}
+	return nil
+}
+
+// runSuggestionPick lets the user choose a suggestion while typing and writes
+// the details of its place. The list is drawn on stderr, so stdout only
+// carries the result and can be piped.
+func runSuggestionPick(ctx context.Context, o *indexOptions, svc placesvc.PlaceIndexer) error {
+	browser, err := tui.New(svc,
+		tui.SetTitle("Suggestions from "+o.indexName),
+		tui.SetQuery(o.text),
+		tui.SetOutput(os.Stderr),
+		o.browserSearch(),
+		tui.SetDebounce(o.debounce),
+	)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error creating browser")
+		return err
+	}
+
+	out := log.Out
+	log.SetOutput(io.Discard)
+	suggestion, err := browser.Pick(ctx)
+	log.SetOutput(out)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error running browser")
+		return err
+	}
+	if suggestion == nil {
+		return nil
+	}
+	if suggestion.PlaceID == "" {
+		err := errors.New("the suggestion has no place ID")
+		log.WithFields(logrus.Fields{
+			"error":      err,
+			"suggestion": suggestion.Text,
+		}).Error("error getting place")
+		return err
+	}
+	return o.writePlace(ctx, svc, suggestion.PlaceID, &o.language)
+}
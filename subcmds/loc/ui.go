@@ -0,0 +1,151 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/placesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/routesvc"
+	"github.com/rmrfslashbin/goawsloc/pkg/geojson"
+	"github.com/rmrfslashbin/goawsloc/pkg/ui"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// uiOptions are the flags of the ui command.
+type uiOptions struct {
+	*globalOptions
+
+	addr           string
+	apiKey         string
+	calculatorName string
+	collectionName string
+	indexName      string
+	mapName        string
+	trackerName    string
+}
+
+func newUICmd(g *globalOptions) *cobra.Command {
+	o := &uiOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "ui",
+		Short: "show results on a map in the browser",
+		Long:  "Starts a local web server with a MapLibre page drawing a map resource, to check results visually. The map style, tiles, sprites and glyphs are fetched through the server, so the page needs no credentials. With --index, places found by a text search are shown; with --calculator, the route between two positions; with --collection, the geofences of the collection; and with --tracker, the latest positions of its devices, optionally refreshed every few seconds. Click a feature to see its properties. The page loads MapLibre GL JS from unpkg.com",
+		Example: `  loc ui --map my-map --index my-index
+  loc ui --map my-map --collection my-collection --tracker my-tracker --listen localhost:9000`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runUI(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.mapName, "map", "", "", "map name")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the map and searches with instead of IAM credentials")
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "place index to search")
+	cmd.Flags().StringVarP(&o.calculatorName, "calculator", "", "", "route calculator to calculate routes with")
+	cmd.Flags().StringVarP(&o.collectionName, "collection", "", "", "geofence collection to show")
+	cmd.Flags().StringVarP(&o.trackerName, "tracker", "", "", "tracker whose device positions to show")
+	cmd.Flags().StringVarP(&o.addr, "listen", "", "localhost:8080", "address to listen on")
+	cmd.MarkFlagRequired("map")
+	return cmd
+}
+
+func runUI(ctx context.Context, o *uiOptions) error {
+	opts := []func(*ui.UI){ui.SetLogger(log)}
+	if o.indexName != "" {
+		opts = append(opts, ui.SetLayer(ui.LayerSearch, o.searchLayer))
+	}
+	if o.calculatorName != "" {
+		opts = append(opts, ui.SetLayer(ui.LayerRoute, o.routeLayer))
+	}
+	if o.collectionName != "" {
+		opts = append(opts, ui.SetLayer(ui.LayerGeofences, o.geofencesLayer))
+	}
+	if o.trackerName != "" {
+		opts = append(opts, ui.SetLayer(ui.LayerPositions, o.positionsLayer))
+	}
+	u, err := ui.New(o.mapService(o.mapName, o.apiKey), opts...)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error creating UI")
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"url":        "http://" + o.addr,
+		"map":        o.mapName,
+		"index":      o.indexName,
+		"calculator": o.calculatorName,
+		"collection": o.collectionName,
+		"tracker":    o.trackerName,
+	}).Info("Serving UI")
+	if err := u.ListenAndServe(ctx, o.addr); err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error serving UI")
+		return err
+	}
+	return nil
+}
+
+// searchLayer returns the places found for the text parameter.
+func (o *uiOptions) searchLayer(ctx context.Context, query url.Values) (*geojson.FeatureCollection, error) {
+	text := query.Get("text")
+	if text == "" {
+		return nil, errors.New("text not set")
+	}
+	ret, err := o.placeService(o.indexName, o.apiKey).SearchPlaceIndexForText(ctx, &placesvc.SuggestionSearch{Text: &text})
+	if err != nil {
+		return nil, err
+	}
+	return textGeoJSON(ret.Results), nil
+}
+
+// routeLayer returns the legs of the route between the from and to
+// parameters, by the travelMode parameter.
+func (o *uiOptions) routeLayer(ctx context.Context, query url.Values) (*geojson.FeatureCollection, error) {
+	from, err := parseLatLon(query.Get("from"))
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseLatLon(query.Get("to"))
+	if err != nil {
+		return nil, err
+	}
+	ret, err := o.routeService(o.calculatorName).CalculateRoute(ctx, &routesvc.RouteRequest{
+		Departure:          from,
+		Destination:        to,
+		DistanceUnit:       o.distanceUnit(),
+		IncludeLegGeometry: true,
+		TravelMode:         query.Get("travelMode"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ret.Summary == nil {
+		return geojson.NewFeatureCollection(), nil
+	}
+	return routeGeoJSON(ret.Summary, ret.Legs), nil
+}
+
+// geofencesLayer returns the geofences of the collection.
+func (o *uiOptions) geofencesLayer(ctx context.Context, query url.Values) (*geojson.FeatureCollection, error) {
+	entries, err := o.geofenceService(o.collectionName).ListGeofences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return geofencesGeoJSON(entries), nil
+}
+
+// positionsLayer returns the latest positions of the devices of the tracker.
+func (o *uiOptions) positionsLayer(ctx context.Context, query url.Values) (*geojson.FeatureCollection, error) {
+	entries, err := o.trackerService(o.trackerName).ListDevicePositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return devicePositionsGeoJSON(listedPositions(entries)), nil
+}
@@ -0,0 +1,49 @@
+package loc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/webhook"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// webhookOptions are the flags of commands forwarding geofence and device
+// position events to webhooks.
+type webhookOptions struct {
+	webhooks       []string
+	webhookSecret  string
+	webhookRetries int
+}
+
+// addWebhookFlags adds the --webhook, --webhook-secret and --webhook-retries
+// flags.
+func (w *webhookOptions) addWebhookFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&w.webhooks, "webhook", "", []string{}, "one or more URLs to post events to as JSON, instead of the Webhooks of the config file")
+	cmd.Flags().StringVarP(&w.webhookSecret, "webhook-secret", "", "", "secret to sign the events posted to --webhook with")
+	cmd.Flags().IntVarP(&w.webhookRetries, "webhook-retries", "", 3, "number of times a failed delivery is retried with backoff")
+}
+
+// dispatcher returns the dispatcher of the --webhook URLs, or else of the
+// Webhooks list of the config file, each with a url and optionally headers,
+// secret and events. It returns nil if neither has any.
+func (w *webhookOptions) dispatcher() (*webhook.Dispatcher, error) {
+	if w.webhookRetries < 0 {
+		return nil, errors.New("--webhook-retries must not be negative")
+	}
+	var endpoints []webhook.Endpoint
+	for _, url := range w.webhooks {
+		endpoints = append(endpoints, webhook.Endpoint{URL: url, Secret: w.webhookSecret})
+	}
+	if len(endpoints) == 0 && viper.IsSet("Webhooks") {
+		if err := viper.UnmarshalKey("Webhooks", &endpoints); err != nil {
+			return nil, err
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+	return webhook.New(endpoints, webhook.SetLogger(log), webhook.SetRetries(w.webhookRetries, time.Second))
+}
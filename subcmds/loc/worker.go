@@ -0,0 +1,123 @@
+package loc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rmrfslashbin/goawsloc/pkg/awslocation/clientmgr"
+	"github.com/rmrfslashbin/goawsloc/pkg/worker"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// workerOptions are the flags of the worker command.
+type workerOptions struct {
+	*globalOptions
+	rateOptions
+	webhookOptions
+
+	apiKey         string
+	dlqURL         string
+	indexName      string
+	maxReceives    int
+	outputPrefix   string
+	outputQueueURL string
+	queueURL       string
+	retryDelay     time.Duration
+	visibility     time.Duration
+}
+
+func newWorkerCmd(g *globalOptions) *cobra.Command {
+	o := &workerOptions{globalOptions: g}
+	cmd := &cobra.Command{
+		Use:   "worker",
+		Short: "geocode jobs from an SQS queue",
+		Long:  "Consumes geocoding jobs from an SQS queue until interrupted, sending their results to --output-queue-url or writing them under --output-prefix, and forwards geofence and device position events on the queue to the webhooks. The job and result formats, retries and webhook deliveries are described in the README",
+		Example: `  loc worker --index my-index --queue-url https://sqs.us-east-1.amazonaws.com/123456789012/jobs --output-queue-url https://sqs.us-east-1.amazonaws.com/123456789012/results
+  loc worker --index my-index --queue-url $QUEUE --output-prefix s3://bucket/results/ --dlq-url $DLQ --rps 10`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.checkRate(); err != nil {
+				return err
+			}
+			if o.outputQueueURL != "" && o.outputPrefix != "" {
+				return errors.New("--output-queue-url and --output-prefix are mutually exclusive")
+			}
+			if o.dlqURL != "" && o.maxReceives < 1 {
+				return errors.New("--max-receives must be at least 1")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runWorker(cmd.Context(), o); err != nil {
+				exit(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.indexName, "index", "", "", "index name")
+	cmd.Flags().StringVarP(&o.apiKey, "api-key", "", "", "API key to authorize the searches with instead of IAM credentials")
+	cmd.Flags().StringVarP(&o.queueURL, "queue-url", "", "", "URL of the SQS queue to consume jobs from")
+	cmd.Flags().StringVarP(&o.outputQueueURL, "output-queue-url", "", "", "URL of the SQS queue to send results to")
+	cmd.Flags().StringVarP(&o.outputPrefix, "output-prefix", "", "", "s3://bucket/prefix/ or directory to write results to, for results over the 256 KiB SQS message size")
+	cmd.Flags().StringVarP(&o.dlqURL, "dlq-url", "", "", "URL of the SQS queue to move failed jobs to")
+	cmd.Flags().IntVarP(&o.maxReceives, "max-receives", "", 5, "receives after which a failing job is moved to --dlq-url")
+	cmd.Flags().DurationVarP(&o.visibility, "visibility-timeout", "", time.Minute, "how long a received job is hidden from other workers, extended while it runs")
+	cmd.Flags().DurationVarP(&o.retryDelay, "retry-delay", "", 30*time.Second, "how long a failed job is hidden before it is retried, doubled with every receive")
+	o.addRateFlags(cmd)
+	o.addWebhookFlags(cmd)
+	cmd.MarkFlagRequired("index")
+	cmd.MarkFlagRequired("queue-url")
+	return cmd
+}
+
+func runWorker(ctx context.Context, o *workerOptions) error {
+	webhooks, err := o.dispatcher()
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error configuring webhooks")
+		return err
+	}
+	c, err := clientmgr.Default.Config(o.clientKey())
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error loading AWS config")
+		return err
+	}
+
+	opts := []func(*worker.Worker){
+		worker.SetLogger(log),
+		worker.SetBatchOptions(o.batchSearchOptions(false)),
+		worker.SetVisibilityTimeout(o.visibility),
+		worker.SetRetryDelay(o.retryDelay),
+	}
+	switch {
+	case o.outputQueueURL != "":
+		opts = append(opts, worker.SetOutputQueue(o.outputQueueURL))
+	case o.outputPrefix != "":
+		opts = append(opts, worker.SetOutputURI(o.outputPrefix, o.blobs()))
+	}
+	if webhooks != nil {
+		opts = append(opts, worker.SetWebhooks(webhooks))
+	}
+	if o.dlqURL != "" {
+		opts = append(opts, worker.SetDeadLetterQueue(o.dlqURL, o.maxReceives))
+	}
+	w, err := worker.New(sqs.NewFromConfig(c), o.placeService(o.indexName, o.apiKey), o.queueURL, opts...)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("error creating worker")
+		return err
+	}
+
+	log.WithFields(logrus.Fields{
+		"queue": o.queueURL,
+		"index": o.indexName,
+	}).Info("Consuming jobs")
+	return w.Run(ctx)
+}